@@ -23,11 +23,15 @@ var ActorCmd = &cli.Command{
 	Name:  "actor",
 	Usage: "矿工管理",
 	Subcommands: []*cli.Command{
-		setOwner,      // 设置所有者
-		setWorker,     // 提议更改 Worker
-		confirmWorker, // 确认更改 Worker
-		WithdrawCmd,   // 提现命令
-		infoCmd,       // 查看矿工信息
+		setOwner,           // 设置所有者
+		setWorker,          // 提议更改 Worker
+		confirmWorker,      // 确认更改 Worker
+		proposeBeneficiary, // 提议更改受益人
+		confirmBeneficiary, // 确认更改受益人
+		beneficiaryCmd,     // 查看受益人
+		controlCmd,         // control 地址管理
+		WithdrawCmd,        // 提现命令
+		infoCmd,            // 查看矿工信息
 	},
 }
 
@@ -61,86 +65,98 @@ var infoCmd = &cli.Command{
 			return err
 		}
 
-		tw := tablewriter.New(
-			tablewriter.Col("name"),
-			tablewriter.Col("ID"),
-			tablewriter.Col("key"),
-			tablewriter.Col("use"),
-			tablewriter.Col("balance"),
-		)
-
-		commit := map[address.Address]struct{}{}
-		precommit := map[address.Address]struct{}{}
-		terminate := map[address.Address]struct{}{}
-		dealPublish := map[address.Address]struct{}{}
-		post := map[address.Address]struct{}{}
-
-		for _, ca := range mi.ControlAddresses {
-			post[ca] = struct{}{}
+		return printMinerAddresses(node, mi, loadControlRoles(node))
+	},
+}
+
+// printMinerAddresses 以表格输出矿工的 owner、受益人、worker 及 control 地址
+// 包括每个地址的余额和用途，用途来自 [Addresses] 配置
+func printMinerAddresses(node *vapi.Node, mi *types.MinerInfo, roles *controlRoles) error {
+	tw := tablewriter.New(
+		tablewriter.Col("name"),
+		tablewriter.Col("ID"),
+		tablewriter.Col("key"),
+		tablewriter.Col("use"),
+		tablewriter.Col("balance"),
+	)
+
+	commit := roles.commit
+	precommit := roles.precommit
+	terminate := roles.terminate
+	dealPublish := roles.dealPublish
+	post := map[address.Address]struct{}{}
+
+	for _, ca := range mi.ControlAddresses {
+		post[ca] = struct{}{}
+	}
+
+	printKey := func(name string, a address.Address) {
+		actor, err := node.StateGetActor(a)
+		if err != nil {
+			fmt.Printf("%s\t%s: error getting actor: %s\n", name, a, err)
+			return
 		}
+		b := actor.Balance
 
-		printKey := func(name string, a address.Address) {
-			var actor *types.Actor
-			if actor, err = node.StateGetActor(a); err != nil {
-				fmt.Printf("%s\t%s: error getting actor: %s\n", name, a, err)
-				return
-			}
-			b := actor.Balance
-
-			k := a
-			if keyAddr, err := node.StateAccountKey(a); err == nil {
-				k = keyAddr
-			}
-			kstr := k.String()
-
-			bstr := types.FIL(b).String()
-			switch {
-			case b.LessThan(types.FromFil(10)):
-				bstr = color.RedString(bstr)
-			case b.LessThan(types.FromFil(50)):
-				bstr = color.YellowString(bstr)
-			default:
-				bstr = color.GreenString(bstr)
-			}
-
-			var uses []string
-			if a == mi.Worker {
-				uses = append(uses, color.YellowString("other"))
-			}
-			if _, ok := post[a]; ok {
-				uses = append(uses, color.GreenString("post"))
-			}
-			if _, ok := precommit[a]; ok {
-				uses = append(uses, color.CyanString("precommit"))
-			}
-			if _, ok := commit[a]; ok {
-				uses = append(uses, color.BlueString("commit"))
-			}
-			if _, ok := terminate[a]; ok {
-				uses = append(uses, color.YellowString("terminate"))
-			}
-			if _, ok := dealPublish[a]; ok {
-				uses = append(uses, color.MagentaString("deals"))
-			}
-
-			tw.Write(map[string]interface{}{
-				"name":    name,
-				"ID":      a,
-				"key":     kstr,
-				"use":     strings.Join(uses, " "),
-				"balance": bstr,
-			})
+		k := a
+		if keyAddr, err := node.StateAccountKey(a); err == nil {
+			k = keyAddr
+		}
+		kstr := k.String()
+
+		bstr := types.FIL(b).String()
+		switch {
+		case b.LessThan(types.FromFil(10)):
+			bstr = color.RedString(bstr)
+		case b.LessThan(types.FromFil(50)):
+			bstr = color.YellowString(bstr)
+		default:
+			bstr = color.GreenString(bstr)
 		}
 
-		printKey("owner", mi.Owner)
-		printKey("worker", mi.Worker)
-		for i, ca := range mi.ControlAddresses {
-			printKey(fmt.Sprintf("control-%d", i), ca)
+		var uses []string
+		if a == mi.Worker {
+			uses = append(uses, color.YellowString("other"))
+		}
+		if _, ok := post[a]; ok {
+			uses = append(uses, color.GreenString("post"))
+		}
+		if _, ok := precommit[a]; ok {
+			uses = append(uses, color.CyanString("precommit"))
+		}
+		if _, ok := commit[a]; ok {
+			uses = append(uses, color.BlueString("commit"))
+		}
+		if _, ok := terminate[a]; ok {
+			uses = append(uses, color.YellowString("terminate"))
+		}
+		if _, ok := dealPublish[a]; ok {
+			uses = append(uses, color.MagentaString("deals"))
 		}
 
-		return tw.Flush(os.Stdout)
-	},
+		tw.Write(map[string]interface{}{
+			"name":    name,
+			"ID":      a,
+			"key":     kstr,
+			"use":     strings.Join(uses, " "),
+			"balance": bstr,
+		})
+	}
+
+	printKey("owner", mi.Owner)
+	if mi.Beneficiary != address.Undef && mi.Beneficiary != mi.Owner {
+		printKey("beneficiary", mi.Beneficiary)
+	}
+	printKey("worker", mi.Worker)
+	for i, ca := range mi.ControlAddresses {
+		printKey(fmt.Sprintf("control-%d", i), ca)
+	}
+
+	return tw.Flush(os.Stdout)
 }
+
+// setOwner 更改矿工 owner 命令
+// 需要执行两次：先由旧 owner 发送，再由新 owner 发送确认
 var setOwner = &cli.Command{
 	Name:      "set-owner",
 	Usage:     "Set owner address (this command should be invoked twice, first with the old owner as the senderAddress, and then with the new owner)",
@@ -152,38 +168,31 @@ var setOwner = &cli.Command{
 			Value: false,
 		},
 		&cli.StringFlag{
-			Name:  "minerid",
-			Usage: "minerID",
+			Name:     "minerid",
+			Usage:    "minerID",
+			Required: true,
 		},
 		&cli.Uint64Flag{
 			Name:  "nonce",
 			Usage: "specify the nonce to use",
 			Value: 0,
 		},
+		msigProposerFlag,
+		dryRunFlag,
 	},
 	Action: func(cctx *cli.Context) error {
-		if !cctx.Bool("really-do-it") {
-			fmt.Println("Pass --really-do-it to actually execute this action")
-			return nil
-		}
-
-		if cctx.NArg() != 2 {
-			return errors.New("参数数量错误")
-		}
-
-		minerid, err := address.NewFromString(cctx.String("minerid"))
-		if minerid.String() != " " {
-			return errors.New("minerid不能为空")
-		}
-
-		na, err := address.NewFromString(cctx.Args().First())
+		data, err := setOwnerPayload(cctx)
 		if err != nil {
 			return err
 		}
 
-		fa, err := address.NewFromString(cctx.Args().Get(1))
-		if err != nil {
-			return err
+		if cctx.Bool("dry-run") {
+			return simulate(cctx, data)
+		}
+
+		if !cctx.Bool("really-do-it") {
+			fmt.Println("Pass --really-do-it to actually execute this action")
+			return nil
 		}
 
 		client, err := service.NewClient()
@@ -191,18 +200,42 @@ var setOwner = &cli.Command{
 			return err
 		}
 
-		data := &service.Payload{
-			Type:      service.RequestTypeMinerChangeOwner,
-			MinerID:   minerid,
-			NewOwner:  na,
-			FromOwner: fa,
-		}
-		client.Ex.Execute(data)
-
-		return nil
+		res, err := client.Ex.Execute(data)
+		return handleResult(cctx, res, err)
 	},
 }
 
+// setOwnerPayload 从命令行参数构建更改 owner 请求
+func setOwnerPayload(cctx *cli.Context) (*service.Payload, error) {
+	if cctx.NArg() != 2 {
+		return nil, errors.New("参数数量错误")
+	}
+
+	minerid, err := minerIDFlag(cctx)
+	if err != nil {
+		return nil, err
+	}
+
+	na, err := address.NewFromString(cctx.Args().First())
+	if err != nil {
+		return nil, err
+	}
+
+	fa, err := address.NewFromString(cctx.Args().Get(1))
+	if err != nil {
+		return nil, err
+	}
+
+	return withMsigProposer(cctx, &service.Payload{
+		Type:      service.RequestTypeMinerChangeOwner,
+		MinerID:   minerid,
+		NewOwner:  na,
+		FromOwner: fa,
+	})
+}
+
+// setWorker 提议更改 worker 命令
+// 可同时通过 --control 替换 control 地址列表
 var setWorker = &cli.Command{
 	Name:      "propose-change-worker",
 	Usage:     "Propose a worker address change",
@@ -214,27 +247,34 @@ var setWorker = &cli.Command{
 			Value: false,
 		},
 		&cli.StringFlag{
-			Name:  "minerid",
-			Usage: "minerID",
+			Name:     "minerid",
+			Usage:    "minerID",
+			Required: true,
+		},
+		&cli.StringSliceFlag{
+			Name:  "control",
+			Usage: "new control addresses, replaces the current list (can be repeated)",
 		},
 		&cli.Uint64Flag{
 			Name:  "nonce",
 			Usage: "specify the nonce to use",
 			Value: 0,
 		},
+		msigProposerFlag,
+		dryRunFlag,
 	},
 	Action: func(cctx *cli.Context) error {
-		miner, err := address.NewFromString(cctx.Args().Get(0))
+		data, err := proposeWorkerPayload(cctx)
 		if err != nil {
 			return err
 		}
 
-		na, err := address.NewFromString(cctx.Args().First())
-		if err != nil {
-			return err
+		if cctx.Bool("dry-run") {
+			return simulate(cctx, data)
 		}
+
 		if !cctx.Bool("really-do-it") {
-			fmt.Println(cctx.App.Writer, "Pass --really-do-it to actually execute this action")
+			fmt.Fprintln(cctx.App.Writer, "Pass --really-do-it to actually execute this action")
 			return nil
 		}
 		client, err := service.NewClient()
@@ -242,21 +282,49 @@ var setWorker = &cli.Command{
 			return err
 		}
 
-		data := &service.Payload{
-			Type:            service.RequestTypeMinerChangeWorker,
-			MinerID:         miner,
-			NewWorker:       na,
-			NewControlAddrs: nil,
-		}
-		client.Ex.Execute(data)
-
-		return nil
+		res, err := client.Ex.Execute(data)
+		return handleResult(cctx, res, err)
 	},
 }
 
+// proposeWorkerPayload 从命令行参数构建提议更改 worker 请求
+func proposeWorkerPayload(cctx *cli.Context) (*service.Payload, error) {
+	if cctx.NArg() != 1 {
+		return nil, errors.New("参数数量错误")
+	}
+
+	miner, err := minerIDFlag(cctx)
+	if err != nil {
+		return nil, err
+	}
+
+	na, err := address.NewFromString(cctx.Args().First())
+	if err != nil {
+		return nil, err
+	}
+
+	var controls []address.Address
+	for _, s := range cctx.StringSlice("control") {
+		ca, err := address.NewFromString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid control address %q: %w", s, err)
+		}
+		controls = append(controls, ca)
+	}
+
+	return withMsigProposer(cctx, &service.Payload{
+		Type:            service.RequestTypeMinerChangeWorker,
+		MinerID:         miner,
+		NewWorker:       na,
+		NewControlAddrs: controls,
+	})
+}
+
+// confirmWorker 确认更改 worker 命令
+// 需要在 WorkerChangeEpoch 之后执行
 var confirmWorker = &cli.Command{
 	Name:      "confirm-change-worker",
-	Usage:     "Propose a worker address change",
+	Usage:     "Confirm a worker address change",
 	ArgsUsage: "[address]",
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
@@ -265,24 +333,26 @@ var confirmWorker = &cli.Command{
 			Value: false,
 		},
 		&cli.StringFlag{
-			Name:  "minerid",
-			Usage: "minerID",
+			Name:     "minerid",
+			Usage:    "minerID",
+			Required: true,
 		},
 		&cli.Uint64Flag{
 			Name:  "nonce",
 			Usage: "specify the nonce to use",
 			Value: 0,
 		},
+		msigProposerFlag,
+		dryRunFlag,
 	},
 	Action: func(cctx *cli.Context) error {
-		miner, err := address.NewFromString(cctx.Args().Get(0))
+		data, err := confirmWorkerPayload(cctx)
 		if err != nil {
 			return err
 		}
 
-		na, err := address.NewFromString(cctx.Args().First())
-		if err != nil {
-			return err
+		if cctx.Bool("dry-run") {
+			return simulate(cctx, data)
 		}
 
 		if !cctx.Bool("really-do-it") {
@@ -295,13 +365,43 @@ var confirmWorker = &cli.Command{
 			return err
 		}
 
-		data := &service.Payload{
-			Type:      service.RequestTypeMinerConfirmWorker,
-			MinerID:   miner,
-			NewWorker: na,
-		}
-		client.Ex.Execute(data)
-
-		return nil
+		res, err := client.Ex.Execute(data)
+		return handleResult(cctx, res, err)
 	},
 }
+
+// confirmWorkerPayload 从命令行参数构建确认更改 worker 请求
+func confirmWorkerPayload(cctx *cli.Context) (*service.Payload, error) {
+	if cctx.NArg() != 1 {
+		return nil, errors.New("参数数量错误")
+	}
+
+	miner, err := minerIDFlag(cctx)
+	if err != nil {
+		return nil, err
+	}
+
+	na, err := address.NewFromString(cctx.Args().First())
+	if err != nil {
+		return nil, err
+	}
+
+	return withMsigProposer(cctx, &service.Payload{
+		Type:      service.RequestTypeMinerConfirmWorker,
+		MinerID:   miner,
+		NewWorker: na,
+	})
+}
+
+// minerIDFlag 解析 --minerid 参数
+func minerIDFlag(cctx *cli.Context) (address.Address, error) {
+	mid := cctx.String("minerid")
+	if mid == "" {
+		return address.Undef, errors.New("minerid不能为空")
+	}
+	maddr, err := address.NewFromString(mid)
+	if err != nil {
+		return address.Undef, fmt.Errorf("invalid minerid %q: %w", mid, err)
+	}
+	return maddr, nil
+}
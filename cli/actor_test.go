@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/urfave/cli/v2"
+
+	"wallet-sign/internal/chain/types"
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/lotusmock"
+	"wallet-sign/internal/repository"
+	"wallet-sign/internal/wallet"
+)
+
+// cliEnv 连接到模拟 Lotus 节点的命令行环境，命令通过与 main 相同的 cli.App 执行
+type cliEnv struct {
+	t     *testing.T
+	node  *lotusmock.Node
+	store *repository.Store
+	cfg   *appcfg.Config
+}
+
+func newCLIEnv(t *testing.T) *cliEnv {
+	t.Helper()
+	node := lotusmock.New()
+	node.Start()
+	t.Cleanup(node.Close)
+
+	cfg := &appcfg.Config{DBDSN: filepath.Join(t.TempDir(), "wallet.db")}
+	prevLotus, prevDB := appcfg.LotusConfig.Lotus, appcfg.LotusConfig.Database
+	appcfg.LotusConfig.Lotus = &appcfg.Lotus{Host: node.URL(), RetryBackoff: "1ms"}
+	appcfg.LotusConfig.Database = &appcfg.Database{Path: cfg.DBDSN}
+	t.Cleanup(func() { appcfg.LotusConfig.Lotus, appcfg.LotusConfig.Database = prevLotus, prevDB })
+
+	t.Setenv(repository.PassphraseEnv, "cli-test")
+	store, err := repository.OpenStore(cfg.DBDSN)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() {
+		if db, err := store.DB.DB(); err == nil {
+			db.Close()
+		}
+	})
+	if err := store.EnsureUnlocked(); err != nil {
+		t.Fatalf("unlock keystore: %v", err)
+	}
+	return &cliEnv{t: t, node: node, store: store, cfg: cfg}
+}
+
+// newKey 生成密钥保存到钱包，并在链上创建余额为 balance 的账户
+func (env *cliEnv) newKey(balance abi.TokenAmount) address.Address {
+	env.t.Helper()
+	ki, addr, err := wallet.WalletNew(types.KTSecp256k1)
+	if err != nil {
+		env.t.Fatalf("new key: %v", err)
+	}
+	if err := env.store.SaveWalletKey(addr.String(), *ki); err != nil {
+		env.t.Fatalf("save key: %v", err)
+	}
+	env.node.AddAccount(addr, balance)
+	return addr
+}
+
+func (env *cliEnv) id(addr address.Address) address.Address {
+	env.t.Helper()
+	id, ok := env.node.LookupID(addr)
+	if !ok {
+		env.t.Fatalf("%s not found on chain", addr)
+	}
+	return id
+}
+
+// run 执行命令行，args 不含程序名
+func (env *cliEnv) run(args ...string) error {
+	env.t.Helper()
+	app := &cli.App{
+		Name:     "wallet-sign",
+		Flags:    []cli.Flag{&cli.BoolFlag{Name: "json"}},
+		Commands: All(),
+		// 不因 cli.Exit 错误退出测试进程
+		ExitErrHandler: func(*cli.Context, error) {},
+	}
+	ctx := context.WithValue(context.Background(), CtxConfig, env.cfg)
+	return app.RunContext(ctx, append([]string{"wallet-sign"}, args...))
+}
+
+func TestActorSetOwner(t *testing.T) {
+	env := newCLIEnv(t)
+	owner, worker, newOwner := env.newKey(types.FromFil(10)), env.newKey(types.FromFil(10)), env.newKey(types.FromFil(10))
+	miner := env.node.AddMiner(owner, worker, types.FromFil(50))
+
+	// 不带 --really-do-it 时不发送消息
+	if err := env.run("actor", "set-owner", "--minerid", miner.String(), newOwner.String(), owner.String()); err != nil {
+		t.Fatalf("set-owner without --really-do-it: %v", err)
+	}
+	if n := env.node.Calls("MpoolPush"); n != 0 {
+		t.Fatalf("MpoolPush called %d times without --really-do-it", n)
+	}
+
+	if err := env.run("actor", "set-owner", "--minerid", miner.String(), "--really-do-it", newOwner.String(), owner.String()); err != nil {
+		t.Fatalf("propose owner: %v", err)
+	}
+	info := env.node.MinerInfo(miner)
+	if info.PendingOwnerAddress == nil || *info.PendingOwnerAddress != env.id(newOwner) {
+		t.Fatalf("pending owner = %v, want %s", info.PendingOwnerAddress, env.id(newOwner))
+	}
+
+	if err := env.run("actor", "set-owner", "--minerid", miner.String(), "--really-do-it", newOwner.String(), newOwner.String()); err != nil {
+		t.Fatalf("confirm owner: %v", err)
+	}
+	info = env.node.MinerInfo(miner)
+	if info.Owner != env.id(newOwner) || info.PendingOwnerAddress != nil {
+		t.Errorf("owner = %s, pending %v, want %s", info.Owner, info.PendingOwnerAddress, env.id(newOwner))
+	}
+
+	if err := env.run("actor", "set-owner", "--minerid", miner.String(), "--really-do-it", newOwner.String()); err == nil {
+		t.Errorf("set-owner with one argument succeeded")
+	}
+}
+
+func TestActorChangeWorker(t *testing.T) {
+	env := newCLIEnv(t)
+	owner, worker := env.newKey(types.FromFil(10)), env.newKey(types.FromFil(10))
+	miner := env.node.AddMiner(owner, worker, types.FromFil(50))
+	newWorker := env.newKey(types.FromFil(1))
+	controls := []address.Address{env.newKey(types.FromFil(1)), env.newKey(types.FromFil(1))}
+
+	if err := env.run("actor", "propose-change-worker", "--minerid", miner.String(), "--really-do-it",
+		"--control", controls[0].String(), "--control", controls[1].String(), newWorker.String()); err != nil {
+		t.Fatalf("propose worker: %v", err)
+	}
+	info := env.node.MinerInfo(miner)
+	if info.NewWorker != env.id(newWorker) {
+		t.Fatalf("new worker = %s, want %s", info.NewWorker, env.id(newWorker))
+	}
+	if len(info.ControlAddresses) != 2 || info.ControlAddresses[0] != env.id(controls[0]) || info.ControlAddresses[1] != env.id(controls[1]) {
+		t.Errorf("control addresses = %v, want %v", info.ControlAddresses, controls)
+	}
+
+	confirm := []string{"actor", "confirm-change-worker", "--minerid", miner.String(), "--really-do-it", newWorker.String()}
+	if err := env.run(confirm...); err == nil || !strings.Contains(err.Error(), "cannot confirm until") {
+		t.Fatalf("early confirm error = %v, want cannot confirm yet", err)
+	}
+	env.node.Advance(int(info.WorkerChangeEpoch - env.node.Height()))
+	if err := env.run(confirm...); err != nil {
+		t.Fatalf("confirm worker: %v", err)
+	}
+	if info := env.node.MinerInfo(miner); info.Worker != env.id(newWorker) || !info.NewWorker.Empty() {
+		t.Errorf("worker = %s, pending %s, want %s", info.Worker, info.NewWorker, env.id(newWorker))
+	}
+}
+
+func TestActorProposeWorkerInvalidControl(t *testing.T) {
+	env := newCLIEnv(t)
+	owner, worker := env.newKey(types.FromFil(10)), env.newKey(types.FromFil(10))
+	miner := env.node.AddMiner(owner, worker, types.FromFil(50))
+
+	err := env.run("actor", "propose-change-worker", "--minerid", miner.String(), "--really-do-it", "--control", "bogus", worker.String())
+	if err == nil || !strings.Contains(err.Error(), "invalid control address") {
+		t.Errorf("error = %v, want invalid control address", err)
+	}
+	if n := env.node.Calls("MpoolPush"); n != 0 {
+		t.Errorf("MpoolPush called %d times", n)
+	}
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+
+	"wallet-sign/internal/chain/types/ethtypes"
+)
+
+// parseAddress 解析 Filecoin 地址或 0x 开头的以太坊地址
+// 以太坊地址转换为对应的 f410 地址（映射 ID 的地址转换为 ID 地址）
+func parseAddress(s string) (address.Address, error) {
+	if ethtypes.IsEthAddress(s) {
+		ea, err := ethtypes.ParseEthAddress(s)
+		if err != nil {
+			return address.Undef, err
+		}
+		return ea.ToFilecoinAddress()
+	}
+
+	addr, err := address.NewFromString(s)
+	if err != nil {
+		return address.Undef, fmt.Errorf("invalid address %q: %w", s, err)
+	}
+	return addr, nil
+}
+
+// ethAddressString 返回 f410 地址对应的以太坊地址，其他地址返回空字符串
+func ethAddressString(addr address.Address) string {
+	if addr.Protocol() != address.Delegated {
+		return ""
+	}
+	ea, err := ethtypes.EthAddressFromFilecoinAddress(addr)
+	if err != nil {
+		return ""
+	}
+	return ea.String()
+}
@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/urfave/cli/v2"
+
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/service"
+	"wallet-sign/internal/ui/tablewriter"
+)
+
+// batchFlags 批量转账参数
+var batchFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "batch",
+		Usage: "从 CSV 或 JSON 文件批量转账，列为 from,to,amount[,memo]",
+	},
+	&cli.StringFlag{
+		Name:  "resume",
+		Usage: "恢复中断的批量转账（批次 ID）",
+	},
+	&cli.BoolFlag{
+		Name:  "allow-duplicates",
+		Usage: "允许发送方、接收方、金额和备注完全相同的重复行",
+	},
+	&cli.BoolFlag{
+		Name:  "force",
+		Usage: "相同内容的文件已经提交过时仍然作为新批次发送",
+	},
+}
+
+// batchRecord 批量转账文件中的一行（JSON 格式）
+type batchRecord struct {
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Amount json.RawMessage `json:"amount"`
+	Memo   string          `json:"memo"`
+}
+
+// sendBatch 执行 send --batch 或 send --resume
+// 新批次先完成预检查，有任何错误时不发送
+func sendBatch(cctx *cli.Context) error {
+	client, err := service.NewClient()
+	if err != nil {
+		return err
+	}
+
+	if id := cctx.String("resume"); id != "" {
+		report, err := client.Ex.RunBatch(id)
+		return handleBatchReport(cctx, report, err)
+	}
+
+	var defaultFrom address.Address
+	if s := cctx.String("from"); s != "" {
+		defaultFrom, err = parseAddress(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	path := cctx.String("batch")
+	entries, hash, err := readBatchFile(path, defaultFrom)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("batch file %s has no rows", path)
+	}
+
+	check, err := client.Ex.CheckBatch(entries, cctx.Bool("allow-duplicates"))
+	if err != nil {
+		return err
+	}
+	if cctx.Bool("json") && (cctx.Bool("dry-run") || !check.OK()) {
+		if err := printJSON(check); err != nil {
+			return err
+		}
+	} else if !cctx.Bool("json") {
+		printBatchCheck(check)
+	}
+	if !check.OK() {
+		return cli.Exit(fmt.Sprintf("batch pre-flight check failed with %d errors, nothing was sent", check.Errors), ExitCodeError)
+	}
+	if cctx.Bool("dry-run") {
+		return nil
+	}
+
+	source, err := filepath.Abs(path)
+	if err != nil {
+		source = path
+	}
+	batch, err := client.Ex.StartBatch(source, hash, entries, cctx.Bool("force"))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Batch %s created with %d transfers, resume with: send --resume %s\n", batch.ID, batch.Rows, batch.ID)
+
+	report, err := client.Ex.RunBatch(batch.ID)
+	return handleBatchReport(cctx, report, err)
+}
+
+// readBatchFile 读取批量转账文件，返回每一行及文件内容的 SHA-256
+// .json 文件或以 [ 开头的内容按 JSON 数组解析，其余按 CSV 解析
+// 解析错误记录在对应行的 Error 中，由预检查统一报告
+func readBatchFile(path string, defaultFrom address.Address) ([]*service.BatchEntry, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read batch file: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	var records []batchRecord
+	if strings.EqualFold(filepath.Ext(path), ".json") || bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, "", fmt.Errorf("failed to parse batch file as JSON: %w", err)
+		}
+	} else {
+		records, err = readBatchCSV(data)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	entries := make([]*service.BatchEntry, 0, len(records))
+	for i, rec := range records {
+		entries = append(entries, batchEntry(i+1, rec, defaultFrom))
+	}
+	return entries, hash, nil
+}
+
+// readBatchCSV 解析 CSV 格式的批量转账文件
+// 第一行为 from、to、amount、memo 等列名时按列名取值，否则按 from,to,amount[,memo] 的顺序取值
+func readBatchCSV(data []byte) ([]batchRecord, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	columns := map[string]int{"from": 0, "to": 1, "amount": 2, "memo": 3}
+	var records []batchRecord
+	for first := true; ; first = false {
+		fields, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse batch file as CSV: %w", err)
+		}
+
+		if first && len(fields) > 0 && isBatchHeader(fields) {
+			columns = make(map[string]int)
+			for i, name := range fields {
+				columns[strings.ToLower(strings.TrimSpace(name))] = i
+			}
+			if _, ok := columns["to"]; !ok {
+				return nil, fmt.Errorf("batch file header has no to column")
+			}
+			if _, ok := columns["amount"]; !ok {
+				return nil, fmt.Errorf("batch file header has no amount column")
+			}
+			continue
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[i])
+		}
+		records = append(records, batchRecord{
+			From:   field("from"),
+			To:     field("to"),
+			Amount: json.RawMessage(fmt.Sprintf("%q", field("amount"))),
+			Memo:   field("memo"),
+		})
+	}
+	return records, nil
+}
+
+// isBatchHeader 判断 CSV 第一行是否为列名
+func isBatchHeader(fields []string) bool {
+	for _, f := range fields {
+		switch strings.ToLower(strings.TrimSpace(f)) {
+		case "from", "to", "amount":
+			return true
+		}
+	}
+	return false
+}
+
+// batchEntry 解析一行的地址和金额，from 为空时使用 --from 指定的发送方
+func batchEntry(row int, rec batchRecord, defaultFrom address.Address) *service.BatchEntry {
+	entry := &service.BatchEntry{Row: row, From: defaultFrom, Amount: types.FIL(types.NewInt(0)), Memo: rec.Memo}
+
+	var errs []string
+	if rec.From != "" {
+		from, err := parseAddress(rec.From)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		entry.From = from
+	} else if defaultFrom == address.Undef {
+		errs = append(errs, "missing sender (set the from column or --from)")
+	}
+
+	to, err := parseAddress(rec.To)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	entry.To = to
+
+	// 金额可以是字符串或数字，单位为 FIL，也可以带 afil 后缀
+	amount := strings.Trim(strings.TrimSpace(string(rec.Amount)), `"`)
+	if amount == "" {
+		errs = append(errs, "missing amount")
+	} else if val, err := types.ParseFIL(amount); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid amount %q: %v", amount, err))
+	} else {
+		entry.Amount = val
+	}
+
+	entry.Error = strings.Join(errs, "; ")
+	return entry
+}
+
+// printBatchCheck 以文本格式输出预检查结果
+// 只列出有错误的行，以及每个发送方的金额、预估手续费和余额
+func printBatchCheck(check *service.BatchCheck) {
+	for _, entry := range check.Entries {
+		if entry.Error != "" {
+			fmt.Printf("row %d: %s\n", entry.Row, entry.Error)
+		}
+	}
+
+	tw := tablewriter.New(
+		tablewriter.Col("From"),
+		tablewriter.Col("Rows"),
+		tablewriter.Col("Total"),
+		tablewriter.Col("Est. Fee"),
+		tablewriter.Col("Balance"),
+		tablewriter.Col("Error"))
+	for _, sc := range check.Senders {
+		tw.Write(map[string]interface{}{
+			"From":     sc.From,
+			"Rows":     sc.Rows,
+			"Total":    sc.Total,
+			"Est. Fee": sc.EstimatedFee,
+			"Balance":  sc.Balance,
+			"Error":    sc.Error,
+		})
+	}
+	_ = tw.Flush(os.Stdout)
+
+	if check.OK() {
+		fmt.Printf("Pre-flight check passed: %d rows\n", len(check.Entries))
+	}
+}
+
+// handleBatchReport 输出批量转账报告，并根据结果设置进程退出码
+func handleBatchReport(cctx *cli.Context, report *service.BatchReport, err error) error {
+	if report == nil {
+		return exitError(err)
+	}
+
+	if cctx.Bool("json") {
+		out := struct {
+			*service.BatchReport
+			Error string `json:"error,omitempty"`
+		}{BatchReport: report}
+		if err != nil {
+			out.Error = err.Error()
+		}
+		if encErr := printJSON(out); encErr != nil {
+			return cli.Exit(encErr.Error(), ExitCodeError)
+		}
+		return exitError(err)
+	}
+
+	tw := tablewriter.New(
+		tablewriter.Col("Row"),
+		tablewriter.Col("From"),
+		tablewriter.Col("To"),
+		tablewriter.Col("Amount"),
+		tablewriter.Col("Memo"),
+		tablewriter.Col("Status"),
+		tablewriter.Col("CID"),
+		tablewriter.Col("Error"))
+	for _, row := range report.Rows {
+		tw.Write(map[string]interface{}{
+			"Row":    row.Row,
+			"From":   row.FromAddr,
+			"To":     row.ToAddr,
+			"Amount": historyValue(row.Amount),
+			"Memo":   row.Memo,
+			"Status": row.Status,
+			"CID":    row.MsgCid,
+			"Error":  row.Error,
+		})
+	}
+	_ = tw.Flush(os.Stdout)
+	fmt.Printf("Batch %s: %s\n", report.Batch.ID, report.Batch.Status)
+	return exitError(err)
+}
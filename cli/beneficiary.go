@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/urfave/cli/v2"
+
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/rpc"
+	"wallet-sign/internal/service"
+	"wallet-sign/internal/vapi"
+)
+
+// proposeBeneficiary 提议更改矿工受益人命令（FIP-0029）
+// 由 owner 发送，新受益人和当前受益人（不是 owner 时）确认后生效
+var proposeBeneficiary = &cli.Command{
+	Name:      "propose-beneficiary",
+	Usage:     "Propose a beneficiary address change (sent by the owner)",
+	ArgsUsage: "[beneficiaryAddress quota expiration]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "Actually send transaction performing the action",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:     "minerid",
+			Usage:    "minerID",
+			Required: true,
+		},
+		msigProposerFlag,
+		dryRunFlag,
+	},
+	Action: reallyDoItAction(proposeBeneficiaryPayload),
+}
+
+// proposeBeneficiaryPayload 从命令行参数构建提议更改受益人请求
+// quota 单位为 FIL，expiration 为额度到期的高度
+func proposeBeneficiaryPayload(cctx *cli.Context) (*service.Payload, error) {
+	if cctx.NArg() != 3 {
+		return nil, errors.New("参数数量错误")
+	}
+
+	miner, err := minerIDFlag(cctx)
+	if err != nil {
+		return nil, err
+	}
+
+	na, err := address.NewFromString(cctx.Args().Get(0))
+	if err != nil {
+		return nil, err
+	}
+
+	quota, err := types.ParseFIL(cctx.Args().Get(1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse quota: %w", err)
+	}
+
+	expiration, err := strconv.ParseInt(cctx.Args().Get(2), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiration epoch: %w", err)
+	}
+
+	return withMsigProposer(cctx, &service.Payload{
+		Type:        service.RequestTypeMinerProposeBeneficiary,
+		MinerID:     miner,
+		Beneficiary: na,
+		Quota:       quota,
+		Expiration:  abi.ChainEpoch(expiration),
+	})
+}
+
+// confirmBeneficiary 确认更改矿工受益人命令
+// 由当前受益人或被提名的新受益人发送
+var confirmBeneficiary = &cli.Command{
+	Name:      "confirm-beneficiary",
+	Usage:     "Confirm a pending beneficiary change (sent by the current beneficiary or the nominee)",
+	ArgsUsage: "[senderAddress]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "Actually send transaction performing the action",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:     "minerid",
+			Usage:    "minerID",
+			Required: true,
+		},
+		msigProposerFlag,
+		dryRunFlag,
+	},
+	Action: reallyDoItAction(confirmBeneficiaryPayload),
+}
+
+// confirmBeneficiaryPayload 从命令行参数构建确认更改受益人请求
+func confirmBeneficiaryPayload(cctx *cli.Context) (*service.Payload, error) {
+	if cctx.NArg() != 1 {
+		return nil, errors.New("参数数量错误")
+	}
+
+	miner, err := minerIDFlag(cctx)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := address.NewFromString(cctx.Args().First())
+	if err != nil {
+		return nil, err
+	}
+
+	return withMsigProposer(cctx, &service.Payload{
+		Type:     service.RequestTypeMinerConfirmBeneficiary,
+		MinerID:  miner,
+		FromAddr: from,
+	})
+}
+
+// beneficiaryCmd 矿工受益人命令
+var beneficiaryCmd = &cli.Command{
+	Name:  "beneficiary",
+	Usage: "矿工受益人",
+	Subcommands: []*cli.Command{
+		beneficiaryInfo,
+	},
+}
+
+// beneficiaryInfo 查看矿工受益人及待确认变更命令
+var beneficiaryInfo = &cli.Command{
+	Name:      "info",
+	Usage:     "查看矿工受益人、额度及待确认的变更",
+	ArgsUsage: "[矿工ID]",
+	Action: func(cctx *cli.Context) error {
+		mid := cctx.Args().First()
+		if mid == "" {
+			return errors.New("请输入minerid")
+		}
+		maddr, err := address.NewFromString(mid)
+		if err != nil {
+			return err
+		}
+
+		node := vapi.NewNode(cctx.Context, rpc.NewLotusApi())
+		mi, err := node.StateMinerInfo(maddr)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			return printJSON(struct {
+				Owner                  address.Address                 `json:"owner"`
+				Beneficiary            address.Address                 `json:"beneficiary"`
+				BeneficiaryTerm        *types.BeneficiaryTerm          `json:"beneficiary_term"`
+				PendingBeneficiaryTerm *types.PendingBeneficiaryChange `json:"pending_beneficiary_term"`
+			}{mi.Owner, mi.Beneficiary, mi.BeneficiaryTerm, mi.PendingBeneficiaryTerm})
+		}
+
+		fmt.Printf("Owner:       %s\n", mi.Owner)
+		fmt.Printf("Beneficiary: %s\n", mi.Beneficiary)
+		if term := mi.BeneficiaryTerm; term != nil && mi.Beneficiary != mi.Owner {
+			fmt.Printf("Quota:       %s\n", types.FIL(term.Quota))
+			fmt.Printf("Used Quota:  %s\n", types.FIL(term.UsedQuota))
+			fmt.Printf("Expiration:  %d\n", term.Expiration)
+		}
+
+		pending := mi.PendingBeneficiaryTerm
+		if pending == nil {
+			fmt.Println("No pending beneficiary change")
+			return nil
+		}
+		fmt.Println("Pending change:")
+		fmt.Printf("  New Beneficiary:         %s\n", pending.NewBeneficiary)
+		fmt.Printf("  New Quota:               %s\n", types.FIL(pending.NewQuota))
+		fmt.Printf("  New Expiration:          %d\n", pending.NewExpiration)
+		fmt.Printf("  Approved by Beneficiary: %t\n", pending.ApprovedByBeneficiary)
+		fmt.Printf("  Approved by Nominee:     %t\n", pending.ApprovedByNominee)
+		return nil
+	},
+}
+
+// reallyDoItAction 返回需要 --really-do-it 确认才发送的命令处理函数
+// 指定 --dry-run 时只预执行
+func reallyDoItAction(payload func(*cli.Context) (*service.Payload, error)) cli.ActionFunc {
+	return func(cctx *cli.Context) error {
+		data, err := payload(cctx)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("dry-run") {
+			return simulate(cctx, data)
+		}
+
+		if !cctx.Bool("really-do-it") {
+			fmt.Println("Pass --really-do-it to actually execute this action")
+			return nil
+		}
+
+		client, err := service.NewClient()
+		if err != nil {
+			return err
+		}
+
+		res, err := client.Ex.Execute(data)
+		return handleResult(cctx, res, err)
+	}
+}
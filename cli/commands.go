@@ -12,5 +12,15 @@ func All() []*cli.Command {
 		ActorCmd,          // 矿工相关操作
 		WithdrawCmd,       // 矿工提现命令
 		MarketWithdrawCmd, // 市场提现命令
+		MessageCmd,        // 离线签名命令
+		KeystoreCmd,       // 密钥库管理命令
+		MsigCmd,           // 多签钱包命令
+		DaemonCmd,         // 远程钱包服务
+		HistoryCmd,        // 消息历史
+		NonceCmd,          // nonce 管理
+		MpoolCmd,          // 内存池消息管理
+		InvokeCmd,         // 调用 actor 方法
+		SweepCmd,          // 自动归集
+		MonitorCmd,        // 余额及状态监控
 	}
 }
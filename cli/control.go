@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/filecoin-project/go-address"
+	"github.com/urfave/cli/v2"
+
+	"wallet-sign/internal/chain/types"
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/rpc"
+	"wallet-sign/internal/service"
+	"wallet-sign/internal/vapi"
+)
+
+// controlCmd 矿工 control 地址管理命令
+var controlCmd = &cli.Command{
+	Name:  "control",
+	Usage: "矿工 control 地址管理",
+	Subcommands: []*cli.Command{
+		controlList,
+		controlSet,
+	},
+}
+
+// controlRoles [Addresses] 配置中各用途对应的 ID 地址
+type controlRoles struct {
+	precommit   map[address.Address]struct{}
+	commit      map[address.Address]struct{}
+	terminate   map[address.Address]struct{}
+	dealPublish map[address.Address]struct{}
+	// configured 配置中出现的所有地址（ID 地址到配置中的写法），用于检查配置是否与链上一致
+	configured map[address.Address]string
+	// warnings 无法解析的配置项
+	warnings []string
+}
+
+// loadControlRoles 读取 [Addresses] 配置并将地址解析为 ID 地址
+// 未配置时返回空的用途集合
+func loadControlRoles(node *vapi.Node) *controlRoles {
+	roles := &controlRoles{
+		precommit:   map[address.Address]struct{}{},
+		commit:      map[address.Address]struct{}{},
+		terminate:   map[address.Address]struct{}{},
+		dealPublish: map[address.Address]struct{}{},
+		configured:  map[address.Address]string{},
+	}
+	cfg := appcfg.LotusConfig.Addresses
+	if cfg == nil {
+		return roles
+	}
+
+	for _, role := range []struct {
+		name  string
+		addrs []string
+		set   map[address.Address]struct{}
+	}{
+		{"PreCommitControl", cfg.PreCommitControl, roles.precommit},
+		{"CommitControl", cfg.CommitControl, roles.commit},
+		{"TerminateControl", cfg.TerminateControl, roles.terminate},
+		{"DealPublishControl", cfg.DealPublishControl, roles.dealPublish},
+	} {
+		for _, s := range role.addrs {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				roles.warnings = append(roles.warnings, fmt.Sprintf("%s: invalid address %q: %v", role.name, s, err))
+				continue
+			}
+			id, err := node.StateLookupID(a)
+			if err != nil {
+				roles.warnings = append(roles.warnings, fmt.Sprintf("%s: address %s not found on chain: %v", role.name, s, err))
+				continue
+			}
+			role.set[id] = struct{}{}
+			roles.configured[id] = s
+		}
+	}
+	return roles
+}
+
+// controlList 查看 control 地址命令
+var controlList = &cli.Command{
+	Name:      "list",
+	Usage:     "查看矿工 control 地址的余额及配置的用途",
+	ArgsUsage: "[矿工ID]",
+	Action: func(cctx *cli.Context) error {
+		mid := cctx.Args().First()
+		if mid == "" {
+			return errors.New("请输入minerid")
+		}
+		maddr, err := address.NewFromString(mid)
+		if err != nil {
+			return err
+		}
+
+		node := vapi.NewNode(cctx.Context, rpc.NewLotusApi())
+		mi, err := node.StateMinerInfo(maddr)
+		if err != nil {
+			return err
+		}
+
+		roles := loadControlRoles(node)
+		if err := printMinerAddresses(node, mi, roles); err != nil {
+			return err
+		}
+
+		// 配置了用途但不是该矿工 worker 或 control 地址的地址发出的消息会被矿工 actor 拒绝
+		usable := map[address.Address]bool{mi.Worker: true}
+		for _, ca := range mi.ControlAddresses {
+			usable[ca] = true
+		}
+		for id, s := range roles.configured {
+			if !usable[id] {
+				roles.warnings = append(roles.warnings, fmt.Sprintf("%s (%s) is configured but is not a worker or control address of %s", s, id, maddr))
+			}
+		}
+		for _, w := range roles.warnings {
+			fmt.Fprintln(os.Stderr, color.YellowString("warning: %s", w))
+		}
+		return nil
+	},
+}
+
+// controlSet 替换 control 地址命令
+// 先输出与当前列表的差异，移除仍有余额的地址时给出警告
+var controlSet = &cli.Command{
+	Name:      "set",
+	Usage:     "Set control address(es), replaces the current list (sent by the owner)",
+	ArgsUsage: "[...address]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "Actually send transaction performing the action",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:     "minerid",
+			Usage:    "minerID",
+			Required: true,
+		},
+		msigProposerFlag,
+		dryRunFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		miner, err := minerIDFlag(cctx)
+		if err != nil {
+			return err
+		}
+		var addrs []address.Address
+		for _, s := range cctx.Args().Slice() {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return fmt.Errorf("invalid control address %q: %w", s, err)
+			}
+			addrs = append(addrs, a)
+		}
+
+		client, err := service.NewClient()
+		if err != nil {
+			return err
+		}
+		change, err := client.Ex.PlanControlChange(miner, addrs)
+		if err != nil {
+			return err
+		}
+		if !change.Changed() {
+			fmt.Println("Control addresses are already up to date, nothing to do")
+			return nil
+		}
+		printControlChange(change)
+
+		data, err := withMsigProposer(cctx, &service.Payload{
+			Type:            service.RequestTypeMinerSetControl,
+			MinerID:         miner,
+			NewControlAddrs: change.New,
+		})
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("dry-run") {
+			return simulate(cctx, data)
+		}
+
+		if !cctx.Bool("really-do-it") {
+			fmt.Println("Pass --really-do-it to actually execute this action")
+			return nil
+		}
+
+		res, err := client.Ex.Execute(data)
+		return handleResult(cctx, res, err)
+	},
+}
+
+// printControlChange 输出 control 地址的变化，移除的地址仍有余额时给出警告
+// 输出到标准错误，不影响 --json 结果
+func printControlChange(change *service.ControlChange) {
+	for _, a := range change.Added {
+		fmt.Fprintf(os.Stderr, "%s %s\n", color.GreenString("+"), a)
+	}
+	for _, r := range change.Removed {
+		fmt.Fprintf(os.Stderr, "%s %s (%s, balance %s)\n", color.RedString("-"), r.ID, r.Key, r.Balance)
+		if types.BigInt(r.Balance).Sign() > 0 {
+			fmt.Fprintln(os.Stderr, color.YellowString("warning: %s still holds %s and will no longer be usable by the miner, transfer the funds out separately", r.Key, r.Balance))
+		}
+	}
+	if len(change.Added) == 0 && len(change.Removed) == 0 {
+		fmt.Fprintln(os.Stderr, "Control addresses reordered")
+	}
+}
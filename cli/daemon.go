@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/policy"
+	"wallet-sign/internal/repository"
+	"wallet-sign/internal/rpc"
+	"wallet-sign/internal/vapi"
+	"wallet-sign/internal/walletapi"
+)
+
+// apiTokenEnv 远程钱包服务认证令牌环境变量
+const apiTokenEnv = "WALLET_SIGN_API_TOKEN"
+
+// DaemonCmd 远程钱包服务命令
+// 通过 JSON-RPC 提供 Lotus 钱包接口，lotus 或 lotus-miner 可将其配置为外部钱包（RemoteBackend）
+var DaemonCmd = &cli.Command{
+	Name:  "daemon",
+	Usage: "启动兼容 Lotus WalletAPI 的远程钱包服务",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "listen",
+			Usage: "监听地址（默认读取配置 Daemon.Listen）",
+		},
+		metricsListenFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		cfg, err := appcfg.LoadConfig()
+		if err != nil {
+			return err
+		}
+		// 打开数据库连接
+		store, err := repository.OpenStore(cfg.DBDSN)
+		if err != nil {
+			return err
+		}
+
+		// 服务运行期间不能在终端输入口令，启动时解锁密钥库
+		if err := store.EnsureUnlocked(); err != nil {
+			return err
+		}
+
+		// 启动时加载签名策略，策略文件有误时不启动服务
+		p, err := policy.Current()
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			fmt.Fprintln(os.Stderr, "Warning: no signing policy configured (Security.PolicyFile), all sign requests are allowed")
+		}
+
+		if err := verifyDaemonNetwork(cctx.Context); err != nil {
+			return err
+		}
+
+		listen, token, err := daemonSettings(cctx)
+		if err != nil {
+			return err
+		}
+
+		lst, err := net.Listen("tcp", listen)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", listen, err)
+		}
+
+		if d := appcfg.LotusConfig.Daemon; d != nil && d.AllowExport {
+			fmt.Fprintln(os.Stderr, "Warning: Daemon.AllowExport is set, any token holder can export private keys")
+		}
+
+		server := walletapi.NewServer(walletapi.NewWalletAPI(store), token)
+		srv := &http.Server{
+			Handler:           server.Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		fmt.Fprintf(os.Stderr, "Wallet API listening on %s\n", lst.Addr())
+		fmt.Fprintf(os.Stderr, "Lotus config: [Wallet] RemoteBackend = \"%s:%s\"\n", token, listenMultiaddr(lst.Addr()))
+
+		ctx, stop := signal.NotifyContext(cctx.Context, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if err := startMetrics(ctx, cctx, store); err != nil {
+			return err
+		}
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- srv.Serve(lst)
+		}()
+
+		select {
+		case err := <-errCh:
+			if !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		case <-ctx.Done():
+		}
+
+		fmt.Fprintln(os.Stderr, "Shutting down wallet API")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	},
+}
+
+// verifyDaemonNetwork 启动时按 [Lotus] 中的节点确认网络，签名使用的地址前缀、链 ID 及密钥网络都依赖它
+// 选择了网络而节点不可用时只警告，仍按选择的网络签名；未选择网络时无法确定网络，不启动服务
+func verifyDaemonNetwork(ctx context.Context) error {
+	name, err := vapi.NewNode(ctx, rpc.NewLotusApi()).StateNetworkName()
+	if err == nil {
+		return appcfg.VerifyNetwork(name)
+	}
+	if appcfg.NetworkConfirmed() {
+		fmt.Fprintf(os.Stderr, "Warning: cannot verify network %s against the Lotus node: %v\n", appcfg.NetworkName(), err)
+		return nil
+	}
+	return fmt.Errorf("cannot determine the network from the Lotus node, select one with --network: %w", err)
+}
+
+// daemonSettings 返回服务的监听地址和认证令牌
+// 令牌优先使用 WALLET_SIGN_API_TOKEN 环境变量，其次是配置 Daemon.Token，都未设置时随机生成
+func daemonSettings(cctx *cli.Context) (string, string, error) {
+	listen := appcfg.DefaultDaemonListen
+	var token string
+	if d := appcfg.LotusConfig.Daemon; d != nil {
+		if d.Listen != "" {
+			listen = d.Listen
+		}
+		token = d.Token
+	}
+	if cctx.IsSet("listen") {
+		listen = cctx.String("listen")
+	}
+	if env, ok := os.LookupEnv(apiTokenEnv); ok && env != "" {
+		token = env
+	}
+
+	if token == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return "", "", fmt.Errorf("failed to generate api token: %w", err)
+		}
+		token = hex.EncodeToString(buf)
+	}
+	return listen, token, nil
+}
+
+// listenMultiaddr 将监听地址转换为 Lotus 使用的 multiaddr 格式
+// 监听所有网卡时使用本机回环地址
+func listenMultiaddr(addr net.Addr) string {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return addr.String()
+	}
+	ip := tcp.IP
+	if ip == nil || ip.IsUnspecified() {
+		ip = net.IPv4(127, 0, 0, 1)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("/ip4/%s/tcp/%d/http", ip4, tcp.Port)
+	}
+	return fmt.Sprintf("/ip6/%s/tcp/%d/http", ip, tcp.Port)
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"wallet-sign/internal/service"
+)
+
+// dryRunFlag 预执行参数
+// 构建完整消息并在节点上预执行，不签名也不推送
+var dryRunFlag = &cli.BoolFlag{
+	Name:  "dry-run",
+	Usage: "构建消息并预执行，输出预测的退出码、Gas 和手续费，不签名也不推送",
+}
+
+// simulate 预执行请求并输出结果
+// 预测退出码非零时以 ExitCodeMessageFailed 退出
+func simulate(cctx *cli.Context, data *service.Payload) error {
+	client, err := service.NewClient()
+	if err != nil {
+		return err
+	}
+
+	sim, err := client.Ex.Simulate(data)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitCodeError)
+	}
+
+	if cctx.Bool("json") {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(sim); err != nil {
+			return err
+		}
+	} else {
+		printSimulation(sim)
+	}
+
+	if sim.ExitCode != 0 {
+		return cli.Exit(fmt.Sprintf("message would fail with exit code: %d", sim.ExitCode), ExitCodeMessageFailed)
+	}
+	return nil
+}
+
+// printSimulation 以文本格式输出预执行结果
+func printSimulation(sim *service.Simulation) {
+	fmt.Printf("Request:     %s (dry run)\n", sim.RequestType)
+	fmt.Printf("Summary:     %s\n", sim.Summary)
+	printMessage(sim.Message)
+	if sim.Params != nil {
+		params, err := json.Marshal(sim.Params)
+		if err == nil {
+			fmt.Printf("Params:      %s\n", string(params))
+		}
+	}
+	fmt.Printf("Exit Code:   %d\n", sim.ExitCode)
+	fmt.Printf("Gas Used:    %d\n", sim.GasUsed)
+	if sim.Error != "" {
+		fmt.Printf("Error:       %s\n", sim.Error)
+	}
+	if !sim.HasKey {
+		fmt.Printf("Warning:     wallet does not have key for %s\n", sim.Message.From)
+	}
+}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/urfave/cli/v2"
+
+	"wallet-sign/internal/chain/types"
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/models"
+	"wallet-sign/internal/repository"
+	"wallet-sign/internal/service"
+	"wallet-sign/internal/ui/tablewriter"
+)
+
+// HistoryCmd 消息历史命令
+// 查看本工具构建、签名和推送过的消息，并同步待确认消息的链上状态
+var HistoryCmd = &cli.Command{
+	Name:  "history",
+	Usage: "消息历史记录",
+	Subcommands: []*cli.Command{
+		historyList,
+		historyShow,
+		historySync,
+	},
+}
+
+// historyList 列出消息历史命令
+var historyList = &cli.Command{
+	Name:  "list",
+	Usage: "列出消息历史",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "status",
+			Usage: "按状态过滤（built、signed、pushed、included、failed、replaced）",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "按发送方过滤",
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "最多显示的记录数，0 表示全部",
+			Value: 50,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		store, err := openHistoryStore()
+		if err != nil {
+			return err
+		}
+
+		var from string
+		if s := cctx.String("from"); s != "" {
+			addr, err := parseAddress(s)
+			if err != nil {
+				return err
+			}
+			from = addr.String()
+		}
+
+		items, err := store.ListMessages(cctx.String("status"), from, cctx.Int("limit"))
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			return printJSON(items)
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Time"),
+			tablewriter.Col("Request"),
+			tablewriter.Col("From"),
+			tablewriter.Col("To"),
+			tablewriter.Col("Nonce"),
+			tablewriter.Col("Value"),
+			tablewriter.Col("Status"),
+			tablewriter.Col("Height"),
+			tablewriter.Col("Exit"),
+			tablewriter.Col("CID"))
+		for _, item := range items {
+			row := map[string]interface{}{
+				"Time":    item.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+				"Request": item.RequestType,
+				"From":    item.FromAddr,
+				"To":      item.ToAddr,
+				"Nonce":   item.Nonce,
+				"Value":   historyValue(item.Value),
+				"Status":  item.Status,
+				"CID":     historyCid(item),
+			}
+			if item.Height > 0 {
+				row["Height"] = item.Height
+				row["Exit"] = item.ExitCode
+			}
+			tw.Write(row)
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
+
+// historyShow 查看消息详情命令
+var historyShow = &cli.Command{
+	Name:      "show",
+	Usage:     "查看消息详情",
+	ArgsUsage: "[消息 CID]",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify message cid")
+		}
+
+		store, err := openHistoryStore()
+		if err != nil {
+			return err
+		}
+
+		item, err := store.GetMessage(cctx.Args().First())
+		if err != nil {
+			return fmt.Errorf("message %s not found in history: %w", cctx.Args().First(), err)
+		}
+
+		if cctx.Bool("json") {
+			return printJSON(item)
+		}
+
+		fmt.Printf("Request:     %s\n", item.RequestType)
+		fmt.Printf("Status:      %s\n", item.Status)
+		fmt.Printf("CID:         %s\n", historyCid(item))
+		fmt.Printf("Unsigned:    %s\n", item.UnsignedCid)
+		if item.ReplacedBy != "" {
+			fmt.Printf("Replaced By: %s\n", item.ReplacedBy)
+		}
+		if item.Height > 0 {
+			fmt.Printf("Height:      %d\n", item.Height)
+			fmt.Printf("Exit Code:   %d\n", item.ExitCode)
+			fmt.Printf("Gas Used:    %d\n", item.GasUsed)
+		}
+		if item.Error != "" {
+			fmt.Printf("Error:       %s\n", item.Error)
+		}
+		fmt.Printf("Created:     %s\n", item.CreatedAt.Local().Format("2006-01-02 15:04:05 MST"))
+		fmt.Printf("Updated:     %s\n", item.UpdatedAt.Local().Format("2006-01-02 15:04:05 MST"))
+
+		if len(item.Message) > 0 {
+			msg, err := types.DecodeMessage(item.Message)
+			if err != nil {
+				return fmt.Errorf("failed to decode stored message: %w", err)
+			}
+			printMessage(msg)
+		}
+		if len(item.Signature) > 0 {
+			var sig crypto.Signature
+			if err := sig.UnmarshalBinary(item.Signature); err != nil {
+				return fmt.Errorf("failed to decode stored signature: %w", err)
+			}
+			fmt.Printf("Signature:   type %d, %x\n", sig.Type, sig.Data)
+		}
+		return nil
+	},
+}
+
+// historySync 同步待确认消息状态命令
+var historySync = &cli.Command{
+	Name:  "sync",
+	Usage: "通过 StateSearchMsg 查询待确认消息的链上状态",
+	Action: func(cctx *cli.Context) error {
+		client, err := service.NewClient()
+		if err != nil {
+			return err
+		}
+
+		updated, err := client.Ex.SyncHistory()
+		for _, item := range updated {
+			fmt.Printf("%s\t%s\theight %d\texit code %d\n", historyCid(item), item.Status, item.Height, item.ExitCode)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d messages updated\n", len(updated))
+		return nil
+	},
+}
+
+// openHistoryStore 打开消息历史所在的数据库
+func openHistoryStore() (*repository.Store, error) {
+	cfg, err := appcfg.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return repository.OpenStore(cfg.DBDSN)
+}
+
+// historyCid 返回消息记录的链上 CID，尚未签名时返回未签名消息 CID
+func historyCid(item *models.Message) string {
+	if item.Cid != "" {
+		return item.Cid
+	}
+	return item.UnsignedCid
+}
+
+// historyValue 将 attoFIL 字符串格式化为 FIL
+func historyValue(v string) string {
+	if v == "" {
+		return ""
+	}
+	f, err := types.ParseFIL(v + "afil")
+	if err != nil {
+		return v
+	}
+	return f.String()
+}
+
+// printJSON 以 JSON 格式输出到标准输出
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
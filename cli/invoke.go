@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/service"
+)
+
+// InvokeCmd 调用任意 actor 方法命令
+// 内置 actor 的方法可以用名称指定，参数以 JSON 给出并按 go-state-types 中的参数类型编码
+var InvokeCmd = &cli.Command{
+	Name:      "invoke",
+	Usage:     "调用 actor 方法，如 ChangePeerID、RepayDebt",
+	ArgsUsage: "[目标地址] [方法名或方法编号]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "发送消息的地址",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "value",
+			Usage: "随消息发送的金额（FIL）",
+			Value: "0",
+		},
+		&cli.StringFlag{
+			Name:  "params-json",
+			Usage: "JSON 格式的方法参数，以 @ 开头时从文件读取",
+		},
+		&cli.StringFlag{
+			Name:  "params-hex",
+			Usage: "十六进制的 CBOR 编码参数，用于非内置 actor",
+		},
+		msigProposerFlag,
+		dryRunFlag,
+	},
+	Action: payloadAction(invokePayload),
+}
+
+// invokePayload 从命令行参数构建 actor 方法调用请求
+func invokePayload(cctx *cli.Context) (*service.Payload, error) {
+	if cctx.NArg() != 2 {
+		return nil, fmt.Errorf("must specify destination and method")
+	}
+	if cctx.IsSet("params-json") && cctx.IsSet("params-hex") {
+		return nil, fmt.Errorf("--params-json and --params-hex are mutually exclusive")
+	}
+
+	to, err := parseAddress(cctx.Args().Get(0))
+	if err != nil {
+		return nil, err
+	}
+	from, err := parseAddress(cctx.String("from"))
+	if err != nil {
+		return nil, err
+	}
+	val, err := types.ParseFIL(cctx.String("value"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse value: %w", err)
+	}
+
+	var paramsJSON json.RawMessage
+	if s := cctx.String("params-json"); s != "" {
+		data := []byte(s)
+		if strings.HasPrefix(s, "@") {
+			data, err = os.ReadFile(strings.TrimPrefix(s, "@"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read params file: %w", err)
+			}
+		}
+		if !json.Valid(data) {
+			return nil, fmt.Errorf("--params-json is not valid JSON")
+		}
+		paramsJSON = data
+	}
+	var params []byte
+	if s := cctx.String("params-hex"); s != "" {
+		params, err = hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex params: %w", err)
+		}
+	}
+
+	return withMsigProposer(cctx, &service.Payload{
+		Type:       service.RequestTypeInvoke,
+		FromAddr:   from,
+		ToAddr:     to,
+		Amount:     val,
+		MethodName: cctx.Args().Get(1),
+		Params:     params,
+		ParamsJSON: paramsJSON,
+	})
+}
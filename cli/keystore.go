@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/repository"
+)
+
+// KeystoreCmd 密钥库管理命令
+var KeystoreCmd = &cli.Command{
+	Name:  "keystore",
+	Usage: "密钥库管理",
+	Subcommands: []*cli.Command{
+		keystoreChangePassphrase,
+	},
+}
+
+// keystoreChangePassphrase 修改密钥库口令命令
+// 使用新口令重新加密所有钱包密钥，整个过程在同一事务中完成
+var keystoreChangePassphrase = &cli.Command{
+	Name:  "change-passphrase",
+	Usage: "修改密钥库口令并重新加密所有密钥",
+	Action: func(cctx *cli.Context) error {
+		cfg, err := appcfg.LoadConfig()
+		if err != nil {
+			return err
+		}
+		// 打开数据库连接
+		store, err := repository.OpenStore(cfg.DBDSN)
+		if err != nil {
+			return err
+		}
+
+		// 先使用当前口令解锁，不使用已有的解锁会话
+		if err := store.Lock(); err != nil {
+			return err
+		}
+		if err := store.EnsureUnlocked(); err != nil {
+			return err
+		}
+
+		newPass, err := repository.ReadNewPassphrase()
+		if err != nil {
+			return err
+		}
+
+		if err := store.ChangePassphrase(newPass); err != nil {
+			return fmt.Errorf("修改口令失败: %w", err)
+		}
+
+		fmt.Println("密钥库口令已修改，所有会话已锁定")
+		fmt.Println("如果配置文件中仍有 Security.Seed，请将其删除")
+		return nil
+	},
+}
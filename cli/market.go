@@ -16,22 +16,18 @@ var MarketWithdrawCmd = &cli.Command{
 	Name:      "market-withdraw",
 	Usage:     "Withdraw funds from the storage market",
 	ArgsUsage: "[address] [amount]",
+	Flags: []cli.Flag{
+		msigProposerFlag,
+		dryRunFlag,
+	},
 	Action: func(cctx *cli.Context) error {
-		// 检查参数数量
-		if cctx.NArg() < 2 {
-			return fmt.Errorf("address and amount are required")
-		}
-
-		// 解析地址参数
-		addr, err := address.NewFromString(cctx.Args().Get(0))
+		data, err := marketWithdrawPayload(cctx)
 		if err != nil {
 			return err
 		}
 
-		// 解析金额参数
-		amount, err := types.ParseFIL(cctx.Args().Get(1))
-		if err != nil {
-			return fmt.Errorf("failed to parse amount: %w", err)
+		if cctx.Bool("dry-run") {
+			return simulate(cctx, data)
 		}
 
 		// 创建审批客户端
@@ -40,14 +36,34 @@ var MarketWithdrawCmd = &cli.Command{
 			return err
 		}
 
-		// 创建矿工提现请求
-		data := &service.Payload{
-			Type:    service.RequestTypeMarketWithdraw,
-			MinerID: addr,
-			Amount:  amount,
-		}
-		client.Ex.Execute(data)
-
-		return nil
+		res, err := client.Ex.Execute(data)
+		return handleResult(cctx, res, err)
 	},
 }
+
+// marketWithdrawPayload 从命令行参数构建市场提现请求
+func marketWithdrawPayload(cctx *cli.Context) (*service.Payload, error) {
+	// 检查参数数量
+	if cctx.NArg() < 2 {
+		return nil, fmt.Errorf("address and amount are required")
+	}
+
+	// 解析地址参数
+	addr, err := address.NewFromString(cctx.Args().Get(0))
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析金额参数
+	amount, err := types.ParseFIL(cctx.Args().Get(1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse amount: %w", err)
+	}
+
+	// 创建市场提现请求
+	return withMsigProposer(cctx, &service.Payload{
+		Type:    service.RequestTypeMarketWithdraw,
+		MinerID: addr,
+		Amount:  amount,
+	})
+}
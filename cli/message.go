@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/urfave/cli/v2"
+
+	"wallet-sign/internal/chain/actors"
+	"wallet-sign/internal/chain/types"
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/policy"
+	"wallet-sign/internal/repository"
+	"wallet-sign/internal/service"
+	"wallet-sign/internal/wallet"
+)
+
+// MessageCmd 离线签名命令
+// 联网机器构建未签名消息，离线机器签名，最后通过 push 命令广播
+var MessageCmd = &cli.Command{
+	Name:  "message",
+	Usage: "离线签名：构建、签名消息",
+	Subcommands: []*cli.Command{
+		messageBuild,
+		messageSign,
+	},
+}
+
+// messageBuild 构建未签名消息命令（需要联网）
+// 子命令与对应的交易命令参数一致
+var messageBuild = &cli.Command{
+	Name:  "build",
+	Usage: "构建未签名消息并写入文件（需要连接 Lotus 节点）",
+	Subcommands: []*cli.Command{
+		buildCommand(SendCmd, sendPayload),
+		buildCommand(WithdrawCmd, withdrawPayload),
+		buildCommand(MarketWithdrawCmd, marketWithdrawPayload),
+		buildCommand(setOwner, setOwnerPayload),
+		buildCommand(setWorker, proposeWorkerPayload),
+		buildCommand(confirmWorker, confirmWorkerPayload),
+		{
+			Name:  "msig",
+			Usage: "构建多签钱包消息",
+			Subcommands: []*cli.Command{
+				buildCommand(msigCreate, msigCreatePayload),
+				buildCommand(msigPropose, msigProposePayload),
+				buildCommand(msigApprove, msigTxnPayload(service.RequestTypeMsigApprove)),
+				buildCommand(msigCancel, msigTxnPayload(service.RequestTypeMsigCancel)),
+				buildCommand(msigAddSigner, msigAddSignerPayload),
+				buildCommand(msigRemoveSigner, msigRemoveSignerPayload),
+				buildCommand(msigSwapSigner, msigSwapSignerPayload),
+				buildCommand(msigThreshold, msigThresholdPayload),
+			},
+		},
+	},
+}
+
+// buildCommand 基于已有交易命令生成对应的 message build 子命令
+// 复用原命令的参数解析，只构建消息而不签名、不推送
+func buildCommand(src *cli.Command, payload func(*cli.Context) (*service.Payload, error)) *cli.Command {
+	var flags []cli.Flag
+	for _, f := range src.Flags {
+		// 仅构建消息，不需要执行确认或预执行
+		if name := f.Names()[0]; name == "really-do-it" || name == "dry-run" {
+			continue
+		}
+		flags = append(flags, f)
+	}
+	flags = append(flags, &cli.StringFlag{
+		Name:     "output",
+		Aliases:  []string{"o"},
+		Usage:    "未签名消息输出文件",
+		Required: true,
+	})
+
+	return &cli.Command{
+		Name:      src.Name,
+		Usage:     src.Usage + "（仅构建未签名消息）",
+		ArgsUsage: src.ArgsUsage,
+		Flags:     flags,
+		Action: func(cctx *cli.Context) error {
+			data, err := payload(cctx)
+			if err != nil {
+				return err
+			}
+
+			client, err := service.NewClient()
+			if err != nil {
+				return err
+			}
+
+			env, err := client.Ex.BuildEnvelope(data)
+			if err != nil {
+				return err
+			}
+
+			if err := service.WriteEnvelope(cctx.String("output"), env); err != nil {
+				return fmt.Errorf("failed to write envelope: %w", err)
+			}
+
+			fmt.Printf("%s\n", env.Summary)
+			fmt.Printf("unsigned message %s written to %s\n", env.MessageCid, cctx.String("output"))
+			return nil
+		},
+	}
+}
+
+// messageSign 签名离线消息命令（无需联网）
+// 读取 message build 生成的文件，展示内容并在确认后签名
+var messageSign = &cli.Command{
+	Name:      "sign",
+	Usage:     "签名 message build 生成的未签名消息（无需连接节点）",
+	ArgsUsage: "[未签名消息文件]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "已签名消息输出文件（默认输出到标准输出）",
+		},
+		&cli.BoolFlag{
+			Name:  "yes",
+			Usage: "跳过签名确认",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify unsigned message file")
+		}
+
+		env, err := service.ReadEnvelope(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+		// 离线机器不连接节点，按构建消息的节点所在网络校验或确定网络
+		if err := appcfg.VerifyNetwork(env.Network); err != nil {
+			return err
+		}
+
+		printEnvelope(env)
+
+		// 如果没有 --yes 标志，请求确认
+		if !cctx.Bool("yes") {
+			fmt.Print("输入 'yes' 确认签名: ")
+			reader := bufio.NewReader(os.Stdin)
+			confirm, _ := reader.ReadString('\n')
+			if strings.TrimSpace(confirm) != "yes" {
+				fmt.Println("已取消签名操作")
+				return nil
+			}
+		}
+
+		cfg, err := appcfg.LoadConfig()
+		if err != nil {
+			return err
+		}
+		store, err := repository.OpenStore(cfg.DBDSN)
+		if err != nil {
+			return err
+		}
+
+		signed, err := wallet.SignMessage(store, env.Message)
+		if err != nil {
+			var rejected *policy.RejectedError
+			if errors.As(err, &rejected) {
+				return cli.Exit(err.Error(), ExitCodePolicyRejected)
+			}
+			return err
+		}
+		service.RecordSigned(store, env.RequestType, signed)
+
+		buf, err := signed.Serialize()
+		if err != nil {
+			return err
+		}
+		out := hex.EncodeToString(buf)
+
+		if path := cctx.String("output"); path != "" {
+			if err := os.WriteFile(path, []byte(out+"\n"), 0600); err != nil {
+				return err
+			}
+			fmt.Printf("signed message %s written to %s\n", signed.Cid(), path)
+			return nil
+		}
+
+		fmt.Println(out)
+		return nil
+	},
+}
+
+// printEnvelope 输出未签名消息的详细内容，供离线操作员核对
+// 地址、金额、方法及按方法表离线解码的参数都从签名的 Message 得出；请求类型、摘要及文件中的参数由构建消息的
+// 机器写入，可能与消息不符，标为 unverified，文件中的参数与解码结果一致时才标为已核对
+func printEnvelope(env *service.MessageEnvelope) {
+	fmt.Printf("Network:     %s\n", env.Network)
+	fmt.Printf("Message CID: %s\n", env.MessageCid)
+	printMessage(env.Message)
+	decoded := printDecodedParams(env.Message)
+	fmt.Printf("Request:     %s (unverified)\n", env.RequestType)
+	fmt.Printf("Summary:     %s (unverified)\n", env.Summary)
+	if len(env.Params) > 0 {
+		var params bytes.Buffer
+		if err := json.Compact(&params, env.Params); err != nil {
+			params.Reset()
+			params.Write(env.Params)
+		}
+		if paramsMatch(params.Bytes(), decoded) {
+			fmt.Printf("Params:      %s (matches the decoded message params)\n", params.String())
+		} else {
+			fmt.Printf("Params:      %s (unverified, does not match the decoded message params)\n", params.String())
+		}
+	}
+	fmt.Printf("Created:     %s\n", env.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+}
+
+// printDecodedParams 不连接节点，按内置 actor 的方法表解码消息参数并输出，返回解码结果
+// 离线无法查询接收方的 actor 类型，多个方法表都能解码时逐一列出
+func printDecodedParams(msg *types.Message) []*actors.DecodedParams {
+	if msg.Method == builtin.MethodSend {
+		if len(msg.Params) > 0 {
+			fmt.Printf("Decoded:     Send, raw params %x\n", msg.Params)
+		} else {
+			fmt.Printf("Decoded:     Send\n")
+		}
+		return nil
+	}
+
+	decoded := actors.DecodeParams(msg.To, msg.Method, msg.Params)
+	if len(decoded) == 0 {
+		fmt.Printf("Decoded:     unknown method %d, raw params %x\n", msg.Method, msg.Params)
+		return nil
+	}
+	if len(decoded) > 1 {
+		fmt.Printf("Decoded:     receiver actor type is unknown offline, candidates:\n")
+	}
+	for _, d := range decoded {
+		text := d.Actor + "." + d.Method.Name
+		if d.Params != nil {
+			if b, err := json.Marshal(d.Params); err == nil {
+				text += " " + string(b)
+			}
+		}
+		if len(decoded) == 1 {
+			fmt.Printf("Decoded:     %s\n", text)
+		} else {
+			fmt.Printf("             %s\n", text)
+		}
+	}
+	return decoded
+}
+
+// paramsMatch 检查文件中的参数（紧凑 JSON）是否与某个解码结果一致
+func paramsMatch(params []byte, decoded []*actors.DecodedParams) bool {
+	for _, d := range decoded {
+		if d.Params == nil {
+			continue
+		}
+		if b, err := json.Marshal(d.Params); err == nil && bytes.Equal(b, params) {
+			return true
+		}
+	}
+	return false
+}
+
+// printMessage 输出消息的地址、金额、方法、nonce 及 Gas 参数
+func printMessage(msg *types.Message) {
+	maxFee := types.BigMul(msg.GasFeeCap, types.NewInt(uint64(msg.GasLimit)))
+
+	fmt.Printf("From:        %s\n", msg.From)
+	fmt.Printf("To:          %s\n", msg.To)
+	fmt.Printf("Value:       %s\n", types.FIL(msg.Value))
+	fmt.Printf("Method:      %d\n", msg.Method)
+	fmt.Printf("Nonce:       %d\n", msg.Nonce)
+	fmt.Printf("GasLimit:    %d\n", msg.GasLimit)
+	fmt.Printf("GasFeeCap:   %s\n", msg.GasFeeCap)
+	fmt.Printf("GasPremium:  %s\n", msg.GasPremium)
+	fmt.Printf("MaxFee:      %s\n", types.FIL(maxFee))
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wallet-sign/internal/chain/types"
+)
+
+// captureStdout 返回 fn 执行期间写入标准输出的内容
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	err = fn()
+	os.Stdout = prev
+	w.Close()
+	return <-out, err
+}
+
+// TestMessageSignDecodesParams 离线签名时按方法表从消息解码参数，文件中的摘要及参数标为未核对，
+// 与消息不符的参数单独标出
+func TestMessageSignDecodesParams(t *testing.T) {
+	env := newCLIEnv(t)
+	owner, worker := env.newKey(types.FromFil(10)), env.newKey(types.FromFil(10))
+	miner := env.node.AddMiner(owner, worker, types.FromFil(50))
+
+	unsigned := filepath.Join(t.TempDir(), "unsigned.json")
+	if err := env.run("message", "build", "withdraw", "--minerId", miner.String(), "-o", unsigned, owner.String(), "5"); err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	sign := func(path string) string {
+		t.Helper()
+		out, err := captureStdout(t, func() error {
+			return env.run("message", "sign", "--yes", "-o", filepath.Join(t.TempDir(), "signed.hex"), path)
+		})
+		if err != nil {
+			t.Fatalf("sign: %v\n%s", err, out)
+		}
+		return out
+	}
+
+	out := sign(unsigned)
+	for _, want := range []string{
+		"Decoded:     storageminer.WithdrawBalance {",
+		"(matches the decoded message params)",
+		"Summary:     ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if !strings.Contains(out, "(unverified)\n") {
+		t.Errorf("summary is not labelled unverified:\n%s", out)
+	}
+
+	// 篡改文件中的参数，消息本身不变
+	data, err := os.ReadFile(unsigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	raw["params"] = json.RawMessage(`{"AmountRequested":"1"}`)
+	tampered := filepath.Join(t.TempDir(), "tampered.json")
+	if data, err = json.Marshal(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tampered, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if out := sign(tampered); !strings.Contains(out, "(unverified, does not match the decoded message params)") {
+		t.Errorf("tampered params are not flagged:\n%s", out)
+	}
+}
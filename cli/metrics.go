@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/urfave/cli/v2"
+
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/metrics"
+	"wallet-sign/internal/repository"
+	"wallet-sign/internal/rpc"
+	"wallet-sign/internal/vapi"
+)
+
+// defaultBalanceInterval 余额指标的默认刷新间隔
+const defaultBalanceInterval = time.Minute
+
+// metricsListenFlag Prometheus 指标监听地址参数
+var metricsListenFlag = &cli.StringFlag{
+	Name:  "metrics-listen",
+	Usage: "Prometheus 指标（/metrics）监听地址（默认读取配置 Metrics.Listen，为空时不启动）",
+}
+
+// startMetrics 按 --metrics-listen 或配置 Metrics.Listen 提供 /metrics，并在后台刷新余额指标
+// 未配置监听地址时不做任何事，ctx 结束时停止
+func startMetrics(ctx context.Context, cctx *cli.Context, store *repository.Store) error {
+	cfg := appcfg.LotusConfig.Metrics
+	if cfg == nil {
+		cfg = &appcfg.Metrics{}
+	}
+	listen := cfg.Listen
+	if cctx.IsSet("metrics-listen") {
+		listen = cctx.String("metrics-listen")
+	}
+	if listen == "" {
+		return nil
+	}
+
+	interval := defaultBalanceInterval
+	if cfg.BalanceInterval != "" {
+		d, err := time.ParseDuration(cfg.BalanceInterval)
+		if err != nil {
+			return fmt.Errorf("invalid Metrics.BalanceInterval: %w", err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("Metrics.BalanceInterval must be positive")
+		}
+		interval = d
+	}
+	names := cfg.Miners
+	if len(names) == 0 && appcfg.LotusConfig.Monitor != nil {
+		names = appcfg.LotusConfig.Monitor.Miners
+	}
+	var miners []address.Address
+	for _, s := range names {
+		a, err := address.NewFromString(s)
+		if err != nil {
+			return fmt.Errorf("invalid miner %q in Metrics.Miners: %w", s, err)
+		}
+		miners = append(miners, a)
+	}
+
+	addr, err := metrics.Serve(ctx, listen)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Metrics available at http://%s/metrics\n", addr)
+
+	wallets := func() ([]address.Address, error) {
+		keys, err := store.ListWalletKeys()
+		if err != nil {
+			return nil, err
+		}
+		addrs := make([]address.Address, 0, len(keys))
+		for _, k := range keys {
+			a, err := address.NewFromString(k.Address)
+			if err != nil {
+				continue
+			}
+			addrs = append(addrs, a)
+		}
+		return addrs, nil
+	}
+	node := vapi.NewNode(ctx, rpc.NewLotusApi())
+	go metrics.WatchBalances(ctx, node, wallets, miners, interval)
+	return nil
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/filecoin-project/go-address"
+	"github.com/urfave/cli/v2"
+
+	"wallet-sign/internal/monitor"
+	"wallet-sign/internal/rpc"
+	"wallet-sign/internal/vapi"
+)
+
+// MonitorCmd 余额及状态监控命令
+// 定期检查配置 [Monitor] 中矿工的 owner、worker、control 余额、待确认的变更及市场余额，
+// 条件开始成立、内容变化及恢复时将事件发送到 webhook 或本地命令
+var MonitorCmd = &cli.Command{
+	Name:  "monitor",
+	Usage: "监控矿工地址余额及待确认的变更，并通过 webhook 或本地命令告警",
+	Subcommands: []*cli.Command{
+		monitorRun,
+		monitorTestHook,
+	},
+}
+
+// monitorRun 启动监控命令
+var monitorRun = &cli.Command{
+	Name:  "run",
+	Usage: "按轮询间隔检查并发送事件",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "miner",
+			Usage: "监控的矿工，覆盖配置 Monitor.Miners",
+		},
+		&cli.DurationFlag{
+			Name:  "interval",
+			Usage: "轮询间隔，覆盖配置 Monitor.Interval",
+		},
+		&cli.BoolFlag{
+			Name:  "once",
+			Usage: "只检查一次后退出",
+		},
+		metricsListenFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		settings, err := monitor.LoadSettings()
+		if err != nil {
+			return err
+		}
+		if cctx.IsSet("miner") {
+			settings.Miners = nil
+			for _, s := range cctx.StringSlice("miner") {
+				a, err := address.NewFromString(s)
+				if err != nil {
+					return fmt.Errorf("invalid miner %q: %w", s, err)
+				}
+				settings.Miners = append(settings.Miners, a)
+			}
+		}
+		if len(settings.Miners) == 0 {
+			return fmt.Errorf("no miners to monitor, pass --miner or set Monitor.Miners")
+		}
+		if cctx.IsSet("interval") {
+			if settings.Interval = cctx.Duration("interval"); settings.Interval < time.Minute {
+				return fmt.Errorf("interval must be at least 1m")
+			}
+		}
+		if len(settings.Hooks) == 0 {
+			fmt.Fprintln(os.Stderr, "Warning: no Monitor.Webhook or Monitor.Command configured, events are only printed")
+		}
+
+		ctx, stop := signal.NotifyContext(cctx.Context, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		store, err := openHistoryStore()
+		if err != nil {
+			return err
+		}
+		if err := startMetrics(ctx, cctx, store); err != nil {
+			return err
+		}
+
+		node := vapi.NewNode(ctx, rpc.NewLotusApi())
+		m := monitor.New(node, settings)
+		if !cctx.Bool("once") {
+			fmt.Fprintf(os.Stderr, "Monitoring %v every %s\n", settings.Miners, settings.Interval)
+		}
+		for {
+			events, err := m.Check()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, color.RedString("monitor check failed: %v", err))
+			} else if err := printEvents(cctx, events); err != nil {
+				return err
+			}
+			// 检查失败时仍重试此前未投递的事件
+			if err := m.Notify(ctx, events); err != nil {
+				fmt.Fprintln(os.Stderr, color.RedString("failed to deliver events, %d deliveries will be retried: %v", m.Pending(), err))
+			}
+			if cctx.Bool("once") {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				fmt.Fprintln(os.Stderr, "Stopping monitor")
+				return nil
+			case <-time.After(settings.Interval):
+			}
+		}
+	},
+}
+
+// monitorTestHook 发送测试事件命令
+var monitorTestHook = &cli.Command{
+	Name:  "test-hook",
+	Usage: "向配置的 webhook 和本地命令发送一个测试事件",
+	Action: func(cctx *cli.Context) error {
+		settings, err := monitor.LoadSettings()
+		if err != nil {
+			return err
+		}
+		if len(settings.Hooks) == 0 {
+			return fmt.Errorf("no Monitor.Webhook or Monitor.Command configured")
+		}
+		ev := &monitor.Event{
+			Type:    monitor.EventTest,
+			Status:  monitor.StatusFiring,
+			Message: "test event from wallet-sign monitor",
+			Time:    time.Now(),
+		}
+		if err := monitor.Notify(cctx.Context, settings.Hooks, []*monitor.Event{ev}); err != nil {
+			return err
+		}
+		for _, h := range settings.Hooks {
+			fmt.Printf("Delivered test event to %s\n", h.Name())
+		}
+		return nil
+	},
+}
+
+// printEvents 输出事件，指定 --json 时每行输出一个事件
+func printEvents(cctx *cli.Context, events []*monitor.Event) error {
+	for _, ev := range events {
+		if cctx.Bool("json") {
+			b, err := json.Marshal(ev)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			continue
+		}
+		status := color.RedString(ev.Status)
+		if ev.Status == monitor.StatusResolved {
+			status = color.GreenString(ev.Status)
+		}
+		fmt.Printf("%s height %d [%s] %s: %s\n", ev.Time.Format("2006-01-02 15:04:05"), ev.Height, status, ev.Type, ev.Message)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/policy"
+	"wallet-sign/internal/service"
+)
+
+// MpoolCmd 内存池消息管理命令
+// 提高滞留消息的 Gas 费用，或以相同 nonce 的自转账取消消息
+var MpoolCmd = &cli.Command{
+	Name:  "mpool",
+	Usage: "内存池消息管理",
+	Subcommands: []*cli.Command{
+		mpoolReplace,
+		mpoolCancel,
+	},
+}
+
+// replaceFlags 替换消息的 Gas 参数
+var replaceFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "gas-premium",
+		Usage: "新的 GasPremium（attoFIL），默认按最小替换比例提高",
+	},
+	&cli.StringFlag{
+		Name:  "gas-feecap",
+		Usage: "新的 GasFeeCap（attoFIL），默认按节点当前估算提高",
+	},
+}
+
+// mpoolReplace 提高待打包消息 Gas 费用命令
+var mpoolReplace = &cli.Command{
+	Name:      "replace",
+	Usage:     "提高内存池中待打包消息的 Gas 费用并重新签名推送",
+	ArgsUsage: "[消息 CID | 发送方 nonce]",
+	Flags:     replaceFlags,
+	Action: func(cctx *cli.Context) error {
+		return replaceAction(cctx, (*service.Executor).Replace)
+	},
+}
+
+// mpoolCancel 取消待打包消息命令
+var mpoolCancel = &cli.Command{
+	Name:      "cancel",
+	Usage:     "以相同 nonce 的零金额自转账替换内存池中待打包的消息",
+	ArgsUsage: "[消息 CID | 发送方 nonce]",
+	Flags:     replaceFlags,
+	Action: func(cctx *cli.Context) error {
+		return replaceAction(cctx, (*service.Executor).Cancel)
+	},
+}
+
+// replaceAction 查找待替换的消息，执行替换并输出结果
+func replaceAction(cctx *cli.Context, replace func(*service.Executor, *types.SignedMessage, service.ReplaceOptions) (*service.Replacement, error)) error {
+	var opts service.ReplaceOptions
+	for name, dst := range map[string]**types.BigInt{
+		"gas-premium": &opts.GasPremium,
+		"gas-feecap":  &opts.GasFeeCap,
+	} {
+		if !cctx.IsSet(name) {
+			continue
+		}
+		v, err := big.FromString(cctx.String(name))
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %w", name, err)
+		}
+		*dst = &v
+	}
+
+	client, err := service.NewClient()
+	if err != nil {
+		return err
+	}
+
+	var old *types.SignedMessage
+	switch cctx.NArg() {
+	case 1:
+		c, err := cid.Decode(cctx.Args().First())
+		if err != nil {
+			return fmt.Errorf("invalid message cid: %w", err)
+		}
+		old, err = client.Ex.PendingMessage(c)
+		if err != nil {
+			return err
+		}
+	case 2:
+		from, err := parseAddress(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+		nonce, err := strconv.ParseUint(cctx.Args().Get(1), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid nonce: %w", err)
+		}
+		old, err = client.Ex.PendingByNonce(from, nonce)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("must specify message cid, or sender and nonce")
+	}
+
+	res, err := replace(client.Ex, old, opts)
+	if err != nil {
+		var rejected *policy.RejectedError
+		if errors.As(err, &rejected) {
+			return cli.Exit(err.Error(), ExitCodePolicyRejected)
+		}
+		return err
+	}
+
+	if cctx.Bool("json") {
+		return printJSON(res)
+	}
+	fmt.Printf("Request:     %s\n", res.RequestType)
+	fmt.Printf("Replaced:    %s\n", res.OldCid)
+	fmt.Printf("Message:     %s\n", res.MsgCid)
+	fmt.Printf("From:        %s\n", res.From)
+	fmt.Printf("Nonce:       %d\n", res.Nonce)
+	fmt.Printf("Gas Premium: %s\n", res.GasPremium)
+	fmt.Printf("Gas Fee Cap: %s\n", res.GasFeeCap)
+	return nil
+}
@@ -0,0 +1,513 @@
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/urfave/cli/v2"
+
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/rpc"
+	"wallet-sign/internal/service"
+	"wallet-sign/internal/ui/tablewriter"
+	"wallet-sign/internal/vapi"
+)
+
+// MsigCmd 多签钱包命令
+// 创建多签钱包、发起和审批提案、管理签名人及阈值
+var MsigCmd = &cli.Command{
+	Name:  "msig",
+	Usage: "多签钱包管理",
+	Subcommands: []*cli.Command{
+		msigCreate,
+		msigPropose,
+		msigApprove,
+		msigCancel,
+		msigInspect,
+		msigAddSigner,
+		msigRemoveSigner,
+		msigSwapSigner,
+		msigThreshold,
+	},
+}
+
+// msigProposerFlag 多签提案人参数
+// 指定后请求构建的消息作为多签提案由该签名人发出，用于 owner 等地址为多签钱包的场景
+var msigProposerFlag = &cli.StringFlag{
+	Name:  "msig-proposer",
+	Usage: "发送方是多签钱包时，由该签名人将消息作为多签提案发出",
+}
+
+// msigFromFlag 多签命令的签名人参数
+var msigFromFlag = &cli.StringFlag{
+	Name:     "from",
+	Usage:    "发送消息的签名人地址",
+	Required: true,
+}
+
+// withMsigProposer 根据 --msig-proposer 参数设置请求的多签提案人
+func withMsigProposer(cctx *cli.Context, p *service.Payload) (*service.Payload, error) {
+	if s := cctx.String("msig-proposer"); s != "" {
+		proposer, err := parseAddress(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid msig proposer: %w", err)
+		}
+		p.Proposer = proposer
+	}
+	return p, nil
+}
+
+// payloadAction 返回执行请求的命令处理函数
+// 指定 --dry-run 时只预执行
+func payloadAction(payload func(*cli.Context) (*service.Payload, error)) cli.ActionFunc {
+	return func(cctx *cli.Context) error {
+		data, err := payload(cctx)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("dry-run") {
+			return simulate(cctx, data)
+		}
+
+		client, err := service.NewClient()
+		if err != nil {
+			return err
+		}
+
+		res, err := client.Ex.Execute(data)
+		return handleResult(cctx, res, err)
+	}
+}
+
+// msigCreate 创建多签钱包命令
+var msigCreate = &cli.Command{
+	Name:      "create",
+	Usage:     "通过 Init actor 创建多签钱包",
+	ArgsUsage: "[签名人地址 ...]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "支付创建费用及初始金额的账户",
+			Required: true,
+		},
+		&cli.Uint64Flag{
+			Name:  "required",
+			Usage: "审批阈值（默认为签名人数量）",
+		},
+		&cli.StringFlag{
+			Name:  "value",
+			Usage: "存入多签钱包的初始金额",
+			Value: "0",
+		},
+		&cli.Int64Flag{
+			Name:  "duration",
+			Usage: "初始金额的线性解锁周期（epoch），0 表示不锁仓",
+		},
+		&cli.Int64Flag{
+			Name:  "start-epoch",
+			Usage: "线性解锁的起始高度",
+		},
+		dryRunFlag,
+	},
+	Action: payloadAction(msigCreatePayload),
+}
+
+// msigCreatePayload 从命令行参数构建创建多签钱包请求
+func msigCreatePayload(cctx *cli.Context) (*service.Payload, error) {
+	if cctx.NArg() < 1 {
+		return nil, fmt.Errorf("must specify at least one signer")
+	}
+
+	from, err := parseAddress(cctx.String("from"))
+	if err != nil {
+		return nil, err
+	}
+
+	var signers []address.Address
+	for _, s := range cctx.Args().Slice() {
+		addr, err := parseAddress(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signer: %w", err)
+		}
+		signers = append(signers, addr)
+	}
+
+	val, err := types.ParseFIL(cctx.String("value"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse value: %w", err)
+	}
+
+	return &service.Payload{
+		Type:           service.RequestTypeMsigCreate,
+		FromAddr:       from,
+		Signers:        signers,
+		Threshold:      cctx.Uint64("required"),
+		UnlockDuration: abi.ChainEpoch(cctx.Int64("duration")),
+		StartEpoch:     abi.ChainEpoch(cctx.Int64("start-epoch")),
+		Amount:         val,
+	}, nil
+}
+
+// msigPropose 发起多签提案命令
+var msigPropose = &cli.Command{
+	Name:      "propose",
+	Usage:     "发起多签转账或方法调用提案",
+	ArgsUsage: "[多签地址] [目标地址] [金额] [方法编号（可选）] [十六进制参数（可选）]",
+	Flags: []cli.Flag{
+		msigFromFlag,
+		dryRunFlag,
+	},
+	Action: payloadAction(msigProposePayload),
+}
+
+// msigProposePayload 从命令行参数构建多签提案请求
+func msigProposePayload(cctx *cli.Context) (*service.Payload, error) {
+	if cctx.NArg() < 3 || cctx.NArg() > 5 {
+		return nil, fmt.Errorf("must specify multisig address, destination and value")
+	}
+
+	msig, err := parseAddress(cctx.Args().Get(0))
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseAddress(cctx.Args().Get(1))
+	if err != nil {
+		return nil, err
+	}
+	val, err := types.ParseFIL(cctx.Args().Get(2))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse amount: %w", err)
+	}
+
+	var method uint64
+	if cctx.NArg() > 3 {
+		method, err = strconv.ParseUint(cctx.Args().Get(3), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid method number: %w", err)
+		}
+	}
+	var params []byte
+	if cctx.NArg() > 4 {
+		params, err = hex.DecodeString(strings.TrimPrefix(cctx.Args().Get(4), "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex params: %w", err)
+		}
+	}
+
+	return withMsigSigner(cctx, &service.Payload{
+		Type:     service.RequestTypeMsigPropose,
+		Multisig: msig,
+		ToAddr:   to,
+		Amount:   val,
+		Method:   abi.MethodNum(method),
+		Params:   params,
+	})
+}
+
+// msigApprove 审批多签提案命令
+var msigApprove = &cli.Command{
+	Name:      "approve",
+	Usage:     "审批多签待处理交易",
+	ArgsUsage: "[多签地址] [交易 ID]",
+	Flags: []cli.Flag{
+		msigFromFlag,
+		dryRunFlag,
+	},
+	Action: payloadAction(msigTxnPayload(service.RequestTypeMsigApprove)),
+}
+
+// msigCancel 取消多签提案命令
+var msigCancel = &cli.Command{
+	Name:      "cancel",
+	Usage:     "取消自己发起的多签待处理交易",
+	ArgsUsage: "[多签地址] [交易 ID]",
+	Flags: []cli.Flag{
+		msigFromFlag,
+		dryRunFlag,
+	},
+	Action: payloadAction(msigTxnPayload(service.RequestTypeMsigCancel)),
+}
+
+// msigTxnPayload 返回构建审批或取消请求的参数解析函数
+func msigTxnPayload(requestType string) func(*cli.Context) (*service.Payload, error) {
+	return func(cctx *cli.Context) (*service.Payload, error) {
+		if cctx.NArg() != 2 {
+			return nil, fmt.Errorf("must specify multisig address and transaction id")
+		}
+
+		msig, err := parseAddress(cctx.Args().Get(0))
+		if err != nil {
+			return nil, err
+		}
+		txnID, err := strconv.ParseInt(cctx.Args().Get(1), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transaction id: %w", err)
+		}
+		from, err := parseAddress(cctx.String("from"))
+		if err != nil {
+			return nil, err
+		}
+
+		return &service.Payload{
+			Type:     requestType,
+			Multisig: msig,
+			FromAddr: from,
+			TxnID:    txnID,
+		}, nil
+	}
+}
+
+// msigAddSigner 添加签名人命令
+var msigAddSigner = &cli.Command{
+	Name:      "add-signer",
+	Usage:     "提议添加多签签名人",
+	ArgsUsage: "[多签地址] [新签名人地址]",
+	Flags: []cli.Flag{
+		msigFromFlag,
+		&cli.BoolFlag{
+			Name:  "increase-threshold",
+			Usage: "同时将审批阈值加一",
+		},
+		dryRunFlag,
+	},
+	Action: payloadAction(msigAddSignerPayload),
+}
+
+// msigAddSignerPayload 从命令行参数构建添加签名人请求
+func msigAddSignerPayload(cctx *cli.Context) (*service.Payload, error) {
+	if cctx.NArg() != 2 {
+		return nil, fmt.Errorf("must specify multisig address and new signer")
+	}
+	msig, err := parseAddress(cctx.Args().Get(0))
+	if err != nil {
+		return nil, err
+	}
+	signer, err := parseAddress(cctx.Args().Get(1))
+	if err != nil {
+		return nil, err
+	}
+
+	return withMsigSigner(cctx, &service.Payload{
+		Type:            service.RequestTypeMsigAddSigner,
+		Multisig:        msig,
+		NewSigner:       signer,
+		ChangeThreshold: cctx.Bool("increase-threshold"),
+	})
+}
+
+// msigRemoveSigner 移除签名人命令
+var msigRemoveSigner = &cli.Command{
+	Name:      "remove-signer",
+	Usage:     "提议移除多签签名人",
+	ArgsUsage: "[多签地址] [签名人地址]",
+	Flags: []cli.Flag{
+		msigFromFlag,
+		&cli.BoolFlag{
+			Name:  "decrease-threshold",
+			Usage: "同时将审批阈值减一",
+		},
+		dryRunFlag,
+	},
+	Action: payloadAction(msigRemoveSignerPayload),
+}
+
+// msigRemoveSignerPayload 从命令行参数构建移除签名人请求
+func msigRemoveSignerPayload(cctx *cli.Context) (*service.Payload, error) {
+	if cctx.NArg() != 2 {
+		return nil, fmt.Errorf("must specify multisig address and signer")
+	}
+	msig, err := parseAddress(cctx.Args().Get(0))
+	if err != nil {
+		return nil, err
+	}
+	signer, err := parseAddress(cctx.Args().Get(1))
+	if err != nil {
+		return nil, err
+	}
+
+	return withMsigSigner(cctx, &service.Payload{
+		Type:            service.RequestTypeMsigRemoveSigner,
+		Multisig:        msig,
+		Signer:          signer,
+		ChangeThreshold: cctx.Bool("decrease-threshold"),
+	})
+}
+
+// msigSwapSigner 替换签名人命令
+var msigSwapSigner = &cli.Command{
+	Name:      "swap-signer",
+	Usage:     "提议替换多签签名人",
+	ArgsUsage: "[多签地址] [旧签名人地址] [新签名人地址]",
+	Flags: []cli.Flag{
+		msigFromFlag,
+		dryRunFlag,
+	},
+	Action: payloadAction(msigSwapSignerPayload),
+}
+
+// msigSwapSignerPayload 从命令行参数构建替换签名人请求
+func msigSwapSignerPayload(cctx *cli.Context) (*service.Payload, error) {
+	if cctx.NArg() != 3 {
+		return nil, fmt.Errorf("must specify multisig address, old signer and new signer")
+	}
+	msig, err := parseAddress(cctx.Args().Get(0))
+	if err != nil {
+		return nil, err
+	}
+	oldSigner, err := parseAddress(cctx.Args().Get(1))
+	if err != nil {
+		return nil, err
+	}
+	newSigner, err := parseAddress(cctx.Args().Get(2))
+	if err != nil {
+		return nil, err
+	}
+
+	return withMsigSigner(cctx, &service.Payload{
+		Type:      service.RequestTypeMsigSwapSigner,
+		Multisig:  msig,
+		Signer:    oldSigner,
+		NewSigner: newSigner,
+	})
+}
+
+// msigThreshold 修改审批阈值命令
+var msigThreshold = &cli.Command{
+	Name:      "threshold",
+	Usage:     "提议修改多签审批阈值",
+	ArgsUsage: "[多签地址] [新阈值]",
+	Flags: []cli.Flag{
+		msigFromFlag,
+		dryRunFlag,
+	},
+	Action: payloadAction(msigThresholdPayload),
+}
+
+// msigThresholdPayload 从命令行参数构建修改阈值请求
+func msigThresholdPayload(cctx *cli.Context) (*service.Payload, error) {
+	if cctx.NArg() != 2 {
+		return nil, fmt.Errorf("must specify multisig address and new threshold")
+	}
+	msig, err := parseAddress(cctx.Args().Get(0))
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := strconv.ParseUint(cctx.Args().Get(1), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid threshold: %w", err)
+	}
+
+	return withMsigSigner(cctx, &service.Payload{
+		Type:      service.RequestTypeMsigThreshold,
+		Multisig:  msig,
+		Threshold: threshold,
+	})
+}
+
+// withMsigSigner 将 --from 指定的签名人设置为提案人
+func withMsigSigner(cctx *cli.Context, p *service.Payload) (*service.Payload, error) {
+	from, err := parseAddress(cctx.String("from"))
+	if err != nil {
+		return nil, err
+	}
+	p.Proposer = from
+	return p, nil
+}
+
+// msigInspect 查看多签钱包命令
+// 显示余额、签名人、阈值、锁仓计划及待处理交易
+var msigInspect = &cli.Command{
+	Name:      "inspect",
+	Usage:     "查看多签钱包的签名人、阈值、锁仓及待处理交易",
+	ArgsUsage: "[多签地址]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return fmt.Errorf("must specify multisig address")
+		}
+		msig, err := parseAddress(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		// 创建 Lotus API 客户端
+		api := rpc.NewLotusApi()
+		node := vapi.NewNode(cctx.Context, api)
+
+		act, err := node.StateReadState(msig)
+		if err != nil {
+			return err
+		}
+		var st types.MsigState
+		if err := json.Unmarshal(act.State, &st); err != nil || len(st.Signers) == 0 {
+			return fmt.Errorf("%s is not a multisig", msig)
+		}
+
+		available, err := node.MsigGetAvailableBalance(msig)
+		if err != nil {
+			return err
+		}
+		pending, err := node.MsigGetPending(msig)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Balance:    %s\n", types.FIL(act.Balance))
+		fmt.Printf("Spendable:  %s\n", types.FIL(available))
+		fmt.Printf("Threshold:  %d / %d\n", st.NumApprovalsThreshold, len(st.Signers))
+		fmt.Println("Signers:")
+		for _, s := range st.Signers {
+			if key, err := node.StateAccountKey(s); err == nil {
+				fmt.Printf("  %s (%s)\n", s, key)
+			} else {
+				fmt.Printf("  %s\n", s)
+			}
+		}
+
+		if st.UnlockDuration > 0 {
+			locked := types.BigSub(act.Balance, available)
+			fmt.Println("Vesting:")
+			fmt.Printf("  InitialBalance: %s\n", types.FIL(st.InitialBalance))
+			fmt.Printf("  StartEpoch:     %d\n", st.StartEpoch)
+			fmt.Printf("  UnlockDuration: %d\n", st.UnlockDuration)
+			fmt.Printf("  Locked:         %s\n", types.FIL(locked))
+		}
+
+		fmt.Printf("Transactions: %d\n", len(pending))
+		if len(pending) == 0 {
+			return nil
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("ID"),
+			tablewriter.Col("State"),
+			tablewriter.Col("Approvals"),
+			tablewriter.Col("To"),
+			tablewriter.Col("Value"),
+			tablewriter.Col("Method"),
+			tablewriter.Col("Params"),
+		)
+		for _, txn := range pending {
+			approvers := make([]string, 0, len(txn.Approved))
+			for _, a := range txn.Approved {
+				approvers = append(approvers, a.String())
+			}
+			tw.Write(map[string]interface{}{
+				"ID":        txn.ID,
+				"State":     "pending",
+				"Approvals": fmt.Sprintf("%d (%s)", len(txn.Approved), strings.Join(approvers, ",")),
+				"To":        txn.To,
+				"Value":     types.FIL(txn.Value),
+				"Method":    txn.Method,
+				"Params":    hex.EncodeToString(txn.Params),
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"wallet-sign/internal/service"
+	"wallet-sign/internal/ui/tablewriter"
+)
+
+// NonceCmd nonce 管理命令
+// 查看发送方链上、内存池及本地预留的 nonce，必要时清除本地预留
+var NonceCmd = &cli.Command{
+	Name:  "nonce",
+	Usage: "本地 nonce 预留管理",
+	Subcommands: []*cli.Command{
+		nonceStatus,
+		nonceReset,
+	},
+}
+
+// nonceStatus 查看 nonce 状态命令
+var nonceStatus = &cli.Command{
+	Name:      "status",
+	Usage:     "查看发送方的链上 nonce、内存池 nonce 及本地预留",
+	ArgsUsage: "[地址]",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify address")
+		}
+		addr, err := parseAddress(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		client, err := service.NewClient()
+		if err != nil {
+			return err
+		}
+		st, err := client.Ex.NonceStatus(addr)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			return printJSON(st)
+		}
+
+		fmt.Printf("Address:     %s\n", st.Address)
+		fmt.Printf("Chain Nonce: %d\n", st.ChainNonce)
+		fmt.Printf("Mpool Nonce: %d\n", st.MpoolNonce)
+		if len(st.Reservations) == 0 {
+			fmt.Println("No local reservations")
+			return nil
+		}
+
+		gaps := make(map[uint64]bool, len(st.Gaps))
+		for _, n := range st.Gaps {
+			gaps[n] = true
+		}
+		tw := tablewriter.New(
+			tablewriter.Col("Nonce"),
+			tablewriter.Col("Reserved"),
+			tablewriter.Col("State"))
+		for _, r := range st.Reservations {
+			state := "reserved"
+			if gaps[r.Nonce] {
+				state = "gap"
+			}
+			tw.Write(map[string]interface{}{
+				"Nonce":    r.Nonce,
+				"Reserved": r.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+				"State":    state,
+			})
+		}
+		if err := tw.Flush(os.Stdout); err != nil {
+			return err
+		}
+		if len(st.Gaps) > 0 {
+			fmt.Printf("%d gaps will be reused by the next messages from %s\n", len(st.Gaps), st.Address)
+		}
+		return nil
+	},
+}
+
+// nonceReset 清除本地预留命令
+var nonceReset = &cli.Command{
+	Name:      "reset",
+	Usage:     "清除发送方的所有本地 nonce 预留，下一条消息从内存池 nonce 开始",
+	ArgsUsage: "[地址]",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify address")
+		}
+		addr, err := parseAddress(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		client, err := service.NewClient()
+		if err != nil {
+			return err
+		}
+		n, err := client.Ex.ResetNonces(addr)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d reservations removed for %s\n", n, addr)
+		return nil
+	},
+}
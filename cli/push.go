@@ -9,7 +9,10 @@ import (
 	"golang.org/x/xerrors"
 
 	"wallet-sign/internal/chain/types"
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/repository"
 	"wallet-sign/internal/rpc"
+	"wallet-sign/internal/service"
 	"wallet-sign/internal/vapi"
 )
 
@@ -17,7 +20,7 @@ import (
 // 用于将已签名的消息推送到 Filecoin 内存池中
 var MpoolPushCmd = &cli.Command{
 	Name:  "push",
-	Usage: "replace a message in the mempool",
+	Usage: "推送已签名消息到内存池",
 	Flags: []cli.Flag{
 		&cli.StringFlag{
 			Name:  "msg",
@@ -50,6 +53,17 @@ var MpoolPushCmd = &cli.Command{
 			return xerrors.Errorf("failed to push new message to mempool: %w", err)
 		}
 
+		// 记录到消息历史
+		cfg, err := appcfg.LoadConfig()
+		if err != nil {
+			return err
+		}
+		store, err := repository.OpenStore(cfg.DBDSN)
+		if err != nil {
+			return err
+		}
+		service.RecordPushed(store, sig, msgCid)
+
 		fmt.Println("new message cid: ", msgCid)
 		return nil
 	},
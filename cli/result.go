@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/policy"
+	"wallet-sign/internal/service"
+)
+
+const (
+	// ExitCodeError 请求执行失败（参数错误、签名失败、节点错误等）
+	ExitCodeError = 1
+	// ExitCodeMessageFailed 消息已上链但执行退出码非零
+	ExitCodeMessageFailed = 2
+	// ExitCodePolicyRejected 签名策略拒绝签名
+	ExitCodePolicyRejected = 3
+)
+
+// resultOutput JSON 输出格式
+type resultOutput struct {
+	*service.Result
+	Error string `json:"error,omitempty"`
+}
+
+// handleResult 输出执行结果，并根据结果设置进程退出码
+// 指定全局 --json 时以 JSON 格式输出到标准输出
+func handleResult(cctx *cli.Context, res *service.Result, err error) error {
+	if cctx.Bool("json") {
+		out := resultOutput{Result: res}
+		if out.Result == nil {
+			out.Result = &service.Result{}
+		}
+		if err != nil {
+			out.Error = err.Error()
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(out); encErr != nil {
+			return cli.Exit(encErr.Error(), ExitCodeError)
+		}
+	} else if res != nil {
+		printResult(res, "")
+	}
+
+	return exitError(err)
+}
+
+// exitError 将执行错误转换为带退出码的错误
+func exitError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var failed *service.MessageFailedError
+	if errors.As(err, &failed) {
+		return cli.Exit(err.Error(), ExitCodeMessageFailed)
+	}
+	var rejected *policy.RejectedError
+	if errors.As(err, &rejected) {
+		return cli.Exit(err.Error(), ExitCodePolicyRejected)
+	}
+	return cli.Exit(err.Error(), ExitCodeError)
+}
+
+// printResult 以文本格式输出执行结果
+func printResult(res *service.Result, indent string) {
+	if len(res.Items) > 0 {
+		fmt.Printf("%sRequest:    %s (%d messages)\n", indent, res.RequestType, len(res.Items))
+		for i, item := range res.Items {
+			fmt.Printf("%s[%d]\n", indent, i+1)
+			printResult(item, indent+"  ")
+		}
+		return
+	}
+
+	fmt.Printf("%sRequest:    %s\n", indent, res.RequestType)
+	fmt.Printf("%sMessage:    %s\n", indent, res.MsgCid)
+	fmt.Printf("%sHeight:     %d\n", indent, res.Height)
+	fmt.Printf("%sExit Code:  %d\n", indent, res.ExitCode)
+	fmt.Printf("%sGas Used:   %d\n", indent, res.GasUsed)
+	if res.FeeBurned != nil {
+		fmt.Printf("%sFee Burned: %s\n", indent, types.FIL(*res.FeeBurned))
+	}
+
+	switch ret := res.Return.(type) {
+	case *service.MsigCreateResult:
+		fmt.Printf("%sMultisig:   %s (%s)\n", indent, ret.IDAddress, ret.RobustAddress)
+	case *service.MsigTxnResult:
+		fmt.Printf("%sTxn ID:     %d\n", indent, ret.TxnID)
+		if ret.Applied {
+			fmt.Printf("%sApplied:    yes, exit code %d\n", indent, ret.ExitCode)
+		} else {
+			fmt.Printf("%sApplied:    no, waiting for more approvals\n", indent)
+		}
+	case nil:
+	default:
+		data, err := json.Marshal(ret)
+		if err != nil {
+			fmt.Printf("%sReturn:     %v\n", indent, ret)
+		} else {
+			fmt.Printf("%sReturn:     %s\n", indent, data)
+		}
+	}
+}
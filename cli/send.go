@@ -3,7 +3,6 @@ package cli
 import (
 	"fmt"
 
-	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/builtin"
 	"github.com/urfave/cli/v2"
 
@@ -16,8 +15,8 @@ import (
 var SendCmd = &cli.Command{
 	Name:      "send",
 	Usage:     "在账户之间转账",
-	ArgsUsage: "[目标地址] [金额]",
-	Flags: []cli.Flag{
+	ArgsUsage: "[目标地址（Filecoin 地址或 0x 以太坊地址）] [金额]",
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "from",
 			Usage: "指定发送方账户地址",
@@ -47,24 +46,21 @@ var SendCmd = &cli.Command{
 			Usage: "指定交易 nonce 值",
 			Value: 0,
 		},
-	},
+		msigProposerFlag,
+		dryRunFlag,
+	}, batchFlags...),
 	Action: func(cctx *cli.Context) error {
-		// 解析发送方地址
-		fromAddr, err := address.NewFromString(cctx.String("from"))
-		if err != nil {
-			return err
+		if cctx.IsSet("batch") || cctx.IsSet("resume") {
+			return sendBatch(cctx)
 		}
 
-		// 解析接收方地址
-		toAddr, err := address.NewFromString(cctx.Args().Get(0))
+		data, err := sendPayload(cctx)
 		if err != nil {
 			return err
 		}
 
-		// 解析转账金额
-		val, err := types.ParseFIL(cctx.Args().Get(1))
-		if err != nil {
-			return fmt.Errorf("failed to parse amount: %w", err)
+		if cctx.Bool("dry-run") {
+			return simulate(cctx, data)
 		}
 
 		client, err := service.NewClient()
@@ -72,15 +68,36 @@ var SendCmd = &cli.Command{
 			return err
 		}
 
-		// 创建转账审批请求
-		data := &service.Payload{
-			Type:     service.RequestTypeTransfer,
-			FromAddr: fromAddr,
-			ToAddr:   toAddr,
-			Amount:   val,
-		}
-		client.Ex.Execute(data)
-
-		return nil
+		res, err := client.Ex.Execute(data)
+		return handleResult(cctx, res, err)
 	},
 }
+
+// sendPayload 从命令行参数构建转账请求
+func sendPayload(cctx *cli.Context) (*service.Payload, error) {
+	// 解析发送方地址
+	fromAddr, err := parseAddress(cctx.String("from"))
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析接收方地址
+	toAddr, err := parseAddress(cctx.Args().Get(0))
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析转账金额
+	val, err := types.ParseFIL(cctx.Args().Get(1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse amount: %w", err)
+	}
+
+	// 创建转账请求
+	return withMsigProposer(cctx, &service.Payload{
+		Type:     service.RequestTypeTransfer,
+		FromAddr: fromAddr,
+		ToAddr:   toAddr,
+		Amount:   val,
+	})
+}
@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/repository"
+	"wallet-sign/internal/service"
+	"wallet-sign/internal/sweep"
+	"wallet-sign/internal/ui/tablewriter"
+)
+
+// SweepCmd 自动归集命令
+// 按规则文件定期提取矿工可用余额、转出到冷钱包，并为 worker/control 地址补充余额
+var SweepCmd = &cli.Command{
+	Name:  "sweep",
+	Usage: "按规则自动提现、归集及补充钱包余额",
+	Subcommands: []*cli.Command{
+		sweepRun,
+		sweepList,
+	},
+}
+
+// sweepRun 启动自动归集命令
+var sweepRun = &cli.Command{
+	Name:  "run",
+	Usage: "按轮询间隔执行归集规则",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "rules",
+			Usage: "规则文件路径（默认读取配置 Sweep.RulesFile）",
+		},
+		&cli.DurationFlag{
+			Name:  "interval",
+			Usage: "轮询间隔，覆盖规则文件中的 Interval",
+		},
+		&cli.BoolFlag{
+			Name:  "once",
+			Usage: "只执行一个周期后退出",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "只输出计划执行的动作，不签名、不发送、不写入数据库",
+		},
+		metricsListenFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		cfg, err := appcfg.LoadConfig()
+		if err != nil {
+			return err
+		}
+
+		path := cctx.String("rules")
+		if path == "" && appcfg.LotusConfig.Sweep != nil {
+			path = appcfg.LotusConfig.Sweep.RulesFile
+		}
+		if path == "" {
+			return fmt.Errorf("no sweep rules file, pass --rules or set Sweep.RulesFile")
+		}
+		rules, err := sweep.LoadFile(path)
+		if err != nil {
+			return err
+		}
+		interval := rules.Interval
+		if cctx.IsSet("interval") {
+			if interval = cctx.Duration("interval"); interval < time.Minute {
+				return fmt.Errorf("interval must be at least 1m")
+			}
+		}
+
+		store, err := repository.OpenStore(cfg.DBDSN)
+		if err != nil {
+			return err
+		}
+		dryRun := cctx.Bool("dry-run")
+		// 运行期间不能在终端输入口令，启动时解锁密钥库
+		if !dryRun {
+			if err := store.EnsureUnlocked(); err != nil {
+				return err
+			}
+		}
+		executor := service.NewExecutor(store)
+
+		ctx, stop := signal.NotifyContext(cctx.Context, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if err := startMetrics(ctx, cctx, store); err != nil {
+			return err
+		}
+
+		if !cctx.Bool("once") {
+			fmt.Fprintf(os.Stderr, "Sweeping every %s with rules from %s\n", interval, path)
+		}
+		for {
+			report, err := executor.Sweep(rules, dryRun)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, color.RedString("sweep failed: %v", err))
+			} else if err := printSweepReport(cctx, report, dryRun); err != nil {
+				return err
+			}
+			if cctx.Bool("once") {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				fmt.Fprintln(os.Stderr, "Stopping sweep")
+				return nil
+			case <-time.After(interval):
+			}
+		}
+	},
+}
+
+// printSweepReport 输出一个周期的执行结果
+func printSweepReport(cctx *cli.Context, report *service.SweepReport, dryRun bool) error {
+	if cctx.Bool("json") {
+		return printJSON(report)
+	}
+
+	prefix := ""
+	if dryRun {
+		prefix = "[dry-run] "
+	}
+	if len(report.Actions) == 0 {
+		fmt.Printf("%s%s height %d: nothing to do\n", prefix, time.Now().Format("2006-01-02 15:04:05"), report.Height)
+	}
+	for _, a := range report.Actions {
+		fmt.Printf("%s%s height %d: %s %s: %s %s -> %s (%s) %s",
+			prefix, time.Now().Format("2006-01-02 15:04:05"), report.Height,
+			a.Rule, a.Kind, historyValue(a.Amount), a.FromAddr, a.ToAddr, a.Reason, a.Status)
+		if a.MsgCid != "" {
+			fmt.Printf(" %s", a.MsgCid)
+		}
+		fmt.Println()
+		if a.Error != "" {
+			fmt.Fprintln(os.Stderr, color.RedString("  error: %s", a.Error))
+		}
+	}
+	for _, s := range report.Skipped {
+		fmt.Fprintln(os.Stderr, color.YellowString("skipped: %s", s))
+	}
+	for _, e := range report.Errors {
+		fmt.Fprintln(os.Stderr, color.RedString("error: %s", e))
+	}
+	return nil
+}
+
+// sweepList 查看自动归集动作记录命令
+var sweepList = &cli.Command{
+	Name:  "list",
+	Usage: "查看自动归集执行过的动作",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "rule",
+			Usage: "按规则名称过滤",
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "最多显示的记录数，0 表示全部",
+			Value: 50,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		store, err := openHistoryStore()
+		if err != nil {
+			return err
+		}
+
+		actions, err := store.ListSweepActions(cctx.String("rule"), cctx.Int("limit"))
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			return printJSON(actions)
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Time"),
+			tablewriter.Col("Rule"),
+			tablewriter.Col("Kind"),
+			tablewriter.Col("From"),
+			tablewriter.Col("To"),
+			tablewriter.Col("Amount"),
+			tablewriter.Col("Status"),
+			tablewriter.Col("Height"),
+			tablewriter.Col("CID"),
+			tablewriter.NewLineCol("Error"))
+		for _, a := range actions {
+			row := map[string]interface{}{
+				"Time":   a.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+				"Rule":   a.Rule,
+				"Kind":   a.Kind,
+				"From":   a.FromAddr,
+				"To":     a.ToAddr,
+				"Amount": historyValue(a.Amount),
+				"Status": a.Status,
+				"CID":    a.MsgCid,
+				"Error":  a.Error,
+			}
+			if a.Height > 0 {
+				row["Height"] = a.Height
+			}
+			tw.Write(row)
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
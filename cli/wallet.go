@@ -7,11 +7,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 	appcfg "wallet-sign/internal/config"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
 	"golang.org/x/xerrors"
 
 	"wallet-sign/internal/chain/types"
@@ -49,20 +52,44 @@ var WalletCmd = &cli.Command{
 
 	Subcommands: []*cli.Command{
 		walletNew,
+		walletRecover,
 		walletList,
 		walletExport,
 		walletImport,
 		walletBalance,
 		walletDelete,
+		walletSetNetwork,
+		walletUnlock,
+		walletLock,
 	},
 }
 
 // walletNew 生成新密钥命令
-// 支持 BLS 和 secp256k1 两种密钥类型
+// 支持 BLS、secp256k1 和委托（f410）三种密钥类型，指定 --mnemonic 时从新生成的助记词派生
 var walletNew = &cli.Command{
 	Name:      "new",
 	Usage:     "生成指定类型的新密钥",
-	ArgsUsage: "[bls|secp256k1 (默认 secp256k1)]",
+	ArgsUsage: "[bls|secp256k1|delegated (默认 secp256k1)]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "mnemonic",
+			Usage: "生成 BIP39 助记词并从中派生密钥",
+		},
+		&cli.IntFlag{
+			Name:  "words",
+			Usage: "助记词单词数（12 或 24）",
+			Value: 24,
+		},
+		&cli.UintFlag{
+			Name:  "index",
+			Usage: "派生的起始账户索引（仅 --mnemonic）",
+		},
+		&cli.UintFlag{
+			Name:  "count",
+			Usage: "派生的密钥数量（仅 --mnemonic）",
+			Value: 1,
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		cfg := cctx.Context.Value(CtxConfig).(*appcfg.Config)
 		// 打开数据库连接
@@ -77,6 +104,33 @@ var walletNew = &cli.Command{
 			t = "secp256k1" // 默认使用 secp256k1
 		}
 
+		if cctx.Bool("mnemonic") {
+			var bits int
+			switch cctx.Int("words") {
+			case 12:
+				bits = 128
+			case 24:
+				bits = 256
+			default:
+				return fmt.Errorf("unsupported mnemonic length: %d words (use 12 or 24)", cctx.Int("words"))
+			}
+
+			mnemonic, err := wallet.NewMnemonic(bits)
+			if err != nil {
+				return err
+			}
+			seed, err := wallet.MnemonicToSeed(mnemonic, "")
+			if err != nil {
+				return err
+			}
+
+			// 助记词只显示一次，输出到标准错误，避免被重定向到文件
+			fmt.Fprintln(os.Stderr, "请离线抄写并妥善保管以下助记词，它可以恢复所有派生的密钥：")
+			fmt.Fprintf(os.Stderr, "\n%s\n\n", mnemonic)
+
+			return deriveWalletKeys(store, types.KeyType(t), seed, uint32(cctx.Uint("index")), cctx.Uint("count"))
+		}
+
 		// 生成新密钥
 		ki, addr, err := wallet.WalletNew(types.KeyType(t))
 		if err != nil {
@@ -90,11 +144,102 @@ var walletNew = &cli.Command{
 
 		// 输出新生成的地址
 		fmt.Println(addr)
+		if eth := ethAddressString(addr); eth != "" {
+			fmt.Fprintf(os.Stderr, "Ethereum address: %s\n", eth)
+		}
 
 		return nil
 	},
 }
 
+// walletRecover 从助记词恢复密钥命令
+// 从标准输入读取助记词（终端输入时不回显），按账户索引重新派生并保存密钥
+var walletRecover = &cli.Command{
+	Name:      "recover",
+	Usage:     "从 BIP39 助记词恢复密钥",
+	ArgsUsage: "[bls|secp256k1|delegated (默认 secp256k1)]",
+	Flags: []cli.Flag{
+		&cli.UintFlag{
+			Name:  "index",
+			Usage: "派生的起始账户索引",
+		},
+		&cli.UintFlag{
+			Name:  "count",
+			Usage: "派生的密钥数量",
+			Value: 1,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		cfg := cctx.Context.Value(CtxConfig).(*appcfg.Config)
+		// 打开数据库连接
+		store, err := repository.OpenStore(cfg.DBDSN)
+		if err != nil {
+			return err
+		}
+
+		t := cctx.Args().First()
+		if t == "" {
+			t = "secp256k1"
+		}
+
+		mnemonic, err := readMnemonic()
+		if err != nil {
+			return err
+		}
+
+		seed, err := wallet.MnemonicToSeed(mnemonic, "")
+		if err != nil {
+			return err
+		}
+
+		return deriveWalletKeys(store, types.KeyType(t), seed, uint32(cctx.Uint("index")), cctx.Uint("count"))
+	},
+}
+
+// readMnemonic 读取助记词，标准输入是终端时不回显，否则读取一行（例如通过管道传入）
+func readMnemonic() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "输入助记词: ")
+		mnemonic, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read mnemonic: %w", err)
+		}
+		return string(mnemonic), nil
+	}
+
+	mnemonic, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && mnemonic == "" {
+		return "", fmt.Errorf("failed to read mnemonic: %w", err)
+	}
+	return mnemonic, nil
+}
+
+// deriveWalletKeys 从种子派生 count 个连续账户索引的密钥并保存
+// 每行输出一个地址及其派生路径
+func deriveWalletKeys(store *repository.Store, typ types.KeyType, seed []byte, index uint32, count uint) error {
+	if count == 0 {
+		return fmt.Errorf("count must be at least 1")
+	}
+
+	for i := uint32(0); i < uint32(count); i++ {
+		ki, addr, path, err := wallet.WalletDerive(typ, seed, index+i)
+		if err != nil {
+			return err
+		}
+		if err := store.SaveDerivedWalletKey(addr.String(), *ki, path, index+i); err != nil {
+			return err
+		}
+		if eth := ethAddressString(addr); eth != "" {
+			fmt.Printf("%s\t%s\t%s\n", addr, eth, path)
+			continue
+		}
+		fmt.Printf("%s\t%s\n", addr, path)
+	}
+	return nil
+}
+
 // walletExport 导出密钥命令
 // 将指定地址的私钥导出为十六进制格式
 var walletExport = &cli.Command{
@@ -258,17 +403,23 @@ var walletList = &cli.Command{
 		ctx := cctx.Context
 		node := vapi.NewNode(ctx, api)
 
-		// 获取本地钱包实例
-		addrs, err := store.GetAllWalletAddresses()
+		// 获取本地钱包地址（不需要解锁密钥库）
+		addrs, err := store.ListWalletKeys()
+		if err != nil {
+			return err
+		}
 		// 创建表格输出
 		tw := tablewriter.New(
 			tablewriter.Col("Address"),
 			tablewriter.Col("ID"),
+			tablewriter.Col("Eth Address"),
 			tablewriter.Col("Amount"),
 			tablewriter.Col("Market(Avail)"),
 			tablewriter.Col("Market(Locked)"),
 			tablewriter.Col("Nonce"),
 			tablewriter.Col("Default"),
+			tablewriter.Col("Network"),
+			tablewriter.Col("Path"),
 			tablewriter.NewLineCol("Error"))
 
 		// 遍历所有地址，获取详细信息
@@ -300,6 +451,15 @@ var walletList = &cli.Command{
 				"Amount":  types.FIL(a.Balance),
 				"Nonce":   a.Nonce,
 			}
+			if addr.DerivationPath != "" {
+				row["Path"] = addr.DerivationPath
+			}
+			if addr.Network != "" {
+				row["Network"] = addr.Network
+			}
+			if eth := ethAddressString(Addr); eth != "" {
+				row["Eth Address"] = eth
+			}
 
 			// 如果需要显示 ID
 			if cctx.Bool("id") {
@@ -340,7 +500,7 @@ var walletBalance = &cli.Command{
 		node := vapi.NewNode(ctx, client)
 
 		// 解析地址参数
-		addr, err := address.NewFromString(os.Args[3])
+		addr, err := parseAddress(cctx.Args().First())
 		if err != nil {
 			fmt.Printf("Invalid address: %v\n", err)
 			os.Exit(1)
@@ -430,3 +590,89 @@ var walletDelete = &cli.Command{
 		return nil
 	},
 }
+
+// walletSetNetwork 修改密钥使用的网络命令
+// 密钥在第一次签名时记录使用的网络，之后只能在该网络上签名
+var walletSetNetwork = &cli.Command{
+	Name:      "set-network",
+	Usage:     "修改密钥使用的网络",
+	ArgsUsage: "[地址] [网络]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return fmt.Errorf("请指定钱包地址和网络")
+		}
+
+		addr, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return fmt.Errorf("无效的地址: %w", err)
+		}
+		network := cctx.Args().Get(1)
+		if !slices.Contains(appcfg.NetworkNames(), network) {
+			return fmt.Errorf("未知的网络 %s，可用网络: %s", network, strings.Join(appcfg.NetworkNames(), ", "))
+		}
+
+		cfg := cctx.Context.Value(CtxConfig).(*appcfg.Config)
+		// 打开数据库连接
+		store, err := repository.OpenStore(cfg.DBDSN)
+		if err != nil {
+			return err
+		}
+
+		if err := store.SetWalletKeyNetwork(addr.String(), network); err != nil {
+			return fmt.Errorf("修改钱包网络失败: %w", err)
+		}
+
+		fmt.Printf("钱包 %s 的密钥只能在 %s 上签名\n", addr, network)
+		return nil
+	},
+}
+
+// walletUnlock 解锁密钥库命令
+// 在指定时间内后续命令无需再次输入口令
+var walletUnlock = &cli.Command{
+	Name:  "unlock",
+	Usage: "解锁密钥库",
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "保持解锁的时间",
+			Value: 15 * time.Minute,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		cfg := cctx.Context.Value(CtxConfig).(*appcfg.Config)
+		// 打开数据库连接
+		store, err := repository.OpenStore(cfg.DBDSN)
+		if err != nil {
+			return err
+		}
+
+		if err := store.UnlockSession(cctx.Duration("timeout")); err != nil {
+			return err
+		}
+
+		fmt.Printf("密钥库已解锁，%s 后自动锁定\n", cctx.Duration("timeout"))
+		return nil
+	},
+}
+
+// walletLock 锁定密钥库命令
+var walletLock = &cli.Command{
+	Name:  "lock",
+	Usage: "锁定密钥库",
+	Action: func(cctx *cli.Context) error {
+		cfg := cctx.Context.Value(CtxConfig).(*appcfg.Config)
+		// 打开数据库连接
+		store, err := repository.OpenStore(cfg.DBDSN)
+		if err != nil {
+			return err
+		}
+
+		if err := store.Lock(); err != nil {
+			return err
+		}
+
+		fmt.Println("密钥库已锁定")
+		return nil
+	},
+}
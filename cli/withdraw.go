@@ -20,18 +20,17 @@ var WithdrawCmd = &cli.Command{
 			Name:  "minerId",
 			Usage: "miner id",
 		},
+		msigProposerFlag,
+		dryRunFlag,
 	},
 	Action: func(cctx *cli.Context) error {
-		// 解析矿工 ID
-		miner, err := address.NewFromString(cctx.String("minerId"))
+		data, err := withdrawPayload(cctx)
 		if err != nil {
 			return err
 		}
 
-		// 解析提现金额
-		val, err := types.ParseFIL(cctx.Args().Get(1))
-		if err != nil {
-			return fmt.Errorf("failed to parse amount: %w", err)
+		if cctx.Bool("dry-run") {
+			return simulate(cctx, data)
 		}
 
 		// 创建审批客户端
@@ -39,15 +38,29 @@ var WithdrawCmd = &cli.Command{
 		if err != nil {
 			return err
 		}
-		// 创建矿工提现请求
-		data := &service.Payload{
-			Type:    service.RequestTypeMinerWithdraw,
-			MinerID: miner,
-			Amount:  val,
-		}
-		client.Ex.Execute(data)
-
-		// 等待审批完成
-		return nil
+		res, err := client.Ex.Execute(data)
+		return handleResult(cctx, res, err)
 	},
 }
+
+// withdrawPayload 从命令行参数构建矿工提现请求
+func withdrawPayload(cctx *cli.Context) (*service.Payload, error) {
+	// 解析矿工 ID
+	miner, err := address.NewFromString(cctx.String("minerId"))
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析提现金额
+	val, err := types.ParseFIL(cctx.Args().Get(1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse amount: %w", err)
+	}
+
+	// 创建矿工提现请求
+	return withMsigProposer(cctx, &service.Payload{
+		Type:    service.RequestTypeMinerWithdraw,
+		MinerID: miner,
+		Amount:  val,
+	})
+}
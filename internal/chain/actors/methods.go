@@ -0,0 +1,272 @@
+package actors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
+	"github.com/filecoin-project/go-state-types/builtin"
+	account "github.com/filecoin-project/go-state-types/builtin/v17/account"
+	cron "github.com/filecoin-project/go-state-types/builtin/v17/cron"
+	datacap "github.com/filecoin-project/go-state-types/builtin/v17/datacap"
+	eam "github.com/filecoin-project/go-state-types/builtin/v17/eam"
+	ethaccount "github.com/filecoin-project/go-state-types/builtin/v17/ethaccount"
+	evm "github.com/filecoin-project/go-state-types/builtin/v17/evm"
+	initactor "github.com/filecoin-project/go-state-types/builtin/v17/init"
+	market "github.com/filecoin-project/go-state-types/builtin/v17/market"
+	miner "github.com/filecoin-project/go-state-types/builtin/v17/miner"
+	multisig "github.com/filecoin-project/go-state-types/builtin/v17/multisig"
+	paych "github.com/filecoin-project/go-state-types/builtin/v17/paych"
+	placeholder "github.com/filecoin-project/go-state-types/builtin/v17/placeholder"
+	power "github.com/filecoin-project/go-state-types/builtin/v17/power"
+	reward "github.com/filecoin-project/go-state-types/builtin/v17/reward"
+	system "github.com/filecoin-project/go-state-types/builtin/v17/system"
+	verifreg "github.com/filecoin-project/go-state-types/builtin/v17/verifreg"
+)
+
+// MethodsVersion 方法表对应的 actors 版本
+const MethodsVersion = actorstypes.Version17
+
+// builtinMethods 内置 actor 名称（与 StateActorCodeCIDs 返回的名称一致）到方法表的映射
+var builtinMethods = map[string]map[abi.MethodNum]builtin.MethodMeta{
+	"account":          account.Methods,
+	"cron":             cron.Methods,
+	"datacap":          datacap.Methods,
+	"eam":              eam.Methods,
+	"ethaccount":       ethaccount.Methods,
+	"evm":              evm.Methods,
+	"init":             initactor.Methods,
+	"storagemarket":    market.Methods,
+	"storageminer":     miner.Methods,
+	"multisig":         multisig.Methods,
+	"paymentchannel":   paych.Methods,
+	"placeholder":      placeholder.Methods,
+	"storagepower":     power.Methods,
+	"reward":           reward.Methods,
+	"system":           system.Methods,
+	"verifiedregistry": verifreg.Methods,
+}
+
+// singletonActors 单例 actor 的地址
+var singletonActors = map[address.Address]string{
+	builtin.SystemActorAddr:                 "system",
+	builtin.InitActorAddr:                   "init",
+	builtin.RewardActorAddr:                 "reward",
+	builtin.CronActorAddr:                   "cron",
+	builtin.StoragePowerActorAddr:           "storagepower",
+	builtin.StorageMarketActorAddr:          "storagemarket",
+	builtin.VerifiedRegistryActorAddr:       "verifiedregistry",
+	builtin.DatacapActorAddr:                "datacap",
+	builtin.EthereumAddressManagerActorAddr: "eam",
+	builtin.BurntFundsActorAddr:             "account",
+}
+
+// Method 内置 actor 的方法
+type Method struct {
+	Num    abi.MethodNum
+	Name   string
+	params reflect.Type // 参数类型（指针），abi.EmptyValue 表示没有参数
+	ret    reflect.Type // 返回值类型（指针）
+}
+
+var emptyValueType = reflect.TypeOf(&abi.EmptyValue{})
+
+// HasParams 方法是否需要参数
+func (m *Method) HasParams() bool {
+	return m.params != nil && m.params != emptyValueType
+}
+
+// NewParams 返回方法参数类型的零值指针，用于 JSON 解码
+func (m *Method) NewParams() interface{} {
+	if !m.HasParams() {
+		return nil
+	}
+	return reflect.New(m.params.Elem()).Interface()
+}
+
+// EncodeParams 将 JSON 格式的参数解码为方法参数类型并进行 CBOR 编码
+// 返回解码后的参数对象和编码结果
+func (m *Method) EncodeParams(data []byte) (interface{}, []byte, error) {
+	data = bytes.TrimSpace(data)
+	if !m.HasParams() {
+		switch string(data) {
+		case "", "null", "{}":
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("method %s takes no params", m.Name)
+	}
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("method %s requires params of type %s", m.Name, m.params.Elem())
+	}
+
+	p := m.NewParams()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(p); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode params as %s: %w", m.params.Elem(), err)
+	}
+	enc, err := SerializeParams(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, enc, nil
+}
+
+// DecodeReturn 将方法返回值解码为返回类型，方法没有返回值时返回 nil
+func (m *Method) DecodeReturn(ret []byte) (interface{}, error) {
+	if m.ret == nil || m.ret == emptyValueType || len(ret) == 0 {
+		return nil, nil
+	}
+	v := reflect.New(m.ret.Elem()).Interface()
+	u, ok := v.(cborUnmarshaler)
+	if !ok {
+		return nil, fmt.Errorf("return type %s does not support cbor", m.ret.Elem())
+	}
+	if err := u.UnmarshalCBOR(bytes.NewReader(ret)); err != nil {
+		return nil, fmt.Errorf("failed to decode return as %s: %w", m.ret.Elem(), err)
+	}
+	return v, nil
+}
+
+// IsBuiltin 检查是否有指定 actor 的方法表
+func IsBuiltin(actorName string) bool {
+	_, ok := builtinMethods[actorName]
+	return ok
+}
+
+// LookupMethod 按方法编号或名称（不区分大小写）查找内置 actor 的方法
+// 同名方法同时有编号方法和 FRC-42 导出方法时使用编号方法
+func LookupMethod(actorName, method string) (*Method, error) {
+	methods, ok := builtinMethods[actorName]
+	if !ok {
+		return nil, fmt.Errorf("no method table for actor %s", actorName)
+	}
+
+	if n, err := strconv.ParseUint(method, 10, 64); err == nil {
+		meta, ok := methods[abi.MethodNum(n)]
+		if !ok {
+			return nil, fmt.Errorf("actor %s has no method %d", actorName, n)
+		}
+		return newMethod(abi.MethodNum(n), meta), nil
+	}
+
+	var found *Method
+	for num, meta := range methods {
+		if !strings.EqualFold(meta.Name, method) {
+			continue
+		}
+		if found == nil || num < found.Num {
+			found = newMethod(num, meta)
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("actor %s has no method named %s", actorName, method)
+	}
+	return found, nil
+}
+
+// ListMethods 按编号列出内置 actor 的所有方法
+func ListMethods(actorName string) ([]*Method, error) {
+	methods, ok := builtinMethods[actorName]
+	if !ok {
+		return nil, fmt.Errorf("no method table for actor %s", actorName)
+	}
+	out := make([]*Method, 0, len(methods))
+	for num, meta := range methods {
+		out = append(out, newMethod(num, meta))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Num < out[j].Num })
+	return out, nil
+}
+
+// ParamsType 返回参数类型名称，没有参数时返回空字符串
+func (m *Method) ParamsType() string {
+	if !m.HasParams() {
+		return ""
+	}
+	return m.params.Elem().String()
+}
+
+// newMethod 从方法元数据中取出参数和返回值类型
+func newMethod(num abi.MethodNum, meta builtin.MethodMeta) *Method {
+	m := &Method{Num: num, Name: meta.Name}
+	t := reflect.TypeOf(meta.Method)
+	if t != nil && t.Kind() == reflect.Func {
+		if t.NumIn() == 1 && t.In(0).Kind() == reflect.Ptr {
+			m.params = t.In(0)
+		}
+		if t.NumOut() == 1 && t.Out(0).Kind() == reflect.Ptr {
+			m.ret = t.Out(0)
+		}
+	}
+	return m
+}
+
+// DecodedParams 按方法表解码的消息参数
+type DecodedParams struct {
+	Actor  string
+	Method *Method
+	Params interface{} // 方法没有参数时为 nil
+}
+
+// DecodeParams 不连接节点，按接收方地址和方法编号在内置 actor 的方法表中解码消息参数
+// 接收方是单例 actor 或账户、委托地址时只使用对应 actor 的方法表，否则（例如矿工、多签）尝试所有方法表；
+// 只返回参数能完整解码且重新编码后与原始字节一致的方法，按 actor 名称排序，没有匹配时返回空
+func DecodeParams(to address.Address, num abi.MethodNum, params []byte) []*DecodedParams {
+	var names []string
+	switch name, ok := singletonActors[to]; {
+	case ok:
+		names = []string{name}
+	case to.Protocol() == address.SECP256K1 || to.Protocol() == address.BLS:
+		names = []string{"account"}
+	case to.Protocol() == address.Delegated:
+		names = []string{"ethaccount", "evm", "placeholder"}
+	default:
+		for name := range builtinMethods {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	var out []*DecodedParams
+	for _, name := range names {
+		meta, ok := builtinMethods[name][num]
+		if !ok {
+			continue
+		}
+		m := newMethod(num, meta)
+		p, ok := m.decodeParams(params)
+		if ok {
+			out = append(out, &DecodedParams{Actor: name, Method: m, Params: p})
+		}
+	}
+	return out
+}
+
+// decodeParams 将 CBOR 参数解码为方法参数类型，解码后有剩余字节或重新编码不一致时返回 false
+func (m *Method) decodeParams(params []byte) (interface{}, bool) {
+	if !m.HasParams() {
+		return nil, len(params) == 0
+	}
+	p := m.NewParams()
+	u, ok := p.(cborUnmarshaler)
+	if !ok {
+		return nil, false
+	}
+	r := bytes.NewReader(params)
+	if err := u.UnmarshalCBOR(r); err != nil || r.Len() != 0 {
+		return nil, false
+	}
+	enc, err := SerializeParams(p)
+	if err != nil || !bytes.Equal(enc, params) {
+		return nil, false
+	}
+	return p, true
+}
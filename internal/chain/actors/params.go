@@ -10,6 +10,10 @@ type cborMarshaler interface {
 	MarshalCBOR(io.Writer) error
 }
 
+type cborUnmarshaler interface {
+	UnmarshalCBOR(io.Reader) error
+}
+
 func SerializeParams(p interface{}) ([]byte, error) {
 	if p == nil {
 		return nil, nil
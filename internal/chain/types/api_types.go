@@ -1,26 +1,73 @@
 package types
 
 import (
+	"encoding/json"
+
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/ipfs/go-cid"
 )
 
 type Receipt struct {
-	ExitCode int64 `json:"ExitCode"`
+	ExitCode int64  `json:"ExitCode"`
+	Return   []byte `json:"Return"`
+	GasUsed  int64  `json:"GasUsed"`
 }
 
 type MsgLookup struct {
+	Message cid.Cid        `json:"Message"`
 	Receipt Receipt        `json:"Receipt"`
+	TipSet  []cid.Cid      `json:"TipSet"`
 	Height  abi.ChainEpoch `json:"Height"`
 }
 
+type MessageGasCost struct {
+	Message            cid.Cid `json:"Message"`
+	GasUsed            BigInt  `json:"GasUsed"`
+	BaseFeeBurn        BigInt  `json:"BaseFeeBurn"`
+	OverEstimationBurn BigInt  `json:"OverEstimationBurn"`
+	MinerPenalty       BigInt  `json:"MinerPenalty"`
+	MinerTip           BigInt  `json:"MinerTip"`
+	Refund             BigInt  `json:"Refund"`
+	TotalCost          BigInt  `json:"TotalCost"`
+}
+
+type InvocResult struct {
+	MsgCid  cid.Cid         `json:"MsgCid"`
+	Msg     *Message        `json:"Msg"`
+	MsgRct  *Receipt        `json:"MsgRct"`
+	GasCost *MessageGasCost `json:"GasCost"`
+	Error   string          `json:"Error"`
+}
+
 type MinerInfo struct {
-	Owner             address.Address   `json:"Owner"`
-	Worker            address.Address   `json:"Worker"`
-	ControlAddresses  []address.Address `json:"ControlAddresses"`
-	NewWorker         address.Address   `json:"NewWorker"`
-	WorkerChangeEpoch abi.ChainEpoch    `json:"WorkerChangeEpoch"`
+	Owner               address.Address   `json:"Owner"`
+	PendingOwnerAddress *address.Address  `json:"PendingOwnerAddress"`
+	Worker              address.Address   `json:"Worker"`
+	ControlAddresses    []address.Address `json:"ControlAddresses"`
+	NewWorker           address.Address   `json:"NewWorker"`
+	WorkerChangeEpoch   abi.ChainEpoch    `json:"WorkerChangeEpoch"`
+
+	Beneficiary            address.Address           `json:"Beneficiary"`
+	BeneficiaryTerm        *BeneficiaryTerm          `json:"BeneficiaryTerm"`
+	PendingBeneficiaryTerm *PendingBeneficiaryChange `json:"PendingBeneficiaryTerm"`
+}
+
+// BeneficiaryTerm 受益人的可提取额度及到期高度（FIP-0029）
+type BeneficiaryTerm struct {
+	Quota      BigInt         `json:"Quota"`
+	UsedQuota  BigInt         `json:"UsedQuota"`
+	Expiration abi.ChainEpoch `json:"Expiration"`
+}
+
+// PendingBeneficiaryChange 待确认的受益人变更
+// 当前受益人和被提名人都确认后生效
+type PendingBeneficiaryChange struct {
+	NewBeneficiary        address.Address `json:"NewBeneficiary"`
+	NewQuota              BigInt          `json:"NewQuota"`
+	NewExpiration         abi.ChainEpoch  `json:"NewExpiration"`
+	ApprovedByBeneficiary bool            `json:"ApprovedByBeneficiary"`
+	ApprovedByNominee     bool            `json:"ApprovedByNominee"`
 }
 
 type MarketBalance struct {
@@ -37,5 +84,36 @@ type Actor struct {
 	Code    cid.Cid `json:"Code"`
 	Head    cid.Cid `json:"Head"`
 	Nonce   uint64  `json:"Nonce"`
-	Balance BigInt  `json:"Amount"`
+	Balance BigInt  `json:"Balance"`
+}
+
+type ActorState struct {
+	Balance BigInt          `json:"Balance"`
+	Code    cid.Cid         `json:"Code"`
+	State   json.RawMessage `json:"State"`
+}
+
+type MsigState struct {
+	Signers               []address.Address `json:"Signers"`
+	NumApprovalsThreshold uint64            `json:"NumApprovalsThreshold"`
+	NextTxnID             int64             `json:"NextTxnID"`
+	InitialBalance        BigInt            `json:"InitialBalance"`
+	StartEpoch            abi.ChainEpoch    `json:"StartEpoch"`
+	UnlockDuration        abi.ChainEpoch    `json:"UnlockDuration"`
+}
+
+type MsigTransaction struct {
+	ID     int64           `json:"ID"`
+	To     address.Address `json:"To"`
+	Value  BigInt          `json:"Value"`
+	Method abi.MethodNum   `json:"Method"`
+	Params []byte          `json:"Params"`
+
+	Approved []address.Address `json:"Approved"`
+}
+
+type MsigVesting struct {
+	InitialBalance BigInt         `json:"InitialBalance"`
+	StartEpoch     abi.ChainEpoch `json:"StartEpoch"`
+	UnlockDuration abi.ChainEpoch `json:"UnlockDuration"`
 }
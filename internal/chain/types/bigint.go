@@ -25,6 +25,10 @@ func BigMul(a, b BigInt) BigInt {
 	return big2.Mul(a, b)
 }
 
+func BigAdd(a, b BigInt) BigInt {
+	return big2.Add(a, b)
+}
+
 func BigSub(a, b BigInt) BigInt {
 	return big2.Sub(a, b)
 }
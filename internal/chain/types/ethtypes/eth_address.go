@@ -0,0 +1,119 @@
+package ethtypes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"golang.org/x/crypto/sha3"
+)
+
+// EthAddressLength 以太坊地址字节长度
+const EthAddressLength = 20
+
+// maskedIDPrefix ID 地址映射为以太坊地址时使用的前缀（0xff 后跟 11 个零字节）
+var maskedIDPrefix = [EthAddressLength - 8]byte{0xff}
+
+// EthAddress 以太坊地址
+type EthAddress [EthAddressLength]byte
+
+// ParseEthAddress 解析 0x 开头的十六进制以太坊地址
+func ParseEthAddress(s string) (EthAddress, error) {
+	var ea EthAddress
+	if !IsEthAddress(s) {
+		return ea, fmt.Errorf("invalid ethereum address %q", s)
+	}
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return ea, fmt.Errorf("invalid ethereum address %q: %w", s, err)
+	}
+	copy(ea[:], b)
+	return ea, nil
+}
+
+// IsEthAddress 判断字符串是否为 0x 开头的 20 字节十六进制地址
+func IsEthAddress(s string) bool {
+	return len(s) == 2+2*EthAddressLength && (strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X"))
+}
+
+// EthAddressFromPubKey 从未压缩的 secp256k1 公钥计算以太坊地址
+// 地址为 keccak256(公钥去掉 0x04 前缀) 的后 20 字节
+func EthAddressFromPubKey(pubKey []byte) (EthAddress, error) {
+	var ea EthAddress
+	if len(pubKey) != 65 || pubKey[0] != 0x04 {
+		return ea, fmt.Errorf("expected 65 byte uncompressed public key, got %d bytes", len(pubKey))
+	}
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(pubKey[1:])
+	copy(ea[:], hasher.Sum(nil)[12:])
+	return ea, nil
+}
+
+// EthAddressFromFilecoinAddress 将 Filecoin 地址转换为以太坊地址
+// 仅支持 ID 地址和 EAM 命名空间下的 f410 地址
+func EthAddressFromFilecoinAddress(addr address.Address) (EthAddress, error) {
+	var ea EthAddress
+	switch addr.Protocol() {
+	case address.ID:
+		id, err := address.IDFromAddress(addr)
+		if err != nil {
+			return ea, err
+		}
+		copy(ea[:], maskedIDPrefix[:])
+		binary.BigEndian.PutUint64(ea[len(maskedIDPrefix):], id)
+		return ea, nil
+	case address.Delegated:
+		payload := addr.Payload()
+		namespace, n := binary.Uvarint(payload)
+		if n <= 0 {
+			return ea, fmt.Errorf("invalid delegated address %s", addr)
+		}
+		if namespace != builtin.EthereumAddressManagerActorID {
+			return ea, fmt.Errorf("delegated address %s is not in the EAM namespace", addr)
+		}
+		sub := payload[n:]
+		if len(sub) != EthAddressLength {
+			return ea, fmt.Errorf("delegated address %s has invalid length %d", addr, len(sub))
+		}
+		copy(ea[:], sub)
+		return ea, nil
+	default:
+		return ea, fmt.Errorf("cannot convert %s address %s to an ethereum address", protocolName(addr.Protocol()), addr)
+	}
+}
+
+// ToFilecoinAddress 将以太坊地址转换为 Filecoin 地址
+// 映射 ID 的地址转换为 ID 地址，其余转换为 f410 地址
+func (ea EthAddress) ToFilecoinAddress() (address.Address, error) {
+	if ea.IsMaskedID() {
+		return address.NewIDAddress(binary.BigEndian.Uint64(ea[len(maskedIDPrefix):]))
+	}
+	return address.NewDelegatedAddress(builtin.EthereumAddressManagerActorID, ea[:])
+}
+
+// IsMaskedID 判断是否为由 ID 地址映射的以太坊地址
+func (ea EthAddress) IsMaskedID() bool {
+	return bytes.HasPrefix(ea[:], maskedIDPrefix[:])
+}
+
+// String 返回 0x 开头的小写十六进制地址
+func (ea EthAddress) String() string {
+	return "0x" + hex.EncodeToString(ea[:])
+}
+
+func protocolName(p address.Protocol) string {
+	switch p {
+	case address.SECP256K1:
+		return "secp256k1"
+	case address.Actor:
+		return "actor"
+	case address.BLS:
+		return "bls"
+	default:
+		return fmt.Sprintf("protocol %d", p)
+	}
+}
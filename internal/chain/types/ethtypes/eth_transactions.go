@@ -0,0 +1,102 @@
+package ethtypes
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"wallet-sign/internal/chain/types"
+)
+
+// EIP1559TxType EIP-1559 交易类型
+const EIP1559TxType = 0x02
+
+// Eth1559TxArgs EIP-1559 交易参数
+// 委托（f410）地址发送的 Filecoin 消息按该格式签名
+type Eth1559TxArgs struct {
+	ChainID              uint64
+	Nonce                uint64
+	To                   *EthAddress
+	Value                big.Int
+	MaxFeePerGas         big.Int
+	MaxPriorityFeePerGas big.Int
+	GasLimit             uint64
+	Input                []byte
+}
+
+// Eth1559TxArgsFromUnsignedFilecoinMessage 将未签名的 Filecoin 消息转换为 EIP-1559 交易参数
+// 与 Lotus 一致，委托地址只能调用 EVM InvokeContract 方法，消息参数必须是 CBOR 字节串
+func Eth1559TxArgsFromUnsignedFilecoinMessage(msg *types.Message, chainID uint64) (*Eth1559TxArgs, error) {
+	if msg.Version != 0 {
+		return nil, fmt.Errorf("unsupported message version: %d", msg.Version)
+	}
+	if msg.To == builtin.EthereumAddressManagerActorAddr {
+		return nil, fmt.Errorf("contract creation is not supported")
+	}
+	if msg.Method != builtin.MethodsEVM.InvokeContract {
+		return nil, fmt.Errorf("invalid method %d: delegated senders can only call InvokeContract (%d)", msg.Method, builtin.MethodsEVM.InvokeContract)
+	}
+
+	to, err := EthAddressFromFilecoinAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert recipient: %w", err)
+	}
+
+	var input []byte
+	if len(msg.Params) > 0 {
+		r := bytes.NewReader(msg.Params)
+		input, err = cbg.ReadByteArray(r, uint64(len(msg.Params)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read params as byte array: %w", err)
+		}
+		if r.Len() != 0 {
+			return nil, fmt.Errorf("extra data found in params")
+		}
+	}
+
+	return &Eth1559TxArgs{
+		ChainID:              chainID,
+		Nonce:                msg.Nonce,
+		To:                   &to,
+		Value:                msg.Value,
+		MaxFeePerGas:         msg.GasFeeCap,
+		MaxPriorityFeePerGas: msg.GasPremium,
+		GasLimit:             uint64(msg.GasLimit),
+		Input:                input,
+	}, nil
+}
+
+// ToRlpUnsignedMsg 返回待签名的交易编码：0x02 || rlp([chainId, nonce, ...])
+func (tx *Eth1559TxArgs) ToRlpUnsignedMsg() ([]byte, error) {
+	var to []byte
+	if tx.To != nil {
+		to = tx.To[:]
+	}
+
+	enc, err := EncodeRLP([]interface{}{
+		encodeUint(tx.ChainID),
+		encodeUint(tx.Nonce),
+		encodeBigInt(tx.MaxPriorityFeePerGas),
+		encodeBigInt(tx.MaxFeePerGas),
+		encodeUint(tx.GasLimit),
+		to,
+		encodeBigInt(tx.Value),
+		tx.Input,
+		[]interface{}{}, // access list
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{EIP1559TxType}, enc...), nil
+}
+
+// encodeBigInt 返回大整数去掉前导零的大端序表示
+func encodeBigInt(v big.Int) []byte {
+	if v.Int == nil || v.Sign() == 0 {
+		return []byte{}
+	}
+	return v.Int.Bytes()
+}
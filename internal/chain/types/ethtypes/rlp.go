@@ -0,0 +1,49 @@
+package ethtypes
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// EncodeRLP 对字节串或嵌套列表进行 RLP 编码
+// 支持的类型：[]byte 以及元素为 []byte 或 []interface{} 的 []interface{}
+func EncodeRLP(val interface{}) ([]byte, error) {
+	switch v := val.(type) {
+	case []byte:
+		if len(v) == 1 && v[0] < 0x80 {
+			return []byte{v[0]}, nil
+		}
+		return append(rlpHeader(0x80, len(v)), v...), nil
+	case []interface{}:
+		var payload []byte
+		for _, item := range v {
+			enc, err := EncodeRLP(item)
+			if err != nil {
+				return nil, err
+			}
+			payload = append(payload, enc...)
+		}
+		return append(rlpHeader(0xc0, len(payload)), payload...), nil
+	default:
+		return nil, fmt.Errorf("cannot RLP encode %T", val)
+	}
+}
+
+// rlpHeader 返回长度为 n 的字节串（offset 0x80）或列表（offset 0xc0）的前缀
+func rlpHeader(offset byte, n int) []byte {
+	if n < 56 {
+		return []byte{offset + byte(n)}
+	}
+	lenBytes := encodeUint(uint64(n))
+	return append([]byte{offset + 55 + byte(len(lenBytes))}, lenBytes...)
+}
+
+// encodeUint 返回整数去掉前导零的大端序表示，0 编码为空字节串
+func encodeUint(n uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, n)
+	for len(buf) > 0 && buf[0] == 0 {
+		buf = buf[1:]
+	}
+	return buf
+}
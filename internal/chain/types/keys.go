@@ -13,6 +13,7 @@ type KeyType string
 const (
 	KTSecp256k1 KeyType = "secp256k1"
 	KTBLS       KeyType = "bls"
+	KTDelegated KeyType = "delegated"
 )
 
 func (kt *KeyType) UnmarshalJSON(bb []byte) error {
@@ -38,6 +39,8 @@ func (kt *KeyType) UnmarshalJSON(bb []byte) error {
 			*kt = KTBLS
 		case crypto.SigTypeSecp256k1:
 			*kt = KTSecp256k1
+		case crypto.SigTypeDelegated:
+			*kt = KTDelegated
 		default:
 			return fmt.Errorf("unsupported signature type: %d", b)
 		}
@@ -23,3 +23,16 @@ func (ts *TipSet) Height() abi.ChainEpoch {
 	}
 	return ts.HeightField
 }
+
+// 链头变化类型
+const (
+	HCRevert  = "revert"
+	HCApply   = "apply"
+	HCCurrent = "current"
+)
+
+// HeadChange ChainNotify 推送的链头变化
+type HeadChange struct {
+	Type string  `json:"Type"`
+	Val  *TipSet `json:"Val"`
+}
@@ -7,14 +7,72 @@ import (
 
 // LotusConfig 全局配置实例（从 TOML 文件加载）
 var LotusConfig struct {
-	Lotus    *Lotus    // Lotus 节点配置
-	Security *Security // 安全配置
-	Database *Database // 数据库配置
+	Network   string              // 默认使用的网络，可通过 --network 覆盖，为空时按 [Lotus] 节点所在的网络确定
+	Networks  map[string]*Network // 网络配置，按名称索引
+	Lotus     *Lotus              // Lotus 节点配置
+	Security  *Security           // 安全配置
+	Database  *Database           // 数据库配置
+	Daemon    *Daemon             // 远程钱包服务配置
+	Addresses *Addresses          // 矿工 control 地址用途配置
+	Sweep     *Sweep              // 自动归集配置
+	Monitor   *Monitor            // 余额及状态监控配置
+	Metrics   *Metrics            // Prometheus 指标配置
+}
+
+// DefaultEthChainID 主网 EIP-155 链 ID
+const DefaultEthChainID = 314
+
+// DefaultDaemonListen 远程钱包服务默认监听地址
+const DefaultDaemonListen = "127.0.0.1:1777"
+
+// Daemon 远程钱包服务配置
+type Daemon struct {
+	Listen      string // 监听地址
+	Token       string // Bearer 认证令牌，为空时启动时随机生成
+	AllowExport bool   // 是否允许通过 WalletExport 导出私钥，默认不允许
+}
+
+// Addresses 矿工 control 地址的用途，与 lotus-miner 配置中的 [Addresses] 一致
+// 仅用于 actor info 和 actor control list 的展示，不影响签名
+type Addresses struct {
+	PreCommitControl   []string // 发送 PreCommit 消息的地址
+	CommitControl      []string // 发送 ProveCommit 消息的地址
+	TerminateControl   []string // 发送终止扇区消息的地址
+	DealPublishControl []string // 发送 PublishStorageDeals 消息的地址
+}
+
+// Sweep 自动归集（wallet-sign sweep run）配置
+type Sweep struct {
+	RulesFile string // 规则文件路径，可通过 --rules 覆盖
+}
+
+// Monitor 余额及状态监控（wallet-sign monitor run）配置
+// 金额单位为 FIL，余额阈值为空时使用 10 FIL，设为 "0" 时不检查；市场阈值为空时不检查
+type Monitor struct {
+	Miners             []string // 监控的矿工
+	Interval           string   // 轮询间隔，例如 "5m"，默认 5 分钟
+	OwnerBalanceLow    string   // owner 余额低于该值时告警
+	WorkerBalanceLow   string   // worker 余额低于该值时告警
+	ControlBalanceLow  string   // control 地址余额低于该值时告警
+	MarketAvailableLow string   // 市场托管可用余额（Escrow - Locked）低于该值时告警
+	MarketLockedHigh   string   // 市场锁定余额高于该值时告警
+	Webhook            string   // 接收事件的 HTTP 地址，事件以 JSON POST
+	WebhookToken       string   // 请求 webhook 时附带的 Bearer 令牌（可选）
+	Command            []string // 接收事件的本地命令及参数，事件 JSON 从标准输入传入
+}
+
+// Metrics Prometheus 指标配置，用于 daemon、sweep run、monitor run 等长期运行的命令
+type Metrics struct {
+	Listen          string   // /metrics 监听地址，为空时不提供指标，可通过 --metrics-listen 覆盖
+	BalanceInterval string   // 余额指标刷新间隔，默认 1 分钟
+	Miners          []string // 采集可用余额的矿工，为空时使用 Monitor.Miners
 }
 
 // Security 安全相关配置
 type Security struct {
-	Seed string // 加密种子
+	PassphraseFile string // 密钥库口令文件路径（可选）
+	PolicyFile     string // 签名策略文件路径（可选），配置后所有待签名消息都需通过策略检查
+	Seed           string // 加密种子（已弃用，仅用于迁移旧数据库）
 }
 
 // Database 数据库配置
@@ -28,9 +86,28 @@ type Config struct {
 }
 
 // Lotus 节点连接配置
+// 配置 Endpoints 后按优先级使用多个节点，节点不可用或落后时自动切换
 type Lotus struct {
-	Host  string // Lotus 节点地址
-	Token string // API 访问令牌
+	Host      string      // Lotus 节点地址（http(s):// 或 ws(s)://），优先级为 0
+	Token     string      // API 访问令牌
+	Endpoints []*Endpoint // 备用节点（可选）
+
+	HealthInterval string // 节点健康检查间隔，默认 30 秒
+	MaxHeadLag     int64  // 链高度落后于其他节点超过该值时视为不可用，默认 3
+	StaleAfter     string // 链高度超过该时间没有增长时视为不可用，默认 3 分钟
+
+	Timeout         string            // 单次请求超时，默认 30 秒
+	Timeouts        map[string]string // 按方法覆盖的请求超时，"0" 表示不限制（StateWaitMsg 默认不限制）
+	Retries         int               // 只读方法（State*、Chain*、Gas*）失败后的重试次数，默认 3，-1 表示不重试
+	RetryBackoff    string            // 第一次重试前的等待时间，之后每次翻倍，默认 500 毫秒
+	RetryMaxBackoff string            // 重试等待时间上限，默认 10 秒
+}
+
+// Endpoint 备用 Lotus 节点
+type Endpoint struct {
+	Host     string // 节点地址
+	Token    string // API 访问令牌
+	Priority int    // 优先级，数值越小越优先，相同时按配置顺序
 }
 
 // LoadConfig 加载配置
@@ -52,6 +129,17 @@ func LoadConfig() (*Config, error) {
 	}, nil
 }
 
+// EthChainID 返回委托地址签名使用的 EIP-155 链 ID
+// 使用当前网络配置的链 ID，未配置时使用主网链 ID
+func EthChainID() uint64 {
+	networkMu.RLock()
+	defer networkMu.RUnlock()
+	if activeNetwork.EthChainID != 0 {
+		return activeNetwork.EthChainID
+	}
+	return DefaultEthChainID
+}
+
 // expandPath 展开路径中的 ~ 为用户主目录
 func expandPath(path string) string {
 	if len(path) > 0 && path[0] == '~' {
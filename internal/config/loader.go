@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/BurntSushi/toml"
@@ -18,8 +19,15 @@ func Load() error {
 	if path == "" {
 		return nil
 	}
-	_, err := toml.DecodeFile(path, &LotusConfig)
-	return err
+	md, err := toml.DecodeFile(path, &LotusConfig)
+	if err != nil {
+		return err
+	}
+	// 链 ID 只由网络配置决定，旧的 [Chain] 段不再读取，拒绝启动以免按错误的链 ID 签名
+	if md.IsDefined("Chain") {
+		return fmt.Errorf("%s: the [Chain] section is no longer supported, select a network with --network or set EthChainID in [Networks.<name>]", path)
+	}
+	return nil
 }
 
 // ResolveConfigPath 解析配置文件路径
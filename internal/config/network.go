@@ -0,0 +1,275 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/filecoin-project/go-address"
+
+	"wallet-sign/internal/chain/types"
+)
+
+// 内置网络名称
+const (
+	NetworkMainnet  = "mainnet"
+	NetworkCalibnet = "calibnet"
+	NetworkDevnet   = "devnet"
+)
+
+// DefaultConfidence 消息上链后默认等待的 tipset 数
+const DefaultConfidence = 3
+
+// DefaultMaxFee 单条消息默认最大手续费（GasFeeCap × GasLimit），单位 FIL
+const DefaultMaxFee = "10"
+
+// Network 网络配置（[Networks.<名称>]）
+// 未配置的字段使用同名内置网络的默认值；配置了 Host 时替换 [Lotus] 中的节点地址、令牌及备用节点，
+// 超时、重试及健康检查参数仍使用 [Lotus] 中的配置
+type Network struct {
+	Host       string      // Lotus 节点地址
+	Token      string      // API 访问令牌
+	Endpoints  []*Endpoint // 备用节点（可选）
+	Prefix     string      // 地址前缀，"f"（主网）或 "t"（测试网）
+	Confidence int64       // 消息上链后等待的 tipset 数
+	MaxFee     string      // 单条消息最大手续费（GasFeeCap × GasLimit），单位 FIL
+	EthChainID uint64      // EIP-155 链 ID，委托（f410）地址签名时使用
+	NodeName   string      // 节点 StateNetworkName 返回的网络名称，为空时不按名称校验
+}
+
+// builtinNetworks 内置网络的默认配置
+// 主网没有默认节点地址，使用 [Lotus] 中的配置；本地开发网络的名称由节点随机生成，不按名称校验
+var builtinNetworks = map[string]Network{
+	NetworkMainnet: {
+		Prefix:     address.MainnetPrefix,
+		EthChainID: DefaultEthChainID,
+		NodeName:   "mainnet",
+	},
+	NetworkCalibnet: {
+		Host:       "https://api.calibration.node.glif.io/rpc/v1",
+		Prefix:     address.TestnetPrefix,
+		EthChainID: 314159,
+		NodeName:   "calibrationnet",
+	},
+	NetworkDevnet: {
+		Host:       "http://127.0.0.1:1234/rpc/v1",
+		Prefix:     address.TestnetPrefix,
+		EthChainID: 31415926,
+	},
+}
+
+// activeNetwork 当前使用的网络，由 UseNetwork 设置，VerifyNetwork 按节点确认
+var activeNetwork = struct {
+	name     string
+	explicit bool   // 是否通过 --network 或配置文件中的 Network 选择
+	nodeName string // 已校验的节点网络名称
+	Network
+}{name: NetworkMainnet, Network: builtinNetworks[NetworkMainnet]}
+
+// networkMu 保护 activeNetwork，节点连接可能在多个协程中校验网络
+var networkMu sync.RWMutex
+
+// UseNetwork 选择网络配置并设置地址前缀
+// name 为空时使用配置文件中的 Network，仍为空时使用 [Lotus] 中的节点，连接节点后按节点所在的网络确定，
+// 确定之前按主网处理
+func UseNetwork(name string) error {
+	explicit := true
+	if name == "" {
+		name = LotusConfig.Network
+	}
+	if name == "" {
+		name, explicit = NetworkMainnet, false
+	}
+
+	n, err := lookupNetwork(name)
+	if err != nil {
+		return err
+	}
+	if err := checkNetwork(name, n); err != nil {
+		return err
+	}
+
+	if n.Host != "" {
+		lotus := &Lotus{}
+		if LotusConfig.Lotus != nil {
+			*lotus = *LotusConfig.Lotus
+		}
+		lotus.Host = n.Host
+		lotus.Token = n.Token
+		lotus.Endpoints = n.Endpoints
+		LotusConfig.Lotus = lotus
+	}
+
+	networkMu.Lock()
+	defer networkMu.Unlock()
+	setNetwork(name, n, explicit)
+	activeNetwork.nodeName = ""
+	return nil
+}
+
+// VerifyNetwork 校验节点所在网络（StateNetworkName 的返回值）与当前网络一致，在向节点发送请求前调用
+// 选择了网络时，节点网络与配置的 NodeName 不一致，或属于其他配置了 NodeName 的网络，则拒绝；
+// 未选择网络时改用节点所在的网络（仍连接 [Lotus] 中的节点），没有对应的网络配置时拒绝。
+// 第一次校验通过后记录节点网络，之后连接到其他网络的节点同样拒绝
+func VerifyNetwork(nodeName string) error {
+	networkMu.Lock()
+	defer networkMu.Unlock()
+
+	if activeNetwork.nodeName != "" {
+		if nodeName != activeNetwork.nodeName {
+			return fmt.Errorf("node is on network %q, but network %s is already in use with a node on %q", nodeName, activeNetwork.name, activeNetwork.nodeName)
+		}
+		return nil
+	}
+
+	detected, found := networkForNode(nodeName)
+	switch {
+	case activeNetwork.explicit && activeNetwork.NodeName != "":
+		if nodeName != activeNetwork.NodeName {
+			return fmt.Errorf("node is on network %q, but network %s expects %q", nodeName, activeNetwork.name, activeNetwork.NodeName)
+		}
+	case activeNetwork.explicit:
+		if found && detected != activeNetwork.name {
+			return fmt.Errorf("node is on network %q (%s), not %s", nodeName, detected, activeNetwork.name)
+		}
+	case !found:
+		return fmt.Errorf("node is on network %q, which matches no network configuration, select one with --network", nodeName)
+	case detected != activeNetwork.name:
+		// 未选择网络时使用节点所在的网络，节点地址不变
+		n, err := lookupNetwork(detected)
+		if err != nil {
+			return err
+		}
+		if err := checkNetwork(detected, n); err != nil {
+			return err
+		}
+		setNetwork(detected, n, false)
+	}
+	activeNetwork.nodeName = nodeName
+	return nil
+}
+
+// NetworkConfirmed 返回当前网络是否已确定：通过 --network 或配置文件中的 Network 选择了网络，
+// 或已按节点所在的网络确认（VerifyNetwork）
+func NetworkConfirmed() bool {
+	networkMu.RLock()
+	defer networkMu.RUnlock()
+	return activeNetwork.explicit || activeNetwork.nodeName != ""
+}
+
+// networkForNode 返回 NodeName 与节点网络名称一致的网络
+func networkForNode(nodeName string) (string, bool) {
+	for _, name := range NetworkNames() {
+		if n, err := lookupNetwork(name); err == nil && n.NodeName != "" && n.NodeName == nodeName {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// checkNetwork 校验网络配置的地址前缀及手续费上限
+func checkNetwork(name string, n Network) error {
+	switch n.Prefix {
+	case address.MainnetPrefix, address.TestnetPrefix:
+	default:
+		return fmt.Errorf("network %s: invalid address prefix %q, expected %q or %q", name, n.Prefix, address.MainnetPrefix, address.TestnetPrefix)
+	}
+	if n.MaxFee != "" {
+		if _, err := types.ParseFIL(n.MaxFee); err != nil {
+			return fmt.Errorf("network %s: invalid MaxFee %q: %w", name, n.MaxFee, err)
+		}
+	}
+	return nil
+}
+
+// setNetwork 设置当前网络及地址前缀，调用方持有 networkMu
+func setNetwork(name string, n Network, explicit bool) {
+	if n.Prefix == address.TestnetPrefix {
+		address.CurrentNetwork = address.Testnet
+	} else {
+		address.CurrentNetwork = address.Mainnet
+	}
+	activeNetwork.name = name
+	activeNetwork.explicit = explicit
+	activeNetwork.Network = n
+}
+
+// lookupNetwork 合并配置文件与内置网络的配置
+func lookupNetwork(name string) (Network, error) {
+	n, builtin := builtinNetworks[name]
+	cfg, ok := LotusConfig.Networks[name]
+	if !builtin && !ok {
+		return Network{}, fmt.Errorf("unknown network %q, available: %s", name, strings.Join(NetworkNames(), ", "))
+	}
+	if cfg == nil {
+		return n, nil
+	}
+
+	if cfg.Host != "" {
+		n.Host = cfg.Host
+		n.Token = cfg.Token
+		n.Endpoints = cfg.Endpoints
+	}
+	if cfg.Prefix != "" {
+		n.Prefix = cfg.Prefix
+	}
+	if cfg.Confidence != 0 {
+		n.Confidence = cfg.Confidence
+	}
+	if cfg.MaxFee != "" {
+		n.MaxFee = cfg.MaxFee
+	}
+	if cfg.EthChainID != 0 {
+		n.EthChainID = cfg.EthChainID
+	}
+	if cfg.NodeName != "" {
+		n.NodeName = cfg.NodeName
+	}
+	if n.Prefix == "" {
+		n.Prefix = address.MainnetPrefix
+	}
+	return n, nil
+}
+
+// NetworkNames 返回内置及配置文件中的网络名称
+func NetworkNames() []string {
+	names := make([]string, 0, len(builtinNetworks)+len(LotusConfig.Networks))
+	for name := range builtinNetworks {
+		names = append(names, name)
+	}
+	for name := range LotusConfig.Networks {
+		if _, ok := builtinNetworks[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NetworkName 返回当前使用的网络名称
+func NetworkName() string {
+	networkMu.RLock()
+	defer networkMu.RUnlock()
+	return activeNetwork.name
+}
+
+// Confidence 返回消息上链后需要等待的 tipset 数
+func Confidence() int64 {
+	networkMu.RLock()
+	defer networkMu.RUnlock()
+	if activeNetwork.Confidence > 0 {
+		return activeNetwork.Confidence
+	}
+	return DefaultConfidence
+}
+
+// MaxFee 返回单条消息最大手续费，单位 FIL
+func MaxFee() string {
+	networkMu.RLock()
+	defer networkMu.RUnlock()
+	if activeNetwork.MaxFee != "" {
+		return activeNetwork.MaxFee
+	}
+	return DefaultMaxFee
+}
@@ -0,0 +1,558 @@
+package lotusmock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/ipfs/go-cid"
+
+	"wallet-sign/internal/chain/types"
+)
+
+// rbfPercentage 替换内存池消息时 GasPremium 至少提高的比例（百分比），与 Lotus 一致
+const rbfPercentage = 110
+
+type handler func(n *Node, ctx context.Context, params []json.RawMessage) (interface{}, error)
+
+// method 一个 Lotus 方法的处理函数及其参数个数
+// 与 Lotus（go-jsonrpc）一样，参数个数不符的请求直接返回错误
+type method struct {
+	h      handler
+	params int
+}
+
+// methods vapi 使用的 Lotus 方法，参数与 Lotus v1 API 一致
+var methods = map[string]method{
+	"ChainHead":                  {chainHead, 0},
+	"ChainGetMessage":            {chainGetMessage, 1},
+	"StateNetworkName":           {stateNetworkName, 0},
+	"StateNetworkVersion":        {stateNetworkVersion, 1},
+	"StateActorCodeCIDs":         {stateActorCodeCIDs, 1},
+	"StateLookupID":              {stateLookupID, 2},
+	"StateAccountKey":            {stateAccountKey, 2},
+	"StateGetActor":              {stateGetActor, 2},
+	"StateReadState":             {stateReadState, 2},
+	"StateMinerInfo":             {stateMinerInfo, 2},
+	"StateMinerAvailableBalance": {stateMinerAvailableBalance, 2},
+	"StateMarketBalance":         {stateMarketBalance, 2},
+	"StateCall":                  {stateCall, 2},
+	"StateSearchMsg":             {stateSearchMsg, 4},
+	"StateWaitMsg":               {stateWaitMsg, 4},
+	"StateReplay":                {stateReplay, 2},
+	"MsigGetPending":             {msigGetPending, 2},
+	"MsigGetAvailableBalance":    {msigGetAvailableBalance, 2},
+	"MsigGetVestingSchedule":     {msigGetVestingSchedule, 2},
+	"WalletBalance":              {walletBalance, 1},
+	"MpoolGetNonce":              {mpoolGetNonce, 1},
+	"MpoolPending":               {mpoolPending, 1},
+	"MpoolPush":                  {mpoolPush, 1},
+	"GasEstimateMessageGas":      {gasEstimateMessageGas, 3},
+	"GasEstimateGasPremium":      {gasEstimateGasPremium, 4},
+	"GasEstimateFeeCap":          {gasEstimateFeeCap, 3},
+}
+
+// v0Method v0 API 中签名不同的方法：参数个数，以及把参数补齐为 v1 形式的函数
+type v0Method struct {
+	params int
+	toV1   func(params []json.RawMessage) []json.RawMessage
+}
+
+var null = json.RawMessage("null")
+
+// v0Methods 与 v1 签名不同的 v0 方法，参数补齐为 v1 形式后由同一个处理函数处理
+var v0Methods = map[string]v0Method{
+	// v0: (cid)，v1: (tsk, cid, limit, allowReplaced)
+	"StateSearchMsg": {1, func(p []json.RawMessage) []json.RawMessage {
+		return []json.RawMessage{null, p[0], null, null}
+	}},
+	// v0: (cid, confidence)，v1: (cid, confidence, limit, allowReplaced)
+	"StateWaitMsg": {2, func(p []json.RawMessage) []json.RawMessage {
+		return append(p, null, null)
+	}},
+}
+
+// parseParams 按顺序解码参数，缺少的参数保持零值
+func parseParams(params []json.RawMessage, dst ...interface{}) error {
+	for i, d := range dst {
+		if i >= len(params) {
+			break
+		}
+		if err := json.Unmarshal(params[i], d); err != nil {
+			return errorf("unmarshaling param %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// actorParam 解码第一个参数为地址并返回对应的 actor，调用方持有锁
+func (n *Node) actorParam(params []json.RawMessage) (*actor, address.Address, error) {
+	var addr address.Address
+	if err := parseParams(params, &addr); err != nil {
+		return nil, addr, err
+	}
+	a := n.st.get(addr)
+	if a == nil {
+		return nil, addr, errorf("resolution lookup failed (%s): resolve address %s: actor not found", addr, addr)
+	}
+	return a, addr, nil
+}
+
+func chainHead(n *Node, _ context.Context, _ []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.tipset(n.height), nil
+}
+
+func chainGetMessage(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	var c cid.Cid
+	if err := parseParams(params, &c); err != nil {
+		return nil, err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	msg, ok := n.messages[c]
+	if !ok {
+		return nil, errorf("failed to load message: blockstore: block not found")
+	}
+	return msg, nil
+}
+
+func stateNetworkName(n *Node, _ context.Context, _ []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.name, nil
+}
+
+func stateNetworkVersion(n *Node, _ context.Context, _ []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.nv, nil
+}
+
+func stateActorCodeCIDs(_ *Node, _ context.Context, _ []json.RawMessage) (interface{}, error) {
+	return builtinCodes, nil
+}
+
+func stateLookupID(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a, _, err := n.actorParam(params)
+	if err != nil {
+		return nil, err
+	}
+	return a.ID, nil
+}
+
+func stateAccountKey(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	var addr address.Address
+	if err := parseParams(params, &addr); err != nil {
+		return nil, err
+	}
+	if p := addr.Protocol(); p == address.SECP256K1 || p == address.BLS {
+		return addr, nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a, _, err := n.actorParam(params)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := a.keyAddress()
+	if !ok {
+		return nil, errorf("failed to get account key for %s: actor code is not account (%s)", addr, a.Code)
+	}
+	return key, nil
+}
+
+func stateGetActor(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a, _, err := n.actorParam(params)
+	if err != nil {
+		return nil, err
+	}
+	head, _ := abi.CidBuilder.Sum([]byte(fmt.Sprintf("lotusmock/state/%s/%d", a.ID, a.Nonce)))
+	return &types.Actor{Code: builtinCodes[a.Code], Head: head, Nonce: a.Nonce, Balance: a.Balance}, nil
+}
+
+func stateReadState(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a, _, err := n.actorParam(params)
+	if err != nil {
+		return nil, err
+	}
+	var st interface{} = struct{}{}
+	switch {
+	case a.Msig != nil:
+		st = &types.MsigState{
+			Signers:               a.Msig.Signers,
+			NumApprovalsThreshold: a.Msig.Threshold,
+			NextTxnID:             a.Msig.NextTxnID,
+			InitialBalance:        a.Msig.InitialBalance,
+			StartEpoch:            a.Msig.StartEpoch,
+			UnlockDuration:        a.Msig.UnlockDuration,
+		}
+	case a.Robust != address.Undef:
+		st = map[string]address.Address{"Address": a.Robust}
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ActorState{Balance: a.Balance, Code: builtinCodes[a.Code], State: data}, nil
+}
+
+// minerParam 解码第一个参数为矿工地址，调用方持有锁
+func (n *Node) minerParam(params []json.RawMessage) (*minerState, error) {
+	a, addr, err := n.actorParam(params)
+	if err != nil {
+		return nil, err
+	}
+	if a.Miner == nil {
+		return nil, errorf("failed to load miner actor state: actor %s is not a miner", addr)
+	}
+	return a.Miner, nil
+}
+
+func stateMinerInfo(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	m, err := n.minerParam(params)
+	if err != nil {
+		return nil, err
+	}
+	info := copyMinerInfo(m.Info)
+	return &info, nil
+}
+
+func stateMinerAvailableBalance(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	m, err := n.minerParam(params)
+	if err != nil {
+		return nil, err
+	}
+	return m.Available, nil
+}
+
+func stateMarketBalance(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a, _, err := n.actorParam(params)
+	if err != nil {
+		return nil, err
+	}
+	if mb, ok := n.st.market[a.ID]; ok {
+		return mb, nil
+	}
+	return &types.MarketBalance{Escrow: big.Zero(), Locked: big.Zero()}, nil
+}
+
+// msigParam 解码第一个参数为多签钱包地址，调用方持有锁
+func (n *Node) msigParam(params []json.RawMessage) (*actor, error) {
+	a, addr, err := n.actorParam(params)
+	if err != nil {
+		return nil, err
+	}
+	if a.Msig == nil {
+		return nil, errorf("actor %s is not a multisig", addr)
+	}
+	return a, nil
+}
+
+func msigGetPending(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a, err := n.msigParam(params)
+	if err != nil {
+		return nil, err
+	}
+	return n.msigPending(a), nil
+}
+
+func msigGetAvailableBalance(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a, err := n.msigParam(params)
+	if err != nil {
+		return nil, err
+	}
+	return big.Sub(a.Balance, a.Msig.lockedBalance(n.height)), nil
+}
+
+func msigGetVestingSchedule(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a, err := n.msigParam(params)
+	if err != nil {
+		return nil, err
+	}
+	return &types.MsigVesting{
+		InitialBalance: a.Msig.InitialBalance,
+		StartEpoch:     a.Msig.StartEpoch,
+		UnlockDuration: a.Msig.UnlockDuration,
+	}, nil
+}
+
+func walletBalance(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	var addr address.Address
+	if err := parseParams(params, &addr); err != nil {
+		return nil, err
+	}
+	return n.Balance(addr), nil
+}
+
+func mpoolGetNonce(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a, _, err := n.actorParam(params)
+	if err != nil {
+		return nil, err
+	}
+	nonce := a.Nonce
+	for _, sm := range n.mpool {
+		if id, _ := n.st.lookupID(sm.Message.From); id == a.ID && sm.Message.Nonce >= nonce {
+			nonce = sm.Message.Nonce + 1
+		}
+	}
+	return nonce, nil
+}
+
+func mpoolPending(n *Node, _ context.Context, _ []json.RawMessage) (interface{}, error) {
+	return n.Pending(), nil
+}
+
+// sigTypeFor 返回地址应当使用的签名类型
+func sigTypeFor(addr address.Address) (crypto.SigType, bool) {
+	switch addr.Protocol() {
+	case address.SECP256K1:
+		return crypto.SigTypeSecp256k1, true
+	case address.BLS:
+		return crypto.SigTypeBLS, true
+	case address.Delegated:
+		return crypto.SigTypeDelegated, true
+	}
+	return crypto.SigTypeUnknown, false
+}
+
+// mpoolPush 校验并接收已签名消息，检查 nonce、余额（含内存池中的其他消息）及替换消息的溢价
+// 签名内容不做校验，只检查签名类型与发送方地址匹配
+func mpoolPush(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	var sm types.SignedMessage
+	if err := parseParams(params, &sm); err != nil {
+		return nil, err
+	}
+	msg := &sm.Message
+	msgCid := sm.Cid()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if typ, ok := sigTypeFor(msg.From); !ok || typ != sm.Signature.Type {
+		return nil, errorf("mpool push: invalid signature type %d for sender %s", sm.Signature.Type, msg.From)
+	}
+	if msg.GasLimit <= 0 {
+		return nil, errorf("mpool push: message will not be included in a block: GasLimit must be positive")
+	}
+	from := n.st.get(msg.From)
+	if from == nil {
+		return nil, errorf("mpool push: failed to resolve sender %s: actor not found", msg.From)
+	}
+	if msg.Nonce < from.Nonce {
+		return nil, errorf("mpool push: minimum expected nonce is %d: message nonce too low", from.Nonce)
+	}
+
+	replace := -1
+	required := big.Add(msg.Value, big.Mul(msg.GasFeeCap, big.NewInt(msg.GasLimit)))
+	for i, p := range n.mpool {
+		if id, _ := n.st.lookupID(p.Message.From); id != from.ID {
+			continue
+		}
+		if p.Message.Nonce == msg.Nonce {
+			if p.Cid() == msgCid {
+				return msgCid, nil
+			}
+			minPremium := big.Add(big.Div(big.Mul(p.Message.GasPremium, big.NewInt(rbfPercentage)), big.NewInt(100)), big.NewInt(1))
+			if msg.GasPremium.LessThan(minPremium) {
+				return nil, errorf("mpool push: replace by fee has too low GasPremium: message from %s with nonce %d already in mpool, increase GasPremium to %s",
+					msg.From, msg.Nonce, minPremium)
+			}
+			replace = i
+			continue
+		}
+		required = big.Add(required, big.Add(p.Message.Value, big.Mul(p.Message.GasFeeCap, big.NewInt(p.Message.GasLimit))))
+	}
+	if from.Balance.LessThan(required) {
+		return nil, errorf("mpool push: not enough funds including pending messages (required: %s, balance: %s): not enough funds",
+			types.FIL(required), types.FIL(from.Balance))
+	}
+
+	if replace >= 0 {
+		n.mpool[replace] = &sm
+	} else {
+		n.mpool = append(n.mpool, &sm)
+	}
+	n.messages[msgCid] = msg
+	for i := 0; i < n.autoMine; i++ {
+		n.mine()
+	}
+	return msgCid, nil
+}
+
+// dryRun 在链状态的副本上执行消息，不收取 Gas 费用，nonce 视为发送方的下一个 nonce
+// 调用方持有锁
+func (n *Node) dryRun(msg *types.Message) (types.Receipt, error) {
+	from := n.st.get(msg.From)
+	if from == nil {
+		return types.Receipt{}, fmt.Errorf("resolution lookup failed (%s): actor not found", msg.From)
+	}
+	call := *msg
+	call.Nonce = from.Nonce
+	call.GasLimit = GasPerMessage
+	call.GasFeeCap = big.Zero()
+	call.GasPremium = big.Zero()
+	v := &vm{st: n.st.clone(), height: n.height, baseFee: big.Zero()}
+	rct, _ := v.apply(&call, exitcode.Ok)
+	return rct, nil
+}
+
+func stateCall(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	var msg types.Message
+	if err := parseParams(params, &msg); err != nil {
+		return nil, err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	rct, err := n.dryRun(&msg)
+	if err != nil {
+		return nil, errorf("call raw get actor: %v", err)
+	}
+	res := &types.InvocResult{MsgCid: msg.Cid(), Msg: &msg, MsgRct: &rct, GasCost: gasCost(&msg, n.baseFee)}
+	if rct.ExitCode != 0 {
+		res.Error = fmt.Sprintf("message execution failed: exit %s", exitcode.ExitCode(rct.ExitCode))
+	}
+	return res, nil
+}
+
+// gasEstimateMessageGas 估算 Gas 参数，消息执行失败（例如余额不足以支付转账金额）时返回错误
+func gasEstimateMessageGas(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	var msg types.Message
+	if err := parseParams(params, &msg); err != nil {
+		return nil, err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	rct, err := n.dryRun(&msg)
+	if err != nil {
+		return nil, errorf("estimating gas used: %v", err)
+	}
+	if rct.ExitCode != 0 {
+		return nil, errorf("estimating gas used: message execution failed (exit=[%s])", exitcode.ExitCode(rct.ExitCode))
+	}
+	msg.GasLimit = GasPerMessage
+	if msg.GasPremium.Nil() || msg.GasPremium.IsZero() {
+		msg.GasPremium = n.premium
+	}
+	if msg.GasFeeCap.Nil() || msg.GasFeeCap.IsZero() {
+		msg.GasFeeCap = n.feeCap()
+	}
+	return &msg, nil
+}
+
+// feeCap 返回估算的 GasFeeCap：两倍基础费加小费，调用方持有锁
+func (n *Node) feeCap() abi.TokenAmount {
+	return big.Add(big.Mul(n.baseFee, big.NewInt(2)), n.premium)
+}
+
+func gasEstimateGasPremium(n *Node, _ context.Context, _ []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.premium, nil
+}
+
+func gasEstimateFeeCap(n *Node, _ context.Context, _ []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.feeCap(), nil
+}
+
+func stateSearchMsg(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	var (
+		tsk json.RawMessage // 只在当前链上查找，不使用 tipset 参数
+		c   cid.Cid
+	)
+	if err := parseParams(params, &tsk, &c); err != nil {
+		return nil, err
+	}
+	return n.Receipt(c), nil
+}
+
+// stateWaitMsg 阻塞到消息上链并达到 confidence 个确认
+// 开启自动出块时，消息上链后直接出块补足确认数
+func stateWaitMsg(n *Node, ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var (
+		c          cid.Cid
+		confidence abi.ChainEpoch
+	)
+	if err := parseParams(params, &c, &confidence); err != nil {
+		return nil, err
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		n.cond.Broadcast()
+	})
+	defer stop()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for {
+		inc, ok := n.receipts[c]
+		switch {
+		case ok && n.height >= inc.lookup.Height+confidence:
+			lookup := *inc.lookup
+			return &lookup, nil
+		case ctx.Err() != nil:
+			return nil, errorf("waiting for message %s: %v", c, ctx.Err())
+		}
+		select {
+		case <-n.shutdownCh:
+			return nil, errorf("waiting for message %s: node closed", c)
+		default:
+		}
+		if ok && n.autoMine > 0 {
+			n.mine()
+			continue
+		}
+		n.cond.Wait()
+	}
+}
+
+func stateReplay(n *Node, _ context.Context, params []json.RawMessage) (interface{}, error) {
+	var (
+		tsk []cid.Cid
+		c   cid.Cid
+	)
+	if err := parseParams(params, &tsk, &c); err != nil {
+		return nil, err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	inc, ok := n.receipts[c]
+	if !ok {
+		return nil, errorf("replay: message %s not found on chain", c)
+	}
+	rct := inc.lookup.Receipt
+	res := &types.InvocResult{MsgCid: c, Msg: inc.msg, MsgRct: &rct, GasCost: inc.cost}
+	if rct.ExitCode != 0 {
+		res.Error = fmt.Sprintf("message execution failed: exit %s", exitcode.ExitCode(rct.ExitCode))
+	}
+	return res, nil
+}
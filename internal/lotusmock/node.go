@@ -0,0 +1,566 @@
+// Package lotusmock 提供进程内的模拟 Lotus 节点，用于测试和离线演示
+//
+// Node 通过 httptest 提供 Lotus JSON-RPC 接口（HTTP 和 WebSocket），实现了 vapi 使用的全部方法。
+// 链状态由测试脚本设置：账户、多签钱包、矿工信息、市场托管余额；推送的消息进入内存池，
+// 出块时按 nonce 顺序执行并生成回执。默认每次推送后立即出块，也可以关闭自动出块后手动推进链高度。
+package lotusmock
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
+	"github.com/filecoin-project/go-state-types/big"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/go-state-types/network"
+	"github.com/ipfs/go-cid"
+
+	"wallet-sign/internal/chain/actors"
+	"wallet-sign/internal/chain/types"
+)
+
+const (
+	// DefaultHeight 新节点的初始链高度
+	DefaultHeight = 1000
+	// DefaultAutoMine 默认每次推送后出块的数量：一个打包消息的 tipset 加 3 个确认
+	DefaultAutoMine = 4
+	// DefaultNetworkName 默认网络名称，与未选择网络时使用的主网一致
+	DefaultNetworkName = "mainnet"
+)
+
+var (
+	// DefaultBaseFee 默认基础费（attoFIL/gas）
+	DefaultBaseFee = big.NewInt(100_000)
+	// DefaultGasPremium GasEstimateGasPremium 返回的小费
+	DefaultGasPremium = big.NewInt(50_000)
+)
+
+// builtinActors 内置 actor 名称，与 Lotus StateActorCodeCIDs 返回的键一致
+var builtinActors = []string{
+	"account", "cron", "datacap", "eam", "ethaccount", "evm", "init", "multisig", "paymentchannel",
+	"placeholder", "reward", "storagemarket", "storageminer", "storagepower", "system", "verifiedregistry",
+}
+
+// builtinCodes 内置 actor 名称到代码 CID 的映射
+var builtinCodes = func() map[string]cid.Cid {
+	codes := make(map[string]cid.Cid, len(builtinActors))
+	for _, name := range builtinActors {
+		c, err := abi.CidBuilder.Sum([]byte("fil/mock/" + name))
+		if err != nil {
+			panic(err)
+		}
+		codes[name] = c
+	}
+	return codes
+}()
+
+// codeName 返回代码 CID 对应的内置 actor 名称
+func codeName(c cid.Cid) string {
+	for name, code := range builtinCodes {
+		if code.Equals(c) {
+			return name
+		}
+	}
+	return ""
+}
+
+// included 已上链消息的执行结果
+type included struct {
+	msg    *types.Message
+	lookup *types.MsgLookup
+	cost   *types.MessageGasCost
+}
+
+// Node 模拟 Lotus 节点
+// 所有方法都可以在服务运行时并发调用
+type Node struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	st         *state
+	height     abi.ChainEpoch
+	baseFee    abi.TokenAmount
+	premium    abi.TokenAmount
+	nv         network.Version
+	name       string
+	autoMine   int
+	mpool      []*types.SignedMessage
+	messages   map[cid.Cid]*types.Message // 内存池和链上的全部消息
+	receipts   map[cid.Cid]*included
+	failNext   map[address.Address][]exitcode.ExitCode // 发送方 ID -> 脚本指定的执行失败
+	rpcFails   map[string][]int                        // 方法名 -> 脚本指定的 HTTP 错误状态码
+	calls      map[string]int
+	subs       map[int]chan []*types.HeadChange
+	nextSubID  int
+	srv        *httptest.Server
+	closeOnce  sync.Once
+	shutdownCh chan struct{}
+}
+
+// New 创建模拟节点，链高度为 DefaultHeight，只有内置 actor
+// 网络版本与钱包方法表的 actors 版本一致
+func New() *Node {
+	n := &Node{
+		st:         newState(),
+		height:     DefaultHeight,
+		baseFee:    DefaultBaseFee,
+		premium:    DefaultGasPremium,
+		nv:         latestNetworkVersion(),
+		name:       DefaultNetworkName,
+		autoMine:   DefaultAutoMine,
+		messages:   map[cid.Cid]*types.Message{},
+		receipts:   map[cid.Cid]*included{},
+		failNext:   map[address.Address][]exitcode.ExitCode{},
+		rpcFails:   map[string][]int{},
+		calls:      map[string]int{},
+		subs:       map[int]chan []*types.HeadChange{},
+		shutdownCh: make(chan struct{}),
+	}
+	n.cond = sync.NewCond(&n.mu)
+	return n
+}
+
+// latestNetworkVersion 返回使用 actors.MethodsVersion 的最新网络版本
+func latestNetworkVersion() network.Version {
+	var nv network.Version
+	for v := network.Version0; v < network.Version0+100; v++ {
+		if av, err := actorstypes.VersionForNetwork(v); err == nil && av == actors.MethodsVersion {
+			nv = v
+		}
+	}
+	return nv
+}
+
+// Start 启动 HTTP 服务并返回 JSON-RPC 地址，WebSocket 地址见 WebSocketURL
+func (n *Node) Start() string {
+	n.srv = httptest.NewServer(n.Handler())
+	return n.URL()
+}
+
+// URL 返回 v0 API 的 HTTP JSON-RPC 地址，需要先调用 Start
+func (n *Node) URL() string {
+	return n.srv.URL + "/rpc/v0"
+}
+
+// WebSocketURL 返回 v0 API 的 WebSocket JSON-RPC 地址，需要先调用 Start
+func (n *Node) WebSocketURL() string {
+	return "ws" + strings.TrimPrefix(n.srv.URL, "http") + "/rpc/v0"
+}
+
+// V1URL 返回 v1 API 的 HTTP JSON-RPC 地址，需要先调用 Start
+func (n *Node) V1URL() string {
+	return n.srv.URL + "/rpc/v1"
+}
+
+// WebSocketV1URL 返回 v1 API 的 WebSocket JSON-RPC 地址，需要先调用 Start
+func (n *Node) WebSocketV1URL() string {
+	return "ws" + strings.TrimPrefix(n.srv.URL, "http") + "/rpc/v1"
+}
+
+// Close 关闭 HTTP 服务，断开 WebSocket 连接并唤醒等待中的请求
+func (n *Node) Close() {
+	n.closeOnce.Do(func() {
+		close(n.shutdownCh)
+		n.mu.Lock()
+		n.cond.Broadcast()
+		n.mu.Unlock()
+		if n.srv != nil {
+			n.srv.CloseClientConnections()
+			n.srv.Close()
+		}
+	})
+}
+
+// Handler 返回 JSON-RPC 处理器，可以挂到自定义的 HTTP 服务上
+func (n *Node) Handler() http.Handler {
+	return http.HandlerFunc(n.serveHTTP)
+}
+
+// SetNetwork 设置 StateNetworkName 返回的网络名称
+func (n *Node) SetNetwork(name string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.name = name
+}
+
+// SetNetworkVersion 设置网络版本
+func (n *Node) SetNetworkVersion(nv network.Version) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nv = nv
+}
+
+// SetBaseFee 设置基础费
+func (n *Node) SetBaseFee(fee abi.TokenAmount) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.baseFee = fee
+}
+
+// SetAutoMine 设置每次推送消息后立即出块的数量，第一个 tipset 打包内存池中的消息
+// 0 表示关闭自动出块，消息留在内存池中直到调用 Mine 或 Advance
+func (n *Node) SetAutoMine(tipsets int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.autoMine = tipsets
+}
+
+// AddAccount 创建账户 actor 并返回其 ID 地址，key 为公钥地址（f1/f3）或委托地址（f4）
+func (n *Node) AddAccount(key address.Address, balance abi.TokenAmount) address.Address {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a := n.st.getOrCreate(key)
+	if a == nil {
+		panic(fmt.Sprintf("lotusmock: %s is not a key address", key))
+	}
+	a.Balance = balance
+	return a.ID
+}
+
+// AddMultisig 创建多签钱包，返回 ID 地址和 f2 地址
+// signers 可以是公钥地址或 ID 地址，不存在的公钥地址自动创建账户
+func (n *Node) AddMultisig(signers []address.Address, threshold uint64, balance abi.TokenAmount) (address.Address, address.Address) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ms := &msigState{Threshold: threshold, InitialBalance: big.Zero(), Pending: map[int64]*types.MsigTransaction{}}
+	for _, s := range signers {
+		a := n.st.getOrCreate(s)
+		if a == nil {
+			panic(fmt.Sprintf("lotusmock: signer %s does not exist", s))
+		}
+		ms.Signers = append(ms.Signers, a.ID)
+	}
+	robust := newRobustAddress(builtintypes.InitActorAddr, n.st.nextID)
+	a := n.st.create("multisig", robust)
+	a.Msig = ms
+	a.Balance = balance
+	return a.ID, robust
+}
+
+// SetMsigVesting 设置多签钱包的线性解锁参数
+func (n *Node) SetMsigVesting(msig address.Address, initial abi.TokenAmount, start, duration abi.ChainEpoch) {
+	n.update(msig, func(a *actor) {
+		a.Msig.InitialBalance = initial
+		a.Msig.StartEpoch = start
+		a.Msig.UnlockDuration = duration
+	})
+}
+
+// AddMsigTxn 在多签钱包中添加待处理交易，proposer 为第一个审批人，返回交易 ID
+func (n *Node) AddMsigTxn(msig, proposer, to address.Address, value abi.TokenAmount, method abi.MethodNum, params []byte) int64 {
+	var id int64
+	n.update(msig, func(a *actor) {
+		proposerID := n.mustLookupID(proposer)
+		id = a.Msig.NextTxnID
+		a.Msig.NextTxnID++
+		a.Msig.Pending[id] = &types.MsigTransaction{
+			ID:       id,
+			To:       to,
+			Value:    value,
+			Method:   method,
+			Params:   params,
+			Approved: []address.Address{proposerID},
+		}
+	})
+	return id
+}
+
+// MsigPending 返回多签钱包的待处理交易，按 ID 排序
+func (n *Node) MsigPending(msig address.Address) []*types.MsigTransaction {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.msigPending(n.mustGet(msig))
+}
+
+// MsigSigners 返回多签钱包的签名人（ID 地址）和审批阈值
+func (n *Node) MsigSigners(msig address.Address) ([]address.Address, uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ms := n.mustGet(msig).Msig
+	return append([]address.Address(nil), ms.Signers...), ms.Threshold
+}
+
+// AddMiner 创建矿工 actor 并返回其 ID 地址，owner 和 worker 必须已经存在
+// 受益人默认为 owner，available 为可提现余额
+func (n *Node) AddMiner(owner, worker address.Address, available abi.TokenAmount) address.Address {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ownerID := n.mustLookupID(owner)
+	a := n.st.create("storageminer", address.Undef)
+	a.Balance = available
+	a.Miner = &minerState{
+		Info: types.MinerInfo{
+			Owner:             ownerID,
+			Worker:            n.mustLookupID(worker),
+			ControlAddresses:  []address.Address{},
+			WorkerChangeEpoch: -1,
+			Beneficiary:       ownerID,
+		},
+		Available: available,
+	}
+	return a.ID
+}
+
+// MinerInfo 返回矿工信息的副本
+func (n *Node) MinerInfo(miner address.Address) types.MinerInfo {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return copyMinerInfo(n.mustGet(miner).Miner.Info)
+}
+
+// UpdateMinerInfo 修改矿工信息，例如设置待确认的 owner、worker 或受益人变更
+func (n *Node) UpdateMinerInfo(miner address.Address, fn func(info *types.MinerInfo)) {
+	n.update(miner, func(a *actor) { fn(&a.Miner.Info) })
+}
+
+// SetMinerAvailable 设置矿工的可提现余额，actor 余额同步调整
+func (n *Node) SetMinerAvailable(miner address.Address, available abi.TokenAmount) {
+	n.update(miner, func(a *actor) {
+		a.Balance = big.Add(a.Balance, big.Sub(available, a.Miner.Available))
+		a.Miner.Available = available
+	})
+}
+
+// MinerAvailable 返回矿工的可提现余额
+func (n *Node) MinerAvailable(miner address.Address) abi.TokenAmount {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.mustGet(miner).Miner.Available
+}
+
+// SetMarketBalance 设置地址在市场 actor 中的托管余额和锁定余额
+func (n *Node) SetMarketBalance(addr address.Address, escrow, locked abi.TokenAmount) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	id := n.mustLookupID(addr)
+	market := n.st.actors[builtintypes.StorageMarketActorAddr]
+	if cur, ok := n.st.market[id]; ok {
+		market.Balance = big.Sub(market.Balance, cur.Escrow)
+	}
+	market.Balance = big.Add(market.Balance, escrow)
+	n.st.market[id] = &types.MarketBalance{Escrow: escrow, Locked: locked}
+}
+
+// MarketBalance 返回地址在市场 actor 中的余额
+func (n *Node) MarketBalance(addr address.Address) types.MarketBalance {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if mb, ok := n.st.market[n.mustLookupID(addr)]; ok {
+		return *mb
+	}
+	return types.MarketBalance{Escrow: big.Zero(), Locked: big.Zero()}
+}
+
+// SetBalance 设置 actor 余额
+func (n *Node) SetBalance(addr address.Address, balance abi.TokenAmount) {
+	n.update(addr, func(a *actor) { a.Balance = balance })
+}
+
+// Balance 返回地址的余额，地址上没有 actor 时返回 0
+func (n *Node) Balance(addr address.Address) abi.TokenAmount {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if a := n.st.get(addr); a != nil {
+		return a.Balance
+	}
+	return big.Zero()
+}
+
+// Nonce 返回地址已上链的 nonce
+func (n *Node) Nonce(addr address.Address) uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.mustGet(addr).Nonce
+}
+
+// LookupID 返回地址对应的 ID 地址
+func (n *Node) LookupID(addr address.Address) (address.Address, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.st.lookupID(addr)
+}
+
+// FailNext 使 from 发出的下一条上链消息以 code 失败
+// 只影响出块时的执行，Gas 估算和 StateCall 不受影响，用于模拟推送后链上状态变化导致的失败
+func (n *Node) FailNext(from address.Address, code exitcode.ExitCode) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	id := n.mustLookupID(from)
+	n.failNext[id] = append(n.failNext[id], code)
+}
+
+// FailRPC 使接下来 times 次 method 请求返回 HTTP status 错误，用于测试重试
+func (n *Node) FailRPC(method string, status, times int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i := 0; i < times; i++ {
+		n.rpcFails[method] = append(n.rpcFails[method], status)
+	}
+}
+
+// Calls 返回 method 被调用的次数
+func (n *Node) Calls(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.calls[method]
+}
+
+// Height 返回当前链高度
+func (n *Node) Height() abi.ChainEpoch {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.height
+}
+
+// Pending 返回内存池中的消息
+func (n *Node) Pending() []*types.SignedMessage {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]*types.SignedMessage(nil), n.mpool...)
+}
+
+// Receipt 返回已上链消息的查找结果，消息尚未上链时返回 nil
+func (n *Node) Receipt(msgCid cid.Cid) *types.MsgLookup {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if inc, ok := n.receipts[msgCid]; ok {
+		lookup := *inc.lookup
+		return &lookup
+	}
+	return nil
+}
+
+// Mine 出一个 tipset，按 nonce 顺序打包内存池中可以执行的消息，返回新的链高度
+func (n *Node) Mine() abi.ChainEpoch {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.mine()
+	return n.height
+}
+
+// Advance 连续出 count 个 tipset，第一个 tipset 打包内存池中的消息
+func (n *Node) Advance(count int) abi.ChainEpoch {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i := 0; i < count; i++ {
+		n.mine()
+	}
+	return n.height
+}
+
+// mine 出一个 tipset，调用方持有锁
+// nonce 不连续的消息留在内存池中，等待缺失的消息
+func (n *Node) mine() {
+	n.height++
+	sort.SliceStable(n.mpool, func(i, j int) bool {
+		a, b := n.mpool[i].Message, n.mpool[j].Message
+		if a.From != b.From {
+			return a.From.String() < b.From.String()
+		}
+		return a.Nonce < b.Nonce
+	})
+
+	ts := n.tipset(n.height)
+	var rest []*types.SignedMessage
+	for _, sm := range n.mpool {
+		msg := &sm.Message
+		from := n.st.get(msg.From)
+		if from == nil || msg.Nonce != from.Nonce {
+			rest = append(rest, sm)
+			continue
+		}
+		var fail exitcode.ExitCode
+		if queue := n.failNext[from.ID]; len(queue) > 0 {
+			fail, n.failNext[from.ID] = queue[0], queue[1:]
+		}
+		v := &vm{st: n.st, height: n.height, baseFee: n.baseFee}
+		rct, cost := v.apply(msg, fail)
+		n.st = v.st
+		msgCid := sm.Cid()
+		cost.Message = msgCid
+		n.receipts[msgCid] = &included{
+			msg:    msg,
+			lookup: &types.MsgLookup{Message: msgCid, Receipt: rct, TipSet: ts.Cids(), Height: n.height},
+			cost:   cost,
+		}
+	}
+	n.mpool = rest
+	n.notify([]*types.HeadChange{{Type: types.HCApply, Val: ts}})
+	n.cond.Broadcast()
+}
+
+// tipset 返回指定高度的 tipset，CID 由高度确定
+func (n *Node) tipset(height abi.ChainEpoch) *types.TipSet {
+	c, _ := abi.CidBuilder.Sum([]byte(fmt.Sprintf("lotusmock/tipset/%d", height)))
+	return &types.TipSet{CidsField: []cid.Cid{c}, HeightField: height}
+}
+
+// subscribe 订阅链头变化，第一次推送当前链头
+func (n *Node) subscribe() (int, <-chan []*types.HeadChange) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ch := make(chan []*types.HeadChange, 64)
+	ch <- []*types.HeadChange{{Type: types.HCCurrent, Val: n.tipset(n.height)}}
+	id := n.nextSubID
+	n.nextSubID++
+	n.subs[id] = ch
+	return id, ch
+}
+
+// unsubscribe 取消订阅并关闭通道
+func (n *Node) unsubscribe(id int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if ch, ok := n.subs[id]; ok {
+		delete(n.subs, id)
+		close(ch)
+	}
+}
+
+// notify 向订阅者推送链头变化，调用方持有锁；订阅者处理过慢时丢弃
+func (n *Node) notify(changes []*types.HeadChange) {
+	for _, ch := range n.subs {
+		select {
+		case ch <- changes:
+		default:
+		}
+	}
+}
+
+// update 在锁内修改 actor，地址不存在时 panic
+func (n *Node) update(addr address.Address, fn func(a *actor)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	fn(n.mustGet(addr))
+}
+
+func (n *Node) mustGet(addr address.Address) *actor {
+	a := n.st.get(addr)
+	if a == nil {
+		panic(fmt.Sprintf("lotusmock: actor %s not found", addr))
+	}
+	return a
+}
+
+func (n *Node) mustLookupID(addr address.Address) address.Address {
+	return n.mustGet(addr).ID
+}
+
+// msigPending 返回按 ID 排序的待处理交易副本，调用方持有锁
+func (n *Node) msigPending(a *actor) []*types.MsigTransaction {
+	txns := make([]*types.MsigTransaction, 0, len(a.Msig.Pending))
+	for _, txn := range a.Msig.Pending {
+		t := *txn
+		t.Approved = append([]address.Address(nil), txn.Approved...)
+		txns = append(txns, &t)
+	}
+	sort.Slice(txns, func(i, j int) bool { return txns[i].ID < txns[j].ID })
+	return txns
+}
@@ -0,0 +1,251 @@
+package lotusmock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// JSON-RPC 错误码
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeServerError    = 1 // Lotus 对方法返回的错误统一使用 1
+)
+
+type request struct {
+	Jsonrpc string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id,omitempty"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type response struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError 方法返回的 JSON-RPC 错误
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// errorf 返回 Lotus 风格的方法错误
+func errorf(format string, args ...interface{}) error {
+	return &rpcError{Code: codeServerError, Message: fmt.Sprintf(format, args...)}
+}
+
+// isV0 检查请求路径是否为 v0 API（/rpc/v0），其他路径按 v1 API 处理
+func isV0(path string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(path, "/"), "/rpc/v0")
+}
+
+// call 执行一次方法调用并记录调用次数，v0 为 true 时按 v0 API 的签名检查参数
+func (n *Node) call(ctx context.Context, v0 bool, name string, params []json.RawMessage) (interface{}, error) {
+	n.mu.Lock()
+	n.calls[name]++
+	n.mu.Unlock()
+
+	m, ok := methods[name]
+	if !ok {
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method 'Filecoin.%s' not found", name)}
+	}
+	want := m.params
+	old, changed := v0Methods[name]
+	if v0 && changed {
+		want = old.params
+	}
+	if len(params) != want {
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("wrong param count (method 'Filecoin.%s'): %d != %d", name, len(params), want)}
+	}
+	if v0 && changed {
+		params = old.toV1(params)
+	}
+	return m.h(n, ctx, params)
+}
+
+// reply 构造方法调用的响应
+func reply(id json.RawMessage, res interface{}, err error) *response {
+	resp := &response{Jsonrpc: "2.0", ID: id}
+	if err != nil {
+		re, ok := err.(*rpcError)
+		if !ok {
+			re = &rpcError{Code: codeServerError, Message: err.Error()}
+		}
+		resp.Error = re
+		return resp
+	}
+	data, merr := json.Marshal(res)
+	if merr != nil {
+		resp.Error = &rpcError{Code: codeServerError, Message: merr.Error()}
+		return resp
+	}
+	resp.Result = data
+	return resp
+}
+
+// injectedFailure 返回脚本为 method 指定的下一个 HTTP 错误状态码，没有时返回 0
+func (n *Node) injectedFailure(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	queue := n.rpcFails[method]
+	if len(queue) == 0 {
+		return 0
+	}
+	n.rpcFails[method] = queue[1:]
+	return queue[0]
+}
+
+func (n *Node) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		n.serveWebSocket(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req request
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		_ = json.NewEncoder(w).Encode(&response{Jsonrpc: "2.0", Error: &rpcError{Code: codeParseError, Message: err.Error()}})
+		return
+	}
+	method := strings.TrimPrefix(req.Method, "Filecoin.")
+	if status := n.injectedFailure(method); status != 0 {
+		http.Error(w, fmt.Sprintf("injected failure of %s", method), status)
+		return
+	}
+	res, err := n.call(r.Context(), isV0(r.URL.Path), method, req.Params)
+	_ = json.NewEncoder(w).Encode(reply(req.ID, res, err))
+}
+
+// Lotus（go-jsonrpc）WebSocket 协议中用于通道的方法
+const (
+	wsChanValue = "xrpc.ch.val"
+	wsChanClose = "xrpc.ch.close"
+	wsCancel    = "xrpc.cancel"
+)
+
+var upgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+// wsConn 一个 WebSocket 客户端连接
+// 每个请求在单独的 goroutine 中处理，xrpc.cancel 取消请求或订阅
+type wsConn struct {
+	node    *Node
+	conn    *websocket.Conn
+	v0      bool // 连接的是 v0 API
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	cancels  map[string]context.CancelFunc // 请求 ID -> 取消函数
+	nextChan int
+}
+
+func (n *Node) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	wc := &wsConn{node: n, conn: conn, v0: isV0(r.URL.Path), cancels: map[string]context.CancelFunc{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer conn.Close()
+	go func() {
+		select {
+		case <-n.shutdownCh:
+			conn.Close()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		var req request
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		if req.Method == wsCancel {
+			if len(req.Params) > 0 {
+				wc.cancel(string(req.Params[0]))
+			}
+			continue
+		}
+		reqCtx, reqCancel := context.WithCancel(ctx)
+		wc.mu.Lock()
+		wc.cancels[string(req.ID)] = reqCancel
+		wc.mu.Unlock()
+		go func() {
+			defer wc.cancel(string(req.ID))
+			wc.handle(reqCtx, &req)
+		}()
+	}
+}
+
+func (wc *wsConn) cancel(id string) {
+	wc.mu.Lock()
+	cancel, ok := wc.cancels[id]
+	delete(wc.cancels, id)
+	wc.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func (wc *wsConn) write(v interface{}) {
+	wc.writeMu.Lock()
+	defer wc.writeMu.Unlock()
+	_ = wc.conn.WriteJSON(v)
+}
+
+// notifyChan 向客户端发送通道消息
+func (wc *wsConn) notifyChan(method string, params ...interface{}) {
+	wc.write(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (wc *wsConn) handle(ctx context.Context, req *request) {
+	method := strings.TrimPrefix(req.Method, "Filecoin.")
+	if method != "ChainNotify" {
+		res, err := wc.node.call(ctx, wc.v0, method, req.Params)
+		wc.write(reply(req.ID, res, err))
+		return
+	}
+
+	wc.node.mu.Lock()
+	wc.node.calls[method]++
+	wc.node.mu.Unlock()
+	wc.mu.Lock()
+	wc.nextChan++
+	chanID := wc.nextChan
+	wc.mu.Unlock()
+
+	subID, heads := wc.node.subscribe()
+	defer wc.node.unsubscribe(subID)
+	wc.write(reply(req.ID, chanID, nil))
+	for {
+		select {
+		case <-ctx.Done():
+			wc.notifyChan(wsChanClose, chanID)
+			return
+		case changes, ok := <-heads:
+			if !ok {
+				wc.notifyChan(wsChanClose, chanID)
+				return
+			}
+			wc.notifyChan(wsChanValue, chanID, changes)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package lotusmock
+
+import (
+	"encoding/binary"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+
+	"wallet-sign/internal/chain/types"
+)
+
+// firstActorID 脚本创建的 actor 从该 ID 开始分配，之前的 ID 保留给内置 actor
+const firstActorID = 1000
+
+// actor 链上的一个 actor
+// Robust 为账户的公钥地址或多签钱包的 f2 地址，矿工没有 Robust 地址
+type actor struct {
+	ID      address.Address
+	Robust  address.Address
+	Code    string // 内置 actor 名称，与 StateActorCodeCIDs 的键一致
+	Balance abi.TokenAmount
+	Nonce   uint64
+	Msig    *msigState
+	Miner   *minerState
+}
+
+// msigState 多签钱包状态，签名人均为 ID 地址
+type msigState struct {
+	Signers        []address.Address
+	Threshold      uint64
+	NextTxnID      int64
+	InitialBalance abi.TokenAmount
+	StartEpoch     abi.ChainEpoch
+	UnlockDuration abi.ChainEpoch
+	Pending        map[int64]*types.MsigTransaction
+}
+
+// minerState 矿工状态，Available 为可提现余额
+type minerState struct {
+	Info      types.MinerInfo
+	Available abi.TokenAmount
+}
+
+// state 链状态，执行消息前复制一份，消息失败时丢弃
+type state struct {
+	nextID uint64
+	actors map[address.Address]*actor          // ID 地址 -> actor
+	ids    map[address.Address]address.Address // Robust 地址 -> ID 地址
+	market map[address.Address]*types.MarketBalance
+}
+
+func newState() *state {
+	s := &state{
+		nextID: firstActorID,
+		actors: map[address.Address]*actor{},
+		ids:    map[address.Address]address.Address{},
+		market: map[address.Address]*types.MarketBalance{},
+	}
+	for name, addr := range map[string]address.Address{
+		"system":           builtintypes.SystemActorAddr,
+		"init":             builtintypes.InitActorAddr,
+		"reward":           builtintypes.RewardActorAddr,
+		"cron":             builtintypes.CronActorAddr,
+		"storagepower":     builtintypes.StoragePowerActorAddr,
+		"storagemarket":    builtintypes.StorageMarketActorAddr,
+		"verifiedregistry": builtintypes.VerifiedRegistryActorAddr,
+		"datacap":          builtintypes.DatacapActorAddr,
+		"eam":              builtintypes.EthereumAddressManagerActorAddr,
+		"account":          builtintypes.BurntFundsActorAddr,
+	} {
+		s.actors[addr] = &actor{ID: addr, Code: name, Balance: big.Zero()}
+	}
+	return s
+}
+
+// clone 深拷贝链状态
+func (s *state) clone() *state {
+	c := &state{
+		nextID: s.nextID,
+		actors: make(map[address.Address]*actor, len(s.actors)),
+		ids:    make(map[address.Address]address.Address, len(s.ids)),
+		market: make(map[address.Address]*types.MarketBalance, len(s.market)),
+	}
+	for k, v := range s.ids {
+		c.ids[k] = v
+	}
+	for k, v := range s.market {
+		mb := *v
+		c.market[k] = &mb
+	}
+	for k, v := range s.actors {
+		a := *v
+		if v.Msig != nil {
+			ms := *v.Msig
+			ms.Signers = append([]address.Address(nil), v.Msig.Signers...)
+			ms.Pending = make(map[int64]*types.MsigTransaction, len(v.Msig.Pending))
+			for id, txn := range v.Msig.Pending {
+				t := *txn
+				t.Approved = append([]address.Address(nil), txn.Approved...)
+				ms.Pending[id] = &t
+			}
+			a.Msig = &ms
+		}
+		if v.Miner != nil {
+			ms := *v.Miner
+			ms.Info = copyMinerInfo(v.Miner.Info)
+			a.Miner = &ms
+		}
+		c.actors[k] = &a
+	}
+	return c
+}
+
+// copyMinerInfo 深拷贝矿工信息
+func copyMinerInfo(info types.MinerInfo) types.MinerInfo {
+	info.ControlAddresses = append([]address.Address(nil), info.ControlAddresses...)
+	if info.PendingOwnerAddress != nil {
+		a := *info.PendingOwnerAddress
+		info.PendingOwnerAddress = &a
+	}
+	if info.BeneficiaryTerm != nil {
+		t := *info.BeneficiaryTerm
+		info.BeneficiaryTerm = &t
+	}
+	if info.PendingBeneficiaryTerm != nil {
+		t := *info.PendingBeneficiaryTerm
+		info.PendingBeneficiaryTerm = &t
+	}
+	return info
+}
+
+// lookupID 将地址解析为 ID 地址
+func (s *state) lookupID(addr address.Address) (address.Address, bool) {
+	if addr.Protocol() == address.ID {
+		_, ok := s.actors[addr]
+		return addr, ok
+	}
+	id, ok := s.ids[addr]
+	return id, ok
+}
+
+// get 返回地址对应的 actor，不存在时返回 nil
+func (s *state) get(addr address.Address) *actor {
+	id, ok := s.lookupID(addr)
+	if !ok {
+		return nil
+	}
+	return s.actors[id]
+}
+
+// create 分配新的 ID 地址并创建 actor
+func (s *state) create(code string, robust address.Address) *actor {
+	id, _ := address.NewIDAddress(s.nextID)
+	s.nextID++
+	a := &actor{ID: id, Robust: robust, Code: code, Balance: big.Zero()}
+	s.actors[id] = a
+	if robust != address.Undef {
+		s.ids[robust] = id
+	}
+	return a
+}
+
+// getOrCreate 返回地址对应的 actor，公钥地址和委托地址第一次收到消息时创建账户
+func (s *state) getOrCreate(addr address.Address) *actor {
+	if a := s.get(addr); a != nil {
+		return a
+	}
+	switch addr.Protocol() {
+	case address.SECP256K1, address.BLS:
+		return s.create("account", addr)
+	case address.Delegated:
+		return s.create("ethaccount", addr)
+	default:
+		return nil
+	}
+}
+
+// newRobustAddress 为新建的 actor 生成 f2 地址
+func newRobustAddress(creator address.Address, seq uint64) address.Address {
+	buf := append([]byte{}, creator.Bytes()...)
+	buf = binary.BigEndian.AppendUint64(buf, seq)
+	addr, _ := address.NewActorAddress(buf)
+	return addr
+}
+
+// keyAddress 返回账户的公钥地址，不是账户时返回 false
+func (a *actor) keyAddress() (address.Address, bool) {
+	if a.Code != "account" && a.Code != "ethaccount" || a.Robust == address.Undef {
+		return address.Undef, false
+	}
+	return a.Robust, true
+}
+
+// lockedBalance 返回多签钱包在 height 时仍锁定的余额
+func (ms *msigState) lockedBalance(height abi.ChainEpoch) abi.TokenAmount {
+	if ms.UnlockDuration <= 0 {
+		return big.Zero()
+	}
+	elapsed := height - ms.StartEpoch
+	if elapsed >= ms.UnlockDuration {
+		return big.Zero()
+	}
+	if elapsed < 0 {
+		return ms.InitialBalance
+	}
+	remaining := big.Mul(ms.InitialBalance, big.NewInt(int64(ms.UnlockDuration-elapsed)))
+	return big.Div(remaining, big.NewInt(int64(ms.UnlockDuration)))
+}
+
+func (ms *msigState) isSigner(id address.Address) bool {
+	for _, s := range ms.Signers {
+		if s == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,536 @@
+package lotusmock
+
+import (
+	"bytes"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	inittypes "github.com/filecoin-project/go-state-types/builtin/v9/init"
+	markettypes "github.com/filecoin-project/go-state-types/builtin/v9/market"
+	minertypes "github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	multisigtypes "github.com/filecoin-project/go-state-types/builtin/v9/multisig"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/crypto/blake2b"
+
+	"wallet-sign/internal/chain/types"
+)
+
+// GasPerMessage 每条消息消耗的 Gas，估算和执行使用同一个值
+const GasPerMessage = 1_000_000
+
+// vm 在链状态上执行消息
+// 只实现了钱包会发送的方法：转账、Init Exec（创建多签）、多签、矿工和市场提现相关方法，
+// 其他方法只转移金额并成功返回
+type vm struct {
+	st      *state
+	height  abi.ChainEpoch
+	baseFee abi.TokenAmount
+}
+
+// gasCost 计算消息的 Gas 费用，小费按 GasPremium 全额支付
+func gasCost(msg *types.Message, baseFee abi.TokenAmount) *types.MessageGasCost {
+	gasUsed := min(msg.GasLimit, GasPerMessage)
+	burn := big.Mul(baseFee, big.NewInt(gasUsed))
+	tip := big.Mul(msg.GasPremium, big.NewInt(msg.GasLimit))
+	total := big.Add(burn, tip)
+	refund := big.Sub(big.Mul(msg.GasFeeCap, big.NewInt(msg.GasLimit)), total)
+	if refund.Sign() < 0 {
+		refund = big.Zero()
+	}
+	return &types.MessageGasCost{
+		Message:            msg.Cid(),
+		GasUsed:            big.NewInt(gasUsed),
+		BaseFeeBurn:        burn,
+		OverEstimationBurn: big.Zero(),
+		MinerPenalty:       big.Zero(),
+		MinerTip:           tip,
+		Refund:             refund,
+		TotalCost:          total,
+	}
+}
+
+// apply 执行消息：扣除 Gas 费用、增加发送方 nonce 并调用接收方方法
+// fail 不为 0 时跳过调用，以该退出码失败
+func (v *vm) apply(msg *types.Message, fail exitcode.ExitCode) (types.Receipt, *types.MessageGasCost) {
+	cost := gasCost(msg, v.baseFee)
+	from := v.st.get(msg.From)
+	switch {
+	case from == nil:
+		return types.Receipt{ExitCode: int64(exitcode.SysErrSenderInvalid)}, cost
+	case msg.Nonce != from.Nonce, from.Balance.LessThan(cost.TotalCost):
+		return types.Receipt{ExitCode: int64(exitcode.SysErrSenderStateInvalid)}, cost
+	}
+	from.Balance = big.Sub(from.Balance, cost.TotalCost)
+	from.Nonce++
+
+	rct := types.Receipt{GasUsed: cost.GasUsed.Int64()}
+	switch {
+	case msg.GasLimit < GasPerMessage:
+		rct.ExitCode = int64(exitcode.SysErrOutOfGas)
+	case fail != exitcode.Ok:
+		rct.ExitCode = int64(fail)
+	default:
+		code, ret := v.send(from.ID, msg.To, msg.Value, msg.Method, msg.Params)
+		rct.ExitCode = int64(code)
+		rct.Return = ret
+	}
+	return rct, cost
+}
+
+// send 以 caller 的身份调用 to 的方法，调用失败时撤销其产生的所有状态变化
+func (v *vm) send(caller, to address.Address, value abi.TokenAmount, method abi.MethodNum, params []byte) (exitcode.ExitCode, []byte) {
+	saved := v.st
+	v.st = saved.clone()
+	code, ret := v.invoke(caller, to, value, method, params)
+	if code != exitcode.Ok {
+		v.st = saved
+		return code, nil
+	}
+	return code, ret
+}
+
+func (v *vm) invoke(caller, to address.Address, value abi.TokenAmount, method abi.MethodNum, params []byte) (exitcode.ExitCode, []byte) {
+	from := v.st.get(caller)
+	recv := v.st.getOrCreate(to)
+	if recv == nil {
+		return exitcode.SysErrInvalidReceiver, nil
+	}
+	if value.Sign() < 0 {
+		return exitcode.ErrIllegalArgument, nil
+	}
+	if from.Balance.LessThan(value) {
+		return exitcode.SysErrInsufficientFunds, nil
+	}
+	from.Balance = big.Sub(from.Balance, value)
+	recv.Balance = big.Add(recv.Balance, value)
+
+	if method == builtintypes.MethodSend {
+		return exitcode.Ok, nil
+	}
+	switch recv.Code {
+	case "init":
+		return v.initActor(from, recv, value, method, params)
+	case "multisig":
+		return v.multisig(from, recv, method, params)
+	case "storageminer":
+		return v.miner(from, recv, method, params)
+	case "storagemarket":
+		return v.market(from, recv, method, params)
+	}
+	return exitcode.Ok, nil
+}
+
+// initActor 实现 Init actor 的 Exec 方法，只支持创建多签钱包
+func (v *vm) initActor(caller, self *actor, value abi.TokenAmount, method abi.MethodNum, params []byte) (exitcode.ExitCode, []byte) {
+	if method != builtintypes.MethodsInit.Exec {
+		return exitcode.SysErrInvalidMethod, nil
+	}
+	var p inittypes.ExecParams
+	if !decode(params, &p) {
+		return exitcode.ErrSerialization, nil
+	}
+	if codeName(p.CodeCID) != "multisig" {
+		return exitcode.ErrForbidden, nil
+	}
+	var ctor multisigtypes.ConstructorParams
+	if !decode(p.ConstructorParams, &ctor) {
+		return exitcode.ErrSerialization, nil
+	}
+	if len(ctor.Signers) == 0 || ctor.NumApprovalsThreshold == 0 || ctor.NumApprovalsThreshold > uint64(len(ctor.Signers)) {
+		return exitcode.ErrIllegalArgument, nil
+	}
+
+	ms := &msigState{
+		Threshold:      ctor.NumApprovalsThreshold,
+		InitialBalance: big.Zero(),
+		StartEpoch:     ctor.StartEpoch,
+		UnlockDuration: ctor.UnlockDuration,
+		Pending:        map[int64]*types.MsigTransaction{},
+	}
+	for _, s := range ctor.Signers {
+		a := v.st.getOrCreate(s)
+		if a == nil || ms.isSigner(a.ID) {
+			return exitcode.ErrIllegalArgument, nil
+		}
+		ms.Signers = append(ms.Signers, a.ID)
+	}
+	if ctor.UnlockDuration > 0 {
+		ms.InitialBalance = value
+	}
+
+	robust := newRobustAddress(caller.ID, caller.Nonce)
+	created := v.st.create("multisig", robust)
+	created.Msig = ms
+	self.Balance = big.Sub(self.Balance, value)
+	created.Balance = value
+	return exitcode.Ok, encode(&inittypes.ExecReturn{IDAddress: created.ID, RobustAddress: robust})
+}
+
+// multisig 实现多签钱包的提案、审批、取消及成员管理方法
+func (v *vm) multisig(caller, self *actor, method abi.MethodNum, params []byte) (exitcode.ExitCode, []byte) {
+	ms := self.Msig
+	switch method {
+	case builtintypes.MethodsMultisig.Propose:
+		if !ms.isSigner(caller.ID) {
+			return exitcode.ErrForbidden, nil
+		}
+		var p multisigtypes.ProposeParams
+		if !decode(params, &p) {
+			return exitcode.ErrSerialization, nil
+		}
+		txn := &types.MsigTransaction{
+			ID:       ms.NextTxnID,
+			To:       p.To,
+			Value:    p.Value,
+			Method:   p.Method,
+			Params:   p.Params,
+			Approved: []address.Address{caller.ID},
+		}
+		ms.NextTxnID++
+		ms.Pending[txn.ID] = txn
+		applied, code, ret := v.approved(self, txn)
+		return exitcode.Ok, encode(&multisigtypes.ProposeReturn{TxnID: multisigtypes.TxnID(txn.ID), Applied: applied, Code: code, Ret: ret})
+
+	case builtintypes.MethodsMultisig.Approve, builtintypes.MethodsMultisig.Cancel:
+		if !ms.isSigner(caller.ID) {
+			return exitcode.ErrForbidden, nil
+		}
+		var p multisigtypes.TxnIDParams
+		if !decode(params, &p) {
+			return exitcode.ErrSerialization, nil
+		}
+		txn, ok := ms.Pending[int64(p.ID)]
+		if !ok {
+			return exitcode.ErrNotFound, nil
+		}
+		if len(p.ProposalHash) > 0 && !bytes.Equal(p.ProposalHash, proposalHash(txn)) {
+			return exitcode.ErrIllegalArgument, nil
+		}
+		if method == builtintypes.MethodsMultisig.Cancel {
+			if txn.Approved[0] != caller.ID {
+				return exitcode.ErrForbidden, nil
+			}
+			delete(ms.Pending, txn.ID)
+			return exitcode.Ok, nil
+		}
+		for _, a := range txn.Approved {
+			if a == caller.ID {
+				return exitcode.ErrForbidden, nil
+			}
+		}
+		txn.Approved = append(txn.Approved, caller.ID)
+		applied, code, ret := v.approved(self, txn)
+		return exitcode.Ok, encode(&multisigtypes.ApproveReturn{Applied: applied, Code: code, Ret: ret})
+	}
+
+	// 成员管理只接受多签钱包自身（通过提案）发出的调用
+	if caller.ID != self.ID {
+		return exitcode.ErrForbidden, nil
+	}
+	switch method {
+	case builtintypes.MethodsMultisig.AddSigner:
+		var p multisigtypes.AddSignerParams
+		if !decode(params, &p) {
+			return exitcode.ErrSerialization, nil
+		}
+		a := v.st.getOrCreate(p.Signer)
+		if a == nil || ms.isSigner(a.ID) {
+			return exitcode.ErrForbidden, nil
+		}
+		ms.Signers = append(ms.Signers, a.ID)
+		if p.Increase {
+			ms.Threshold++
+		}
+	case builtintypes.MethodsMultisig.RemoveSigner:
+		var p multisigtypes.RemoveSignerParams
+		if !decode(params, &p) {
+			return exitcode.ErrSerialization, nil
+		}
+		id, ok := v.st.lookupID(p.Signer)
+		if !ok || !ms.isSigner(id) || len(ms.Signers) == 1 {
+			return exitcode.ErrForbidden, nil
+		}
+		if p.Decrease {
+			if ms.Threshold < 2 {
+				return exitcode.ErrIllegalArgument, nil
+			}
+			ms.Threshold--
+		}
+		if ms.Threshold > uint64(len(ms.Signers)-1) {
+			return exitcode.ErrIllegalArgument, nil
+		}
+		ms.Signers = removeAddress(ms.Signers, id)
+	case builtintypes.MethodsMultisig.SwapSigner:
+		var p multisigtypes.SwapSignerParams
+		if !decode(params, &p) {
+			return exitcode.ErrSerialization, nil
+		}
+		fromID, ok := v.st.lookupID(p.From)
+		if !ok || !ms.isSigner(fromID) {
+			return exitcode.ErrForbidden, nil
+		}
+		a := v.st.getOrCreate(p.To)
+		if a == nil || ms.isSigner(a.ID) {
+			return exitcode.ErrIllegalArgument, nil
+		}
+		for i, s := range ms.Signers {
+			if s == fromID {
+				ms.Signers[i] = a.ID
+			}
+		}
+	case builtintypes.MethodsMultisig.ChangeNumApprovalsThreshold:
+		var p multisigtypes.ChangeNumApprovalsThresholdParams
+		if !decode(params, &p) {
+			return exitcode.ErrSerialization, nil
+		}
+		if p.NewThreshold == 0 || p.NewThreshold > uint64(len(ms.Signers)) {
+			return exitcode.ErrIllegalArgument, nil
+		}
+		ms.Threshold = p.NewThreshold
+	default:
+		return exitcode.SysErrInvalidMethod, nil
+	}
+	return exitcode.Ok, nil
+}
+
+// approved 审批数达到阈值时执行交易，锁仓中的余额不能转出
+func (v *vm) approved(self *actor, txn *types.MsigTransaction) (bool, exitcode.ExitCode, []byte) {
+	ms := self.Msig
+	if uint64(len(txn.Approved)) < ms.Threshold {
+		return false, exitcode.Ok, nil
+	}
+	delete(ms.Pending, txn.ID)
+	available := big.Sub(self.Balance, ms.lockedBalance(v.height))
+	if available.LessThan(txn.Value) {
+		return true, exitcode.ErrInsufficientFunds, nil
+	}
+	code, ret := v.send(self.ID, txn.To, txn.Value, txn.Method, txn.Params)
+	return true, code, ret
+}
+
+// miner 实现矿工提现及 owner、worker、control、受益人变更方法
+func (v *vm) miner(caller, self *actor, method abi.MethodNum, params []byte) (exitcode.ExitCode, []byte) {
+	ms := self.Miner
+	info := &ms.Info
+	switch method {
+	case builtintypes.MethodsMiner.WithdrawBalance:
+		if caller.ID != info.Owner && caller.ID != info.Beneficiary {
+			return exitcode.ErrForbidden, nil
+		}
+		var p minertypes.WithdrawBalanceParams
+		if !decode(params, &p) {
+			return exitcode.ErrSerialization, nil
+		}
+		if p.AmountRequested.Sign() < 0 {
+			return exitcode.ErrIllegalArgument, nil
+		}
+		amount := big.Min(p.AmountRequested, ms.Available)
+		if term := info.BeneficiaryTerm; info.Beneficiary != info.Owner && term != nil {
+			remaining := big.Sub(term.Quota, term.UsedQuota)
+			if v.height >= term.Expiration {
+				remaining = big.Zero()
+			}
+			amount = big.Min(amount, remaining)
+			term.UsedQuota = big.Add(term.UsedQuota, amount)
+		}
+		ms.Available = big.Sub(ms.Available, amount)
+		self.Balance = big.Sub(self.Balance, amount)
+		ben := v.st.get(info.Beneficiary)
+		ben.Balance = big.Add(ben.Balance, amount)
+		return exitcode.Ok, encode(&amount)
+
+	case builtintypes.MethodsMiner.ChangeOwnerAddress:
+		var newAddr address.Address
+		if !decode(params, &newAddr) {
+			return exitcode.ErrSerialization, nil
+		}
+		newID, ok := v.st.lookupID(newAddr)
+		if !ok {
+			return exitcode.ErrIllegalArgument, nil
+		}
+		switch {
+		case caller.ID == info.Owner:
+			if newID == info.Owner {
+				info.PendingOwnerAddress = nil
+			} else {
+				info.PendingOwnerAddress = &newID
+			}
+		case info.PendingOwnerAddress != nil && caller.ID == *info.PendingOwnerAddress:
+			if newID != caller.ID {
+				return exitcode.ErrIllegalArgument, nil
+			}
+			if info.Beneficiary == info.Owner {
+				info.Beneficiary = newID
+			}
+			info.Owner = newID
+			info.PendingOwnerAddress = nil
+		default:
+			return exitcode.ErrForbidden, nil
+		}
+
+	case builtintypes.MethodsMiner.ChangeWorkerAddress:
+		if caller.ID != info.Owner {
+			return exitcode.ErrForbidden, nil
+		}
+		var p minertypes.ChangeWorkerAddressParams
+		if !decode(params, &p) {
+			return exitcode.ErrSerialization, nil
+		}
+		worker, ok := v.st.lookupID(p.NewWorker)
+		if !ok {
+			return exitcode.ErrIllegalArgument, nil
+		}
+		controls := make([]address.Address, 0, len(p.NewControlAddrs))
+		for _, a := range p.NewControlAddrs {
+			id, ok := v.st.lookupID(a)
+			if !ok {
+				return exitcode.ErrIllegalArgument, nil
+			}
+			controls = append(controls, id)
+		}
+		info.ControlAddresses = controls
+		if worker != info.Worker {
+			info.NewWorker = worker
+			info.WorkerChangeEpoch = v.height + minertypes.WorkerKeyChangeDelay
+		} else if !info.NewWorker.Empty() {
+			info.NewWorker = address.Undef
+			info.WorkerChangeEpoch = -1
+		}
+
+	case builtintypes.MethodsMiner.ConfirmChangeWorkerAddress:
+		if caller.ID != info.Owner {
+			return exitcode.ErrForbidden, nil
+		}
+		if info.NewWorker.Empty() || v.height < info.WorkerChangeEpoch {
+			return exitcode.ErrIllegalState, nil
+		}
+		info.Worker = info.NewWorker
+		info.NewWorker = address.Undef
+		info.WorkerChangeEpoch = -1
+
+	case builtintypes.MethodsMiner.ChangeBeneficiary:
+		var p minertypes.ChangeBeneficiaryParams
+		if !decode(params, &p) {
+			return exitcode.ErrSerialization, nil
+		}
+		newID, ok := v.st.lookupID(p.NewBeneficiary)
+		if !ok {
+			return exitcode.ErrIllegalArgument, nil
+		}
+		if caller.ID == info.Owner {
+			if newID == info.Owner && (!p.NewQuota.IsZero() || p.NewExpiration != 0) {
+				return exitcode.ErrIllegalArgument, nil
+			}
+			info.PendingBeneficiaryTerm = &types.PendingBeneficiaryChange{
+				NewBeneficiary:        newID,
+				NewQuota:              p.NewQuota,
+				NewExpiration:         p.NewExpiration,
+				ApprovedByBeneficiary: info.Beneficiary == info.Owner,
+				ApprovedByNominee:     newID == info.Owner,
+			}
+		} else {
+			pending := info.PendingBeneficiaryTerm
+			if pending == nil {
+				return exitcode.ErrForbidden, nil
+			}
+			if pending.NewBeneficiary != newID || !pending.NewQuota.Equals(p.NewQuota) || pending.NewExpiration != p.NewExpiration {
+				return exitcode.ErrIllegalArgument, nil
+			}
+			switch caller.ID {
+			case pending.NewBeneficiary, info.Beneficiary:
+				pending.ApprovedByNominee = pending.ApprovedByNominee || caller.ID == pending.NewBeneficiary
+				pending.ApprovedByBeneficiary = pending.ApprovedByBeneficiary || caller.ID == info.Beneficiary
+			default:
+				return exitcode.ErrForbidden, nil
+			}
+		}
+		if pending := info.PendingBeneficiaryTerm; pending.ApprovedByBeneficiary && pending.ApprovedByNominee {
+			info.Beneficiary = pending.NewBeneficiary
+			info.BeneficiaryTerm = &types.BeneficiaryTerm{
+				Quota:      pending.NewQuota,
+				UsedQuota:  big.Zero(),
+				Expiration: pending.NewExpiration,
+			}
+			info.PendingBeneficiaryTerm = nil
+		}
+	}
+	return exitcode.Ok, nil
+}
+
+// market 实现市场托管余额提现，矿工的余额提现到 owner
+func (v *vm) market(caller, self *actor, method abi.MethodNum, params []byte) (exitcode.ExitCode, []byte) {
+	if method != builtintypes.MethodsMarket.WithdrawBalance {
+		return exitcode.Ok, nil
+	}
+	var p markettypes.WithdrawBalanceParams
+	if !decode(params, &p) {
+		return exitcode.ErrSerialization, nil
+	}
+	if p.Amount.Sign() < 0 {
+		return exitcode.ErrIllegalArgument, nil
+	}
+	target := v.st.get(p.ProviderOrClientAddress)
+	if target == nil {
+		return exitcode.ErrNotFound, nil
+	}
+	recipient := target
+	if m := target.Miner; m != nil {
+		if caller.ID != m.Info.Owner && caller.ID != m.Info.Worker {
+			return exitcode.ErrForbidden, nil
+		}
+		recipient = v.st.get(m.Info.Owner)
+	} else if caller.ID != target.ID {
+		return exitcode.ErrForbidden, nil
+	}
+
+	amount := big.Zero()
+	if bal, ok := v.st.market[target.ID]; ok {
+		amount = big.Min(p.Amount, big.Sub(bal.Escrow, bal.Locked))
+		bal.Escrow = big.Sub(bal.Escrow, amount)
+	}
+	self.Balance = big.Sub(self.Balance, amount)
+	recipient.Balance = big.Add(recipient.Balance, amount)
+	return exitcode.Ok, encode(&amount)
+}
+
+// proposalHash 计算多签交易的提案哈希
+func proposalHash(txn *types.MsigTransaction) []byte {
+	data := &multisigtypes.ProposalHashData{
+		Requester: txn.Approved[0],
+		To:        txn.To,
+		Value:     txn.Value,
+		Method:    txn.Method,
+		Params:    txn.Params,
+	}
+	buf, err := data.Serialize()
+	if err != nil {
+		return nil
+	}
+	hash := blake2b.Sum256(buf)
+	return hash[:]
+}
+
+func removeAddress(addrs []address.Address, a address.Address) []address.Address {
+	out := make([]address.Address, 0, len(addrs))
+	for _, x := range addrs {
+		if x != a {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+func decode(params []byte, v cbg.CBORUnmarshaler) bool {
+	return v.UnmarshalCBOR(bytes.NewReader(params)) == nil
+}
+
+func encode(v cbg.CBORMarshaler) []byte {
+	var buf bytes.Buffer
+	if err := v.MarshalCBOR(&buf); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+
+	"wallet-sign/internal/chain/types"
+)
+
+// BalanceSource 查询余额的节点接口
+type BalanceSource interface {
+	WalletBalance(addr address.Address) (types.BigInt, error)
+	StateMinerAvailableBalance(addr address.Address) (types.BigInt, error)
+}
+
+// WatchBalances 每隔 interval 刷新钱包余额及矿工可用余额指标，直到 ctx 结束
+// wallets 每次刷新时调用，以包含运行期间新增的钱包；单个地址查询失败时保留上次的值
+func WatchBalances(ctx context.Context, src BalanceSource, wallets func() ([]address.Address, error), miners []address.Address, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		refreshBalances(src, wallets, miners)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// walletLabels 上次刷新时的钱包，用于删除已从密钥库移除的钱包的指标
+var walletLabels = map[string]bool{}
+
+// refreshBalances 刷新一次余额指标
+func refreshBalances(src BalanceSource, wallets func() ([]address.Address, error), miners []address.Address) {
+	ok := true
+	addrs, err := wallets()
+	if err != nil {
+		log.Errorf("refreshBalances: failed to list wallets: %v", err)
+		ok = false
+	} else {
+		current := make(map[string]bool, len(addrs))
+		for _, a := range addrs {
+			current[a.String()] = true
+		}
+		for a := range walletLabels {
+			if !current[a] {
+				WalletBalance.DeleteLabelValues(a)
+			}
+		}
+		walletLabels = current
+	}
+	for _, a := range addrs {
+		b, err := src.WalletBalance(a)
+		if err != nil {
+			log.Warnf("refreshBalances: failed to get balance of %s: %v", a, err)
+			ok = false
+			continue
+		}
+		WalletBalance.WithLabelValues(a.String()).Set(toFIL(b))
+	}
+	for _, m := range miners {
+		b, err := src.StateMinerAvailableBalance(m)
+		if err != nil {
+			log.Warnf("refreshBalances: failed to get available balance of %s: %v", m, err)
+			ok = false
+			continue
+		}
+		MinerAvailableBalance.WithLabelValues(m.String()).Set(toFIL(b))
+	}
+	if ok {
+		BalanceRefreshed.SetToCurrentTime()
+	}
+}
+
+// toFIL 将 attoFIL 转换为 FIL，仅用于指标展示
+func toFIL(v types.BigInt) float64 {
+	if v.Int == nil {
+		return 0
+	}
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(v.Int), big.NewFloat(1e18)).Float64()
+	return f
+}
@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var log = logging.Logger("metrics")
+
+const namespace = "wallet_sign"
+
+// 消息状态标签
+const (
+	StatusSigned = "signed"
+	StatusPushed = "pushed"
+	StatusFailed = "failed" // 签名失败、推送失败或上链后退出码非零
+)
+
+// Registry 本工具的指标注册表，只包含本工具及 Go 运行时指标
+var Registry = prometheus.NewRegistry()
+
+var (
+	// Messages 按请求类型统计签名、推送及失败的消息数
+	Messages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_total",
+		Help:      "Messages signed, pushed and failed, by request type.",
+	}, []string{"request_type", "status"})
+
+	// RPCDuration 按方法统计 Lotus RPC 调用耗时
+	RPCDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_request_duration_seconds",
+		Help:      "Latency of Lotus JSON-RPC calls, by method.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"method"})
+
+	// RPCErrors 按方法统计 Lotus RPC 调用失败次数
+	RPCErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_errors_total",
+		Help:      "Failed Lotus JSON-RPC calls, by method.",
+	}, []string{"method"})
+
+	// RPCEndpointHealthy 配置了多个 Lotus 节点时各节点最近一次健康检查的结果
+	RPCEndpointHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rpc_endpoint_healthy",
+		Help:      "Whether a Lotus endpoint passed the last health check (1) or not (0).",
+	}, []string{"endpoint"})
+
+	// SignDuration 按密钥类型统计签名耗时
+	SignDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sign_duration_seconds",
+		Help:      "Latency of signing operations, by key type.",
+		Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25},
+	}, []string{"key_type"})
+
+	// PolicyRejections 按发送方统计被签名策略拒绝的请求数
+	PolicyRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "policy_rejections_total",
+		Help:      "Sign requests rejected by the signing policy, by signer.",
+	}, []string{"signer"})
+
+	// WalletBalance 密钥库中钱包的余额
+	WalletBalance = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "wallet_balance_fil",
+		Help:      "Balance of wallets in the keystore, in FIL.",
+	}, []string{"address"})
+
+	// MinerAvailableBalance 矿工可提现余额
+	MinerAvailableBalance = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "miner_available_balance_fil",
+		Help:      "Available (withdrawable) balance of miners, in FIL.",
+	}, []string{"miner"})
+
+	// BalanceRefreshed 余额指标最近一次刷新成功的时间
+	BalanceRefreshed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "balance_last_refresh_timestamp_seconds",
+		Help:      "Unix time of the last successful balance refresh.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		Messages,
+		RPCDuration,
+		RPCErrors,
+		RPCEndpointHealthy,
+		SignDuration,
+		PolicyRejections,
+		WalletBalance,
+		MinerAvailableBalance,
+		BalanceRefreshed,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// MessageSigned 记录一条已签名的消息
+func MessageSigned(requestType string) {
+	Messages.WithLabelValues(requestType, StatusSigned).Inc()
+}
+
+// MessagePushed 记录一条已推送的消息
+func MessagePushed(requestType string) {
+	Messages.WithLabelValues(requestType, StatusPushed).Inc()
+}
+
+// MessageFailed 记录一条签名、推送或执行失败的消息
+func MessageFailed(requestType string) {
+	Messages.WithLabelValues(requestType, StatusFailed).Inc()
+}
+
+// ObserveRPC 记录一次 RPC 调用的耗时及结果
+func ObserveRPC(method string, start time.Time, err error) {
+	RPCDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		RPCErrors.WithLabelValues(method).Inc()
+	}
+}
+
+// ObserveSign 记录一次签名的耗时
+func ObserveSign(keyType string, start time.Time) {
+	SignDuration.WithLabelValues(keyType).Observe(time.Since(start).Seconds())
+}
+
+// PolicyRejected 记录一次被签名策略拒绝的请求
+func PolicyRejected(signer string) {
+	PolicyRejections.WithLabelValues(signer).Inc()
+}
+
+// Handler 返回 /metrics 的 HTTP 处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Serve 在 listen 上提供 /metrics，ctx 结束时关闭
+// 监听失败时立即返回 error，之后的错误只记录日志
+func Serve(ctx context.Context, listen string) (net.Addr, error) {
+	lst, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", listen, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := srv.Serve(lst); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("Serve: metrics server stopped: %v", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	return lst.Addr(), nil
+}
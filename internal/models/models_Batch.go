@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// 批量转账状态
+const (
+	BatchStatusRunning     = "running"     // 正在发送
+	BatchStatusInterrupted = "interrupted" // 发送中断，可以通过 --resume 继续
+	BatchStatusCompleted   = "completed"   // 所有转账已上链且执行成功
+	BatchStatusFailed      = "failed"      // 所有转账已上链，部分执行失败
+)
+
+// 批量转账行状态
+const (
+	BatchRowPending  = "pending"  // 尚未发送，或发送失败可以重试
+	BatchRowSigned   = "signed"   // 已签名，推送结果未知
+	BatchRowPushed   = "pushed"   // 已推送到内存池，等待上链
+	BatchRowIncluded = "included" // 已上链且执行成功
+	BatchRowFailed   = "failed"   // 已上链但执行退出码非零
+)
+
+// Batch 批量转账记录
+// FileHash 为输入文件内容的 SHA-256，用于发现重复提交的文件
+type Batch struct {
+	ID        string    `gorm:"primaryKey;size:32" json:"id"`
+	Source    string    `gorm:"size:512" json:"source"`
+	FileHash  string    `gorm:"size:64;index" json:"fileHash"`
+	Rows      int       `json:"rows"`
+	Status    string    `gorm:"size:16" json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (Batch) TableName() string { return "batches" }
+
+// BatchRow 批量转账中的一笔转账
+// 签名后、推送前记录消息 CID 和 nonce，中断后恢复时据此判断转账是否已经发出，避免重复转账
+type BatchRow struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	BatchID   string    `gorm:"size:32;uniqueIndex:idx_batch_row" json:"batchId"`
+	Row       int       `gorm:"uniqueIndex:idx_batch_row" json:"row"`
+	FromAddr  string    `gorm:"size:128" json:"from"`
+	ToAddr    string    `gorm:"size:128" json:"to"`
+	Amount    string    `gorm:"size:80" json:"amount"` // attoFIL
+	Memo      string    `gorm:"size:256" json:"memo,omitempty"`
+	Status    string    `gorm:"size:16" json:"status"`
+	MsgCid    string    `gorm:"size:128" json:"msgCid,omitempty"`
+	Nonce     uint64    `json:"nonce,omitempty"`
+	Height    int64     `json:"height,omitempty"`
+	ExitCode  int64     `json:"exitCode"`
+	Error     string    `gorm:"type:text" json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (BatchRow) TableName() string { return "batch_rows" }
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// Keystore 密钥库元数据
+// Salt 用于从口令派生加密密钥，Check 用于校验口令是否正确
+type Keystore struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Salt      []byte    `gorm:"type:blob" json:"-"`
+	Check     []byte    `gorm:"type:blob" json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (Keystore) TableName() string { return "keystore" }
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// 消息状态
+const (
+	MessageStatusBuilt    = "built"    // 已构建，未签名
+	MessageStatusSigned   = "signed"   // 已签名，未推送
+	MessageStatusPushed   = "pushed"   // 已推送到内存池，等待上链
+	MessageStatusIncluded = "included" // 已上链且执行成功
+	MessageStatusFailed   = "failed"   // 已上链但执行退出码非零
+	MessageStatusReplaced = "replaced" // 被相同 nonce 的其他消息替换
+)
+
+// Message 消息记录
+// UnsignedCid 为未签名消息的 CID，Cid 为链上使用的 CID（BLS 签名时与 UnsignedCid 相同）
+type Message struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UnsignedCid string    `gorm:"size:128;uniqueIndex" json:"unsignedCid"`
+	Cid         string    `gorm:"size:128;index" json:"cid,omitempty"`
+	RequestType string    `gorm:"size:64" json:"requestType,omitempty"`
+	FromAddr    string    `gorm:"size:128;index" json:"from"`
+	ToAddr      string    `gorm:"size:128" json:"to"`
+	Nonce       uint64    `json:"nonce"`
+	Value       string    `gorm:"size:80" json:"value"`
+	Method      uint64    `json:"method"`
+	Message     []byte    `gorm:"type:blob" json:"-"` // 未签名消息的 CBOR 编码
+	Signature   []byte    `gorm:"type:blob" json:"-"` // 签名的二进制编码（类型字节 + 签名数据）
+	Status      string    `gorm:"size:16;index" json:"status"`
+	Height      int64     `json:"height,omitempty"`
+	ExitCode    int64     `json:"exitCode"`
+	GasUsed     int64     `json:"gasUsed,omitempty"`
+	ReplacedBy  string    `gorm:"size:128" json:"replacedBy,omitempty"`
+	Error       string    `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func (Message) TableName() string { return "messages" }
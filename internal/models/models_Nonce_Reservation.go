@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// NonceReservation 本地预留的 nonce
+// 发送方的 nonce 在推送前先在本地预留，使多条消息可以连续推送而不必等待上一条上链
+type NonceReservation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Address   string    `gorm:"size:128;uniqueIndex:idx_nonce_reservation" json:"address"`
+	Nonce     uint64    `gorm:"uniqueIndex:idx_nonce_reservation" json:"nonce"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (NonceReservation) TableName() string { return "nonce_reservations" }
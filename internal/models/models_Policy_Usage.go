@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// PolicyUsage 签名策略用量记录
+// 每条通过策略检查的消息记录一次，用于统计发送方的每日、每周转账金额
+// 同一发送方同一 nonce 只保留金额最大的消息（替换消息不重复计入），旧版本的记录没有 Nonce
+type PolicyUsage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Address   string    `gorm:"size:128;index;index:idx_policy_usage_nonce" json:"address"`
+	Nonce     *uint64   `gorm:"index:idx_policy_usage_nonce" json:"nonce"`
+	MsgCid    string    `gorm:"size:128;uniqueIndex" json:"msgCid"`
+	Value     string    `gorm:"size:80" json:"value"`
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
+
+func (PolicyUsage) TableName() string { return "policy_usage" }
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// 自动归集动作类型
+const (
+	SweepKindWithdraw = "withdraw" // 从矿工提现到 owner
+	SweepKindForward  = "forward"  // 将 owner 余额转到冷钱包
+	SweepKindTopUp    = "topup"    // 为 worker/control 地址补充余额
+)
+
+// 自动归集动作状态
+const (
+	SweepActionPending  = "pending"  // 已记录，尚未签名
+	SweepActionSigned   = "signed"   // 已签名，尚未确认推送
+	SweepActionPushed   = "pushed"   // 已推送，等待上链
+	SweepActionIncluded = "included" // 已上链且执行成功
+	SweepActionFailed   = "failed"   // 构建、签名、推送失败，或上链后执行失败
+)
+
+// SweepAction 自动归集执行的一次动作
+// IdemKey 由规则、动作类型、对象和决策时的链高度组成，同一高度的同一动作只会记录一次；
+// 同一规则的同一对象存在未完成的动作时不会发起新的动作
+type SweepAction struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	IdemKey   string    `gorm:"size:255;uniqueIndex" json:"idemKey"`
+	Rule      string    `gorm:"size:128;index:idx_sweep_subject" json:"rule"`
+	Kind      string    `gorm:"size:16;index:idx_sweep_subject" json:"kind"`
+	Subject   string    `gorm:"size:128;index:idx_sweep_subject" json:"subject"`
+	FromAddr  string    `gorm:"size:128" json:"from"`
+	ToAddr    string    `gorm:"size:128" json:"to"`
+	Amount    string    `gorm:"size:64" json:"amount"` // attoFIL
+	Reason    string    `gorm:"size:255" json:"reason"`
+	Status    string    `gorm:"size:16;index" json:"status"`
+	MsgCid    string    `gorm:"size:128" json:"msgCid,omitempty"`
+	Nonce     uint64    `json:"nonce"`
+	Height    int64     `json:"height,omitempty"`
+	ExitCode  int64     `json:"exitCode"`
+	Error     string    `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (SweepAction) TableName() string { return "sweep_actions" }
@@ -4,13 +4,19 @@ import (
 	"time"
 )
 
+// WalletKey 钱包密钥
+// DerivationPath 与 AccountIndex 仅对助记词派生的密钥有效
+// Address 统一使用主网前缀（f）保存，Network 为密钥使用的网络，为空时在第一次签名时记录
 type WalletKey struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	Address      string    `gorm:"size:128;uniqueIndex" json:"address"`
-	KeyType      string    `gorm:"size:32" json:"keyType"`
-	EncryptedKey []byte    `gorm:"type:blob" json:"-"`
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Address        string    `gorm:"size:128;uniqueIndex" json:"address"`
+	KeyType        string    `gorm:"size:32" json:"keyType"`
+	EncryptedKey   []byte    `gorm:"type:blob" json:"-"`
+	DerivationPath string    `gorm:"size:64" json:"derivationPath,omitempty"`
+	AccountIndex   *uint32   `json:"accountIndex,omitempty"`
+	Network        string    `gorm:"size:32" json:"network,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
 }
 
 func (WalletKey) TableName() string { return "wallet_keys" }
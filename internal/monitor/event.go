@@ -0,0 +1,40 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// 事件类型
+const (
+	EventBalanceLow         = "balance_low"                // owner、worker 或 control 地址余额低于阈值
+	EventWorkerChange       = "worker_change_pending"      // 存在待生效的 worker 变更
+	EventOwnerChange        = "owner_change_pending"       // 存在待确认的 owner 变更
+	EventBeneficiaryChange  = "beneficiary_change_pending" // 存在待确认的受益人变更
+	EventMarketAvailableLow = "market_available_low"       // 市场托管可用余额低于阈值
+	EventMarketLockedHigh   = "market_locked_high"         // 市场锁定余额高于阈值
+	EventCheckFailed        = "check_failed"               // 无法从节点查询矿工状态
+	EventTest               = "test"                       // monitor test-hook 发送的测试事件
+)
+
+// 事件状态
+const (
+	StatusFiring   = "firing"   // 条件开始成立，或条件的内容发生变化
+	StatusResolved = "resolved" // 条件不再成立
+)
+
+// Event 发送给 webhook 或本地命令的事件
+// 同一条件只在开始成立、内容变化及恢复时各发送一次
+type Event struct {
+	Type      string         `json:"type"`
+	Status    string         `json:"status"`
+	Miner     string         `json:"miner,omitempty"`
+	Role      string         `json:"role,omitempty"`
+	Address   string         `json:"address,omitempty"`
+	Value     string         `json:"value,omitempty"`
+	Threshold string         `json:"threshold,omitempty"`
+	Message   string         `json:"message"`
+	Height    abi.ChainEpoch `json:"height"`
+	Time      time.Time      `json:"time"`
+}
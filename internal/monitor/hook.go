@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// hookTimeout 单个事件的投递超时
+const hookTimeout = 30 * time.Second
+
+// Hook 事件的接收方
+type Hook interface {
+	// Name 用于日志输出
+	Name() string
+	// Send 投递一个事件，失败时返回 error，不重试
+	Send(ctx context.Context, ev *Event) error
+}
+
+// Webhook 以 JSON POST 投递事件，返回非 2xx 状态码时视为失败
+type Webhook struct {
+	URL    string
+	Token  string // 不为空时附带 Authorization: Bearer 请求头
+	Client *http.Client
+}
+
+// NewWebhook 创建 webhook 接收方
+func NewWebhook(url, token string) *Webhook {
+	return &Webhook{URL: url, Token: token, Client: &http.Client{Timeout: hookTimeout}}
+}
+
+func (w *Webhook) Name() string { return "webhook " + w.URL }
+
+func (w *Webhook) Send(ctx context.Context, ev *Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.Token)
+	}
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// CommandHook 为每个事件执行一次本地命令
+// 事件 JSON 从标准输入传入，事件类型和状态同时通过 WALLET_SIGN_EVENT_TYPE、WALLET_SIGN_EVENT_STATUS 环境变量传入
+type CommandHook struct {
+	Args []string
+}
+
+func (c *CommandHook) Name() string { return "command " + c.Args[0] }
+
+func (c *CommandHook) Send(ctx context.Context, ev *Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.Args[0], c.Args[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"WALLET_SIGN_EVENT_TYPE="+ev.Type,
+		"WALLET_SIGN_EVENT_STATUS="+ev.Status)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
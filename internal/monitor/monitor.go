@@ -0,0 +1,418 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	logging "github.com/ipfs/go-log/v2"
+
+	"wallet-sign/internal/chain/types"
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/vapi"
+)
+
+var log = logging.Logger("monitor")
+
+// DefaultInterval 默认轮询间隔
+const DefaultInterval = 5 * time.Minute
+
+// maxPending 等待重试的投递数上限，超过时丢弃最早的
+const maxPending = 1000
+
+// defaultBalanceLow 余额阈值的默认值，与 actor info 中标红的余额一致
+var defaultBalanceLow = types.FromFil(10)
+
+// Settings 解析后的监控配置，阈值为 nil 时不检查
+type Settings struct {
+	Interval           time.Duration
+	Miners             []address.Address
+	OwnerBalanceLow    *types.BigInt
+	WorkerBalanceLow   *types.BigInt
+	ControlBalanceLow  *types.BigInt
+	MarketAvailableLow *types.BigInt
+	MarketLockedHigh   *types.BigInt
+	Hooks              []Hook
+}
+
+// LoadSettings 从配置 [Monitor] 段解析监控配置
+func LoadSettings() (*Settings, error) {
+	cfg := appcfg.LotusConfig.Monitor
+	if cfg == nil {
+		cfg = &appcfg.Monitor{}
+	}
+	s := &Settings{Interval: DefaultInterval}
+	if cfg.Interval != "" {
+		d, err := time.ParseDuration(cfg.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Monitor.Interval: %w", err)
+		}
+		if d < time.Minute {
+			return nil, fmt.Errorf("Monitor.Interval must be at least 1m")
+		}
+		s.Interval = d
+	}
+	for _, m := range cfg.Miners {
+		a, err := address.NewFromString(m)
+		if err != nil {
+			return nil, fmt.Errorf("invalid miner %q in Monitor.Miners: %w", m, err)
+		}
+		s.Miners = append(s.Miners, a)
+	}
+
+	var err error
+	if s.OwnerBalanceLow, err = balanceThreshold("OwnerBalanceLow", cfg.OwnerBalanceLow); err != nil {
+		return nil, err
+	}
+	if s.WorkerBalanceLow, err = balanceThreshold("WorkerBalanceLow", cfg.WorkerBalanceLow); err != nil {
+		return nil, err
+	}
+	if s.ControlBalanceLow, err = balanceThreshold("ControlBalanceLow", cfg.ControlBalanceLow); err != nil {
+		return nil, err
+	}
+	if s.MarketAvailableLow, err = parseThreshold("MarketAvailableLow", cfg.MarketAvailableLow); err != nil {
+		return nil, err
+	}
+	if s.MarketLockedHigh, err = parseThreshold("MarketLockedHigh", cfg.MarketLockedHigh); err != nil {
+		return nil, err
+	}
+
+	if cfg.Webhook != "" {
+		s.Hooks = append(s.Hooks, NewWebhook(cfg.Webhook, cfg.WebhookToken))
+	}
+	if len(cfg.Command) > 0 {
+		s.Hooks = append(s.Hooks, &CommandHook{Args: cfg.Command})
+	}
+	return s, nil
+}
+
+// balanceThreshold 解析余额阈值，为空时使用默认值，为 0 时不检查
+func balanceThreshold(name, s string) (*types.BigInt, error) {
+	if s == "" {
+		v := defaultBalanceLow
+		return &v, nil
+	}
+	v, err := parseThreshold(name, s)
+	if err != nil || v == nil || v.Sign() == 0 {
+		return nil, err
+	}
+	return v, nil
+}
+
+// parseThreshold 解析 FIL 金额阈值，为空时返回 nil
+func parseThreshold(name, s string) (*types.BigInt, error) {
+	if s == "" {
+		return nil, nil
+	}
+	f, err := types.ParseFIL(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Monitor.%s: %w", name, err)
+	}
+	v := types.BigInt(f)
+	if v.Sign() < 0 {
+		return nil, fmt.Errorf("Monitor.%s must not be negative", name)
+	}
+	return &v, nil
+}
+
+// condition 一次检查中成立的条件
+// key 标识条件，fingerprint 变化时（例如待确认的变更内容不同）重新发送事件
+type condition struct {
+	key         string
+	fingerprint string
+	event       *Event
+}
+
+// Monitor 定期检查矿工相关地址的余额及待确认的变更
+// 只在条件开始成立、内容变化及恢复时产生事件
+type Monitor struct {
+	node     *vapi.Node
+	settings *Settings
+	// active 上次检查时成立的条件
+	active map[string]*condition
+	// pending 投递失败、下次 Notify 时重试的事件
+	pending []delivery
+}
+
+// delivery 一个事件到一个接收方（Settings.Hooks 的下标）的投递
+type delivery struct {
+	hook  int
+	event *Event
+}
+
+// New 创建监控实例
+func New(node *vapi.Node, settings *Settings) *Monitor {
+	return &Monitor{node: node, settings: settings, active: map[string]*condition{}}
+}
+
+// Check 检查所有矿工并返回状态发生变化的事件
+// 某个矿工查询失败时产生 check_failed 事件，该矿工此前成立的条件保持不变
+func (m *Monitor) Check() ([]*Event, error) {
+	head, err := m.node.ChainHead()
+	if err != nil {
+		log.Errorf("Check: failed to get chain head: %v", err)
+		return nil, err
+	}
+	now := time.Now()
+
+	current := map[string]*condition{}
+	for _, maddr := range m.settings.Miners {
+		conds, err := m.checkMiner(maddr, head.Height())
+		if err != nil {
+			log.Errorf("Check: failed to check miner %s: %v", maddr, err)
+			// 无法确认状态时不发送恢复事件
+			prefix := maddr.String() + "/"
+			for key, c := range m.active {
+				if strings.HasPrefix(key, prefix) {
+					current[key] = c
+				}
+			}
+			conds = []*condition{{
+				key: prefix + EventCheckFailed,
+				event: &Event{
+					Type:    EventCheckFailed,
+					Miner:   maddr.String(),
+					Message: fmt.Sprintf("failed to check miner %s: %v", maddr, err),
+				},
+			}}
+		}
+		for _, c := range conds {
+			current[c.key] = c
+		}
+	}
+
+	var events []*Event
+	for key, c := range current {
+		if prev, ok := m.active[key]; ok && prev.fingerprint == c.fingerprint {
+			continue
+		}
+		c.event.Status = StatusFiring
+		events = append(events, c.event)
+	}
+	for key, prev := range m.active {
+		if _, ok := current[key]; ok {
+			continue
+		}
+		ev := *prev.event
+		ev.Status = StatusResolved
+		ev.Message = "resolved: " + prev.event.Message
+		events = append(events, &ev)
+	}
+	for _, ev := range events {
+		ev.Height = head.Height()
+		ev.Time = now
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Miner != events[j].Miner {
+			return events[i].Miner < events[j].Miner
+		}
+		if events[i].Type != events[j].Type {
+			return events[i].Type < events[j].Type
+		}
+		return events[i].Address < events[j].Address
+	})
+
+	m.active = current
+	return events, nil
+}
+
+// checkMiner 返回一个矿工当前成立的条件
+func (m *Monitor) checkMiner(maddr address.Address, height abi.ChainEpoch) ([]*condition, error) {
+	mi, err := m.node.StateMinerInfo(maddr)
+	if err != nil {
+		return nil, err
+	}
+	prefix := maddr.String() + "/"
+	var conds []*condition
+
+	// worker 同时是 control 地址时按 worker 检查
+	type roleAddr struct {
+		role      string
+		addr      address.Address
+		threshold *types.BigInt
+	}
+	roles := []roleAddr{
+		{"owner", mi.Owner, m.settings.OwnerBalanceLow},
+		{"worker", mi.Worker, m.settings.WorkerBalanceLow},
+	}
+	for _, ca := range mi.ControlAddresses {
+		if ca != mi.Worker {
+			roles = append(roles, roleAddr{"control", ca, m.settings.ControlBalanceLow})
+		}
+	}
+	for _, r := range roles {
+		if r.threshold == nil {
+			continue
+		}
+		act, err := m.node.StateGetActor(r.addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s actor %s: %w", r.role, r.addr, err)
+		}
+		if !act.Balance.LessThan(*r.threshold) {
+			continue
+		}
+		display := r.addr.String()
+		if key, err := m.node.StateAccountKey(r.addr); err == nil {
+			display = key.String()
+		}
+		conds = append(conds, &condition{
+			key: prefix + EventBalanceLow + "/" + r.role + "/" + r.addr.String(),
+			event: &Event{
+				Type:      EventBalanceLow,
+				Miner:     maddr.String(),
+				Role:      r.role,
+				Address:   display,
+				Value:     types.FIL(act.Balance).String(),
+				Threshold: types.FIL(*r.threshold).String(),
+				Message: fmt.Sprintf("%s %s of %s balance %s is below %s",
+					r.role, display, maddr, types.FIL(act.Balance), types.FIL(*r.threshold)),
+			},
+		})
+	}
+
+	if mi.NewWorker != address.Undef {
+		state := "pending"
+		if height >= mi.WorkerChangeEpoch {
+			state = "ready, run actor confirm-change-worker"
+		}
+		conds = append(conds, &condition{
+			key: prefix + EventWorkerChange,
+			// 到达生效高度时再次发送，提醒确认变更
+			fingerprint: fmt.Sprintf("%s@%d/%s", mi.NewWorker, mi.WorkerChangeEpoch, state),
+			event: &Event{
+				Type:    EventWorkerChange,
+				Miner:   maddr.String(),
+				Role:    "worker",
+				Address: mi.NewWorker.String(),
+				Message: fmt.Sprintf("worker change of %s from %s to %s effective at epoch %d (%s)",
+					maddr, mi.Worker, mi.NewWorker, mi.WorkerChangeEpoch, state),
+			},
+		})
+	}
+
+	if mi.PendingOwnerAddress != nil && *mi.PendingOwnerAddress != address.Undef {
+		conds = append(conds, &condition{
+			key:         prefix + EventOwnerChange,
+			fingerprint: mi.PendingOwnerAddress.String(),
+			event: &Event{
+				Type:    EventOwnerChange,
+				Miner:   maddr.String(),
+				Role:    "owner",
+				Address: mi.PendingOwnerAddress.String(),
+				Message: fmt.Sprintf("owner change of %s from %s to %s is waiting for confirmation by the new owner",
+					maddr, mi.Owner, *mi.PendingOwnerAddress),
+			},
+		})
+	}
+
+	if p := mi.PendingBeneficiaryTerm; p != nil {
+		conds = append(conds, &condition{
+			key: prefix + EventBeneficiaryChange,
+			fingerprint: fmt.Sprintf("%s/%s/%d/%t/%t", p.NewBeneficiary, p.NewQuota, p.NewExpiration,
+				p.ApprovedByBeneficiary, p.ApprovedByNominee),
+			event: &Event{
+				Type:    EventBeneficiaryChange,
+				Miner:   maddr.String(),
+				Role:    "beneficiary",
+				Address: p.NewBeneficiary.String(),
+				Value:   types.FIL(p.NewQuota).String(),
+				Message: fmt.Sprintf("beneficiary change of %s to %s (quota %s, expiration %d) proposed, approved by beneficiary: %t, approved by nominee: %t",
+					maddr, p.NewBeneficiary, types.FIL(p.NewQuota), p.NewExpiration, p.ApprovedByBeneficiary, p.ApprovedByNominee),
+			},
+		})
+	}
+
+	if m.settings.MarketAvailableLow != nil || m.settings.MarketLockedHigh != nil {
+		mb, err := m.node.StateMarketBalance(maddr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get market balance: %w", err)
+		}
+		available := types.BigSub(mb.Escrow, mb.Locked)
+		if t := m.settings.MarketAvailableLow; t != nil && available.LessThan(*t) {
+			conds = append(conds, &condition{
+				key: prefix + EventMarketAvailableLow,
+				event: &Event{
+					Type:      EventMarketAvailableLow,
+					Miner:     maddr.String(),
+					Value:     types.FIL(available).String(),
+					Threshold: types.FIL(*t).String(),
+					Message: fmt.Sprintf("market available balance of %s is %s (escrow %s, locked %s), below %s",
+						maddr, types.FIL(available), types.FIL(mb.Escrow), types.FIL(mb.Locked), types.FIL(*t)),
+				},
+			})
+		}
+		if t := m.settings.MarketLockedHigh; t != nil && mb.Locked.GreaterThan(*t) {
+			conds = append(conds, &condition{
+				key: prefix + EventMarketLockedHigh,
+				event: &Event{
+					Type:      EventMarketLockedHigh,
+					Miner:     maddr.String(),
+					Value:     types.FIL(mb.Locked).String(),
+					Threshold: types.FIL(*t).String(),
+					Message: fmt.Sprintf("market locked funds of %s are %s, above %s",
+						maddr, types.FIL(mb.Locked), types.FIL(*t)),
+				},
+			})
+		}
+	}
+	return conds, nil
+}
+
+// Notify 先重试此前投递失败的事件，再将事件依次投递给所有接收方
+// Check 产生的事件只返回一次，投递失败的事件保留到下次调用时重试，不会因接收方暂时不可用而丢失；
+// 某个接收方失败后，本次剩余的事件也留待下次按原顺序投递，保证 firing 与 resolved 的先后顺序。
+// 返回本次投递失败的汇总 error
+func (m *Monitor) Notify(ctx context.Context, events []*Event) error {
+	queue := m.pending
+	for _, ev := range events {
+		for i := range m.settings.Hooks {
+			queue = append(queue, delivery{hook: i, event: ev})
+		}
+	}
+
+	m.pending = nil
+	failed := map[int]bool{}
+	var errs []error
+	for _, d := range queue {
+		if failed[d.hook] {
+			m.pending = append(m.pending, d)
+			continue
+		}
+		h := m.settings.Hooks[d.hook]
+		if err := h.Send(ctx, d.event); err != nil {
+			log.Errorf("Notify: failed to deliver %s event to %s, retrying next check: %v", d.event.Type, h.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.Name(), err))
+			failed[d.hook] = true
+			m.pending = append(m.pending, d)
+		}
+	}
+	if n := len(m.pending) - maxPending; n > 0 {
+		log.Errorf("Notify: dropping %d undelivered events", n)
+		m.pending = m.pending[n:]
+	}
+	return errors.Join(errs...)
+}
+
+// Pending 返回等待重试的投递数
+func (m *Monitor) Pending() int {
+	return len(m.pending)
+}
+
+// Notify 将事件依次投递给 hooks，投递失败只记录日志并返回汇总的 error，不重试
+func Notify(ctx context.Context, hooks []Hook, events []*Event) error {
+	var errs []error
+	for _, ev := range events {
+		for _, h := range hooks {
+			if err := h.Send(ctx, ev); err != nil {
+				log.Errorf("Notify: failed to deliver %s event to %s: %v", ev.Type, h.Name(), err)
+				errs = append(errs, fmt.Errorf("%s: %w", h.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,233 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+
+	"wallet-sign/internal/chain/types"
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/lotusmock"
+	"wallet-sign/internal/rpc"
+	"wallet-sign/internal/vapi"
+	"wallet-sign/internal/wallet"
+)
+
+var testEvent = &Event{
+	Type:      EventBalanceLow,
+	Status:    StatusFiring,
+	Miner:     "f01000",
+	Role:      "owner",
+	Address:   "f01001",
+	Value:     "5 FIL",
+	Threshold: "10 FIL",
+	Message:   "owner f01001 of f01000 balance 5 FIL is below 10 FIL",
+	Height:    100,
+	Time:      time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC),
+}
+
+func TestWebhook(t *testing.T) {
+	var (
+		got    Event
+		auth   string
+		ctype  string
+		status = http.StatusOK
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, ctype = r.Header.Get("Authorization"), r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		if status != http.StatusOK {
+			http.Error(w, "hook unavailable", status)
+		}
+	}))
+	defer srv.Close()
+
+	if err := NewWebhook(srv.URL, "secret").Send(context.Background(), testEvent); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want bearer token", auth)
+	}
+	if ctype != "application/json" {
+		t.Errorf("Content-Type = %q", ctype)
+	}
+	if got != *testEvent {
+		t.Errorf("payload = %+v, want %+v", got, *testEvent)
+	}
+
+	if err := NewWebhook(srv.URL, "").Send(context.Background(), testEvent); err != nil {
+		t.Fatalf("send without token: %v", err)
+	}
+	if auth != "" {
+		t.Errorf("Authorization = %q without a token", auth)
+	}
+
+	status = http.StatusBadGateway
+	err := NewWebhook(srv.URL, "secret").Send(context.Background(), testEvent)
+	if err == nil || !strings.Contains(err.Error(), "502") || !strings.Contains(err.Error(), "hook unavailable") {
+		t.Errorf("error = %v, want 502 with the response body", err)
+	}
+}
+
+func TestCommandHook(t *testing.T) {
+	dir := t.TempDir()
+	stdin, env := filepath.Join(dir, "event.json"), filepath.Join(dir, "env")
+	hook := &CommandHook{Args: []string{"sh", "-c",
+		`cat > "$0" && printf '%s %s' "$WALLET_SIGN_EVENT_TYPE" "$WALLET_SIGN_EVENT_STATUS" > "$1"`, stdin, env}}
+	if err := hook.Send(context.Background(), testEvent); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	data, err := os.ReadFile(stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Event
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decode stdin: %v", err)
+	}
+	if got != *testEvent {
+		t.Errorf("stdin = %+v, want %+v", got, *testEvent)
+	}
+	if data, err := os.ReadFile(env); err != nil || string(data) != "balance_low firing" {
+		t.Errorf("env = %q (%v), want event type and status", data, err)
+	}
+
+	failing := &CommandHook{Args: []string{"sh", "-c", "echo hook failed; exit 3"}}
+	if err := failing.Send(context.Background(), testEvent); err == nil || !strings.Contains(err.Error(), "hook failed") {
+		t.Errorf("error = %v, want the command output", err)
+	}
+}
+
+func newKeyAddress(t *testing.T) address.Address {
+	t.Helper()
+	_, addr, err := wallet.WalletNew(types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+// TestCheckBalanceThreshold 余额低于阈值时产生一次 firing 事件，恢复后产生 resolved 事件
+func TestCheckBalanceThreshold(t *testing.T) {
+	node := lotusmock.New()
+	node.Start()
+	t.Cleanup(node.Close)
+	prev := appcfg.LotusConfig.Lotus
+	appcfg.LotusConfig.Lotus = &appcfg.Lotus{Host: node.URL(), RetryBackoff: "1ms"}
+	t.Cleanup(func() { appcfg.LotusConfig.Lotus = prev })
+
+	owner, worker := newKeyAddress(t), newKeyAddress(t)
+	node.AddAccount(owner, types.FromFil(5))
+	node.AddAccount(worker, types.FromFil(20))
+	miner := node.AddMiner(owner, worker, types.FromFil(50))
+
+	threshold := types.FromFil(10)
+	m := New(vapi.NewNode(context.Background(), rpc.NewLotusApi()), &Settings{
+		Miners:           []address.Address{miner},
+		OwnerBalanceLow:  &threshold,
+		WorkerBalanceLow: &threshold,
+	})
+
+	events, err := m.Check()
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("events = %+v, want one", events)
+	}
+	ev := events[0]
+	if ev.Type != EventBalanceLow || ev.Status != StatusFiring || ev.Role != "owner" || ev.Address != owner.String() || ev.Miner != miner.String() {
+		t.Errorf("event = %+v, want owner balance low", ev)
+	}
+	if ev.Height != node.Height() {
+		t.Errorf("height = %d, want %d", ev.Height, node.Height())
+	}
+
+	// 条件未变化时不重复发送
+	if events, err := m.Check(); err != nil || len(events) != 0 {
+		t.Fatalf("second check = %+v (%v), want no events", events, err)
+	}
+
+	node.SetBalance(owner, types.FromFil(15))
+	node.SetBalance(worker, types.FromFil(1))
+	events, err = m.Check()
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want owner resolved and worker firing", events)
+	}
+	byRole := map[string]*Event{}
+	for _, ev := range events {
+		byRole[ev.Role] = ev
+	}
+	if ev := byRole["owner"]; ev == nil || ev.Status != StatusResolved {
+		t.Errorf("owner event = %+v, want resolved", ev)
+	}
+	if ev := byRole["worker"]; ev == nil || ev.Status != StatusFiring || ev.Address != worker.String() {
+		t.Errorf("worker event = %+v, want firing", ev)
+	}
+}
+
+// recordHook 记录收到的事件，fail 为 true 时投递失败
+type recordHook struct {
+	fail bool
+	got  []string
+}
+
+func (h *recordHook) Name() string { return "record" }
+
+func (h *recordHook) Send(_ context.Context, ev *Event) error {
+	if h.fail {
+		return errors.New("hook unavailable")
+	}
+	h.got = append(h.got, ev.Status+" "+ev.Message)
+	return nil
+}
+
+// TestNotifyRetry 投递失败的事件在下次 Notify 时按原顺序重试，不影响其他接收方
+func TestNotifyRetry(t *testing.T) {
+	down, up := &recordHook{fail: true}, &recordHook{}
+	m := New(nil, &Settings{Hooks: []Hook{down, up}})
+	firing := &Event{Type: EventBalanceLow, Status: StatusFiring, Message: "low"}
+	resolved := &Event{Type: EventBalanceLow, Status: StatusResolved, Message: "resolved: low"}
+
+	if err := m.Notify(context.Background(), []*Event{firing}); err == nil {
+		t.Fatalf("notify succeeded with a failing hook")
+	}
+	if m.Pending() != 1 {
+		t.Fatalf("pending = %d, want 1", m.Pending())
+	}
+
+	// 接收方恢复前没有新事件时仍重试
+	if err := m.Notify(context.Background(), nil); err == nil || m.Pending() != 1 {
+		t.Fatalf("retry = %v, pending %d, want still failing", err, m.Pending())
+	}
+
+	down.fail = false
+	if err := m.Notify(context.Background(), []*Event{resolved}); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	want := []string{"firing low", "resolved resolved: low"}
+	if strings.Join(down.got, ",") != strings.Join(want, ",") {
+		t.Errorf("recovered hook got %q, want %q", down.got, want)
+	}
+	if strings.Join(up.got, ",") != strings.Join(want, ",") {
+		t.Errorf("healthy hook got %q, want each event once: %q", up.got, want)
+	}
+	if m.Pending() != 0 {
+		t.Errorf("pending = %d after delivery", m.Pending())
+	}
+}
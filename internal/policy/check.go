@@ -0,0 +1,220 @@
+package policy
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/config"
+	"wallet-sign/internal/metrics"
+	"wallet-sign/internal/repository"
+)
+
+var (
+	loadOnce sync.Once
+	current  *Policy
+	loadErr  error
+
+	// usageMu 串行化累计限额的检查与记录，防止并发签名超出限额
+	usageMu sync.Mutex
+)
+
+// RejectedError 签名请求被策略拒绝
+type RejectedError struct {
+	Signer address.Address
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("signing policy rejected request from %s: %s", e.Signer, e.Reason)
+}
+
+// Current 返回配置 Security.PolicyFile 指定的签名策略，未配置时返回 nil
+// 策略文件只在首次调用时加载
+func Current() (*Policy, error) {
+	loadOnce.Do(func() {
+		sec := config.LotusConfig.Security
+		if sec == nil || sec.PolicyFile == "" {
+			return
+		}
+		current, loadErr = LoadFile(sec.PolicyFile)
+	})
+	return current, loadErr
+}
+
+// Authorize 按当前策略检查待签名的消息，未配置策略时直接通过
+// 通过检查后立即记录消息金额，用于后续的每日、每周限额统计
+func Authorize(store *repository.Store, msg *types.Message) error {
+	p, err := Current()
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+	return p.Authorize(store, msg, time.Now())
+}
+
+// AuthorizeRaw 检查是否允许签名非链上消息数据，kind 为数据类型，仅用于错误信息
+func AuthorizeRaw(signer address.Address, kind string) error {
+	p, err := Current()
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+	return p.AuthorizeRaw(signer, kind, time.Now())
+}
+
+// Authorize 检查消息是否符合策略，now 为签名时间
+func (p *Policy) Authorize(store *repository.Store, msg *types.Message, now time.Time) error {
+	reject := func(format string, args ...interface{}) error {
+		err := &RejectedError{Signer: msg.From, Reason: fmt.Sprintf(format, args...)}
+		metrics.PolicyRejected(msg.From.String())
+		log.Warnf("Authorize: %v (message %s)", err, msg.Cid())
+		return err
+	}
+
+	r := p.rulesFor(msg.From)
+	if r == nil {
+		return reject("sender is not listed in the policy")
+	}
+	if reason := p.checkWindows(r, now); reason != "" {
+		return reject("%s", reason)
+	}
+	if r.maxFee != nil && msg.GasFeeCap.Int != nil {
+		fee := types.BigMul(msg.GasFeeCap, types.NewInt(uint64(msg.GasLimit)))
+		if fee.GreaterThan(*r.maxFee) {
+			return reject("max fee %s (fee cap %s × gas limit %d) exceeds the limit of %s",
+				types.FIL(fee), types.FIL(msg.GasFeeCap), msg.GasLimit, types.FIL(*r.maxFee))
+		}
+	}
+
+	// 多签提案、矿工及市场提现的实际接收方和金额在参数中，与消息本身一样检查接收方、方法及金额
+	list, err := transfers(msg)
+	if err != nil {
+		return reject("%v", err)
+	}
+	value := types.NewInt(0)
+	for _, t := range list {
+		if r.allowedTo != nil && t.To != address.Undef && !r.allowedTo[t.To] {
+			return reject("destination %s of %s is not in the allowlist", t.To, t.Source)
+		}
+		if r.allowedMethods != nil && !r.allowedMethods[t.Method] {
+			return reject("method %d of %s is not allowed", t.Method, t.Source)
+		}
+		if r.maxValue != nil && t.Value.GreaterThan(*r.maxValue) {
+			return reject("value %s of %s exceeds the per-message limit of %s", types.FIL(t.Value), t.Source, types.FIL(*r.maxValue))
+		}
+		value = types.BigAdd(value, t.Value)
+	}
+
+	if value.Sign() == 0 {
+		return nil
+	}
+
+	usageMu.Lock()
+	defer usageMu.Unlock()
+
+	// 同一消息重复签名（例如离线签名重试）不重复计入限额
+	msgCid := msg.Cid().String()
+	recorded, err := store.HasPolicyUsage(msgCid)
+	if err != nil {
+		return err
+	}
+	if recorded {
+		return nil
+	}
+	// 同一 nonce 已签名的消息（例如替换消息取代的原消息）最多只有一条上链，但签名时无法确认原消息是否已经上链，
+	// 因此原消息的用量只会被更大的金额取代，不会减少或删除：金额不超过原消息时不再计入，超过时按新金额计入
+	replaced, err := store.PolicyUsageByNonce(msg.From.String(), msg.Nonce)
+	if err != nil {
+		return err
+	}
+	var prev *big.Int
+	if replaced != nil {
+		var ok bool
+		if prev, ok = new(big.Int).SetString(replaced.Value, 10); !ok {
+			return fmt.Errorf("invalid usage value %q for %s", replaced.Value, replaced.MsgCid)
+		}
+		if value.Int.Cmp(prev) <= 0 {
+			log.Infof("Authorize: message %s is covered by the usage of %s (nonce %d)", msgCid, replaced.MsgCid, msg.Nonce)
+			return nil
+		}
+	}
+
+	for _, limit := range []struct {
+		name   string
+		limit  *types.BigInt
+		period time.Duration
+	}{
+		{"daily", r.dailyLimit, 24 * time.Hour},
+		{"weekly", r.weeklyLimit, 7 * 24 * time.Hour},
+	} {
+		if limit.limit == nil {
+			continue
+		}
+		since := now.Add(-limit.period)
+		used, err := store.SumPolicyUsage(msg.From.String(), since)
+		if err != nil {
+			return err
+		}
+		if replaced != nil && !replaced.CreatedAt.Before(since) {
+			used.Sub(used, prev)
+		}
+		total := types.BigAdd(types.BigInt{Int: used}, value)
+		if total.GreaterThan(*limit.limit) {
+			return reject("%s limit of %s exceeded (already used %s, requested %s)",
+				limit.name, types.FIL(*limit.limit), types.FIL(types.BigInt{Int: used}), types.FIL(value))
+		}
+	}
+
+	if replaced != nil {
+		log.Infof("Authorize: message %s replaces %s (nonce %d) in usage", msgCid, replaced.MsgCid, msg.Nonce)
+	}
+	return store.RecordPolicyUsage(msg.From.String(), msgCid, msg.Nonce, value.Int, now)
+}
+
+// AuthorizeRaw 检查是否允许发送方签名非链上消息数据
+func (p *Policy) AuthorizeRaw(signer address.Address, kind string, now time.Time) error {
+	reject := func(format string, args ...interface{}) error {
+		err := &RejectedError{Signer: signer, Reason: fmt.Sprintf(format, args...)}
+		metrics.PolicyRejected(signer.String())
+		log.Warnf("AuthorizeRaw: %v", err)
+		return err
+	}
+
+	r := p.rulesFor(signer)
+	if r == nil {
+		return reject("sender is not listed in the policy")
+	}
+	if !r.allowRawSign {
+		return reject("signing %s data is not allowed (set AllowRawSign to permit it)", kind)
+	}
+	if reason := p.checkWindows(r, now); reason != "" {
+		return reject("%s", reason)
+	}
+	return nil
+}
+
+// checkWindows 检查签名时间是否在允许的时间窗口内，不符合时返回原因
+func (p *Policy) checkWindows(r *rules, now time.Time) string {
+	if len(r.windows) == 0 {
+		return ""
+	}
+	local := now.In(p.loc)
+	specs := make([]string, 0, len(r.windows))
+	for _, w := range r.windows {
+		if w.contains(local) {
+			return ""
+		}
+		specs = append(specs, w.spec)
+	}
+	return fmt.Sprintf("signing is not allowed at %s (allowed: %s)", local.Format("Mon 15:04 MST"), strings.Join(specs, ", "))
+}
@@ -0,0 +1,247 @@
+package policy
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	markettypes "github.com/filecoin-project/go-state-types/builtin/v9/market"
+	minertypes "github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	multisigtypes "github.com/filecoin-project/go-state-types/builtin/v9/multisig"
+
+	"wallet-sign/internal/chain/actors"
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/repository"
+)
+
+var (
+	owner    = mustAddress("f1ys5qqiciehcml3sp764ymbbytfn3qoar5fo3iwy")
+	msig     = mustAddress("f01001")
+	miner    = mustAddress("f01002")
+	allowed  = mustAddress("f01003")
+	stranger = mustAddress("f01004")
+	testNow  = time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+)
+
+func mustAddress(s string) address.Address {
+	a, err := address.NewFromString(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func newTestStore(t *testing.T) *repository.Store {
+	t.Helper()
+	store, err := repository.OpenStore(filepath.Join(t.TempDir(), "wallet.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() {
+		if db, err := store.DB.DB(); err == nil {
+			db.Close()
+		}
+	})
+	return store
+}
+
+// ownerPolicy 只允许 owner 向多签钱包、矿工及 allowed 发送，单笔不超过 10 FIL，每日不超过 15 FIL
+func ownerPolicy(t *testing.T) *Policy {
+	t.Helper()
+	p, err := New(&File{
+		DenyUnlisted: true,
+		Sender: []SenderRules{{
+			Address: owner.String(),
+			Rules: Rules{
+				AllowedTo:  []string{msig.String(), miner.String(), allowed.String(), builtintypes.StorageMarketActorAddr.String()},
+				MaxValue:   "10",
+				DailyLimit: "15",
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	return p
+}
+
+func message(to address.Address, value abi.TokenAmount, method abi.MethodNum, params interface{}) *types.Message {
+	msg := &types.Message{
+		From:       owner,
+		To:         to,
+		Value:      value,
+		Method:     method,
+		GasFeeCap:  abi.NewTokenAmount(0),
+		GasPremium: abi.NewTokenAmount(0),
+	}
+	if params != nil {
+		enc, err := actors.SerializeParams(params)
+		if err != nil {
+			panic(err)
+		}
+		msg.Params = enc
+	}
+	return msg
+}
+
+func proposal(to address.Address, value abi.TokenAmount) *types.Message {
+	return message(msig, abi.NewTokenAmount(0), builtintypes.MethodsMultisig.Propose, &multisigtypes.ProposeParams{
+		To:     to,
+		Value:  value,
+		Method: builtintypes.MethodSend,
+	})
+}
+
+func expectRejected(t *testing.T, err error, reason string) {
+	t.Helper()
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) {
+		t.Fatalf("error = %v, want rejection", err)
+	}
+	if !strings.Contains(rejected.Reason, reason) {
+		t.Errorf("reason = %q, want %q", rejected.Reason, reason)
+	}
+}
+
+func TestAuthorizeProposalAboveMaxValue(t *testing.T) {
+	p, store := ownerPolicy(t), newTestStore(t)
+	err := p.Authorize(store, proposal(allowed, types.FromFil(11)), testNow)
+	expectRejected(t, err, "exceeds the per-message limit")
+}
+
+func TestAuthorizeProposalDestination(t *testing.T) {
+	p, store := ownerPolicy(t), newTestStore(t)
+	err := p.Authorize(store, proposal(stranger, types.FromFil(1)), testNow)
+	expectRejected(t, err, "destination "+stranger.String())
+}
+
+func TestAuthorizeProposalDailyLimit(t *testing.T) {
+	p, store := ownerPolicy(t), newTestStore(t)
+	if err := p.Authorize(store, proposal(allowed, types.FromFil(10)), testNow); err != nil {
+		t.Fatalf("first proposal: %v", err)
+	}
+	second := proposal(allowed, types.FromFil(6))
+	second.Nonce = 1
+	err := p.Authorize(store, second, testNow.Add(time.Hour))
+	expectRejected(t, err, "daily limit")
+
+	used, err := store.SumPolicyUsage(owner.String(), testNow.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("sum usage: %v", err)
+	}
+	if used.Cmp(types.FromFil(10).Int) != 0 {
+		t.Errorf("recorded usage = %s, want 10 FIL", types.FIL(types.BigInt{Int: used}))
+	}
+}
+
+func TestAuthorizeNestedProposal(t *testing.T) {
+	p, store := ownerPolicy(t), newTestStore(t)
+	inner, err := actors.SerializeParams(&multisigtypes.ProposeParams{To: stranger, Value: types.FromFil(1), Method: builtintypes.MethodSend})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := message(msig, abi.NewTokenAmount(0), builtintypes.MethodsMultisig.Propose, &multisigtypes.ProposeParams{
+		To:     msig,
+		Value:  abi.NewTokenAmount(0),
+		Method: builtintypes.MethodsMultisig.Propose,
+		Params: inner,
+	})
+	expectRejected(t, p.Authorize(store, msg, testNow), "destination "+stranger.String())
+}
+
+func TestAuthorizeUndecodableProposal(t *testing.T) {
+	p, store := ownerPolicy(t), newTestStore(t)
+	msg := message(msig, abi.NewTokenAmount(0), builtintypes.MethodsMultisig.Propose, nil)
+	msg.Params = []byte{0x01}
+	expectRejected(t, p.Authorize(store, msg, testNow), "cannot decode")
+}
+
+func TestAuthorizeWithdrawals(t *testing.T) {
+	p, store := ownerPolicy(t), newTestStore(t)
+
+	withdraw := message(miner, abi.NewTokenAmount(0), builtintypes.MethodsMiner.WithdrawBalance, &minertypes.WithdrawBalanceParams{
+		AmountRequested: types.FromFil(11),
+	})
+	expectRejected(t, p.Authorize(store, withdraw, testNow), "miner "+miner.String()+" withdrawal")
+
+	market := message(builtintypes.StorageMarketActorAddr, abi.NewTokenAmount(0), builtintypes.MethodsMarket.WithdrawBalance, &markettypes.WithdrawBalanceParams{
+		ProviderOrClientAddress: miner,
+		Amount:                  types.FromFil(11),
+	})
+	expectRejected(t, p.Authorize(store, market, testNow), "market withdrawal")
+}
+
+func TestAuthorizePlainTransfer(t *testing.T) {
+	p, store := ownerPolicy(t), newTestStore(t)
+	if err := p.Authorize(store, message(allowed, types.FromFil(5), builtintypes.MethodSend, nil), testNow); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	expectRejected(t, p.Authorize(store, message(stranger, types.FromFil(1), builtintypes.MethodSend, nil), testNow), "not in the allowlist")
+}
+
+// TestAuthorizeReplacement 替换消息（同一 nonce、更高的 gas）不重复计入限额，金额更大时按新金额计入
+func TestAuthorizeReplacement(t *testing.T) {
+	p, store := ownerPolicy(t), newTestStore(t)
+	msg := message(allowed, types.FromFil(8), builtintypes.MethodSend, nil)
+	if err := p.Authorize(store, msg, testNow); err != nil {
+		t.Fatalf("original: %v", err)
+	}
+
+	rep := *msg
+	rep.GasPremium = abi.NewTokenAmount(100)
+	if err := p.Authorize(store, &rep, testNow.Add(time.Minute)); err != nil {
+		t.Fatalf("replacement: %v", err)
+	}
+	expectUsage(t, store, types.FromFil(8))
+
+	larger := message(allowed, types.FromFil(10), builtintypes.MethodSend, nil)
+	if err := p.Authorize(store, larger, testNow.Add(time.Minute)); err != nil {
+		t.Fatalf("larger replacement: %v", err)
+	}
+	expectUsage(t, store, types.FromFil(10))
+
+	next := message(allowed, types.FromFil(6), builtintypes.MethodSend, nil)
+	next.Nonce = 1
+	expectRejected(t, p.Authorize(store, next, testNow.Add(time.Hour)), "daily limit")
+	next.Value = types.FromFil(5)
+	if err := p.Authorize(store, next, testNow.Add(time.Hour)); err != nil {
+		t.Fatalf("next message: %v", err)
+	}
+}
+
+// TestAuthorizeReuseNonce 原消息可能已经上链，同一 nonce 的零金额或较小金额消息不能释放其用量
+func TestAuthorizeReuseNonce(t *testing.T) {
+	p, store := ownerPolicy(t), newTestStore(t)
+	if err := p.Authorize(store, message(allowed, types.FromFil(10), builtintypes.MethodSend, nil), testNow); err != nil {
+		t.Fatalf("original: %v", err)
+	}
+
+	for i, value := range []abi.TokenAmount{abi.NewTokenAmount(0), types.FromFil(1)} {
+		reuse := message(allowed, value, builtintypes.MethodSend, nil)
+		reuse.GasPremium = abi.NewTokenAmount(int64(100 + i))
+		if err := p.Authorize(store, reuse, testNow.Add(time.Minute)); err != nil {
+			t.Fatalf("reuse nonce with %s: %v", types.FIL(value), err)
+		}
+		expectUsage(t, store, types.FromFil(10))
+	}
+
+	next := message(allowed, types.FromFil(6), builtintypes.MethodSend, nil)
+	next.Nonce = 1
+	expectRejected(t, p.Authorize(store, next, testNow.Add(time.Hour)), "daily limit")
+}
+
+func expectUsage(t *testing.T, store *repository.Store, want abi.TokenAmount) {
+	t.Helper()
+	used, err := store.SumPolicyUsage(owner.String(), testNow.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("sum usage: %v", err)
+	}
+	if used.Cmp(want.Int) != 0 {
+		t.Errorf("recorded usage = %s, want %s", types.FIL(types.BigInt{Int: used}), types.FIL(want))
+	}
+}
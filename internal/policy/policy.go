@@ -0,0 +1,205 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	logging "github.com/ipfs/go-log/v2"
+
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/chain/types/ethtypes"
+)
+
+var log = logging.Logger("policy")
+
+// File 签名策略文件（TOML）
+// Default 对所有发送方生效；[[Sender]] 中设置的字段覆盖该发送方的默认规则
+type File struct {
+	TimeZone     string        // 时间窗口使用的时区，默认本地时区
+	DenyUnlisted bool          // 拒绝未在 [[Sender]] 中列出的发送方
+	Default      Rules         // 默认规则
+	Sender       []SenderRules // 按发送方设置的规则
+}
+
+// Rules 签名规则，未设置的字段不做限制
+type Rules struct {
+	AllowedTo      []string // 允许的接收方地址（支持 f/t 地址和 0x 地址）
+	AllowedMethods []uint64 // 允许调用的 actor 方法编号
+	MaxValue       string   // 单笔消息最大金额
+	MaxFee         string   // 单笔消息最大手续费（GasFeeCap × GasLimit）
+	DailyLimit     string   // 最近 24 小时累计转账金额上限
+	WeeklyLimit    string   // 最近 7 天累计转账金额上限
+	TimeWindows    []string // 允许签名的时间窗口，例如 "Mon-Fri 09:00-18:00"
+	AllowRawSign   *bool    // 是否允许签名非链上消息数据（区块、交易等），仅远程钱包服务使用
+}
+
+// SenderRules 指定发送方的规则
+type SenderRules struct {
+	Address string
+	Rules
+}
+
+// Policy 解析后的签名策略
+type Policy struct {
+	loc          *time.Location
+	denyUnlisted bool
+	def          *rules
+	senders      map[address.Address]*rules
+}
+
+// rules 解析后的签名规则，nil 字段表示不限制
+type rules struct {
+	allowedTo      map[address.Address]bool
+	allowedMethods map[abi.MethodNum]bool
+	maxValue       *types.BigInt
+	maxFee         *types.BigInt
+	dailyLimit     *types.BigInt
+	weeklyLimit    *types.BigInt
+	windows        []window
+	allowRawSign   bool
+}
+
+// LoadFile 读取并解析策略文件
+func LoadFile(path string) (*Policy, error) {
+	var f File
+	md, err := toml.DecodeFile(path, &f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("unknown keys in policy file %s: %v", path, undecoded)
+	}
+	p, err := New(&f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	log.Infof("LoadFile: loaded signing policy from %s (%d senders)", path, len(p.senders))
+	return p, nil
+}
+
+// New 校验策略定义并生成 Policy
+func New(f *File) (*Policy, error) {
+	loc := time.Local
+	if f.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(f.TimeZone); err != nil {
+			return nil, fmt.Errorf("invalid time zone: %w", err)
+		}
+	}
+
+	def, err := (&rules{}).merge(&f.Default)
+	if err != nil {
+		return nil, fmt.Errorf("default rules: %w", err)
+	}
+
+	p := &Policy{
+		loc:          loc,
+		denyUnlisted: f.DenyUnlisted,
+		def:          def,
+		senders:      make(map[address.Address]*rules, len(f.Sender)),
+	}
+	for _, s := range f.Sender {
+		addr, err := parseAddress(s.Address)
+		if err != nil {
+			return nil, fmt.Errorf("sender %q: %w", s.Address, err)
+		}
+		if _, ok := p.senders[addr]; ok {
+			return nil, fmt.Errorf("duplicate sender %s", addr)
+		}
+		r, err := def.merge(&s.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("sender %s: %w", addr, err)
+		}
+		p.senders[addr] = r
+	}
+	return p, nil
+}
+
+// rulesFor 返回发送方适用的规则，未列出且 DenyUnlisted 时返回 nil
+func (p *Policy) rulesFor(addr address.Address) *rules {
+	if r, ok := p.senders[addr]; ok {
+		return r
+	}
+	if p.denyUnlisted {
+		return nil
+	}
+	return p.def
+}
+
+// merge 以 r 为基础，用 def 中设置的字段覆盖，返回新的规则
+func (r *rules) merge(def *Rules) (*rules, error) {
+	out := *r
+
+	if len(def.AllowedTo) > 0 {
+		out.allowedTo = make(map[address.Address]bool, len(def.AllowedTo))
+		for _, s := range def.AllowedTo {
+			addr, err := parseAddress(s)
+			if err != nil {
+				return nil, fmt.Errorf("allowed destination %q: %w", s, err)
+			}
+			out.allowedTo[addr] = true
+		}
+	}
+	if len(def.AllowedMethods) > 0 {
+		out.allowedMethods = make(map[abi.MethodNum]bool, len(def.AllowedMethods))
+		for _, m := range def.AllowedMethods {
+			out.allowedMethods[abi.MethodNum(m)] = true
+		}
+	}
+
+	for _, v := range []struct {
+		name string
+		in   string
+		out  **types.BigInt
+	}{
+		{"MaxValue", def.MaxValue, &out.maxValue},
+		{"MaxFee", def.MaxFee, &out.maxFee},
+		{"DailyLimit", def.DailyLimit, &out.dailyLimit},
+		{"WeeklyLimit", def.WeeklyLimit, &out.weeklyLimit},
+	} {
+		if v.in == "" {
+			continue
+		}
+		f, err := types.ParseFIL(v.in)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.name, err)
+		}
+		if f.Sign() < 0 {
+			return nil, fmt.Errorf("%s must not be negative", v.name)
+		}
+		amount := types.BigInt(f)
+		*v.out = &amount
+	}
+
+	if len(def.TimeWindows) > 0 {
+		out.windows = make([]window, 0, len(def.TimeWindows))
+		for _, s := range def.TimeWindows {
+			w, err := parseWindow(s)
+			if err != nil {
+				return nil, err
+			}
+			out.windows = append(out.windows, w)
+		}
+	}
+	if def.AllowRawSign != nil {
+		out.allowRawSign = *def.AllowRawSign
+	}
+	return &out, nil
+}
+
+// parseAddress 解析 Filecoin 地址或 0x 以太坊地址
+func parseAddress(s string) (address.Address, error) {
+	s = strings.TrimSpace(s)
+	if ethtypes.IsEthAddress(s) {
+		ea, err := ethtypes.ParseEthAddress(s)
+		if err != nil {
+			return address.Undef, err
+		}
+		return ea.ToFilecoinAddress()
+	}
+	return address.NewFromString(s)
+}
@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	markettypes "github.com/filecoin-project/go-state-types/builtin/v9/market"
+	minertypes "github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	multisigtypes "github.com/filecoin-project/go-state-types/builtin/v9/multisig"
+
+	"wallet-sign/internal/chain/types"
+)
+
+// maxProposalDepth 嵌套多签提案（提案的内容是对另一个多签钱包的提案）的最大解码层数
+const maxProposalDepth = 4
+
+// transfer 消息转出的一笔资金
+// 多签提案的实际接收方和金额在参数中；矿工及市场提现的资金转给 owner（或受益人），接收方不在消息中，To 为 Undef
+type transfer struct {
+	To     address.Address
+	Value  types.BigInt
+	Method abi.MethodNum
+	Source string // 资金来源，用于拒绝原因
+}
+
+// transfers 返回消息转出的所有资金：消息本身，以及参数中的多签提案、矿工提现和市场提现
+// 多签提案的 Approve 执行的交易内容只在链上状态中，不在此检查
+func transfers(msg *types.Message) ([]transfer, error) {
+	value := msg.Value
+	if value.Int == nil {
+		value = types.NewInt(0)
+	}
+	out := []transfer{{To: msg.To, Value: value, Method: msg.Method, Source: "message"}}
+	inner, err := innerTransfers(msg.To, msg.Method, msg.Params, 0)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, inner...), nil
+}
+
+// innerTransfers 解码发送到 to 的方法调用参数中包含的资金转出
+// 多签提案和矿工提现不检查接收方的 actor 类型（离线签名时无法查询链上状态），按方法编号解码，
+// 解码失败时返回错误，避免无法检查的提案绕过策略
+func innerTransfers(to address.Address, method abi.MethodNum, params []byte, depth int) ([]transfer, error) {
+	if to == builtintypes.StorageMarketActorAddr && method == builtintypes.MethodsMarket.WithdrawBalance {
+		var p markettypes.WithdrawBalanceParams
+		if err := p.UnmarshalCBOR(bytes.NewReader(params)); err != nil {
+			return nil, fmt.Errorf("cannot decode market withdrawal params: %w", err)
+		}
+		return []transfer{{Value: p.Amount, Method: method, Source: "market withdrawal"}}, nil
+	}
+	if isSingleton(to) {
+		return nil, nil
+	}
+
+	switch method {
+	case builtintypes.MethodsMultisig.Propose:
+		if depth >= maxProposalDepth {
+			return nil, fmt.Errorf("multisig proposals nested deeper than %d levels", maxProposalDepth)
+		}
+		var p multisigtypes.ProposeParams
+		if err := p.UnmarshalCBOR(bytes.NewReader(params)); err != nil {
+			return nil, fmt.Errorf("cannot decode params of method %d as a multisig proposal: %w", method, err)
+		}
+		value := p.Value
+		if value.Int == nil {
+			value = types.NewInt(0)
+		}
+		out := []transfer{{To: p.To, Value: value, Method: p.Method, Source: fmt.Sprintf("multisig %s proposal", to)}}
+		inner, err := innerTransfers(p.To, p.Method, p.Params, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return append(out, inner...), nil
+	case builtintypes.MethodsMiner.WithdrawBalance:
+		var p minertypes.WithdrawBalanceParams
+		if err := p.UnmarshalCBOR(bytes.NewReader(params)); err != nil {
+			return nil, fmt.Errorf("cannot decode params of method %d as a miner withdrawal: %w", method, err)
+		}
+		return []transfer{{Value: p.AmountRequested, Method: method, Source: fmt.Sprintf("miner %s withdrawal", to)}}, nil
+	}
+	return nil, nil
+}
+
+// isSingleton 检查地址是否为系统内置的单例 actor（f00 - f099）
+func isSingleton(addr address.Address) bool {
+	if addr.Protocol() != address.ID {
+		return false
+	}
+	id, err := address.IDFromAddress(addr)
+	return err == nil && id < builtintypes.FirstNonSingletonActorId
+}
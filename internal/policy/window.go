@@ -0,0 +1,123 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// weekdays 星期缩写
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// window 允许签名的时间窗口
+// start、end 为当天的分钟数，end 小于 start 时表示跨越午夜
+type window struct {
+	spec  string
+	days  [7]bool
+	start int
+	end   int
+}
+
+// parseWindow 解析时间窗口
+// 格式为 "[星期] HH:MM-HH:MM"，星期可以是 "Mon-Fri"、"Sat,Sun"，省略时表示每天
+func parseWindow(s string) (window, error) {
+	w := window{spec: s}
+
+	fields := strings.Fields(s)
+	var span string
+	switch len(fields) {
+	case 1:
+		for i := range w.days {
+			w.days[i] = true
+		}
+		span = fields[0]
+	case 2:
+		if err := w.parseDays(fields[0]); err != nil {
+			return w, fmt.Errorf("time window %q: %w", s, err)
+		}
+		span = fields[1]
+	default:
+		return w, fmt.Errorf("time window %q: expected \"[days] HH:MM-HH:MM\"", s)
+	}
+
+	from, to, ok := strings.Cut(span, "-")
+	if !ok {
+		return w, fmt.Errorf("time window %q: expected HH:MM-HH:MM", s)
+	}
+	var err error
+	if w.start, err = parseClock(from); err != nil {
+		return w, fmt.Errorf("time window %q: %w", s, err)
+	}
+	if w.end, err = parseClock(to); err != nil {
+		return w, fmt.Errorf("time window %q: %w", s, err)
+	}
+	if w.start == w.end {
+		return w, fmt.Errorf("time window %q: start and end must differ", s)
+	}
+	return w, nil
+}
+
+// parseDays 解析星期列表，支持逗号分隔和范围
+func (w *window) parseDays(s string) error {
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok := weekdays[strings.ToLower(from)]
+		if !ok {
+			return fmt.Errorf("unknown weekday %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdays[strings.ToLower(to)]; !ok {
+				return fmt.Errorf("unknown weekday %q", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			w.days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// parseClock 解析 HH:MM，返回当天的分钟数，允许 24:00
+func parseClock(s string) (int, error) {
+	hh, mm, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	h, err := strconv.Atoi(hh)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil || len(mm) != 2 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return h*60 + m, nil
+}
+
+// contains 判断时间是否在窗口内，星期按 t 所在的日期判断
+func (w window) contains(t time.Time) bool {
+	if !w.days[t.Weekday()] {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"wallet-sign/internal/models"
+)
+
+// CreateBatch 保存批量转账及其所有行
+func (s *Store) CreateBatch(batch *models.Batch, rows []*models.BatchRow) error {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		log.Errorf("CreateBatch: failed to create batch %s: %v", batch.ID, err)
+		return err
+	}
+	return nil
+}
+
+// GetBatch 根据 ID 获取批量转账
+func (s *Store) GetBatch(id string) (*models.Batch, error) {
+	var batch models.Batch
+	if err := s.DB.Where("id = ?", id).First(&batch).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// FindBatchByHash 查找使用相同输入文件的最近一次批量转账，不存在时返回 nil
+func (s *Store) FindBatchByHash(hash string) (*models.Batch, error) {
+	var items []*models.Batch
+	if err := s.DB.Where("file_hash = ?", hash).Order("created_at DESC").Limit(1).Find(&items).Error; err != nil {
+		log.Errorf("FindBatchByHash: failed to query batches: %v", err)
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return items[0], nil
+}
+
+// UpdateBatchStatus 更新批量转账状态
+func (s *Store) UpdateBatchStatus(id, status string) error {
+	return s.DB.Model(&models.Batch{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// ListBatchRows 按行号列出批量转账的所有行
+func (s *Store) ListBatchRows(id string) ([]*models.BatchRow, error) {
+	var rows []*models.BatchRow
+	if err := s.DB.Where("batch_id = ?", id).Order("row").Find(&rows).Error; err != nil {
+		log.Errorf("ListBatchRows: failed to query rows of batch %s: %v", id, err)
+		return nil, err
+	}
+	return rows, nil
+}
+
+// SaveBatchRow 保存批量转账行的状态
+func (s *Store) SaveBatchRow(row *models.BatchRow) error {
+	if err := s.DB.Save(row).Error; err != nil {
+		log.Errorf("SaveBatchRow: failed to save row %d of batch %s: %v", row.Row, row.BatchID, err)
+		return err
+	}
+	return nil
+}
@@ -3,39 +3,42 @@ package repository
 import (
 	"encoding/json"
 	"errors"
-	"wallet-sign/internal/config"
+	"strings"
+	appcfg "wallet-sign/internal/config"
 	crypto2 "wallet-sign/internal/crypto"
 	"wallet-sign/internal/models"
 
+	"github.com/filecoin-project/go-address"
 	"gorm.io/gorm"
 
 	"wallet-sign/internal/chain/types"
 )
 
-// 加密密钥（使用 Scrypt + Argon2id 双重派生）
-var encryptionKey []byte
+func (s *Store) SaveWalletKey(addr string, ki types.KeyInfo) error {
+	return s.saveWalletKey(addr, ki, "", nil)
+}
 
-// InitEncryptionKey 初始化加密密钥
-func InitEncryptionKey() {
-	seed := []byte(config.LotusConfig.Security.Seed)
-	salt := crypto2.Hash256(seed)
-	// 使用组合密钥派生函数（Scrypt + Argon2id）
-	key, err := crypto2.GenerateEncryptKey(seed, salt)
-	if err != nil {
-		panic("failed to derive encryption key: " + err.Error())
-	}
-	encryptionKey = key
+// SaveDerivedWalletKey 保存由助记词派生的钱包密钥，同时记录派生路径和账户索引
+func (s *Store) SaveDerivedWalletKey(addr string, ki types.KeyInfo, path string, index uint32) error {
+	return s.saveWalletKey(addr, ki, path, &index)
 }
 
-func (s *Store) SaveWalletKey(addr string, ki types.KeyInfo) error {
+// saveWalletKey 保存钱包密钥，新密钥记录为当前网络使用的密钥，已有密钥保留原来的网络
+func (s *Store) saveWalletKey(addr string, ki types.KeyInfo, path string, index *uint32) error {
 	log.Infof("SaveWalletKey: saving key for address %s, type %s", addr, ki.Type)
+	addr = storedAddress(addr)
+
+	key, err := s.unlockedKey()
+	if err != nil {
+		return err
+	}
 
 	raw, err := json.Marshal(ki)
 	if err != nil {
 		log.Errorf("SaveWalletKey: failed to marshal key info: %v", err)
 		return err
 	}
-	enc, err := crypto2.EncryptGCM(raw, encryptionKey)
+	enc, err := crypto2.EncryptGCM(raw, key)
 	if err != nil {
 		log.Errorf("SaveWalletKey: failed to encrypt key data: %v", err)
 		return err
@@ -46,6 +49,8 @@ func (s *Store) SaveWalletKey(addr string, ki types.KeyInfo) error {
 		log.Infof("SaveWalletKey: updating existing key for %s", addr)
 		existing.KeyType = string(ki.Type)
 		existing.EncryptedKey = enc
+		existing.DerivationPath = path
+		existing.AccountIndex = index
 		if err := s.DB.Save(&existing).Error; err != nil {
 			log.Errorf("SaveWalletKey: failed to update key: %v", err)
 			return err
@@ -59,9 +64,15 @@ func (s *Store) SaveWalletKey(addr string, ki types.KeyInfo) error {
 
 	log.Infof("SaveWalletKey: creating new key record for %s", addr)
 	item := &models.WalletKey{
-		Address:      addr,
-		KeyType:      string(ki.Type),
-		EncryptedKey: enc,
+		Address:        addr,
+		KeyType:        string(ki.Type),
+		EncryptedKey:   enc,
+		DerivationPath: path,
+		AccountIndex:   index,
+	}
+	// 网络未确定时不记录，第一次签名时记录
+	if appcfg.NetworkConfirmed() {
+		item.Network = appcfg.NetworkName()
 	}
 	if err := s.DB.Create(&item).Error; err != nil {
 		log.Errorf("SaveWalletKey: failed to create key: %v", err)
@@ -75,12 +86,18 @@ func (s *Store) GetWalletKey(addr string) (*models.WalletKey, error) {
 	log.Debugf("GetWalletKey: retrieving key for address %s", addr)
 
 	item := &models.WalletKey{}
-	if err := s.DB.Where("address = ?", addr).First(item).Error; err != nil {
+	if err := s.DB.Where("address = ?", storedAddress(addr)).First(item).Error; err != nil {
 		log.Warnf("GetWalletKey: key not found for address %s: %v", addr, err)
 		return nil, err
 	}
+	item.Address = displayAddress(item.Address)
 
-	dnc, err := crypto2.DecryptGCM(item.EncryptedKey, encryptionKey)
+	key, err := s.unlockedKey()
+	if err != nil {
+		return nil, err
+	}
+
+	dnc, err := crypto2.DecryptGCM(item.EncryptedKey, key)
 	if err != nil {
 		log.Errorf("GetWalletKey: failed to decrypt key for %s: %v", addr, err)
 		return nil, err
@@ -95,7 +112,7 @@ func (s *Store) DeleteWalletKey(addr string) error {
 	log.Infof("DeleteWalletKey: deleting key for address %s", addr)
 
 	item := &models.WalletKey{}
-	if err := s.DB.Where("address = ?", addr).First(item).Error; err != nil {
+	if err := s.DB.Where("address = ?", storedAddress(addr)).First(item).Error; err != nil {
 		log.Errorf("DeleteWalletKey: failed to find key for %s: %v", addr, err)
 		return err
 	}
@@ -120,20 +137,28 @@ func (s *Store) GetAllWalletAddresses() ([]*models.WalletKey, error) {
 
 	log.Infof("GetAllWalletAddresses: found %d wallet keys", len(items))
 
+	key, err := s.unlockedKey()
+	if err != nil {
+		return nil, err
+	}
+
 	result := make([]*models.WalletKey, 0, len(items))
 	for _, t := range items {
-		decryptedKey, err := crypto2.DecryptGCM(t.EncryptedKey, encryptionKey)
+		decryptedKey, err := crypto2.DecryptGCM(t.EncryptedKey, key)
 		if err != nil {
 			log.Errorf("GetAllWalletAddresses: failed to decrypt key for %s: %v", t.Address, err)
 			return nil, err
 		}
 
 		wk := &models.WalletKey{
-			Address:      t.Address,
-			KeyType:      string(t.KeyType),
-			EncryptedKey: decryptedKey,
-			CreatedAt:    t.CreatedAt,
-			UpdatedAt:    t.UpdatedAt,
+			Address:        displayAddress(t.Address),
+			KeyType:        string(t.KeyType),
+			EncryptedKey:   decryptedKey,
+			DerivationPath: t.DerivationPath,
+			AccountIndex:   t.AccountIndex,
+			Network:        t.Network,
+			CreatedAt:      t.CreatedAt,
+			UpdatedAt:      t.UpdatedAt,
 		}
 		result = append(result, wk)
 	}
@@ -141,3 +166,68 @@ func (s *Store) GetAllWalletAddresses() ([]*models.WalletKey, error) {
 	log.Infof("GetAllWalletAddresses: successfully retrieved %d wallet keys", len(result))
 	return result, nil
 }
+
+// HasWalletKey 检查数据库中是否存在指定地址的密钥，不需要解锁密钥库
+func (s *Store) HasWalletKey(addr string) (bool, error) {
+	var count int64
+	if err := s.DB.Model(&models.WalletKey{}).Where("address = ?", storedAddress(addr)).Count(&count).Error; err != nil {
+		log.Errorf("HasWalletKey: failed to query key for %s: %v", addr, err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ListWalletKeys 列出所有钱包地址及密钥类型，不解密密钥，不需要解锁密钥库
+func (s *Store) ListWalletKeys() ([]*models.WalletKey, error) {
+	var items []*models.WalletKey
+	if err := s.DB.Select("id", "address", "key_type", "derivation_path", "account_index", "network", "created_at", "updated_at").Find(&items).Error; err != nil {
+		log.Errorf("ListWalletKeys: failed to query wallet keys: %v", err)
+		return nil, err
+	}
+	for _, item := range items {
+		item.Address = displayAddress(item.Address)
+	}
+	return items, nil
+}
+
+// WalletKeyNetwork 返回密钥使用的网络，未记录时返回空字符串，不需要解锁密钥库
+func (s *Store) WalletKeyNetwork(addr string) (string, error) {
+	item := &models.WalletKey{}
+	if err := s.DB.Select("network").Where("address = ?", storedAddress(addr)).First(item).Error; err != nil {
+		log.Errorf("WalletKeyNetwork: failed to find key for %s: %v", addr, err)
+		return "", err
+	}
+	return item.Network, nil
+}
+
+// SetWalletKeyNetwork 记录密钥使用的网络
+func (s *Store) SetWalletKeyNetwork(addr string, network string) error {
+	res := s.DB.Model(&models.WalletKey{}).Where("address = ?", storedAddress(addr)).Update("network", network)
+	if res.Error != nil {
+		log.Errorf("SetWalletKeyNetwork: failed to update network for %s: %v", addr, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	log.Infof("SetWalletKeyNetwork: key for %s is now used on %s", addr, network)
+	return nil
+}
+
+// storedAddress 返回密钥表中保存的地址
+// 同一密钥在主网和测试网上的地址只有前缀不同，统一使用主网前缀保存
+func storedAddress(addr string) string {
+	if strings.HasPrefix(addr, address.TestnetPrefix) {
+		return address.MainnetPrefix + addr[len(address.TestnetPrefix):]
+	}
+	return addr
+}
+
+// displayAddress 将保存的地址转换为当前网络前缀的地址
+func displayAddress(addr string) string {
+	a, err := address.NewFromString(addr)
+	if err != nil {
+		return addr
+	}
+	return a.String()
+}
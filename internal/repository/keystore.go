@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	crypto2 "wallet-sign/internal/crypto"
+	"wallet-sign/internal/models"
+)
+
+const (
+	// keystoreCheck 用于校验口令的明文，加密后保存在 keystore 表中
+	keystoreCheck = "wallet-sign keystore v1"
+	// saltSize 新建密钥库时随机盐值的长度
+	saltSize = 32
+)
+
+var (
+	ErrWrongPassphrase = errors.New("incorrect keystore passphrase")
+	ErrKeystoreMissing = errors.New("keystore metadata missing: configure Security.Seed to migrate the existing database")
+	ErrNoRuntimeDir    = errors.New("XDG_RUNTIME_DIR is not set: unlocked sessions need a private runtime directory")
+)
+
+var (
+	// encryptionKey 当前进程已解锁的加密密钥，由 keyMu 保护
+	encryptionKey []byte
+	keyMu         sync.RWMutex
+)
+
+// cachedKey 返回进程内缓存的加密密钥，未解锁时返回 nil
+func cachedKey() []byte {
+	keyMu.RLock()
+	defer keyMu.RUnlock()
+	return encryptionKey
+}
+
+// setKey 设置进程内缓存的加密密钥，key 为 nil 时清除
+func setKey(key []byte) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	encryptionKey = key
+}
+
+// session 解锁会话文件内容
+type session struct {
+	Key     []byte    `json:"key"`
+	Expires time.Time `json:"expires"`
+}
+
+// unlockedKey 返回加密密钥，必要时解锁密钥库
+// 依次尝试：进程内缓存、wallet unlock 创建的会话、口令来源（环境变量、口令文件、终端输入）
+func (s *Store) unlockedKey() ([]byte, error) {
+	if key := cachedKey(); key != nil {
+		return key, nil
+	}
+
+	ks, err := s.loadKeystore()
+	if err != nil {
+		return nil, err
+	}
+	if ks == nil {
+		return s.initKeystore()
+	}
+
+	if key := s.readSession(ks); key != nil {
+		log.Debug("unlockedKey: using unlocked session")
+		setKey(key)
+		return key, nil
+	}
+
+	pass, err := readPassphrase("Keystore passphrase: ", false)
+	if err != nil {
+		return nil, err
+	}
+	return s.Unlock(pass)
+}
+
+// EnsureUnlocked 确保密钥库已解锁，必要时获取口令
+func (s *Store) EnsureUnlocked() error {
+	_, err := s.unlockedKey()
+	return err
+}
+
+// Unlock 使用口令解锁密钥库
+// 口令错误时返回 ErrWrongPassphrase
+func (s *Store) Unlock(passphrase []byte) ([]byte, error) {
+	ks, err := s.loadKeystore()
+	if err != nil {
+		return nil, err
+	}
+	if ks == nil {
+		return nil, ErrKeystoreMissing
+	}
+
+	key, err := crypto2.GenerateEncryptKey(passphrase, ks.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+	if !checkKey(ks, key) {
+		log.Warn("Unlock: incorrect keystore passphrase")
+		return nil, ErrWrongPassphrase
+	}
+
+	log.Debug("Unlock: keystore unlocked")
+	setKey(key)
+	return key, nil
+}
+
+// UnlockSession 解锁密钥库并在 ttl 时间内保持解锁状态
+// 会话文件保存在 $XDG_RUNTIME_DIR/wallet-sign-<uid> 中，权限为 0600，wallet lock 时删除；
+// 未设置 XDG_RUNTIME_DIR 时拒绝创建会话，避免把加密密钥写入共享的临时目录
+func (s *Store) UnlockSession(ttl time.Duration) error {
+	key, err := s.unlockedKey()
+	if err != nil {
+		return err
+	}
+
+	dir, err := sessionDir(true)
+	if err != nil {
+		log.Errorf("UnlockSession: %v", err)
+		return err
+	}
+	data, err := json.Marshal(&session{Key: key, Expires: time.Now().Add(ttl)})
+	if err != nil {
+		return err
+	}
+	log.Infof("UnlockSession: keystore unlocked until %s", time.Now().Add(ttl).Format(time.RFC3339))
+	return os.WriteFile(filepath.Join(dir, s.sessionName()), data, 0600)
+}
+
+// Lock 锁定密钥库
+// 删除解锁会话并清除进程内缓存的加密密钥
+func (s *Store) Lock() error {
+	setKey(nil)
+	if dir, err := sessionDir(false); err == nil {
+		if err := os.Remove(filepath.Join(dir, s.sessionName())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	log.Info("Lock: keystore locked")
+	return nil
+}
+
+// ChangePassphrase 修改密钥库口令
+// 在同一事务中使用新口令派生的密钥重新加密所有钱包密钥，任何一步失败都不会修改数据库
+func (s *Store) ChangePassphrase(newPassphrase []byte) error {
+	oldKey, err := s.unlockedKey()
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+	newKey, err := crypto2.GenerateEncryptKey(newPassphrase, salt)
+	if err != nil {
+		return fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+	check, err := crypto2.EncryptGCM([]byte(keystoreCheck), newKey)
+	if err != nil {
+		return err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var items []models.WalletKey
+		if err := tx.Find(&items).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			raw, err := crypto2.DecryptGCM(items[i].EncryptedKey, oldKey)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt key for %s: %w", items[i].Address, err)
+			}
+			enc, err := crypto2.EncryptGCM(raw, newKey)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&items[i]).Update("encrypted_key", enc).Error; err != nil {
+				return err
+			}
+		}
+
+		var ks models.Keystore
+		if err := tx.First(&ks).Error; err != nil {
+			return err
+		}
+		ks.Salt = salt
+		ks.Check = check
+		if err := tx.Save(&ks).Error; err != nil {
+			return err
+		}
+
+		log.Infof("ChangePassphrase: re-encrypted %d wallet keys", len(items))
+		return nil
+	})
+	if err != nil {
+		log.Errorf("ChangePassphrase: failed to change passphrase: %v", err)
+		return err
+	}
+
+	return s.Lock()
+}
+
+// initKeystore 初始化密钥库元数据
+// 配置了 Security.Seed 的旧数据库沿用种子派生的盐值，新数据库使用随机盐值并提示设置口令
+func (s *Store) initKeystore() ([]byte, error) {
+	var (
+		salt []byte
+		pass []byte
+		err  error
+	)
+	if seed := legacySeed(); seed != "" {
+		log.Warn("initKeystore: migrating keystore from Security.Seed, run 'keystore change-passphrase' and remove the seed from config")
+		pass = []byte(seed)
+		salt = crypto2.Hash256(pass)
+	} else {
+		var count int64
+		if err := s.DB.Model(&models.WalletKey{}).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, ErrKeystoreMissing
+		}
+
+		salt = make([]byte, saltSize)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, err
+		}
+		pass, err = readPassphrase("New keystore passphrase: ", true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := crypto2.GenerateEncryptKey(pass, salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+	check, err := crypto2.EncryptGCM([]byte(keystoreCheck), key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.Create(&models.Keystore{Salt: salt, Check: check}).Error; err != nil {
+		log.Errorf("initKeystore: failed to create keystore: %v", err)
+		return nil, err
+	}
+
+	log.Info("initKeystore: keystore initialized")
+	setKey(key)
+	return key, nil
+}
+
+// loadKeystore 读取密钥库元数据，不存在时返回 nil
+func (s *Store) loadKeystore() (*models.Keystore, error) {
+	var ks models.Keystore
+	err := s.DB.First(&ks).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ks, nil
+}
+
+// readSession 读取未过期的解锁会话，会话无效时返回 nil
+// 会话目录不安全（见 sessionDir）时不读取会话
+func (s *Store) readSession(ks *models.Keystore) []byte {
+	dir, err := sessionDir(false)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) && !errors.Is(err, ErrNoRuntimeDir) {
+			log.Warnf("readSession: ignoring unlocked session: %v", err)
+		}
+		return nil
+	}
+	path := filepath.Join(dir, s.sessionName())
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var sess session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil
+	}
+	if time.Now().After(sess.Expires) || !checkKey(ks, sess.Key) {
+		_ = os.Remove(path)
+		return nil
+	}
+	return sess.Key
+}
+
+// sessionName 返回当前数据库对应的解锁会话文件名
+func (s *Store) sessionName() string {
+	abs, err := filepath.Abs(s.path)
+	if err != nil {
+		abs = s.path
+	}
+	return fmt.Sprintf("%x.session", crypto2.Hash256([]byte(abs))[:8])
+}
+
+// sessionDir 返回保存解锁会话的目录 $XDG_RUNTIME_DIR/wallet-sign-<uid>，create 为 true 时按需创建
+// 目录必须是当前用户所有、权限为 0700 的目录（不能是符号链接），否则返回错误
+func sessionDir(create bool) (string, error) {
+	runtime := os.Getenv("XDG_RUNTIME_DIR")
+	if runtime == "" {
+		return "", ErrNoRuntimeDir
+	}
+	dir := filepath.Join(runtime, fmt.Sprintf("wallet-sign-%d", os.Getuid()))
+	if create {
+		if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+			return "", err
+		}
+	}
+
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		return "", fmt.Errorf("session directory %s is a symbolic link", dir)
+	}
+	if !fi.IsDir() {
+		return "", fmt.Errorf("session directory %s is not a directory", dir)
+	}
+	if uid, ok := fileOwner(fi); !ok || uid != os.Getuid() {
+		return "", fmt.Errorf("session directory %s is not owned by the current user", dir)
+	}
+	if perm := fi.Mode().Perm(); perm != 0700 {
+		return "", fmt.Errorf("session directory %s has mode %#o, want 0700", dir, perm)
+	}
+	return dir, nil
+}
+
+// checkKey 校验加密密钥是否能解开密钥库的校验密文
+func checkKey(ks *models.Keystore, key []byte) bool {
+	plain, err := crypto2.DecryptGCM(ks.Check, key)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(plain, []byte(keystoreCheck)) == 1
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"wallet-sign/internal/models"
+)
+
+// SaveMessage 保存消息记录
+// 已存在相同 UnsignedCid 的记录时只更新 item 中的非零字段
+func (s *Store) SaveMessage(item *models.Message) error {
+	var existing models.Message
+	err := s.DB.Where("unsigned_cid = ?", item.UnsignedCid).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := s.DB.Create(item).Error; err != nil {
+			log.Errorf("SaveMessage: failed to create message %s: %v", item.UnsignedCid, err)
+			return err
+		}
+		return nil
+	} else if err != nil {
+		log.Errorf("SaveMessage: database error when checking message %s: %v", item.UnsignedCid, err)
+		return err
+	}
+
+	if err := s.DB.Model(&existing).Updates(item).Error; err != nil {
+		log.Errorf("SaveMessage: failed to update message %s: %v", item.UnsignedCid, err)
+		return err
+	}
+	return nil
+}
+
+// GetMessage 按链上 CID 或未签名消息 CID 查找消息记录
+func (s *Store) GetMessage(msgCid string) (*models.Message, error) {
+	item := &models.Message{}
+	if err := s.DB.Where("cid = ? OR unsigned_cid = ?", msgCid, msgCid).First(item).Error; err != nil {
+		log.Warnf("GetMessage: message %s not found: %v", msgCid, err)
+		return nil, err
+	}
+	return item, nil
+}
+
+// ListMessages 按时间倒序列出消息记录，status 或 from 为空时不过滤，limit 为 0 时不限制数量
+func (s *Store) ListMessages(status, from string, limit int) ([]*models.Message, error) {
+	q := s.DB.Order("id DESC")
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if from != "" {
+		q = q.Where("from_addr = ?", from)
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	var items []*models.Message
+	if err := q.Find(&items).Error; err != nil {
+		log.Errorf("ListMessages: failed to query messages: %v", err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// PendingMessages 列出已签名或已推送但尚未确认上链的消息记录
+func (s *Store) PendingMessages() ([]*models.Message, error) {
+	var items []*models.Message
+	if err := s.DB.Where("status IN ?", []string{models.MessageStatusSigned, models.MessageStatusPushed}).
+		Order("id").Find(&items).Error; err != nil {
+		log.Errorf("PendingMessages: failed to query messages: %v", err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// MessageByNonce 查找发送方使用指定 nonce 的最新消息记录，不存在时返回 nil
+func (s *Store) MessageByNonce(from string, nonce uint64) (*models.Message, error) {
+	var items []*models.Message
+	if err := s.DB.Where("from_addr = ? AND nonce = ?", from, nonce).Order("id DESC").Limit(1).Find(&items).Error; err != nil {
+		log.Errorf("MessageByNonce: failed to query message for %s nonce %d: %v", from, nonce, err)
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return items[0], nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"wallet-sign/internal/models"
+)
+
+// ReserveNonce 预留发送方的 nonce，已被其他进程预留时返回 false
+func (s *Store) ReserveNonce(addr string, nonce uint64) (bool, error) {
+	res := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.NonceReservation{
+		Address: addr,
+		Nonce:   nonce,
+	})
+	if res.Error != nil {
+		log.Errorf("ReserveNonce: failed to reserve nonce %d for %s: %v", nonce, addr, res.Error)
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// RenewNonceReservation 更新预留时间，用于重新分配已失效的预留
+func (s *Store) RenewNonceReservation(addr string, nonce uint64) error {
+	return s.DB.Model(&models.NonceReservation{}).
+		Where("address = ? AND nonce = ?", addr, nonce).
+		Update("created_at", time.Now()).Error
+}
+
+// ReleaseNonce 删除 nonce 预留，使其可以被重新分配
+func (s *Store) ReleaseNonce(addr string, nonce uint64) error {
+	if err := s.DB.Where("address = ? AND nonce = ?", addr, nonce).Delete(&models.NonceReservation{}).Error; err != nil {
+		log.Errorf("ReleaseNonce: failed to release nonce %d for %s: %v", nonce, addr, err)
+		return err
+	}
+	return nil
+}
+
+// PruneNonceReservations 删除小于 below 的预留，这些 nonce 已经进入内存池或上链
+func (s *Store) PruneNonceReservations(addr string, below uint64) error {
+	return s.DB.Where("address = ? AND nonce < ?", addr, below).Delete(&models.NonceReservation{}).Error
+}
+
+// ClearNonceReservations 删除发送方的所有预留
+func (s *Store) ClearNonceReservations(addr string) (int64, error) {
+	res := s.DB.Where("address = ?", addr).Delete(&models.NonceReservation{})
+	return res.RowsAffected, res.Error
+}
+
+// ListNonceReservations 按 nonce 升序列出发送方的预留
+func (s *Store) ListNonceReservations(addr string) ([]*models.NonceReservation, error) {
+	var items []*models.NonceReservation
+	if err := s.DB.Where("address = ?", addr).Order("nonce").Find(&items).Error; err != nil {
+		log.Errorf("ListNonceReservations: failed to query reservations for %s: %v", addr, err)
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package repository
+
+import "os"
+
+// fileOwner 无法获取文件所有者，解锁会话不可用
+func fileOwner(os.FileInfo) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"wallet-sign/internal/config"
+)
+
+const (
+	// PassphraseEnv 密钥库口令环境变量
+	PassphraseEnv = "WALLET_SIGN_PASSPHRASE"
+	// NewPassphraseEnv 修改口令时新口令的环境变量
+	NewPassphraseEnv = "WALLET_SIGN_NEW_PASSPHRASE"
+)
+
+// readPassphrase 获取密钥库口令
+// 优先级：环境变量、Security.PassphraseFile、Security.Seed（已弃用）、终端输入
+func readPassphrase(prompt string, confirm bool) ([]byte, error) {
+	if pass, ok := os.LookupEnv(PassphraseEnv); ok {
+		log.Debugf("readPassphrase: using passphrase from %s", PassphraseEnv)
+		return []byte(pass), nil
+	}
+
+	if sec := config.LotusConfig.Security; sec != nil && sec.PassphraseFile != "" {
+		data, err := os.ReadFile(sec.PassphraseFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		log.Debugf("readPassphrase: using passphrase file %s", sec.PassphraseFile)
+		return bytes.TrimRight(data, "\r\n"), nil
+	}
+
+	if seed := legacySeed(); seed != "" {
+		log.Warn("readPassphrase: Security.Seed is deprecated, run 'keystore change-passphrase' and remove it from config")
+		return []byte(seed), nil
+	}
+
+	return promptPassphrase(prompt, confirm)
+}
+
+// ReadNewPassphrase 获取新的密钥库口令
+// 优先使用 WALLET_SIGN_NEW_PASSPHRASE 环境变量，否则在终端输入并确认
+func ReadNewPassphrase() ([]byte, error) {
+	if pass, ok := os.LookupEnv(NewPassphraseEnv); ok {
+		return []byte(pass), nil
+	}
+	return promptPassphrase("New keystore passphrase: ", true)
+}
+
+// promptPassphrase 在终端中输入口令（不回显）
+func promptPassphrase(prompt string, confirm bool) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("keystore is locked: set %s, configure Security.PassphraseFile or run 'wallet unlock'", PassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	pass, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if len(pass) == 0 {
+		return nil, errors.New("passphrase must not be empty")
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(pass, again) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+	return pass, nil
+}
+
+// legacySeed 返回配置中已弃用的加密种子
+func legacySeed() string {
+	if sec := config.LotusConfig.Security; sec != nil {
+		return strings.TrimSpace(sec.Seed)
+	}
+	return ""
+}
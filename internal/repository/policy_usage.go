@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"wallet-sign/internal/models"
+)
+
+// HasPolicyUsage 检查消息是否已经记录过用量
+func (s *Store) HasPolicyUsage(msgCid string) (bool, error) {
+	var count int64
+	if err := s.DB.Model(&models.PolicyUsage{}).Where("msg_cid = ?", msgCid).Count(&count).Error; err != nil {
+		log.Errorf("HasPolicyUsage: failed to query usage for %s: %v", msgCid, err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// PolicyUsageByNonce 返回发送方 nonce 已记录的用量，没有记录时返回 nil
+// 签名同一 nonce 的新消息（例如提高 gas 的替换消息）时用于找到之前签名的消息
+func (s *Store) PolicyUsageByNonce(addr string, nonce uint64) (*models.PolicyUsage, error) {
+	var item models.PolicyUsage
+	err := s.DB.Where("address = ? AND nonce = ?", addr, nonce).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Errorf("PolicyUsageByNonce: failed to query usage for %s nonce %d: %v", addr, nonce, err)
+		return nil, err
+	}
+	return &item, nil
+}
+
+// RecordPolicyUsage 记录通过策略检查的消息金额及签名时间，同一消息重复签名时只记录一次
+// 同一发送方同一 nonce 之前记录的消息会被取代，在同一事务中删除，避免替换消息重复计入限额；
+// 调用方只在新消息的金额大于之前记录的金额时调用，之前的用量不会因此减少
+func (s *Store) RecordPolicyUsage(addr, msgCid string, nonce uint64, value *big.Int, at time.Time) error {
+	item := &models.PolicyUsage{
+		Address:   addr,
+		Nonce:     &nonce,
+		MsgCid:    msgCid,
+		Value:     value.String(),
+		CreatedAt: at,
+	}
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("address = ? AND nonce = ? AND msg_cid <> ?", addr, nonce, msgCid).Delete(&models.PolicyUsage{}).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(item).Error
+	})
+	if err != nil {
+		log.Errorf("RecordPolicyUsage: failed to record usage for %s: %v", msgCid, err)
+		return err
+	}
+	return nil
+}
+
+// SumPolicyUsage 统计发送方自 since 以来记录的转账总额（attoFIL）
+func (s *Store) SumPolicyUsage(addr string, since time.Time) (*big.Int, error) {
+	var values []string
+	if err := s.DB.Model(&models.PolicyUsage{}).
+		Where("address = ? AND created_at >= ?", addr, since).
+		Pluck("value", &values).Error; err != nil {
+		log.Errorf("SumPolicyUsage: failed to query usage for %s: %v", addr, err)
+		return nil, err
+	}
+
+	total := new(big.Int)
+	for _, v := range values {
+		n, ok := new(big.Int).SetString(v, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid usage value %q for %s", v, addr)
+		}
+		total.Add(total, n)
+	}
+	return total, nil
+}
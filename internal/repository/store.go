@@ -16,7 +16,8 @@ var log = logging.Logger("repository")
 // Store 数据存储结构
 // 封装了 GORM 数据库连接，提供数据访问功能
 type Store struct {
-	DB *gorm.DB // GORM 数据库实例
+	DB   *gorm.DB // GORM 数据库实例
+	path string   // SQLite 数据库文件路径
 }
 
 // OpenStore 打开数据库存储
@@ -59,11 +60,26 @@ func OpenStore(dbPath string) (*Store, error) {
 	// 自动迁移所有数据表
 	if err = db.AutoMigrate(
 		&models.WalletKey{},
+		&models.Keystore{},
+		&models.PolicyUsage{},
+		&models.Message{},
+		&models.NonceReservation{},
+		&models.Batch{},
+		&models.BatchRow{},
+		&models.SweepAction{},
 	); err != nil {
 		log.Errorf("OpenStore: auto migration failed: %v", err)
 		return nil, err
 	}
 
+	// SQLite 同一时间只允许一个写入者，并发签名、批量等待时共用一个连接避免 database is locked
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Errorf("OpenStore: failed to get database handle: %v", err)
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+
 	log.Debugf("OpenStore: SQLite database opened successfully at %s", dbPath)
-	return &Store{DB: db}, nil
+	return &Store{DB: db, path: dbPath}, nil
 }
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"gorm.io/gorm/clause"
+
+	"wallet-sign/internal/models"
+)
+
+// sweepUnfinished 未完成的自动归集动作状态
+var sweepUnfinished = []string{models.SweepActionPending, models.SweepActionSigned, models.SweepActionPushed}
+
+// CreateSweepAction 记录自动归集动作，相同 IdemKey 的动作已存在时返回 false
+func (s *Store) CreateSweepAction(action *models.SweepAction) (bool, error) {
+	res := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(action)
+	if res.Error != nil {
+		log.Errorf("CreateSweepAction: failed to create action %s: %v", action.IdemKey, res.Error)
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// SaveSweepAction 保存自动归集动作的状态
+func (s *Store) SaveSweepAction(action *models.SweepAction) error {
+	if err := s.DB.Save(action).Error; err != nil {
+		log.Errorf("SaveSweepAction: failed to save action %d: %v", action.ID, err)
+		return err
+	}
+	return nil
+}
+
+// UnfinishedSweepActions 返回规则对指定对象尚未完成的动作
+func (s *Store) UnfinishedSweepActions(rule, kind, subject string) ([]*models.SweepAction, error) {
+	var items []*models.SweepAction
+	err := s.DB.Where("rule = ? AND kind = ? AND subject = ? AND status IN ?", rule, kind, subject, sweepUnfinished).
+		Order("id").Find(&items).Error
+	if err != nil {
+		log.Errorf("UnfinishedSweepActions: failed to query actions of rule %s: %v", rule, err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// ListSweepActions 按时间倒序列出自动归集动作，rule 为空时不过滤，limit 为 0 时返回全部
+func (s *Store) ListSweepActions(rule string, limit int) ([]*models.SweepAction, error) {
+	q := s.DB.Order("id DESC")
+	if rule != "" {
+		q = q.Where("rule = ?", rule)
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	var items []*models.SweepAction
+	if err := q.Find(&items).Error; err != nil {
+		log.Errorf("ListSweepActions: failed to query actions: %v", err)
+		return nil, err
+	}
+	return items, nil
+}
@@ -4,23 +4,39 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	logging "github.com/ipfs/go-log/v2"
 
 	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/metrics"
 )
 
 var log = logging.Logger("rpc")
 
 // Client represents a JSON-RPC client for communicating with Lotus API endpoints.
 // It handles authentication, request formatting, and response parsing.
+// When several endpoints are configured it fails over between them, see endpoint.go.
 type Client struct {
-	url    string
-	token  string
-	client *http.Client
+	endpoints []*endpoint
+	health    healthSettings
+	retry     retrySettings
+	client    *http.Client
+	nextID    atomic.Int64 // JSON-RPC request ID, unique per client
+
+	mu        sync.Mutex
+	active    *endpoint // endpoint that receives requests until it fails
+	pins      int       // operations that must stay on the active endpoint
+	lastCheck time.Time
+	checking  bool
 }
 
 // jsonRPCRequest represents a JSON-RPC 2.0 request structure.
@@ -28,7 +44,7 @@ type jsonRPCRequest struct {
 	Jsonrpc string        `json:"jsonrpc"`
 	Method  string        `json:"method"`
 	Params  []interface{} `json:"params"`
-	ID      int           `json:"id"`
+	ID      int64         `json:"id,omitempty"`
 }
 
 // jsonRPCResponse represents a JSON-RPC 2.0 response structure.
@@ -36,7 +52,7 @@ type jsonRPCResponse struct {
 	Jsonrpc string          `json:"jsonrpc"`
 	Result  json.RawMessage `json:"result"`
 	Error   *jsonRPCError   `json:"error,omitempty"`
-	ID      int             `json:"id"`
+	ID      int64           `json:"id"`
 }
 
 // jsonRPCError represents a JSON-RPC 2.0 error object.
@@ -45,98 +61,202 @@ type jsonRPCError struct {
 	Message string `json:"message"`
 }
 
-// NewLotusApi creates a new Lotus API client using environment variables for configuration.
-// It reads LOTUS_API_URL (defaults to Glif public node) and LOTUS_API_TOKEN for authentication.
+// newTransport returns an HTTP transport that bounds connecting to an endpoint. Requests
+// themselves are bounded by the per-method timeout.
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	t.TLSHandshakeTimeout = dialTimeout
+	return t
+}
+
+// NewLotusApi creates a new Lotus API client from the [Lotus] section of the config.
+// Lotus.Host is the preferred endpoint, Lotus.Endpoints are used for failover.
 func NewLotusApi() *Client {
 	log.Info("NewLotusApi: initializing Lotus API client")
 
-	apiURL := appcfg.LotusConfig.Lotus.Host
-
-	apiToken := appcfg.LotusConfig.Lotus.Token
+	endpoints, health := loadEndpoints(appcfg.LotusConfig.Lotus)
+	for _, ep := range endpoints {
+		if ep.token != "" {
+			log.Infof("NewLotusApi: using endpoint %s (priority %d, with token)", ep.url, ep.priority)
+		} else {
+			log.Warnf("NewLotusApi: using endpoint %s (priority %d, no token)", ep.url, ep.priority)
+		}
+	}
 
-	if apiToken != "" {
-		log.Infof("NewLotusApi: connecting to %s (with token)", apiURL)
-		fmt.Printf("Connecting to %s (with token)\n", apiURL)
+	active := endpoints[0]
+	if active.token != "" {
+		fmt.Fprintf(os.Stderr, "Connecting to %s (with token)", active.url)
 	} else {
-		log.Warnf("NewLotusApi: connecting to %s (no token)", apiURL)
-		fmt.Printf("Connecting to %s (no token)\n", apiURL)
+		fmt.Fprintf(os.Stderr, "Connecting to %s (no token)", active.url)
 	}
+	if len(endpoints) > 1 {
+		fmt.Fprintf(os.Stderr, ", %d failover endpoint(s)", len(endpoints)-1)
+	}
+	fmt.Fprintln(os.Stderr)
 
 	return &Client{
-		url:    apiURL,
-		token:  apiToken,
-		client: &http.Client{},
+		endpoints: endpoints,
+		health:    health,
+		retry:     loadRetrySettings(appcfg.LotusConfig.Lotus),
+		client:    &http.Client{Transport: newTransport()},
+		active:    active,
 	}
 }
 
 // Call executes a JSON-RPC method call on the Lotus API.
-// The method name is automatically prefixed with "Filecoin.".
+// The method name is automatically prefixed with "Filecoin.". params follow the v1 API
+// signature of the method; they are rewritten for endpoints serving the v0 API (/rpc/v0).
+// Endpoints with a ws:// or wss:// URL share one WebSocket connection for all calls,
+// other endpoints use one HTTP request per call.
 // If result is not nil, the response will be unmarshaled into it.
-func (c *Client) Call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+func (c *Client) Call(ctx context.Context, method string, params []interface{}, result interface{}) (err error) {
 	log.Debugf("Call: calling RPC method %s with %d params", method, len(params))
+	start := time.Now()
+	defer func() { metrics.ObserveRPC(method, start, err) }()
+
+	policy := policyFor(method)
+	for attempt := 1; ; attempt++ {
+		err = c.callEndpoints(ctx, method, params, result, policy)
+		if err == nil || policy != retryBackoff || !IsTransient(err) || attempt > c.retry.retries || ctx.Err() != nil {
+			return err
+		}
+		log.Warnf("Call: %s failed, retrying (%d/%d): %v", method, attempt, c.retry.retries, err)
+		if c.Backoff(ctx, attempt) != nil {
+			return err
+		}
+	}
+}
+
+// callEndpoints tries the endpoints in order until one of them serves the request.
+func (c *Client) callEndpoints(ctx context.Context, method string, params []interface{}, result interface{}, policy retryPolicy) (err error) {
+	c.maybeCheckHealth(ctx)
+	for _, ep := range c.candidates() {
+		err = c.callEndpoint(ctx, ep, method, params, result)
+		c.recordResult(ep, err)
+		if !isEndpointError(err) || ctx.Err() != nil {
+			return err
+		}
+		if policy == retryNever && !notSent(err) {
+			// the node may have applied the request, sending it elsewhere could apply it twice
+			return err
+		}
+		if len(c.endpoints) > 1 {
+			log.Warnf("Call: endpoint %s failed for %s, trying next endpoint: %v", ep.url, method, err)
+		}
+	}
+	return err
+}
 
-	reqBody := jsonRPCRequest{
+// newRequest builds a request with a new ID.
+func (c *Client) newRequest(method string, params []interface{}) jsonRPCRequest {
+	return jsonRPCRequest{
 		Jsonrpc: "2.0",
 		Method:  "Filecoin." + method,
 		Params:  params,
-		ID:      1,
+		ID:      c.nextID.Add(1),
+	}
+}
+
+// callEndpoint executes a JSON-RPC method call on a single endpoint, bounded by the timeout
+// of the method. Failures of the endpoint itself are returned as *NetworkError or *HTTPError,
+// errors returned by Lotus as *RPCError. The first request to an endpoint checks the network of
+// the node, see verifyNetwork.
+func (c *Client) callEndpoint(ctx context.Context, ep *endpoint, method string, params []interface{}, result interface{}) error {
+	if err := c.verifyNetwork(ctx, ep, method); err != nil {
+		return err
+	}
+	timeout := c.retry.timeoutFor(method)
+	if timeout <= 0 {
+		return c.send(ctx, ep, method, params, result)
+	}
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := c.send(cctx, ep, method, params, result)
+	if err != nil && ctx.Err() == nil && errors.Is(cctx.Err(), context.DeadlineExceeded) {
+		log.Errorf("callEndpoint: %s timed out on %s after %s", method, ep.url, timeout)
+		return &NetworkError{ep.url, OpReceive, fmt.Errorf("%s timed out after %s", method, timeout)}
+	}
+	return err
+}
+
+// send sends the request over the transport of the endpoint. params are in the shape of the
+// v1 API and are converted for v0 endpoints.
+func (c *Client) send(ctx context.Context, ep *endpoint, method string, params []interface{}, result interface{}) error {
+	reqBody := c.newRequest(method, ep.paramsFor(method, params))
+	if ep.isWebSocket() {
+		wc, err := ep.webSocket(ctx)
+		if err != nil {
+			return err
+		}
+		f, err := wc.call(ctx, reqBody, nil)
+		if err != nil {
+			log.Errorf("send: %s failed on %s: %v", method, ep.url, err)
+			return err
+		}
+		return decodeResult(method, f.Error, f.Result, result)
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		log.Errorf("Call: failed to marshal request: %v", err)
+		log.Errorf("send: failed to marshal request: %v", err)
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", ep.url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		log.Errorf("Call: failed to create request: %v", err)
+		log.Errorf("send: failed to create request: %v", err)
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
+	if ep.token != "" {
+		req.Header.Set("Authorization", "Bearer "+ep.token)
 	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		log.Errorf("Call: failed to send request to %s: %v", c.url, err)
-		return fmt.Errorf("failed to send request: %w", err)
+		log.Errorf("send: failed to send request to %s: %v", ep.url, err)
+		return sendError(ep.url, err)
 	}
 	defer resp.Body.Close()
 
 	// Check HTTP status code
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		log.Errorf("Call: HTTP error %d: %s", resp.StatusCode, string(body))
-		return fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
+		log.Errorf("send: HTTP error %d: %s", resp.StatusCode, string(body))
+		return &HTTPError{ep.url, resp.StatusCode, string(body)}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Errorf("Call: failed to read response: %v", err)
-		return fmt.Errorf("failed to read response: %w", err)
+		log.Errorf("send: failed to read response: %v", err)
+		return &NetworkError{ep.url, OpReceive, err}
 	}
 
 	var rpcResp jsonRPCResponse
 	if err := json.Unmarshal(body, &rpcResp); err != nil {
-		log.Errorf("Call: failed to unmarshal response: %v", err)
-		return fmt.Errorf("failed to unmarshal response: %w", err)
+		log.Errorf("send: failed to unmarshal response: %v", err)
+		return &NetworkError{ep.url, OpDecode, err}
 	}
 
-	if rpcResp.Error != nil {
-		log.Errorf("Call: RPC error for method %s: %s (code: %d)", method, rpcResp.Error.Message, rpcResp.Error.Code)
-		return fmt.Errorf("RPC error: %s (code: %d)", rpcResp.Error.Message, rpcResp.Error.Code)
+	return decodeResult(method, rpcResp.Error, rpcResp.Result, result)
+}
+
+// decodeResult returns the JSON-RPC error of a response, or unmarshals its result into result.
+func decodeResult(method string, rpcErr *jsonRPCError, raw json.RawMessage, result interface{}) error {
+	if rpcErr != nil {
+		log.Errorf("decodeResult: RPC error for method %s: %s (code: %d)", method, rpcErr.Message, rpcErr.Code)
+		return &RPCError{Method: method, Code: rpcErr.Code, Message: rpcErr.Message}
 	}
 
 	if result != nil {
-		if err := json.Unmarshal(rpcResp.Result, result); err != nil {
-			log.Errorf("Call: failed to unmarshal result for method %s: %v", method, err)
+		if err := json.Unmarshal(raw, result); err != nil {
+			log.Errorf("decodeResult: failed to unmarshal result for method %s: %v", method, err)
 			return fmt.Errorf("failed to unmarshal result: %w", err)
 		}
 	}
 
-	log.Debugf("Call: successfully called %s", method)
+	log.Debugf("decodeResult: successfully called %s", method)
 	return nil
 }
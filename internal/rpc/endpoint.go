@@ -0,0 +1,285 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/metrics"
+)
+
+// Default health check settings.
+const (
+	defaultHealthInterval = 30 * time.Second
+	defaultMaxHeadLag     = 3
+	defaultStaleAfter     = 3 * time.Minute
+
+	// healthCheckTimeout bounds the ChainHead call of a single endpoint during a health check.
+	healthCheckTimeout = 10 * time.Second
+
+	// An endpoint is unhealthy when more than errorRateLimit of its last errorWindow requests
+	// failed, once at least errorMinSamples requests were made.
+	errorWindow     = 20
+	errorMinSamples = 5
+	errorRateLimit  = 0.5
+)
+
+// endpoint is a single Lotus node and its health state.
+type endpoint struct {
+	url      string
+	token    string
+	priority int         // lower is preferred, ties keep config order
+	version  apiVersion  // API version, from the URL path
+	verified atomic.Bool // the node is on the selected network, see verifyNetwork
+
+	// The fields below are guarded by Client.mu.
+	healthy    bool
+	reason     string // why the endpoint is unhealthy
+	height     int64
+	advancedAt time.Time // when the head height last increased
+	outcomes   []bool    // results of recent requests, true means failed
+
+	wsMu sync.Mutex
+	ws   *wsConn // connection of a ws:// or wss:// endpoint, dialed on first use
+}
+
+// record adds the result of a request. Only failures of the endpoint itself count.
+func (ep *endpoint) record(failed bool) {
+	ep.outcomes = append(ep.outcomes, failed)
+	if len(ep.outcomes) > errorWindow {
+		ep.outcomes = ep.outcomes[len(ep.outcomes)-errorWindow:]
+	}
+}
+
+// errorRate returns the failure rate of recent requests, or 0 when there are too few samples.
+func (ep *endpoint) errorRate() float64 {
+	if len(ep.outcomes) < errorMinSamples {
+		return 0
+	}
+	n := 0
+	for _, failed := range ep.outcomes {
+		if failed {
+			n++
+		}
+	}
+	return float64(n) / float64(len(ep.outcomes))
+}
+
+// healthSettings controls when an endpoint is considered unhealthy.
+type healthSettings struct {
+	interval   time.Duration
+	maxHeadLag int64
+	staleAfter time.Duration
+}
+
+// loadEndpoints reads the endpoints and health settings from config, sorted by priority.
+func loadEndpoints(cfg *appcfg.Lotus) ([]*endpoint, healthSettings) {
+	eps := []*endpoint{{url: cfg.Host, token: cfg.Token, version: versionOf(cfg.Host), healthy: true}}
+	for _, e := range cfg.Endpoints {
+		if e == nil || e.Host == "" {
+			continue
+		}
+		eps = append(eps, &endpoint{url: e.Host, token: e.Token, priority: e.Priority, version: versionOf(e.Host), healthy: true})
+	}
+	sort.SliceStable(eps, func(i, j int) bool { return eps[i].priority < eps[j].priority })
+
+	hs := healthSettings{interval: defaultHealthInterval, maxHeadLag: defaultMaxHeadLag, staleAfter: defaultStaleAfter}
+	if cfg.HealthInterval != "" {
+		if d, err := time.ParseDuration(cfg.HealthInterval); err != nil || d <= 0 {
+			log.Warnf("loadEndpoints: invalid Lotus.HealthInterval %q, using %s", cfg.HealthInterval, hs.interval)
+		} else {
+			hs.interval = d
+		}
+	}
+	if cfg.MaxHeadLag > 0 {
+		hs.maxHeadLag = cfg.MaxHeadLag
+	}
+	if cfg.StaleAfter != "" {
+		if d, err := time.ParseDuration(cfg.StaleAfter); err != nil || d <= 0 {
+			log.Warnf("loadEndpoints: invalid Lotus.StaleAfter %q, using %s", cfg.StaleAfter, hs.staleAfter)
+		} else {
+			hs.staleAfter = d
+		}
+	}
+	return eps, hs
+}
+
+// candidates returns the endpoints to try for a request: the active endpoint first (sticky),
+// then healthy endpoints by priority, then unhealthy ones.
+func (c *Client) candidates() []*endpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]*endpoint, 0, len(c.endpoints))
+	out = append(out, c.active)
+	var rest []*endpoint
+	for _, ep := range c.endpoints {
+		if ep != c.active {
+			rest = append(rest, ep)
+		}
+	}
+	sort.SliceStable(rest, func(i, j int) bool { return rest[i].healthy && !rest[j].healthy })
+	return append(out, rest...)
+}
+
+// recordResult records the result of a request. An endpoint that served a request after
+// the active one failed becomes the active endpoint.
+func (c *Client) recordResult(ep *endpoint, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ep.record(isEndpointError(err))
+	if err != nil && isEndpointError(err) {
+		// re-check all endpoints on the next request
+		c.lastCheck = time.Time{}
+		return
+	}
+	if ep != c.active {
+		c.switchTo(ep, fmt.Sprintf("%s failed", c.active.url))
+	}
+}
+
+// switchTo changes the active endpoint. The caller holds c.mu.
+func (c *Client) switchTo(ep *endpoint, reason string) {
+	if c.pins > 0 {
+		log.Warnf("switchTo: switching Lotus endpoint from %s to %s while %d operation(s) are pinned (%s), nonce and mempool state may differ",
+			c.active.url, ep.url, c.pins, reason)
+	} else {
+		log.Warnf("switchTo: switching Lotus endpoint from %s to %s (%s)", c.active.url, ep.url, reason)
+	}
+	c.active = ep
+}
+
+// Pin keeps requests on the active endpoint until the returned function is called: health
+// checks will not move back to a preferred endpoint in the meantime. It is used around nonce
+// assignment, gas estimation and push of one message so they reach the same node.
+// A failing endpoint is still abandoned.
+func (c *Client) Pin() func() {
+	c.mu.Lock()
+	c.pins++
+	c.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			c.pins--
+			c.mu.Unlock()
+		})
+	}
+}
+
+// maybeCheckHealth checks all endpoints when the last check is older than the interval.
+// Nothing is checked with a single endpoint, or while another request is checking.
+func (c *Client) maybeCheckHealth(ctx context.Context) {
+	if len(c.endpoints) < 2 {
+		return
+	}
+	c.mu.Lock()
+	due := !c.checking && time.Since(c.lastCheck) >= c.health.interval
+	if due {
+		c.checking = true
+	}
+	c.mu.Unlock()
+	if !due {
+		return
+	}
+	c.checkHealth(ctx)
+}
+
+// checkHealth queries the head of all endpoints concurrently and updates their health.
+// An unhealthy active endpoint is replaced by the preferred healthy one; when no operation
+// is pinned, a preferred endpoint that became healthy again is switched back to.
+func (c *Client) checkHealth(ctx context.Context) {
+	type head struct {
+		height int64
+		err    error
+	}
+	heads := make([]head, len(c.endpoints))
+	var wg sync.WaitGroup
+	for i, ep := range c.endpoints {
+		wg.Add(1)
+		go func(i int, ep *endpoint) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			var ts struct {
+				Height int64 `json:"Height"`
+			}
+			err := c.callEndpoint(cctx, ep, "ChainHead", []interface{}{}, &ts)
+			heads[i] = head{ts.Height, err}
+		}(i, ep)
+	}
+	wg.Wait()
+
+	now := time.Now()
+	var best int64
+	for _, h := range heads {
+		if h.err == nil && h.height > best {
+			best = h.height
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checking = false
+	c.lastCheck = now
+	for i, ep := range c.endpoints {
+		h := heads[i]
+		ep.record(h.err != nil && isEndpointError(h.err))
+		switch {
+		case h.err != nil:
+			ep.setHealth(false, fmt.Sprintf("ChainHead failed: %v", h.err))
+			continue
+		case h.height > ep.height:
+			ep.height = h.height
+			ep.advancedAt = now
+		case ep.advancedAt.IsZero():
+			ep.advancedAt = now
+		}
+		switch {
+		case best-h.height > c.health.maxHeadLag:
+			ep.setHealth(false, fmt.Sprintf("head %d is %d epochs behind %d", h.height, best-h.height, best))
+		case now.Sub(ep.advancedAt) > c.health.staleAfter:
+			ep.setHealth(false, fmt.Sprintf("head %d has not advanced for %s", h.height, now.Sub(ep.advancedAt).Truncate(time.Second)))
+		case ep.errorRate() > errorRateLimit:
+			ep.setHealth(false, fmt.Sprintf("error rate %.0f%%", ep.errorRate()*100))
+		default:
+			ep.setHealth(true, "")
+		}
+	}
+
+	var preferred *endpoint
+	for _, ep := range c.endpoints {
+		if ep.healthy {
+			preferred = ep
+			break
+		}
+	}
+	switch {
+	case preferred == nil || preferred == c.active:
+	case !c.active.healthy:
+		c.switchTo(preferred, fmt.Sprintf("%s is unhealthy: %s", c.active.url, c.active.reason))
+	case c.pins == 0 && preferred.priority < c.active.priority:
+		c.switchTo(preferred, fmt.Sprintf("%s is healthy again", preferred.url))
+	}
+}
+
+// setHealth updates the health of the endpoint and logs changes.
+func (ep *endpoint) setHealth(healthy bool, reason string) {
+	if ep.healthy != healthy {
+		if healthy {
+			log.Infof("setHealth: Lotus endpoint %s is healthy", ep.url)
+		} else {
+			log.Warnf("setHealth: Lotus endpoint %s is unhealthy: %s", ep.url, reason)
+		}
+	}
+	ep.healthy = healthy
+	ep.reason = reason
+	v := 0.0
+	if healthy {
+		v = 1
+	}
+	metrics.RPCEndpointHealthy.WithLabelValues(ep.url).Set(v)
+}
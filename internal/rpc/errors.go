@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Operations reported by NetworkError.
+const (
+	OpConnect = "connect"          // the request was not sent
+	OpSend    = "send request"     // the request may or may not have reached the node
+	OpReceive = "receive response" // the request was sent, the response was lost or timed out
+	OpDecode  = "decode response"  // the response is not valid JSON-RPC
+)
+
+// RPCError is an error returned by Lotus in the JSON-RPC response, such as "actor not found".
+// The request reached the node and failed there, it is neither retried nor sent to another endpoint.
+type RPCError struct {
+	Method  string
+	Code    int
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("RPC error: %s (code: %d)", e.Message, e.Code)
+}
+
+// HTTPError is a response with a status other than 200, typically from a gateway or proxy.
+type HTTPError struct {
+	Endpoint   string
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP error %d: %s", e.StatusCode, e.Body)
+}
+
+// NetworkError is a failure to exchange the request with the endpoint: connection failures,
+// timeouts, lost WebSocket connections and unparsable responses.
+type NetworkError struct {
+	Endpoint string
+	Op       string
+	Err      error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("failed to %s: %v", e.Op, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+// IsTransient reports whether err is a failure that may go away when the request is repeated:
+// network errors and HTTP 408, 429 and 5xx. RPC errors are never transient.
+func IsTransient(err error) bool {
+	var ne *NetworkError
+	if errors.As(err, &ne) {
+		return true
+	}
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he.StatusCode == http.StatusRequestTimeout || he.StatusCode == http.StatusTooManyRequests || he.StatusCode >= 500
+	}
+	return false
+}
+
+// IsRPCError reports whether err is an error returned by Lotus for the request.
+func IsRPCError(err error) bool {
+	var re *RPCError
+	return errors.As(err, &re)
+}
+
+// isEndpointError reports whether err is a failure of the endpoint rather than of the request.
+// Such requests are tried on the next endpoint.
+func isEndpointError(err error) bool {
+	var ne *NetworkError
+	var he *HTTPError
+	return errors.As(err, &ne) || errors.As(err, &he)
+}
+
+// notSent reports whether the request certainly did not reach the endpoint.
+func notSent(err error) bool {
+	var ne *NetworkError
+	return errors.As(err, &ne) && ne.Op == OpConnect
+}
+
+// sendError classifies an error of http.Client.Do: dial failures mean the request was not sent.
+func sendError(url string, err error) *NetworkError {
+	var oe *net.OpError
+	if errors.As(err, &oe) && oe.Op == "dial" {
+		return &NetworkError{url, OpConnect, err}
+	}
+	return &NetworkError{url, OpSend, err}
+}
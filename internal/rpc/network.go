@@ -0,0 +1,32 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	appcfg "wallet-sign/internal/config"
+)
+
+// methodNetworkName returns the network the node is on.
+const methodNetworkName = "StateNetworkName"
+
+// verifyNetwork checks, before the first request sent to an endpoint, that the node is on the
+// selected network (see appcfg.VerifyNetwork), so that keys, address prefixes and chain IDs of
+// one network are never used against a node of another. A node on the wrong network fails
+// every request; failing to ask the node is an endpoint error and is retried on the next request.
+func (c *Client) verifyNetwork(ctx context.Context, ep *endpoint, method string) error {
+	if method == methodNetworkName || ep.verified.Load() {
+		return nil
+	}
+	var name string
+	if err := c.callEndpoint(ctx, ep, methodNetworkName, []interface{}{}, &name); err != nil {
+		log.Errorf("verifyNetwork: failed to get network name from %s: %v", ep.url, err)
+		return err
+	}
+	if err := appcfg.VerifyNetwork(name); err != nil {
+		log.Errorf("verifyNetwork: endpoint %s: %v", ep.url, err)
+		return fmt.Errorf("endpoint %s: %w", ep.url, err)
+	}
+	ep.verified.Store(true)
+	return nil
+}
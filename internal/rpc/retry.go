@@ -0,0 +1,145 @@
+package rpc
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	appcfg "wallet-sign/internal/config"
+)
+
+// Default timeout and retry settings.
+const (
+	defaultTimeout         = 30 * time.Second
+	defaultRetries         = 3
+	defaultRetryBackoff    = 500 * time.Millisecond
+	defaultRetryMaxBackoff = 10 * time.Second
+
+	// dialTimeout bounds connecting to an endpoint, including the TLS and WebSocket handshakes.
+	dialTimeout = 10 * time.Second
+)
+
+// defaultMethodTimeouts overrides defaultTimeout for methods that are slow by design.
+// Zero means no timeout, the call is only bounded by its context.
+var defaultMethodTimeouts = map[string]time.Duration{
+	"StateWaitMsg": 0,
+	"StateReplay":  2 * time.Minute,
+}
+
+// nonIdempotent lists methods that change state. They are not retried, and are only sent to
+// another endpoint when the request certainly did not reach the first one.
+var nonIdempotent = map[string]bool{
+	"MpoolPush":          true,
+	"MpoolPushUntrusted": true,
+	"MpoolPushMessage":   true,
+	"MpoolBatchPush":     true,
+}
+
+// retryPolicy says what Call does after a failure of the endpoint.
+type retryPolicy int
+
+const (
+	retryFailover retryPolicy = iota // try the other endpoints once
+	retryBackoff                     // try the other endpoints, then repeat with backoff
+	retryNever                       // only try another endpoint when the request was not sent
+)
+
+// policyFor returns the retry policy of a method. Read methods (State*, Chain*, Gas*) are
+// idempotent and retried with backoff.
+func policyFor(method string) retryPolicy {
+	switch {
+	case nonIdempotent[method]:
+		return retryNever
+	case strings.HasPrefix(method, "State"), strings.HasPrefix(method, "Chain"), strings.HasPrefix(method, "Gas"):
+		return retryBackoff
+	default:
+		return retryFailover
+	}
+}
+
+// retrySettings controls timeouts and retries of requests.
+type retrySettings struct {
+	timeout    time.Duration
+	timeouts   map[string]time.Duration
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// loadRetrySettings reads the timeout and retry settings from config.
+func loadRetrySettings(cfg *appcfg.Lotus) retrySettings {
+	rs := retrySettings{
+		timeout:    defaultTimeout,
+		timeouts:   map[string]time.Duration{},
+		retries:    defaultRetries,
+		backoff:    defaultRetryBackoff,
+		maxBackoff: defaultRetryMaxBackoff,
+	}
+	for m, d := range defaultMethodTimeouts {
+		rs.timeouts[m] = d
+	}
+	parse := func(key, value string, dst *time.Duration) {
+		if value == "" {
+			return
+		}
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			log.Warnf("loadRetrySettings: invalid Lotus.%s %q, using %s", key, value, *dst)
+		} else {
+			*dst = d
+		}
+	}
+	parse("Timeout", cfg.Timeout, &rs.timeout)
+	for m, v := range cfg.Timeouts {
+		d := rs.timeout
+		if cur, ok := rs.timeouts[m]; ok {
+			d = cur
+		}
+		parse("Timeouts."+m, v, &d)
+		rs.timeouts[m] = d
+	}
+	switch {
+	case cfg.Retries < 0:
+		rs.retries = 0
+	case cfg.Retries > 0:
+		rs.retries = cfg.Retries
+	}
+	parse("RetryBackoff", cfg.RetryBackoff, &rs.backoff)
+	parse("RetryMaxBackoff", cfg.RetryMaxBackoff, &rs.maxBackoff)
+	return rs
+}
+
+// timeoutFor returns the timeout of a single request of method, zero for none.
+func (rs retrySettings) timeoutFor(method string) time.Duration {
+	if d, ok := rs.timeouts[method]; ok {
+		return d
+	}
+	return rs.timeout
+}
+
+// Retries returns how many times a failed request is repeated.
+func (c *Client) Retries() int {
+	return c.retry.retries
+}
+
+// Backoff waits before retry number attempt (starting at 1): the delay doubles with each
+// attempt up to the configured maximum, and is randomized between half and all of it so that
+// clients do not retry in lockstep. It returns early with the context error.
+func (c *Client) Backoff(ctx context.Context, attempt int) error {
+	d := c.retry.backoff
+	for i := 1; i < attempt && d < c.retry.maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, c.retry.maxBackoff)
+	if d > 0 {
+		d = d/2 + rand.N(d/2+1)
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
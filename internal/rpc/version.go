@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"net/url"
+	"strings"
+)
+
+// apiVersion is the Lotus JSON-RPC API version served by an endpoint.
+type apiVersion int
+
+const (
+	apiV1 apiVersion = iota // /rpc/v1, the current API
+	apiV0                   // /rpc/v0, the legacy API
+)
+
+func (v apiVersion) String() string {
+	if v == apiV0 {
+		return "v0"
+	}
+	return "v1"
+}
+
+// versionOf returns the API version of an endpoint from its URL path. Lotus serves the legacy
+// API under /rpc/v0; any other path, including gateways without a version, is treated as v1.
+func versionOf(rawURL string) apiVersion {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return apiV1
+	}
+	if strings.HasSuffix(strings.TrimSuffix(u.Path, "/"), "/rpc/v0") {
+		return apiV0
+	}
+	return apiV1
+}
+
+// v0Params converts the v1 parameter list of methods whose signature changed in the v1 API.
+// The node rejects a request with the wrong number of parameters, so callers always pass the
+// v1 parameters and they are rewritten when the request goes to a v0 endpoint.
+var v0Params = map[string]func(params []interface{}) []interface{}{
+	// v1: (tsk, cid, limit, allowReplaced), v0: (cid)
+	"StateSearchMsg": func(params []interface{}) []interface{} { return params[1:2] },
+	// v1: (cid, confidence, limit, allowReplaced), v0: (cid, confidence)
+	"StateWaitMsg": func(params []interface{}) []interface{} { return params[:2] },
+}
+
+// paramsFor returns the parameters of method for the API version of the endpoint.
+func (ep *endpoint) paramsFor(method string, params []interface{}) []interface{} {
+	if ep.version != apiV0 {
+		return params
+	}
+	if convert, ok := v0Params[method]; ok {
+		return convert(params)
+	}
+	return params
+}
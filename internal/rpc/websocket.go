@@ -0,0 +1,433 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"wallet-sign/internal/metrics"
+)
+
+// Methods of the Lotus (go-jsonrpc) WebSocket protocol used for channels and cancellation.
+const (
+	wsChanValue = "xrpc.ch.val"   // server sends a value on a channel: params [chanID, value]
+	wsChanClose = "xrpc.ch.close" // server closed a channel: params [chanID]
+	wsCancel    = "xrpc.cancel"   // client cancels a request or subscription: params [requestID]
+)
+
+const (
+	// wsPingInterval keeps idle connections alive through proxies.
+	wsPingInterval = 30 * time.Second
+	// wsWriteTimeout bounds a single write to the connection.
+	wsWriteTimeout = 10 * time.Second
+)
+
+// ErrSubscriptionsUnsupported is returned by Subscribe when no WebSocket endpoint is configured.
+var ErrSubscriptionsUnsupported = errors.New("subscriptions require a ws:// or wss:// Lotus endpoint")
+
+// isWebSocket reports whether requests to the endpoint use the WebSocket transport.
+func (ep *endpoint) isWebSocket() bool {
+	return strings.HasPrefix(ep.url, "ws://") || strings.HasPrefix(ep.url, "wss://")
+}
+
+// webSocket returns the connection of the endpoint, dialing a new one when there is none
+// or the previous one was lost.
+func (ep *endpoint) webSocket(ctx context.Context) (*wsConn, error) {
+	ep.wsMu.Lock()
+	defer ep.wsMu.Unlock()
+	if ep.ws != nil && ep.ws.alive() {
+		return ep.ws, nil
+	}
+	wc, err := dialWebSocket(ctx, ep)
+	if err != nil {
+		return nil, err
+	}
+	ep.ws = wc
+	return wc, nil
+}
+
+// wsFrame is a message on the WebSocket connection: a response to one of our requests,
+// or a request from the server (channel values and channel close).
+type wsFrame struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+// wsCall is a request waiting for its response.
+type wsCall struct {
+	resp chan *wsFrame // receives the response, closed when the connection is lost
+	sub  *subscription // set when the response carries a channel ID
+}
+
+// wsConn is a WebSocket connection to one endpoint. Any number of requests can be in flight,
+// responses are matched to requests by ID.
+type wsConn struct {
+	url  string
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[int64]*wsCall       // by request ID
+	subs    map[int64]*subscription // by channel ID
+	err     error                   // set once the connection is lost
+	done    chan struct{}
+}
+
+// dialWebSocket connects to the endpoint and starts reading from the connection.
+func dialWebSocket(ctx context.Context, ep *endpoint) (*wsConn, error) {
+	header := http.Header{}
+	if ep.token != "" {
+		header.Set("Authorization", "Bearer "+ep.token)
+	}
+	dialer := *websocket.DefaultDialer
+	dialer.HandshakeTimeout = dialTimeout
+	conn, resp, err := dialer.DialContext(ctx, ep.url, header)
+	if err != nil {
+		if resp != nil {
+			err = fmt.Errorf("%w (HTTP %d)", err, resp.StatusCode)
+		}
+		log.Errorf("dialWebSocket: failed to connect to %s: %v", ep.url, err)
+		return nil, &NetworkError{ep.url, OpConnect, err}
+	}
+	log.Infof("dialWebSocket: connected to %s", ep.url)
+
+	wc := &wsConn{
+		url:     ep.url,
+		conn:    conn,
+		pending: map[int64]*wsCall{},
+		subs:    map[int64]*subscription{},
+		done:    make(chan struct{}),
+	}
+	go wc.readLoop()
+	go wc.pingLoop()
+	return wc, nil
+}
+
+// alive reports whether the connection can still be used.
+func (wc *wsConn) alive() bool {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	return wc.err == nil
+}
+
+// call sends a request and waits for its response. When sub is set, the channel ID in the
+// response is registered before any value on the channel is read.
+func (wc *wsConn) call(ctx context.Context, req jsonRPCRequest, sub *subscription) (*wsFrame, error) {
+	call := &wsCall{resp: make(chan *wsFrame, 1), sub: sub}
+	wc.mu.Lock()
+	if wc.err != nil {
+		err := wc.err
+		wc.mu.Unlock()
+		return nil, &NetworkError{wc.url, OpConnect, err}
+	}
+	wc.pending[req.ID] = call
+	wc.mu.Unlock()
+
+	if err := wc.write(req); err != nil {
+		wc.fail(fmt.Errorf("failed to send request: %w", err))
+		return nil, &NetworkError{wc.url, OpSend, err}
+	}
+
+	select {
+	case f, ok := <-call.resp:
+		if !ok {
+			wc.mu.Lock()
+			err := wc.err
+			wc.mu.Unlock()
+			return nil, &NetworkError{wc.url, OpReceive, err}
+		}
+		return f, nil
+	case <-ctx.Done():
+		wc.mu.Lock()
+		delete(wc.pending, req.ID)
+		wc.mu.Unlock()
+		wc.cancel(req.ID)
+		return nil, ctx.Err()
+	}
+}
+
+// cancel asks the server to stop a request or subscription. Errors are ignored: a lost
+// connection cancels everything anyway.
+func (wc *wsConn) cancel(id int64) {
+	if !wc.alive() {
+		return
+	}
+	err := wc.write(jsonRPCRequest{Jsonrpc: "2.0", Method: wsCancel, Params: []interface{}{id}})
+	if err != nil {
+		log.Debugf("cancel: failed to cancel request %d on %s: %v", id, wc.url, err)
+	}
+}
+
+// write sends one message. Writes are serialized, the connection allows a single writer.
+func (wc *wsConn) write(v interface{}) error {
+	wc.writeMu.Lock()
+	defer wc.writeMu.Unlock()
+	_ = wc.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return wc.conn.WriteJSON(v)
+}
+
+// readLoop dispatches responses and channel notifications until the connection is lost.
+func (wc *wsConn) readLoop() {
+	for {
+		_, data, err := wc.conn.ReadMessage()
+		if err != nil {
+			wc.fail(fmt.Errorf("connection lost: %w", err))
+			return
+		}
+		var f wsFrame
+		if err := json.Unmarshal(data, &f); err != nil {
+			log.Warnf("readLoop: ignoring unparsable message from %s: %v", wc.url, err)
+			continue
+		}
+		if f.Method != "" {
+			wc.handleRequest(&f)
+			continue
+		}
+		if f.ID == nil {
+			continue
+		}
+
+		wc.mu.Lock()
+		call, ok := wc.pending[*f.ID]
+		delete(wc.pending, *f.ID)
+		if ok && call.sub != nil && f.Error == nil {
+			if err := json.Unmarshal(f.Result, &call.sub.chanID); err == nil {
+				wc.subs[call.sub.chanID] = call.sub
+			}
+		}
+		wc.mu.Unlock()
+		if ok {
+			call.resp <- &f
+		}
+	}
+}
+
+// handleRequest handles a request from the server. Only channel values and channel close
+// are expected, both are notifications that need no response.
+func (wc *wsConn) handleRequest(f *wsFrame) {
+	var params []json.RawMessage
+	var chanID int64
+	if err := json.Unmarshal(f.Params, &params); err != nil || len(params) == 0 {
+		log.Warnf("handleRequest: invalid %s params from %s", f.Method, wc.url)
+		return
+	}
+	if err := json.Unmarshal(params[0], &chanID); err != nil {
+		log.Warnf("handleRequest: invalid %s channel ID from %s", f.Method, wc.url)
+		return
+	}
+
+	wc.mu.Lock()
+	sub := wc.subs[chanID]
+	if f.Method == wsChanClose {
+		delete(wc.subs, chanID)
+	}
+	wc.mu.Unlock()
+
+	switch {
+	case sub == nil:
+		log.Debugf("handleRequest: %s for unknown channel %d", f.Method, chanID)
+	case f.Method == wsChanValue && len(params) > 1:
+		sub.push(params[1])
+	case f.Method == wsChanClose:
+		sub.close()
+	default:
+		log.Debugf("handleRequest: ignoring %s from %s", f.Method, wc.url)
+	}
+}
+
+// pingLoop pings the server so that idle connections are not dropped.
+func (wc *wsConn) pingLoop() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-wc.done:
+			return
+		case <-ticker.C:
+			wc.writeMu.Lock()
+			err := wc.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+			wc.writeMu.Unlock()
+			if err != nil {
+				wc.fail(fmt.Errorf("ping failed: %w", err))
+				return
+			}
+		}
+	}
+}
+
+// fail marks the connection as lost: pending requests fail with an endpoint error and
+// subscriptions are closed. The next request dials a new connection.
+func (wc *wsConn) fail(err error) {
+	wc.mu.Lock()
+	if wc.err != nil {
+		wc.mu.Unlock()
+		return
+	}
+	wc.err = err
+	pending, subs := wc.pending, wc.subs
+	wc.pending, wc.subs = map[int64]*wsCall{}, map[int64]*subscription{}
+	wc.mu.Unlock()
+
+	log.Warnf("fail: WebSocket connection to %s closed: %v", wc.url, err)
+	close(wc.done)
+	_ = wc.conn.Close()
+	for _, call := range pending {
+		close(call.resp)
+	}
+	for _, sub := range subs {
+		sub.close()
+	}
+}
+
+// forget removes a subscription, e.g. after its consumer went away.
+func (wc *wsConn) forget(sub *subscription) {
+	wc.mu.Lock()
+	delete(wc.subs, sub.chanID)
+	wc.mu.Unlock()
+}
+
+// subscription is a channel opened by a method such as ChainNotify. Values are queued so
+// that a slow consumer does not block the read loop of the connection.
+type subscription struct {
+	reqID  int64 // request that opened the channel, used to cancel it
+	chanID int64 // set by the read loop from the response
+
+	out    chan json.RawMessage
+	notify chan struct{}
+
+	mu     sync.Mutex
+	queue  []json.RawMessage
+	closed bool
+}
+
+func newSubscription(reqID int64) *subscription {
+	return &subscription{
+		reqID:  reqID,
+		out:    make(chan json.RawMessage),
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// push queues a value from the server.
+func (s *subscription) push(v json.RawMessage) {
+	s.mu.Lock()
+	if !s.closed {
+		s.queue = append(s.queue, v)
+	}
+	s.mu.Unlock()
+	s.wake()
+}
+
+// close closes the subscription once queued values are delivered.
+func (s *subscription) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.wake()
+}
+
+func (s *subscription) wake() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers queued values to out until the server closes the channel, the connection
+// is lost or ctx is done. In the last case the subscription is cancelled on the server.
+func (s *subscription) run(ctx context.Context, wc *wsConn) {
+	defer close(s.out)
+	for {
+		s.mu.Lock()
+		queue, closed := s.queue, s.closed
+		s.queue = nil
+		s.mu.Unlock()
+
+		for _, v := range queue {
+			select {
+			case s.out <- v:
+			case <-ctx.Done():
+				wc.forget(s)
+				wc.cancel(s.reqID)
+				return
+			}
+		}
+		if closed {
+			return
+		}
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			wc.forget(s)
+			wc.cancel(s.reqID)
+			return
+		}
+	}
+}
+
+// Subscribe calls a Lotus method that returns a channel, such as ChainNotify, and returns
+// the values sent on it. Subscriptions need a WebSocket endpoint; ErrSubscriptionsUnsupported
+// is returned when none is configured. The channel is closed when ctx is done, the server
+// closes it or the connection is lost, callers resubscribe or fall back to polling.
+func (c *Client) Subscribe(ctx context.Context, method string, params []interface{}) (ch <-chan json.RawMessage, err error) {
+	log.Debugf("Subscribe: subscribing to %s", method)
+	start := time.Now()
+	defer func() { metrics.ObserveRPC(method, start, err) }()
+
+	c.maybeCheckHealth(ctx)
+	err = ErrSubscriptionsUnsupported
+	for _, ep := range c.candidates() {
+		if !ep.isWebSocket() {
+			continue
+		}
+		ch, err = c.subscribeEndpoint(ctx, ep, method, params)
+		c.mu.Lock()
+		ep.record(isEndpointError(err))
+		c.mu.Unlock()
+		if err == nil || !isEndpointError(err) || ctx.Err() != nil {
+			return ch, err
+		}
+		log.Warnf("Subscribe: endpoint %s failed for %s, trying next endpoint: %v", ep.url, method, err)
+	}
+	return nil, err
+}
+
+// subscribeEndpoint opens a subscription on a single WebSocket endpoint.
+func (c *Client) subscribeEndpoint(ctx context.Context, ep *endpoint, method string, params []interface{}) (<-chan json.RawMessage, error) {
+	if err := c.verifyNetwork(ctx, ep, method); err != nil {
+		return nil, err
+	}
+	wc, err := ep.webSocket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := c.newRequest(method, ep.paramsFor(method, params))
+	sub := newSubscription(req.ID)
+	f, err := wc.call(ctx, req, sub)
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeResult(method, f.Error, nil, nil); err != nil {
+		return nil, err
+	}
+	var chanID int64
+	if err := json.Unmarshal(f.Result, &chanID); err != nil {
+		log.Errorf("subscribeEndpoint: %s did not return a channel: %s", method, string(f.Result))
+		return nil, fmt.Errorf("%s did not return a channel: %w", method, err)
+	}
+	go sub.run(ctx, wc)
+	log.Debugf("subscribeEndpoint: subscribed to %s on %s (channel %d)", method, ep.url, chanID)
+	return sub.out, nil
+}
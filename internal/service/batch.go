@@ -0,0 +1,512 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/ipfs/go-cid"
+
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/models"
+	"wallet-sign/internal/wallet"
+)
+
+// BatchEntry 批量转账输入文件中的一行，Error 为解析或检查错误
+type BatchEntry struct {
+	Row    int             `json:"row"`
+	From   address.Address `json:"from"`
+	To     address.Address `json:"to"`
+	Amount types.FIL       `json:"amount"`
+	Memo   string          `json:"memo,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// BatchSenderCheck 发送方的余额检查结果
+type BatchSenderCheck struct {
+	From         address.Address `json:"from"`
+	Rows         int             `json:"rows"`
+	Total        types.FIL       `json:"total"`
+	EstimatedFee types.FIL       `json:"estimated_fee"`
+	Balance      types.FIL       `json:"balance"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// BatchCheck 批量转账预检查报告
+type BatchCheck struct {
+	Entries []*BatchEntry       `json:"entries"`
+	Senders []*BatchSenderCheck `json:"senders"`
+	Errors  int                 `json:"errors"`
+}
+
+// OK 检查是否全部通过
+func (c *BatchCheck) OK() bool {
+	return c.Errors == 0
+}
+
+// BatchReport 批量转账执行报告
+type BatchReport struct {
+	Batch *models.Batch      `json:"batch"`
+	Rows  []*models.BatchRow `json:"rows"`
+}
+
+// CheckBatch 在发送前检查批量转账
+// 检查每行的金额、重复行，以及每个发送方是否持有私钥、余额是否足够支付转账金额和预估手续费
+func (e *Executor) CheckBatch(entries []*BatchEntry, allowDuplicates bool) (*BatchCheck, error) {
+	check := &BatchCheck{Entries: entries}
+	seen := make(map[string]int)
+	senders := make(map[address.Address]*BatchSenderCheck)
+	samples := make(map[address.Address]*BatchEntry)
+
+	for _, entry := range entries {
+		if entry.Error == "" {
+			switch {
+			case entry.Amount.Int == nil || entry.Amount.Sign() <= 0:
+				entry.Error = "amount must be positive"
+			case entry.From == entry.To:
+				entry.Error = "sender and recipient are the same"
+			}
+		}
+		if entry.Error == "" && !allowDuplicates {
+			key := strings.Join([]string{entry.From.String(), entry.To.String(), entry.Amount.Int.String(), entry.Memo}, "|")
+			if prev, ok := seen[key]; ok {
+				entry.Error = fmt.Sprintf("duplicate of row %d", prev)
+			} else {
+				seen[key] = entry.Row
+			}
+		}
+		if entry.Error != "" {
+			check.Errors++
+			continue
+		}
+
+		sc, ok := senders[entry.From]
+		if !ok {
+			sc = &BatchSenderCheck{
+				From:         entry.From,
+				Total:        types.FIL(types.NewInt(0)),
+				EstimatedFee: types.FIL(types.NewInt(0)),
+				Balance:      types.FIL(types.NewInt(0)),
+			}
+			senders[entry.From] = sc
+			samples[entry.From] = entry
+			check.Senders = append(check.Senders, sc)
+		}
+		sc.Rows++
+		sc.Total = types.FIL(types.BigAdd(types.BigInt(sc.Total), types.BigInt(entry.Amount)))
+	}
+
+	for _, sc := range check.Senders {
+		e.checkBatchSender(sc, samples[sc.From])
+		if sc.Error != "" {
+			check.Errors++
+		}
+	}
+
+	log.Infof("CheckBatch: checked %d rows from %d senders, %d errors", len(entries), len(check.Senders), check.Errors)
+	return check, nil
+}
+
+// checkBatchSender 检查发送方是否持有私钥，余额是否足够
+// 以发送方的第一笔转账估算单条消息的最大手续费
+func (e *Executor) checkBatchSender(sc *BatchSenderCheck, sample *BatchEntry) {
+	pm, err := e.prepare(&Payload{
+		Type:     RequestTypeTransfer,
+		FromAddr: sample.From,
+		ToAddr:   sample.To,
+		Amount:   sample.Amount,
+	}, false)
+	if err != nil {
+		sc.Error = err.Error()
+		return
+	}
+	msg := pm.Message
+
+	has, err := wallet.WalletHas(e.store, msg.From)
+	if err != nil {
+		sc.Error = err.Error()
+		return
+	}
+	if !has {
+		sc.Error = fmt.Sprintf("wallet does not have key for %s", msg.From)
+		return
+	}
+
+	fee := types.BigMul(msg.GasFeeCap, types.NewInt(uint64(msg.GasLimit)))
+	sc.EstimatedFee = types.FIL(types.BigMul(fee, types.NewInt(uint64(sc.Rows))))
+
+	balance, err := e.node.WalletBalance(msg.From)
+	if err != nil {
+		sc.Error = err.Error()
+		return
+	}
+	sc.Balance = types.FIL(balance)
+
+	need := types.BigAdd(types.BigInt(sc.Total), types.BigInt(sc.EstimatedFee))
+	if need.GreaterThan(balance) {
+		sc.Error = fmt.Sprintf("insufficient balance: need %s (amount %s + estimated fee %s), have %s",
+			types.FIL(need), sc.Total, sc.EstimatedFee, sc.Balance)
+	}
+}
+
+// StartBatch 保存通过检查的批量转账，返回的批次 ID 用于恢复中断的发送
+// 相同内容的文件已经提交过时返回错误，除非 force 为 true
+func (e *Executor) StartBatch(source, fileHash string, entries []*BatchEntry, force bool) (*models.Batch, error) {
+	if !force {
+		prev, err := e.store.FindBatchByHash(fileHash)
+		if err != nil {
+			return nil, err
+		}
+		if prev != nil {
+			return nil, fmt.Errorf("the same file was already submitted as batch %s (%s), resume it with --resume %s or use --force to send again",
+				prev.ID, prev.Status, prev.ID)
+		}
+	}
+
+	id, err := newBatchID()
+	if err != nil {
+		return nil, err
+	}
+	batch := &models.Batch{
+		ID:       id,
+		Source:   source,
+		FileHash: fileHash,
+		Rows:     len(entries),
+		Status:   models.BatchStatusRunning,
+	}
+	rows := make([]*models.BatchRow, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, &models.BatchRow{
+			BatchID:  id,
+			Row:      entry.Row,
+			FromAddr: entry.From.String(),
+			ToAddr:   entry.To.String(),
+			Amount:   entry.Amount.Int.String(),
+			Memo:     entry.Memo,
+			Status:   models.BatchRowPending,
+		})
+	}
+	if err := e.store.CreateBatch(batch, rows); err != nil {
+		return nil, err
+	}
+
+	log.Infof("StartBatch: created batch %s with %d rows from %s", id, len(rows), source)
+	return batch, nil
+}
+
+// RunBatch 发送批量转账中尚未完成的行，并行等待上链
+// 中断后再次调用时，先根据链上和内存池状态核对已签名、已推送的行，确认未发出的转账才会重新发送
+func (e *Executor) RunBatch(id string) (*BatchReport, error) {
+	batch, err := e.store.GetBatch(id)
+	if err != nil {
+		return nil, fmt.Errorf("batch %s not found: %w", id, err)
+	}
+	rows, err := e.store.ListBatchRows(id)
+	if err != nil {
+		return nil, err
+	}
+	report := &BatchReport{Batch: batch, Rows: rows}
+	e.setBatchStatus(batch, models.BatchStatusRunning)
+
+	for _, row := range rows {
+		if row.Status != models.BatchRowSigned && row.Status != models.BatchRowPushed {
+			continue
+		}
+		if err := e.reconcileBatchRow(row); err != nil {
+			log.Errorf("RunBatch: failed to reconcile row %d of batch %s: %v", row.Row, id, err)
+			e.setBatchStatus(batch, models.BatchStatusInterrupted)
+			return report, err
+		}
+	}
+
+	// 依次发送，遇到错误时停止发送，已推送的行继续等待上链
+	var sendErr error
+	for _, row := range rows {
+		if row.Status != models.BatchRowPending {
+			continue
+		}
+		log.Infof("RunBatch: sending row %d of batch %s", row.Row, id)
+		if err := e.sendBatchRow(row); err != nil {
+			log.Errorf("RunBatch: row %d of batch %s failed: %v", row.Row, id, err)
+			sendErr = fmt.Errorf("row %d: %w", row.Row, err)
+			break
+		}
+	}
+
+	e.waitBatchRows(rows)
+
+	var (
+		unfinished int
+		failed     *models.BatchRow
+	)
+	for _, row := range rows {
+		switch row.Status {
+		case models.BatchRowIncluded:
+		case models.BatchRowFailed:
+			if failed == nil {
+				failed = row
+			}
+		default:
+			unfinished++
+		}
+	}
+
+	switch {
+	case unfinished > 0:
+		e.setBatchStatus(batch, models.BatchStatusInterrupted)
+		if sendErr == nil {
+			sendErr = fmt.Errorf("%d rows of batch %s are not confirmed, resume with --resume %s", unfinished, id, id)
+		}
+		return report, sendErr
+	case failed != nil:
+		e.setBatchStatus(batch, models.BatchStatusFailed)
+		c, _ := cid.Decode(failed.MsgCid)
+		return report, &MessageFailedError{MsgCid: c, ExitCode: failed.ExitCode}
+	default:
+		e.setBatchStatus(batch, models.BatchStatusCompleted)
+		log.Infof("RunBatch: batch %s completed, %d rows", id, len(rows))
+		return report, nil
+	}
+}
+
+// sendBatchRow 构建、签名并推送一行转账
+// 签名后先记录消息 CID 和 nonce 再推送，推送结果未知时保持 signed 状态，恢复时核对
+func (e *Executor) sendBatchRow(row *models.BatchRow) error {
+	defer e.node.Pin()()
+	from, to, amount, err := batchRowTransfer(row)
+	if err != nil {
+		return e.failBatchRow(row, err)
+	}
+	pm, err := e.Prepare(&Payload{
+		Type:     RequestTypeTransfer,
+		FromAddr: from,
+		ToAddr:   to,
+		Amount:   amount,
+	})
+	if err != nil {
+		return e.failBatchRow(row, err)
+	}
+	pm.Summary = fmt.Sprintf("%s (batch %s row %d)", pm.Summary, row.BatchID, row.Row)
+
+	signed, record, err := e.signMessage(pm)
+	if err != nil {
+		return e.failBatchRow(row, err)
+	}
+	row.Status = models.BatchRowSigned
+	row.MsgCid = signed.Cid().String()
+	row.Nonce = pm.Message.Nonce
+	row.Error = ""
+	if err := e.store.SaveBatchRow(row); err != nil {
+		// 无法记录签名结果时不能推送，否则中断后无法确认转账是否已经发出
+		e.nonces.Release(pm.Message.From, pm.Message.Nonce)
+		return err
+	}
+
+	msgCid, err := e.pushSigned(pm, signed, record)
+	if err != nil {
+		row.Error = err.Error()
+		if serr := e.store.SaveBatchRow(row); serr != nil {
+			log.Warnf("sendBatchRow: failed to save row %d: %v", row.Row, serr)
+		}
+		return err
+	}
+	row.Status = models.BatchRowPushed
+	row.MsgCid = msgCid.String()
+	return e.store.SaveBatchRow(row)
+}
+
+// failBatchRow 记录未发出的转账的错误，该行保持 pending 状态，恢复时重新发送
+func (e *Executor) failBatchRow(row *models.BatchRow, err error) error {
+	row.Status = models.BatchRowPending
+	row.MsgCid = ""
+	row.Nonce = 0
+	row.Error = err.Error()
+	if serr := e.store.SaveBatchRow(row); serr != nil {
+		log.Warnf("failBatchRow: failed to save row %d: %v", row.Row, serr)
+	}
+	return err
+}
+
+// reconcileBatchRow 核对上次中断时已签名或已推送的行
+// 消息已上链时记录结果；仍在内存池时继续等待；确认没有发出时恢复为 pending 重新发送
+func (e *Executor) reconcileBatchRow(row *models.BatchRow) error {
+	c, err := cid.Decode(row.MsgCid)
+	if err != nil {
+		return fmt.Errorf("invalid message cid %q in row %d: %w", row.MsgCid, row.Row, err)
+	}
+
+	lookup, err := e.node.StateSearchMsg(c)
+	if err != nil {
+		return err
+	}
+	if lookup != nil {
+		if err := e.applyBatchLookup(row, c, lookup); err != nil {
+			return err
+		}
+		return e.store.SaveBatchRow(row)
+	}
+
+	from, err := e.batchSender(row)
+	if err != nil {
+		return err
+	}
+	pending, err := e.node.MpoolPending()
+	if err != nil {
+		return err
+	}
+	for _, sm := range pending {
+		if sm.Message.From == from && sm.Message.Nonce == row.Nonce {
+			log.Infof("reconcileBatchRow: row %d is pending in mpool as %s", row.Row, sm.Cid())
+			row.Status = models.BatchRowPushed
+			return e.store.SaveBatchRow(row)
+		}
+	}
+
+	act, err := e.node.StateGetActor(from)
+	if err != nil {
+		return err
+	}
+	if act.Nonce > row.Nonce {
+		log.Warnf("reconcileBatchRow: nonce %d of %s was used by another message, resending row %d", row.Nonce, from, row.Row)
+		row.Error = fmt.Sprintf("nonce %d was used by another message", row.Nonce)
+	} else {
+		// 消息没有进入内存池，释放 nonce 使重新发送的消息使用相同的 nonce
+		log.Warnf("reconcileBatchRow: message %s of row %d was never included, resending", c, row.Row)
+		e.nonces.Release(from, row.Nonce)
+		row.Error = fmt.Sprintf("message %s was not found in mpool or chain", c)
+	}
+	row.Status = models.BatchRowPending
+	row.MsgCid = ""
+	row.Nonce = 0
+	return e.store.SaveBatchRow(row)
+}
+
+// waitBatchRows 并行等待所有已推送的行上链
+func (e *Executor) waitBatchRows(rows []*models.BatchRow) {
+	sem := make(chan struct{}, batchWaitConcurrency)
+	var wg sync.WaitGroup
+	for _, row := range rows {
+		if row.Status != models.BatchRowPushed {
+			continue
+		}
+		wg.Add(1)
+		go func(row *models.BatchRow) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			c, err := cid.Decode(row.MsgCid)
+			if err != nil {
+				row.Error = err.Error()
+				return
+			}
+			log.Infof("waitBatchRows: waiting for row %d message %s", row.Row, c)
+			lookup, err := e.node.StateWaitMsg(c)
+			if err != nil {
+				row.Error = err.Error()
+			} else if err := e.applyBatchLookup(row, c, lookup); err != nil {
+				row.Error = err.Error()
+			}
+			if err := e.store.SaveBatchRow(row); err != nil {
+				log.Warnf("waitBatchRows: failed to save row %d: %v", row.Row, err)
+			}
+		}(row)
+	}
+	wg.Wait()
+}
+
+// applyBatchLookup 根据链上查找结果更新行状态，并同步消息历史
+// 消息被内容相同的消息替换（mpool replace）时视为已转账，被其他消息替换（mpool cancel）时恢复为 pending
+func (e *Executor) applyBatchLookup(row *models.BatchRow, msgCid cid.Cid, lookup *types.MsgLookup) error {
+	if item, err := e.store.GetMessage(msgCid.String()); err == nil {
+		applyLookup(item, lookup)
+		saveRecord(e.store, item)
+	}
+
+	if lookup.Message != msgCid {
+		same, err := e.sameTransfer(msgCid, lookup.Message)
+		if err != nil {
+			return err
+		}
+		if !same {
+			log.Warnf("applyBatchLookup: message %s of row %d was replaced by %s, resending", msgCid, row.Row, lookup.Message)
+			row.Status = models.BatchRowPending
+			row.MsgCid = ""
+			row.Nonce = 0
+			row.Error = fmt.Sprintf("message %s was replaced by %s", msgCid, lookup.Message)
+			return nil
+		}
+		row.MsgCid = lookup.Message.String()
+	}
+
+	row.Height = int64(lookup.Height)
+	row.ExitCode = int64(lookup.Receipt.ExitCode)
+	row.Error = ""
+	if lookup.Receipt.ExitCode != 0 {
+		row.Status = models.BatchRowFailed
+	} else {
+		row.Status = models.BatchRowIncluded
+	}
+	return nil
+}
+
+// sameTransfer 检查替换消息与原消息的接收方、金额和方法是否相同
+func (e *Executor) sameTransfer(orig, replacement cid.Cid) (bool, error) {
+	a, err := e.node.ChainGetMessage(orig)
+	if err != nil {
+		return false, err
+	}
+	b, err := e.node.ChainGetMessage(replacement)
+	if err != nil {
+		return false, err
+	}
+	return a.To == b.To && a.Method == b.Method && a.Value.Equals(b.Value) && string(a.Params) == string(b.Params), nil
+}
+
+// batchSender 返回行的发送方在消息中使用的地址
+func (e *Executor) batchSender(row *models.BatchRow) (address.Address, error) {
+	from, err := address.NewFromString(row.FromAddr)
+	if err != nil {
+		return address.Undef, err
+	}
+	return e.resolveSender(from)
+}
+
+// setBatchStatus 更新批量转账状态
+func (e *Executor) setBatchStatus(batch *models.Batch, status string) {
+	batch.Status = status
+	if err := e.store.UpdateBatchStatus(batch.ID, status); err != nil {
+		log.Warnf("setBatchStatus: failed to update batch %s: %v", batch.ID, err)
+	}
+}
+
+// batchRowTransfer 解析行中的发送方、接收方和金额
+func batchRowTransfer(row *models.BatchRow) (address.Address, address.Address, types.FIL, error) {
+	from, err := address.NewFromString(row.FromAddr)
+	if err != nil {
+		return address.Undef, address.Undef, types.FIL{}, err
+	}
+	to, err := address.NewFromString(row.ToAddr)
+	if err != nil {
+		return address.Undef, address.Undef, types.FIL{}, err
+	}
+	amount, err := big.FromString(row.Amount)
+	if err != nil {
+		return address.Undef, address.Undef, types.FIL{}, err
+	}
+	return from, to, types.FIL(amount), nil
+}
+
+// newBatchID 生成批次 ID，格式为创建时间加随机后缀
+func newBatchID() (string, error) {
+	buf := make([]byte, 3)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate batch id: %w", err)
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(buf), nil
+}
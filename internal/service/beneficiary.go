@@ -0,0 +1,148 @@
+package service
+
+import (
+	"fmt"
+
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	minertypes "github.com/filecoin-project/go-state-types/builtin/v9/miner"
+
+	"wallet-sign/internal/chain/actors"
+	"wallet-sign/internal/chain/types"
+)
+
+// buildProposeBeneficiary 构建由 owner 提议更改矿工受益人的消息（FIP-0029）
+// 新受益人为 owner 时额度和到期高度必须为 0；当前受益人不是 owner 时，变更还需要当前受益人确认
+func (e *Executor) buildProposeBeneficiary(p MinerProposeBeneficiaryPayload) (*PreparedMessage, error) {
+	minerInfo, err := e.node.StateMinerInfo(p.MinerID)
+	if err != nil {
+		log.Errorf("proposeBeneficiary: failed to get miner info: %v", err)
+		return nil, err
+	}
+
+	newAddr, err := e.node.StateLookupID(p.Beneficiary)
+	if err != nil {
+		log.Errorf("proposeBeneficiary: failed to lookup beneficiary ID: %v", err)
+		return nil, err
+	}
+
+	quota := types.BigInt(p.Quota)
+	if newAddr == minerInfo.Owner {
+		if minerInfo.Beneficiary == minerInfo.Owner {
+			return nil, fmt.Errorf("beneficiary of %s is already the owner %s", p.MinerID, minerInfo.Owner)
+		}
+		if !quota.IsZero() || p.Expiration != 0 {
+			return nil, fmt.Errorf("quota and expiration must be 0 when changing the beneficiary back to the owner")
+		}
+	} else {
+		if quota.Sign() <= 0 {
+			return nil, fmt.Errorf("quota must be greater than 0")
+		}
+		head, err := e.node.ChainHead()
+		if err != nil {
+			log.Errorf("proposeBeneficiary: failed to get chain head: %v", err)
+			return nil, err
+		}
+		if p.Expiration <= head.Height() {
+			return nil, fmt.Errorf("expiration %d must be after the current height %d", p.Expiration, head.Height())
+		}
+	}
+
+	owner, err := e.resolveSender(minerInfo.Owner)
+	if err != nil {
+		log.Errorf("proposeBeneficiary: failed to get owner account key: %v", err)
+		return nil, err
+	}
+
+	changeParams := &minertypes.ChangeBeneficiaryParams{
+		NewBeneficiary: newAddr,
+		NewQuota:       quota,
+		NewExpiration:  p.Expiration,
+	}
+	params, err := actors.SerializeParams(changeParams)
+	if err != nil {
+		log.Errorf("proposeBeneficiary: failed to serialize params: %v", err)
+		return nil, err
+	}
+	msg := &types.Message{
+		To:     p.MinerID,
+		From:   owner,
+		Value:  types.NewInt(0),
+		Method: builtintypes.MethodsMiner.ChangeBeneficiary,
+		Params: params,
+	}
+
+	summary := fmt.Sprintf("propose beneficiary change of miner %s from %s to %s with quota %s until epoch %d",
+		p.MinerID, minerInfo.Beneficiary, newAddr, p.Quota, p.Expiration)
+	if minerInfo.PendingBeneficiaryTerm != nil {
+		summary += fmt.Sprintf(", replacing the pending proposal for %s", minerInfo.PendingBeneficiaryTerm.NewBeneficiary)
+	}
+	return &PreparedMessage{
+		RequestType: RequestTypeMinerProposeBeneficiary,
+		Summary:     summary,
+		Message:     msg,
+		Params:      changeParams,
+	}, nil
+}
+
+// buildConfirmBeneficiary 构建确认待处理受益人变更的消息
+// 由当前受益人或被提名人发送，参数必须与提议完全一致
+func (e *Executor) buildConfirmBeneficiary(p MinerConfirmBeneficiaryPayload) (*PreparedMessage, error) {
+	minerInfo, err := e.node.StateMinerInfo(p.MinerID)
+	if err != nil {
+		log.Errorf("confirmBeneficiary: failed to get miner info: %v", err)
+		return nil, err
+	}
+	pending := minerInfo.PendingBeneficiaryTerm
+	if pending == nil {
+		return nil, fmt.Errorf("no pending beneficiary change for %s", p.MinerID)
+	}
+
+	fromID, err := e.node.StateLookupID(p.From)
+	if err != nil {
+		log.Errorf("confirmBeneficiary: failed to lookup %s: %v", p.From, err)
+		return nil, err
+	}
+
+	var role string
+	switch {
+	case fromID == pending.NewBeneficiary && !pending.ApprovedByNominee:
+		role = "nominee"
+	case fromID == minerInfo.Beneficiary && !pending.ApprovedByBeneficiary:
+		role = "current beneficiary"
+	case fromID == pending.NewBeneficiary || fromID == minerInfo.Beneficiary:
+		return nil, fmt.Errorf("%s has already approved the beneficiary change of %s", p.From, p.MinerID)
+	default:
+		return nil, fmt.Errorf("%s is neither the current beneficiary %s nor the nominee %s", p.From, minerInfo.Beneficiary, pending.NewBeneficiary)
+	}
+	from, err := e.resolveSender(fromID)
+	if err != nil {
+		log.Errorf("confirmBeneficiary: failed to get account key for %s: %v", fromID, err)
+		return nil, err
+	}
+
+	changeParams := &minertypes.ChangeBeneficiaryParams{
+		NewBeneficiary: pending.NewBeneficiary,
+		NewQuota:       pending.NewQuota,
+		NewExpiration:  pending.NewExpiration,
+	}
+	params, err := actors.SerializeParams(changeParams)
+	if err != nil {
+		log.Errorf("confirmBeneficiary: failed to serialize params: %v", err)
+		return nil, err
+	}
+	msg := &types.Message{
+		To:     p.MinerID,
+		From:   from,
+		Value:  types.NewInt(0),
+		Method: builtintypes.MethodsMiner.ChangeBeneficiary,
+		Params: params,
+	}
+
+	return &PreparedMessage{
+		RequestType: RequestTypeMinerConfirmBeneficiary,
+		Summary: fmt.Sprintf("confirm beneficiary change of miner %s to %s with quota %s until epoch %d (as %s)",
+			p.MinerID, pending.NewBeneficiary, types.FIL(pending.NewQuota), pending.NewExpiration, role),
+		Message: msg,
+		Params:  changeParams,
+	}, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	minertypes "github.com/filecoin-project/go-state-types/builtin/v9/miner"
+
+	"wallet-sign/internal/chain/actors"
+	"wallet-sign/internal/chain/types"
+)
+
+// maxControlAddresses 矿工 actor 允许的 control 地址数量上限
+const maxControlAddresses = 10
+
+// ControlAddress control 地址及其余额
+type ControlAddress struct {
+	ID      address.Address `json:"id"`
+	Key     address.Address `json:"key"`
+	Balance types.FIL       `json:"balance"`
+}
+
+// ControlChange 设置 control 地址前后的差异
+// Removed 中余额不为零的地址移除后不会再被用于发送消息，需要另行转出余额
+type ControlChange struct {
+	Miner   address.Address   `json:"miner"`
+	Current []address.Address `json:"current"`
+	New     []address.Address `json:"new"`
+	Added   []address.Address `json:"added"`
+	Removed []*ControlAddress `json:"removed"`
+}
+
+// Changed 新列表与当前列表是否不同（顺序不同也视为变化）
+func (c *ControlChange) Changed() bool {
+	if len(c.Current) != len(c.New) {
+		return true
+	}
+	for i := range c.Current {
+		if c.Current[i] != c.New[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanControlChange 将 control 地址解析为 ID 地址，并与矿工当前的 control 地址比较
+func (e *Executor) PlanControlChange(miner address.Address, addrs []address.Address) (*ControlChange, error) {
+	minerInfo, err := e.node.StateMinerInfo(miner)
+	if err != nil {
+		log.Errorf("PlanControlChange: failed to get miner info: %v", err)
+		return nil, err
+	}
+	ids, err := e.resolveControlAddrs(addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &ControlChange{Miner: miner, Current: minerInfo.ControlAddresses, New: ids}
+	current := make(map[address.Address]bool, len(change.Current))
+	for _, a := range change.Current {
+		current[a] = true
+	}
+	next := make(map[address.Address]bool, len(ids))
+	for _, a := range ids {
+		next[a] = true
+		if !current[a] {
+			change.Added = append(change.Added, a)
+		}
+	}
+	for _, a := range change.Current {
+		if next[a] {
+			continue
+		}
+		removed := &ControlAddress{ID: a, Key: a}
+		if key, err := e.node.StateAccountKey(a); err == nil {
+			removed.Key = key
+		}
+		act, err := e.node.StateGetActor(a)
+		if err != nil {
+			log.Errorf("PlanControlChange: failed to get actor %s: %v", a, err)
+			return nil, err
+		}
+		removed.Balance = types.FIL(act.Balance)
+		change.Removed = append(change.Removed, removed)
+	}
+	return change, nil
+}
+
+// resolveControlAddrs 将 control 地址解析为 ID 地址并去重
+// control 地址必须是已上链的账户
+func (e *Executor) resolveControlAddrs(addrs []address.Address) ([]address.Address, error) {
+	if len(addrs) > maxControlAddresses {
+		return nil, fmt.Errorf("at most %d control addresses are allowed, got %d", maxControlAddresses, len(addrs))
+	}
+	ids := make([]address.Address, 0, len(addrs))
+	seen := make(map[address.Address]bool, len(addrs))
+	for _, a := range addrs {
+		id, err := e.node.StateLookupID(a)
+		if err != nil {
+			log.Errorf("resolveControlAddrs: failed to lookup %s: %v", a, err)
+			return nil, fmt.Errorf("control address %s not found on chain: %w", a, err)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("control address %s (%s) given more than once", a, id)
+		}
+		if _, err := e.node.StateAccountKey(id); err != nil {
+			return nil, fmt.Errorf("control address %s is not an account: %w", a, err)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// buildSetControl 构建替换矿工 control 地址列表的消息
+// worker 保持不变，由 owner 发送
+func (e *Executor) buildSetControl(p MinerSetControlPayload) (*PreparedMessage, error) {
+	change, err := e.PlanControlChange(p.MinerID, p.ControlAddrs)
+	if err != nil {
+		return nil, err
+	}
+	if !change.Changed() {
+		return nil, fmt.Errorf("control addresses of %s are already %v", p.MinerID, change.Current)
+	}
+
+	minerInfo, err := e.node.StateMinerInfo(p.MinerID)
+	if err != nil {
+		log.Errorf("setControl: failed to get miner info: %v", err)
+		return nil, err
+	}
+	changeParams := &minertypes.ChangeWorkerAddressParams{
+		NewWorker:       minerInfo.Worker,
+		NewControlAddrs: change.New,
+	}
+	params, err := actors.SerializeParams(changeParams)
+	if err != nil {
+		log.Errorf("setControl: failed to serialize params: %v", err)
+		return nil, err
+	}
+
+	owner, err := e.resolveSender(minerInfo.Owner)
+	if err != nil {
+		log.Errorf("setControl: failed to get owner account key: %v", err)
+		return nil, err
+	}
+	msg := &types.Message{
+		To:     p.MinerID,
+		From:   owner,
+		Value:  types.NewInt(0),
+		Method: builtintypes.MethodsMiner.ChangeWorkerAddress,
+		Params: params,
+	}
+
+	return &PreparedMessage{
+		RequestType: RequestTypeMinerSetControl,
+		Summary: fmt.Sprintf("set control addresses of miner %s to %v (%d added, %d removed)",
+			p.MinerID, change.New, len(change.Added), len(change.Removed)),
+		Message: msg,
+		Params:  changeParams,
+	}, nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+)
+
+// adaptDelegatedSender 调整委托（f410）地址发送的消息
+// Lotus 只接受委托地址调用 EVM InvokeContract 方法：普通转账改为不带参数的 InvokeContract，
+// 接收方必须能表示为以太坊地址，因此 f1/f2/f3 接收方需要先解析为 ID 地址
+func (e *Executor) adaptDelegatedSender(pm *PreparedMessage) error {
+	msg := pm.Message
+	if msg.From.Protocol() != address.Delegated {
+		return nil
+	}
+
+	switch msg.Method {
+	case builtintypes.MethodSend:
+		if len(msg.Params) > 0 {
+			return fmt.Errorf("%s: delegated sender %s cannot send params with method %d", pm.RequestType, msg.From, msg.Method)
+		}
+		msg.Method = builtintypes.MethodsEVM.InvokeContract
+	case builtintypes.MethodsEVM.InvokeContract:
+	default:
+		return fmt.Errorf("%s: delegated sender %s can only transfer or invoke contracts, not call method %d", pm.RequestType, msg.From, msg.Method)
+	}
+
+	if p := msg.To.Protocol(); p != address.ID && p != address.Delegated {
+		id, err := e.node.StateLookupID(msg.To)
+		if err != nil {
+			log.Errorf("%s: failed to resolve recipient %s: %v", pm.RequestType, msg.To, err)
+			return fmt.Errorf("recipient %s must exist on chain to receive from a delegated address: %w", msg.To, err)
+		}
+		msg.To = id
+	}
+	return nil
+}
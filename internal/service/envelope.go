@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-cid"
+
+	"wallet-sign/internal/chain/types"
+)
+
+// EnvelopeVersion 离线签名文件格式版本
+const EnvelopeVersion = 1
+
+// PreparedMessage 已构建但尚未签名的消息
+// Summary 与 Params 仅用于展示，签名内容以 Message 为准
+// DecodeReturn 不为空时用于解码消息执行成功后的返回值
+type PreparedMessage struct {
+	RequestType  string
+	Summary      string
+	Message      *types.Message
+	Params       interface{}
+	DecodeReturn func([]byte) (interface{}, error)
+}
+
+// MessageEnvelope 离线签名文件格式
+// 由联网机器通过 message build 生成，交给离线机器通过 message sign 签名
+type MessageEnvelope struct {
+	Version     int             `json:"version"`
+	Network     string          `json:"network"`
+	RequestType string          `json:"request_type"`
+	Summary     string          `json:"summary"`
+	From        address.Address `json:"from"`
+	MessageCid  cid.Cid         `json:"message_cid"`
+	Message     *types.Message  `json:"message"`
+	Params      json.RawMessage `json:"params,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// BuildEnvelope 构建请求对应的消息，并附带网络名称等上下文信息
+func (e *Executor) BuildEnvelope(req *Payload) (*MessageEnvelope, error) {
+	pm, err := e.Prepare(req)
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := e.node.StateNetworkName()
+	if err != nil {
+		log.Errorf("BuildEnvelope: failed to get network name: %v", err)
+		e.nonces.Release(pm.Message.From, pm.Message.Nonce)
+		return nil, err
+	}
+
+	env := &MessageEnvelope{
+		Version:     EnvelopeVersion,
+		Network:     network,
+		RequestType: pm.RequestType,
+		Summary:     pm.Summary,
+		From:        pm.Message.From,
+		MessageCid:  pm.Message.Cid(),
+		Message:     pm.Message,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if pm.Params != nil {
+		env.Params, err = json.Marshal(pm.Params)
+		if err != nil {
+			e.nonces.Release(pm.Message.From, pm.Message.Nonce)
+			return nil, fmt.Errorf("failed to marshal params: %w", err)
+		}
+	}
+	// 离线签名期间 nonce 保持预留，由消息记录跟踪
+	RecordBuilt(e.store, env)
+	e.nonces.Commit(pm.Message.From, pm.Message.Nonce)
+	return env, nil
+}
+
+// Verify 检查文件内容是否完整
+// 消息 CID 必须与记录的 CID 一致，发送方必须与消息 From 一致
+func (env *MessageEnvelope) Verify() error {
+	if env.Version != EnvelopeVersion {
+		return fmt.Errorf("unsupported envelope version: %d", env.Version)
+	}
+	if env.Message == nil {
+		return fmt.Errorf("envelope does not contain a message")
+	}
+	if env.Message.From != env.From {
+		return fmt.Errorf("envelope sender %s does not match message sender %s", env.From, env.Message.From)
+	}
+	if c := env.Message.Cid(); c != env.MessageCid {
+		return fmt.Errorf("message cid mismatch: envelope %s, computed %s", env.MessageCid, c)
+	}
+	return nil
+}
+
+// WriteEnvelope 将离线签名文件写入指定路径
+func WriteEnvelope(path string, env *MessageEnvelope) error {
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// ReadEnvelope 读取并校验离线签名文件
+func ReadEnvelope(path string) (*MessageEnvelope, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var env MessageEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed to parse envelope: %w", err)
+	}
+	if err := env.Verify(); err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"fmt"
+	"sync"
+	"wallet-sign/internal/metrics"
 	"wallet-sign/internal/repository"
 
 	"github.com/filecoin-project/go-address"
@@ -10,10 +12,12 @@ import (
 	builtintypes "github.com/filecoin-project/go-state-types/builtin"
 	markettypes "github.com/filecoin-project/go-state-types/builtin/v9/market"
 	minertypes "github.com/filecoin-project/go-state-types/builtin/v9/miner"
+	"github.com/ipfs/go-cid"
 	logging "github.com/ipfs/go-log/v2"
 
 	"wallet-sign/internal/chain/actors"
 	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/models"
 	"wallet-sign/internal/rpc"
 	"wallet-sign/internal/vapi"
 	"wallet-sign/internal/wallet"
@@ -21,121 +25,349 @@ import (
 
 var log = logging.Logger("executor")
 
+// batchWaitConcurrency 批量转账时同时等待上链的消息数量
+const batchWaitConcurrency = 16
+
 type Executor struct {
-	store *repository.Store
-	node  *vapi.Node
+	store  *repository.Store
+	node   *vapi.Node
+	nonces *NonceManager
 }
 
 func NewExecutor(store *repository.Store) *Executor {
 	log.Info("NewExecutor: creating new executor instance")
 	client := rpc.NewLotusApi()
 	node := vapi.NewNode(contextBackground(), client)
-	return &Executor{store: store, node: node}
+	return &Executor{store: store, node: node, nonces: NewNonceManager(store, node)}
+}
+
+// Execute 执行请求并返回执行结果
+// 消息上链但退出码非零时同时返回结果和 *MessageFailedError
+func (e *Executor) Execute(req *Payload) (*Result, error) {
+
+	res, err := e.executeRequest(req)
+	if err != nil {
+		log.Errorf("Execute: failed to execute request %s: %v", req.Type, err)
+		return res, err
+	}
+
+	log.Infof("Execute: request %s completed successfully", req.Type)
+	return res, nil
 }
 
-func (e *Executor) Execute(req *Payload) {
+func (e *Executor) executeRequest(req *Payload) (*Result, error) {
+	// nonce 分配、Gas 估算、推送及等待发往同一个节点
+	defer e.node.Pin()()
+	if req.Type == RequestTypeBatchTransfer {
+		var payload BatchTransferPayload
+		payload.Items = req.Items
+		return e.batchTransfer(payload)
+	}
 
-	err := e.executeRequest(req)
+	pm, err := e.Prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	msgCid, record, err := e.pushMessage(pm)
 	if err != nil {
-		log.Errorf("Execute: failed to execute request #%d: %v", req.Type, err)
+		return nil, err
 	}
+	return e.waitMessage(pm, msgCid, record)
+}
 
-	log.Infof("Execute: request #%d completed successfully", req.Type)
+// Prepare 构建请求对应的未签名消息，并填充 nonce 与 Gas 参数
+// nonce 由 NonceManager 在本地预留，返回的消息可以直接签名，也可以写入文件交给离线机器签名
+func (e *Executor) Prepare(req *Payload) (*PreparedMessage, error) {
+	return e.prepare(req, true)
+}
 
+// prepare 构建并填充消息，reserve 为 false 时直接使用内存池 nonce 而不预留（用于预执行）
+func (e *Executor) prepare(req *Payload, reserve bool) (*PreparedMessage, error) {
+	pm, err := e.compose(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Proposer != address.Undef {
+		if err := e.wrapMultisig(pm, req.Proposer); err != nil {
+			return nil, err
+		}
+	} else if p := pm.Message.From.Protocol(); p == address.ID || p == address.Actor {
+		return nil, fmt.Errorf("%s: sender %s is not an account, specify a proposer to send it as a multisig proposal", pm.RequestType, pm.Message.From)
+	}
+	if err := e.adaptDelegatedSender(pm); err != nil {
+		return nil, err
+	}
+	if err := e.fillMessage(pm, reserve); err != nil {
+		return nil, err
+	}
+	return pm, nil
 }
 
-func (e *Executor) executeRequest(req *Payload) error {
+// compose 根据请求类型构建消息的 To/From/Value/Method/Params，不访问 nonce 与 Gas
+func (e *Executor) compose(req *Payload) (*PreparedMessage, error) {
 	switch req.Type {
 	case RequestTypeTransfer:
 		var payload TransferPayload
 		payload.From = req.FromAddr
 		payload.To = req.ToAddr
 		payload.Amount = req.Amount
-
-		return e.transfer(payload)
+		return e.buildTransfer(payload)
 	case RequestTypeMinerWithdraw:
 		var payload MinerWithdrawPayload
 		payload.MinerID = req.MinerID
 		payload.Amount = req.Amount
-		return e.minerWithdraw(payload)
+		return e.buildMinerWithdraw(payload)
 	case RequestTypeMarketWithdraw:
 		var payload MarketWithdrawPayload
 		payload.Address = req.MinerID
 		payload.Amount = req.Amount
-		return e.marketWithdraw(payload)
+		return e.buildMarketWithdraw(payload)
+	case RequestTypeMinerChangeOwner:
+		var payload MinerChangeOwnerPayload
+		payload.MinerID = req.MinerID
+		payload.NewOwner = req.NewOwner
+		payload.FromOwner = req.FromOwner
+		return e.buildChangeMinerOwner(payload)
+	case RequestTypeMinerChangeWorker:
+		var payload MinerChangeWorkerPayload
+		payload.MinerID = req.MinerID
+		payload.NewWorker = req.NewWorker
+		payload.NewControlAddrs = req.NewControlAddrs
+		return e.buildChangeMinerWorker(payload)
+	case RequestTypeMinerConfirmWorker:
+		var payload MinerConfirmWorkerPayload
+		payload.MinerID = req.MinerID
+		payload.NewWorker = req.NewWorker
+		return e.buildConfirmMinerWorker(payload)
+	case RequestTypeMinerSetControl:
+		var payload MinerSetControlPayload
+		payload.MinerID = req.MinerID
+		payload.ControlAddrs = req.NewControlAddrs
+		return e.buildSetControl(payload)
+	case RequestTypeMinerProposeBeneficiary:
+		var payload MinerProposeBeneficiaryPayload
+		payload.MinerID = req.MinerID
+		payload.Beneficiary = req.Beneficiary
+		payload.Quota = req.Quota
+		payload.Expiration = req.Expiration
+		return e.buildProposeBeneficiary(payload)
+	case RequestTypeMinerConfirmBeneficiary:
+		var payload MinerConfirmBeneficiaryPayload
+		payload.MinerID = req.MinerID
+		payload.From = req.FromAddr
+		return e.buildConfirmBeneficiary(payload)
+	case RequestTypeMsigCreate:
+		var payload MsigCreatePayload
+		payload.From = req.FromAddr
+		payload.Signers = req.Signers
+		payload.Threshold = req.Threshold
+		payload.UnlockDuration = req.UnlockDuration
+		payload.StartEpoch = req.StartEpoch
+		payload.Amount = req.Amount
+		return e.buildMsigCreate(payload)
+	case RequestTypeMsigPropose:
+		var payload MsigProposePayload
+		payload.Multisig = req.Multisig
+		payload.To = req.ToAddr
+		payload.Amount = req.Amount
+		payload.Method = req.Method
+		payload.Params = req.Params
+		return e.buildMsigPropose(payload)
+	case RequestTypeMsigApprove, RequestTypeMsigCancel:
+		var payload MsigTxnPayload
+		payload.Multisig = req.Multisig
+		payload.Signer = req.FromAddr
+		payload.TxnID = req.TxnID
+		return e.buildMsigTxn(payload, req.Type)
+	case RequestTypeMsigAddSigner, RequestTypeMsigRemoveSigner, RequestTypeMsigSwapSigner:
+		var payload MsigSignerPayload
+		payload.Multisig = req.Multisig
+		payload.Signer = req.Signer
+		payload.NewSigner = req.NewSigner
+		payload.ChangeThreshold = req.ChangeThreshold
+		switch req.Type {
+		case RequestTypeMsigAddSigner:
+			return e.buildMsigAddSigner(payload)
+		case RequestTypeMsigRemoveSigner:
+			return e.buildMsigRemoveSigner(payload)
+		default:
+			return e.buildMsigSwapSigner(payload)
+		}
+	case RequestTypeMsigThreshold:
+		var payload MsigThresholdPayload
+		payload.Multisig = req.Multisig
+		payload.Threshold = req.Threshold
+		return e.buildMsigThreshold(payload)
+	case RequestTypeInvoke:
+		var payload InvokePayload
+		payload.From = req.FromAddr
+		payload.To = req.ToAddr
+		payload.Amount = req.Amount
+		payload.Method = req.MethodName
+		payload.Params = req.Params
+		payload.ParamsJSON = req.ParamsJSON
+		return e.buildInvoke(payload)
 	case RequestTypeBatchTransfer:
-		var payload BatchTransferPayload
-		payload.Items = req.Items
-		return e.batchTransfer(payload)
-
+		return nil, fmt.Errorf("request type %s cannot be built as a single message", req.Type)
 	default:
-		return fmt.Errorf("unsupported request type: %s", req.Type)
+		return nil, fmt.Errorf("unsupported request type: %s", req.Type)
 	}
 }
 
-func (e *Executor) transfer(p TransferPayload) error {
-
-	fromAddr := p.From
-	toAddr := p.To
-	val := p.Amount
-	nonce, err := e.node.MpoolGetNonce(p.From)
+// fillMessage 为消息填充发送方的 nonce 以及 Gas 参数
+func (e *Executor) fillMessage(pm *PreparedMessage, reserve bool) error {
+	msg := pm.Message
+	var (
+		nonce uint64
+		err   error
+	)
+	if reserve {
+		nonce, err = e.nonces.Reserve(msg.From)
+	} else {
+		nonce, err = e.node.MpoolGetNonce(msg.From)
+	}
 	if err != nil {
-		log.Errorf("transfer: failed to get nonce for %s: %v", fromAddr, err)
+		log.Errorf("%s: failed to get nonce for %s: %v", pm.RequestType, msg.From, err)
 		return err
 	}
+	msg.Nonce = nonce
 
-	msg := &types.Message{
-		Version:    0,
-		To:         toAddr,
-		From:       fromAddr,
-		Nonce:      nonce,
-		Value:      types.BigInt(val),
-		GasLimit:   0,
-		GasFeeCap:  abi.NewTokenAmount(0),
-		GasPremium: abi.NewTokenAmount(0),
-		Method:     builtintypes.MethodSend,
-	}
 	if err := wallet.SetGas(e.node, msg); err != nil {
-		log.Errorf("transfer: failed to set gas: %v", err)
+		log.Errorf("%s: failed to set gas: %v", pm.RequestType, err)
+		if reserve {
+			e.nonces.Release(msg.From, nonce)
+		}
 		return err
 	}
+	return nil
+}
 
-	hasKey, err := wallet.WalletHas(e.store, fromAddr)
+// pushMessage 签名已准备好的消息并推送到内存池
+// 签名或推送失败时释放预留的 nonce
+func (e *Executor) pushMessage(pm *PreparedMessage) (cid.Cid, *models.Message, error) {
+	signed, record, err := e.signMessage(pm)
 	if err != nil {
-		log.Errorf("transfer: failed to check key for %s: %v", fromAddr, err)
-		return err
+		return cid.Undef, nil, err
 	}
-	if !hasKey {
-		log.Errorf("transfer: wallet does not have key for %s", fromAddr)
-		return fmt.Errorf("wallet does not have key for %s", fromAddr)
+	msgCid, err := e.pushSigned(pm, signed, record)
+	if err != nil {
+		return cid.Undef, nil, err
 	}
+	return msgCid, record, nil
+}
 
-	sig, err := wallet.WalletSign(e.store, fromAddr, msg.Cid().Bytes())
+// signMessage 签名已准备好的消息并记录到消息历史，签名失败时释放预留的 nonce
+func (e *Executor) signMessage(pm *PreparedMessage) (*types.SignedMessage, *models.Message, error) {
+	msg := pm.Message
+	signed, err := wallet.SignMessage(e.store, msg)
 	if err != nil {
-		log.Errorf("transfer: failed to sign message: %v", err)
-		return err
+		log.Errorf("%s: failed to sign message: %v", pm.RequestType, err)
+		metrics.MessageFailed(pm.RequestType)
+		e.nonces.Release(msg.From, msg.Nonce)
+		return nil, nil, err
 	}
-	signed := &types.SignedMessage{Message: *msg, Signature: *sig}
+	metrics.MessageSigned(pm.RequestType)
+	record := signedRecord(pm.RequestType, signed, models.MessageStatusSigned)
+	saveRecord(e.store, record)
+	return signed, record, nil
+}
+
+// pushSigned 推送已签名的消息并更新消息历史，推送失败时释放预留的 nonce
+func (e *Executor) pushSigned(pm *PreparedMessage, signed *types.SignedMessage, record *models.Message) (cid.Cid, error) {
+	name := pm.RequestType
+	msg := pm.Message
 
-	log.Infof("transfer: pushing message to mempool")
+	log.Infof("%s: pushing message with nonce %d to mempool", name, msg.Nonce)
 	msgCid, err := e.node.MpoolPush(signed)
 	if err != nil {
-		log.Errorf("transfer: failed to push message: %v", err)
-		return err
+		log.Errorf("%s: failed to push message: %v", name, err)
+		metrics.MessageFailed(name)
+		record.Error = err.Error()
+		saveRecord(e.store, record)
+		e.nonces.Release(msg.From, msg.Nonce)
+		return cid.Undef, err
+	}
+	metrics.MessagePushed(name)
+	record.Cid = msgCid.String()
+	record.Status = models.MessageStatusPushed
+	saveRecord(e.store, record)
+	e.nonces.Commit(msg.From, msg.Nonce)
+	return msgCid, nil
+}
+
+// waitMessage 等待已推送的消息上链，并解析执行结果
+// record 为消息历史记录，为 nil 时不更新历史
+func (e *Executor) waitMessage(pm *PreparedMessage, msgCid cid.Cid, record *models.Message) (*Result, error) {
+	name := pm.RequestType
+
+	log.Infof("%s: waiting for message %s", name, msgCid)
+	lookup, err := e.node.StateWaitMsg(msgCid)
+	if err != nil {
+		log.Errorf("%s: failed to wait for message %s: %v", name, msgCid, err)
+		return &Result{RequestType: name, MsgCid: msgCid}, err
+	}
+	if record != nil {
+		applyLookup(record, lookup)
+		saveRecord(e.store, record)
+	}
+
+	res := newResult(name, msgCid, lookup)
+	if replay, err := e.node.StateReplay(lookup.TipSet, msgCid); err != nil {
+		log.Warnf("%s: failed to get gas cost for %s: %v", name, msgCid, err)
+	} else if replay.GasCost != nil {
+		burned := types.BigAdd(replay.GasCost.BaseFeeBurn, replay.GasCost.OverEstimationBurn)
+		res.FeeBurned = &burned
+	}
+
+	if lookup.Receipt.ExitCode != 0 {
+		log.Errorf("%s: message %s failed with exit code: %d", name, msgCid, lookup.Receipt.ExitCode)
+		metrics.MessageFailed(name)
+		return res, &MessageFailedError{MsgCid: msgCid, ExitCode: lookup.Receipt.ExitCode}
+	}
+
+	if pm.DecodeReturn != nil {
+		ret, err := pm.DecodeReturn(lookup.Receipt.Return)
+		if err != nil {
+			log.Warnf("%s: failed to decode return value of %s: %v", name, msgCid, err)
+		} else {
+			res.Return = ret
+		}
+	}
+	// 多签交易达到阈值后立即执行，外层消息成功不代表被执行的交易成功
+	if ret, ok := res.Return.(*MsigTxnResult); ok && ret.Applied && ret.ExitCode != 0 {
+		log.Errorf("%s: multisig transaction %d failed with exit code: %d", name, ret.TxnID, ret.ExitCode)
+		metrics.MessageFailed(name)
+		return res, &MessageFailedError{MsgCid: msgCid, ExitCode: ret.ExitCode}
 	}
 
-	log.Infof("transfer: waiting for message %s", msgCid)
-	_, err = e.node.StateWaitMsg(msgCid)
+	log.Infof("%s: completed successfully, msgCid=%s", name, msgCid)
+	return res, nil
+}
+
+func (e *Executor) buildTransfer(p TransferPayload) (*PreparedMessage, error) {
+	from, err := e.resolveSender(p.From)
 	if err != nil {
-		log.Errorf("transfer: message %s failed: %v", msgCid, err)
-		return err
+		return nil, err
+	}
+	msg := &types.Message{
+		Version:    0,
+		To:         p.To,
+		From:       from,
+		Value:      types.BigInt(p.Amount),
+		GasLimit:   0,
+		GasFeeCap:  abi.NewTokenAmount(0),
+		GasPremium: abi.NewTokenAmount(0),
+		Method:     builtintypes.MethodSend,
 	}
 
-	log.Infof("transfer: completed successfully, msgCid=%s", msgCid)
-	return err
+	return &PreparedMessage{
+		RequestType: RequestTypeTransfer,
+		Summary:     fmt.Sprintf("transfer %s from %s to %s", p.Amount, p.From, p.To),
+		Message:     msg,
+	}, nil
 }
 
-func (e *Executor) minerWithdraw(p MinerWithdrawPayload) error {
+func (e *Executor) buildMinerWithdraw(p MinerWithdrawPayload) (*PreparedMessage, error) {
 
 	minerAddr := p.MinerID
 	val := p.Amount
@@ -143,45 +375,37 @@ func (e *Executor) minerWithdraw(p MinerWithdrawPayload) error {
 	minerInfo, err := e.node.StateMinerInfo(minerAddr)
 	if err != nil {
 		log.Errorf("minerWithdraw: failed to get miner info for %s: %v", p.MinerID, err)
-		return err
+		return nil, err
 	}
-	ownerAddr := minerInfo.Owner
-	if ownerAddr.Protocol() == address.ID {
-		ownerAddr, err = e.node.StateAccountKey(ownerAddr)
-		if err != nil {
-			log.Errorf("minerWithdraw: failed to get account key for owner: %v", err)
-			return err
-		}
+	ownerAddr, err := e.resolveSender(minerInfo.Owner)
+	if err != nil {
+		log.Errorf("minerWithdraw: failed to get account key for owner: %v", err)
+		return nil, err
 	}
 
 	available, err := e.node.StateMinerAvailableBalance(minerAddr)
 	if err != nil {
 		log.Errorf("minerWithdraw: failed to get available balance: %v", err)
-		return err
+		return nil, err
 	}
 	if types.BigCmp(types.BigInt(val), available) == 1 {
 		log.Errorf("minerWithdraw: requested %s > available %s", val, types.FIL(available))
-		return fmt.Errorf("requested %s > available %s", val, types.FIL(available))
+		return nil, fmt.Errorf("requested %s > available %s", val, types.FIL(available))
 	}
 
-	params, err := actors.SerializeParams(&minertypes.WithdrawBalanceParams{
+	withdrawParams := &minertypes.WithdrawBalanceParams{
 		AmountRequested: types.BigInt(val),
-	})
+	}
+	params, err := actors.SerializeParams(withdrawParams)
 	if err != nil {
 		log.Errorf("minerWithdraw: failed to serialize params: %v", err)
-		return err
+		return nil, err
 	}
 
-	nonce, err := e.node.MpoolGetNonce(ownerAddr)
-	if err != nil {
-		log.Errorf("minerWithdraw: failed to get nonce: %v", err)
-		return err
-	}
 	msg := &types.Message{
 		Version:    0,
 		To:         minerAddr,
 		From:       ownerAddr,
-		Nonce:      nonce,
 		Value:      abi.NewTokenAmount(0),
 		GasLimit:   0,
 		GasFeeCap:  abi.NewTokenAmount(0),
@@ -189,92 +413,56 @@ func (e *Executor) minerWithdraw(p MinerWithdrawPayload) error {
 		Method:     builtintypes.MethodsMiner.WithdrawBalance,
 		Params:     params,
 	}
-	if err := wallet.SetGas(e.node, msg); err != nil {
-		log.Errorf("minerWithdraw: failed to set gas: %v", err)
-		return err
-	}
-
-	hasKey, err := wallet.WalletHas(e.store, ownerAddr)
-	if err != nil {
-		log.Errorf("minerWithdraw: failed to check key: %v", err)
-		return err
-	}
-	if !hasKey {
-		log.Errorf("minerWithdraw: wallet does not have key for %s", ownerAddr)
-		return fmt.Errorf("wallet does not have key for %s", ownerAddr)
-	}
-
-	sig, err := wallet.WalletSign(e.store, ownerAddr, msg.Cid().Bytes())
-	if err != nil {
-		log.Errorf("minerWithdraw: failed to sign: %v", err)
-		return err
-	}
-	signed := &types.SignedMessage{Message: *msg, Signature: *sig}
 
-	msgCid, err := e.node.MpoolPush(signed)
-	if err != nil {
-		log.Errorf("minerWithdraw: failed to push message: %v", err)
-		return err
-	}
-
-	_, err = e.node.StateWaitMsg(msgCid)
-	if err != nil {
-		log.Errorf("minerWithdraw: message %s failed: %v", msgCid, err)
-		return err
-	}
-
-	log.Infof("minerWithdraw: completed successfully, msgCid=%s", msgCid)
-	return err
+	return &PreparedMessage{
+		RequestType: RequestTypeMinerWithdraw,
+		Summary:     fmt.Sprintf("withdraw %s from miner %s to owner %s", val, minerAddr, ownerAddr),
+		Message:     msg,
+		Params:      withdrawParams,
+	}, nil
 }
 
-func (e *Executor) marketWithdraw(p MarketWithdrawPayload) error {
+func (e *Executor) buildMarketWithdraw(p MarketWithdrawPayload) (*PreparedMessage, error) {
+	var err error
 	idAddr := p.Address
 	if p.Address.Protocol() != address.ID {
-		_, err := e.node.StateLookupID(p.Address)
+		idAddr, err = e.node.StateLookupID(p.Address)
 		if err != nil {
 			log.Errorf("marketWithdraw: failed to lookup ID for %s: %v", p.Address, err)
-			return err
+			return nil, err
 		}
 	}
-	signAddr := p.Address
-	if p.Address.Protocol() == address.ID {
-		_, err := e.node.StateAccountKey(p.Address)
-		if err != nil {
-			log.Errorf("marketWithdraw: failed to get account key for %s: %v", p.Address, err)
-			return err
-		}
+	signAddr, err := e.resolveSender(p.Address)
+	if err != nil {
+		log.Errorf("marketWithdraw: failed to get account key for %s: %v", p.Address, err)
+		return nil, err
 	}
 
 	bal, err := e.node.StateMarketBalance(idAddr)
 	if err != nil {
 		log.Errorf("marketWithdraw: failed to get market balance: %v", err)
-		return err
+		return nil, err
 	}
 	available := types.BigSub(bal.Escrow, bal.Locked)
 	if types.BigCmp(types.BigInt(p.Amount), available) == 1 {
 		log.Errorf("marketWithdraw: requested %s > available %s", p.Amount, types.FIL(available))
-		return fmt.Errorf("requested %s > available %s", p.Amount, types.FIL(available))
+		return nil, fmt.Errorf("requested %s > available %s", p.Amount, types.FIL(available))
 	}
 
-	params, err := actors.SerializeParams(&markettypes.WithdrawBalanceParams{
+	withdrawParams := &markettypes.WithdrawBalanceParams{
 		ProviderOrClientAddress: idAddr,
 		Amount:                  types.BigInt(p.Amount),
-	})
+	}
+	params, err := actors.SerializeParams(withdrawParams)
 	if err != nil {
 		log.Errorf("marketWithdraw: failed to serialize params: %v", err)
-		return err
+		return nil, err
 	}
 
-	nonce, err := e.node.MpoolGetNonce(signAddr)
-	if err != nil {
-		log.Errorf("marketWithdraw: failed to get nonce: %v", err)
-		return err
-	}
 	msg := &types.Message{
 		Version:    0,
 		To:         builtintypes.StorageMarketActorAddr,
 		From:       signAddr,
-		Nonce:      nonce,
 		Value:      abi.NewTokenAmount(0),
 		GasLimit:   0,
 		GasFeeCap:  abi.NewTokenAmount(0),
@@ -282,313 +470,279 @@ func (e *Executor) marketWithdraw(p MarketWithdrawPayload) error {
 		Method:     builtintypes.MethodsMarket.WithdrawBalance,
 		Params:     params,
 	}
-	if err := wallet.SetGas(e.node, msg); err != nil {
-		log.Errorf("marketWithdraw: failed to set gas: %v", err)
-		return err
-	}
-
-	hasKey, err := wallet.WalletHas(e.store, signAddr)
-	if err != nil {
-		log.Errorf("marketWithdraw: failed to check key: %v", err)
-		return err
-	}
-	if !hasKey {
-		log.Errorf("marketWithdraw: wallet does not have key for %s", signAddr)
-		return fmt.Errorf("wallet does not have key for %s", signAddr)
-	}
-
-	sig, err := wallet.WalletSign(e.store, signAddr, msg.Cid().Bytes())
-	if err != nil {
-		log.Errorf("marketWithdraw: failed to sign: %v", err)
-		return err
-	}
-	signed := &types.SignedMessage{Message: *msg, Signature: *sig}
-
-	msgCid, err := e.node.MpoolPush(signed)
-	if err != nil {
-		log.Errorf("marketWithdraw: failed to push message: %v", err)
-		return err
-	}
-
-	_, err = e.node.StateWaitMsg(msgCid)
-	if err != nil {
-		log.Errorf("marketWithdraw: message %s failed: %v", msgCid, err)
-		return err
-	}
 
-	log.Infof("marketWithdraw: completed successfully, msgCid=%s", msgCid)
-	return err
+	return &PreparedMessage{
+		RequestType: RequestTypeMarketWithdraw,
+		Summary:     fmt.Sprintf("withdraw %s from market escrow of %s", p.Amount, idAddr),
+		Message:     msg,
+		Params:      withdrawParams,
+	}, nil
 }
 
-func (e *Executor) batchTransfer(p BatchTransferPayload) error {
+func (e *Executor) batchTransfer(p BatchTransferPayload) (*Result, error) {
+	batch := &Result{RequestType: RequestTypeBatchTransfer}
 
+	// 依次构建并推送所有消息，nonce 在本地连续预留，无需等待前一条消息上链
+	type pushed struct {
+		pm     *PreparedMessage
+		msgCid cid.Cid
+		record *models.Message
+	}
+	var (
+		sent    []pushed
+		pushErr error
+	)
 	for idx, item := range p.Items {
-		log.Infof("batchTransfer: processing item %d/%d", idx+1, len(p.Items))
-		data := TransferPayload{
-			From:   item.From,
-			To:     item.To,
-			Amount: item.Amount,
+		log.Infof("batchTransfer: pushing item %d/%d", idx+1, len(p.Items))
+		pm, err := e.Prepare(&Payload{
+			Type:     RequestTypeTransfer,
+			FromAddr: item.From,
+			ToAddr:   item.To,
+			Amount:   item.Amount,
+		})
+		if err != nil {
+			log.Errorf("batchTransfer: item %d failed: %v", idx+1, err)
+			pushErr = err
+			break
 		}
-		if err := e.transfer(data); err != nil {
+		msgCid, record, err := e.pushMessage(pm)
+		if err != nil {
 			log.Errorf("batchTransfer: item %d failed: %v", idx+1, err)
-			return err
+			pushErr = err
+			break
+		}
+		sent = append(sent, pushed{pm: pm, msgCid: msgCid, record: record})
+	}
+
+	// 并行等待已推送的消息上链
+	results := make([]*Result, len(sent))
+	errs := make([]error, len(sent))
+	sem := make(chan struct{}, batchWaitConcurrency)
+	var wg sync.WaitGroup
+	for i, s := range sent {
+		wg.Add(1)
+		go func(i int, s pushed) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i], errs[i] = e.waitMessage(s.pm, s.msgCid, s.record)
+		}(i, s)
+	}
+	wg.Wait()
+
+	var firstErr error
+	for i, res := range results {
+		if res != nil {
+			batch.Items = append(batch.Items, res)
+			batch.GasUsed += res.GasUsed
 		}
+		if errs[i] != nil && firstErr == nil {
+			log.Errorf("batchTransfer: item %d failed: %v", i+1, errs[i])
+			firstErr = errs[i]
+			if res != nil {
+				batch.ExitCode = res.ExitCode
+			}
+		}
+	}
+	if firstErr == nil {
+		firstErr = pushErr
+	}
+	if firstErr != nil {
+		return batch, firstErr
 	}
 
 	log.Infof("batchTransfer: completed all %d items", len(p.Items))
-	return nil
+	return batch, nil
 }
 
-func (e *Executor) changeMinerOwner(p MinerChangeOwnerPayload) error {
+// buildChangeMinerOwner 构建更改矿工 owner 的消息
+// 需要发送两次：先由旧 owner 提议，再由新 owner 确认
+func (e *Executor) buildChangeMinerOwner(p MinerChangeOwnerPayload) (*PreparedMessage, error) {
 	newAddrID, err := e.node.StateLookupID(p.NewOwner)
 	if err != nil {
 		log.Errorf("changeMinerOwner: failed to lookup new owner ID: %v", err)
-		return err
+		return nil, err
 	}
 	fromAddrID, err := e.node.StateLookupID(p.FromOwner)
 	if err != nil {
 		log.Errorf("changeMinerOwner: failed to lookup from owner ID: %v", err)
-		return err
+		return nil, err
 	}
 
 	minerInfo, err := e.node.StateMinerInfo(p.MinerID)
 	if err != nil {
 		log.Errorf("changeMinerOwner: failed to get miner info: %v", err)
-		return err
+		return nil, err
 	}
-	if fromAddrID != minerInfo.Owner && fromAddrID != newAddrID {
+
+	var summary string
+	switch fromAddrID {
+	case minerInfo.Owner:
+		if newAddrID == minerInfo.Owner {
+			log.Errorf("changeMinerOwner: %s is already the owner of %s", p.NewOwner, p.MinerID)
+			return nil, fmt.Errorf("%s is already the owner of %s", p.NewOwner, p.MinerID)
+		}
+		summary = fmt.Sprintf("propose owner change of miner %s from %s to %s (step 1/2, sent by old owner)", p.MinerID, minerInfo.Owner, newAddrID)
+	case newAddrID:
+		if minerInfo.PendingOwnerAddress == nil || *minerInfo.PendingOwnerAddress != newAddrID {
+			log.Errorf("changeMinerOwner: no pending owner change to %s", newAddrID)
+			return nil, fmt.Errorf("no pending owner change to %s, the old owner must propose it first", newAddrID)
+		}
+		summary = fmt.Sprintf("confirm owner change of miner %s from %s to %s (step 2/2, sent by new owner)", p.MinerID, minerInfo.Owner, newAddrID)
+	default:
 		log.Errorf("changeMinerOwner: from address must be old owner or new owner")
-		return fmt.Errorf("from address must be old owner or new owner")
+		return nil, fmt.Errorf("from address must be old owner or new owner")
 	}
 
-	params, err := actors.SerializeParams(&newAddrID)
+	fromAddr, err := e.resolveSender(p.FromOwner)
 	if err != nil {
-		log.Errorf("changeMinerOwner: failed to serialize params: %v", err)
-		return err
+		log.Errorf("changeMinerOwner: failed to get account key for %s: %v", p.FromOwner, err)
+		return nil, err
 	}
-	nonce, err := e.node.MpoolGetNonce(p.FromOwner)
+
+	params, err := actors.SerializeParams(&newAddrID)
 	if err != nil {
-		log.Errorf("changeMinerOwner: failed to get nonce: %v", err)
-		return err
+		log.Errorf("changeMinerOwner: failed to serialize params: %v", err)
+		return nil, err
 	}
 	msg := &types.Message{
-		From:   p.FromOwner,
+		From:   fromAddr,
 		To:     p.MinerID,
 		Method: builtintypes.MethodsMiner.ChangeOwnerAddress,
 		Value:  types.NewInt(0),
-		Nonce:  nonce,
 		Params: params,
 	}
-	if err := wallet.SetGas(e.node, msg); err != nil {
-		log.Errorf("changeMinerOwner: failed to set gas: %v", err)
-		return err
-	}
 
-	hasKey, err := wallet.WalletHas(e.store, p.FromOwner)
-	if err != nil {
-		log.Errorf("changeMinerOwner: failed to check key: %v", err)
-		return err
-	}
-	if !hasKey {
-		log.Errorf("changeMinerOwner: wallet does not have key for %s", p.FromOwner)
-		return fmt.Errorf("wallet does not have key for %s", p.FromOwner)
-	}
-
-	sig, err := wallet.WalletSign(e.store, p.FromOwner, msg.Cid().Bytes())
-	if err != nil {
-		log.Errorf("changeMinerOwner: failed to sign: %v", err)
-		return err
-	}
-	signed := &types.SignedMessage{Message: *msg, Signature: *sig}
-
-	msgCid, err := e.node.MpoolPush(signed)
-	if err != nil {
-		log.Errorf("changeMinerOwner: failed to push message: %v", err)
-		return err
-	}
-
-	_, err = e.node.StateWaitMsg(msgCid)
-	if err != nil {
-		log.Errorf("changeMinerOwner: message %s failed: %v", msgCid, err)
-		return err
-	}
-
-	log.Infof("changeMinerOwner: completed successfully, msgCid=%s", msgCid)
-	return err
+	return &PreparedMessage{
+		RequestType: RequestTypeMinerChangeOwner,
+		Summary:     summary,
+		Message:     msg,
+		Params:      newAddrID,
+	}, nil
 }
 
-func (e *Executor) changeMinerWorker(p MinerChangeWorkerPayload) error {
+// buildChangeMinerWorker 构建提议更改 worker 及 control 地址的消息
+// 新 worker 需要等待 WorkerChangeEpoch 之后再确认，control 地址立即生效
+func (e *Executor) buildChangeMinerWorker(p MinerChangeWorkerPayload) (*PreparedMessage, error) {
 	minerInfo, err := e.node.StateMinerInfo(p.MinerID)
 	if err != nil {
 		log.Errorf("changeMinerWorker: failed to get miner info: %v", err)
-		return err
+		return nil, err
 	}
 
 	newWorker := minerInfo.Worker
 	if p.NewWorker != address.Undef {
-		newWorker = p.NewWorker
+		newWorker, err = e.node.StateLookupID(p.NewWorker)
+		if err != nil {
+			log.Errorf("changeMinerWorker: failed to lookup new worker ID: %v", err)
+			return nil, err
+		}
+		if newWorker != minerInfo.Worker && newWorker == minerInfo.NewWorker {
+			log.Errorf("changeMinerWorker: worker change to %s already proposed", newWorker)
+			return nil, fmt.Errorf("worker change to %s already proposed, confirm it after epoch %d", newWorker, minerInfo.WorkerChangeEpoch)
+		}
 	}
 
 	controlAddrs := minerInfo.ControlAddresses
 	if len(p.NewControlAddrs) > 0 {
 		controlAddrs = make([]address.Address, 0, len(p.NewControlAddrs))
 		for _, a := range p.NewControlAddrs {
-			addr := a
+			addr, err := e.node.StateLookupID(a)
+			if err != nil {
+				log.Errorf("changeMinerWorker: failed to lookup control address %s: %v", a, err)
+				return nil, err
+			}
 			controlAddrs = append(controlAddrs, addr)
 		}
 	}
 
-	params, err := actors.SerializeParams(&minertypes.ChangeWorkerAddressParams{
+	// 提议当前 worker 可以撤销尚未确认的 worker 变更
+	cancelPending := p.NewWorker != address.Undef && !minerInfo.NewWorker.Empty()
+	if newWorker == minerInfo.Worker && len(p.NewControlAddrs) == 0 && !cancelPending {
+		log.Errorf("changeMinerWorker: nothing to change for %s", p.MinerID)
+		return nil, fmt.Errorf("%s is already the worker of %s and no control addresses given", newWorker, p.MinerID)
+	}
+
+	changeParams := &minertypes.ChangeWorkerAddressParams{
 		NewWorker:       newWorker,
 		NewControlAddrs: controlAddrs,
-	})
+	}
+	params, err := actors.SerializeParams(changeParams)
 	if err != nil {
 		log.Errorf("changeMinerWorker: failed to serialize params: %v", err)
-		return err
+		return nil, err
 	}
 
-	nonce, err := e.node.MpoolGetNonce(minerInfo.Owner)
-	if err != nil {
-		log.Errorf("changeMinerWorker: failed to get nonce: %v", err)
-		return err
-	}
-	owner, err := e.node.StateAccountKey(minerInfo.Owner)
+	owner, err := e.resolveSender(minerInfo.Owner)
 	if err != nil {
 		log.Errorf("changeMinerWorker: failed to get owner account key: %v", err)
-		return err
+		return nil, err
 	}
 	msg := &types.Message{
 		To:     p.MinerID,
 		From:   owner,
 		Value:  types.NewInt(0),
-		Nonce:  nonce,
 		Method: builtintypes.MethodsMiner.ChangeWorkerAddress,
 		Params: params,
 	}
-	if err = wallet.SetGas(e.node, msg); err != nil {
-		log.Errorf("changeMinerWorker: failed to set gas: %v", err)
-		return err
-	}
-
-	hasKey, err := wallet.WalletHas(e.store, msg.From)
-	if err != nil {
-		log.Errorf("changeMinerWorker: failed to check key: %v", err)
-		return err
-	}
-	if !hasKey {
-		log.Errorf("changeMinerWorker: wallet does not have key for %s", msg.From)
-		return fmt.Errorf("wallet does not have key for %s", msg.From)
-	}
-
-	sig, err := wallet.WalletSign(e.store, msg.From, msg.Cid().Bytes())
-	if err != nil {
-		log.Errorf("changeMinerWorker: failed to sign: %v", err)
-		return err
-	}
-	signed := &types.SignedMessage{Message: *msg, Signature: *sig}
-
-	msgCid, err := e.node.MpoolPush(signed)
-	if err != nil {
-		log.Errorf("changeMinerWorker: failed to push message: %v", err)
-		return err
-	}
-
-	_, err = e.node.StateWaitMsg(msgCid)
-	if err != nil {
-		log.Errorf("changeMinerWorker: message %s failed: %v", msgCid, err)
-		return err
-	}
 
-	log.Infof("changeMinerWorker: completed successfully, msgCid=%s", msgCid)
-	return err
+	return &PreparedMessage{
+		RequestType: RequestTypeMinerChangeWorker,
+		Summary:     fmt.Sprintf("propose worker change of miner %s from %s to %s with %d control addresses", p.MinerID, minerInfo.Worker, newWorker, len(controlAddrs)),
+		Message:     msg,
+		Params:      changeParams,
+	}, nil
 }
 
-func (e *Executor) confirmMinerWorker(reqID uint, p MinerConfirmWorkerPayload) error {
+// buildConfirmMinerWorker 构建确认更改 worker 的消息
+// 只有当前高度达到 WorkerChangeEpoch 后才能确认
+func (e *Executor) buildConfirmMinerWorker(p MinerConfirmWorkerPayload) (*PreparedMessage, error) {
 
 	minerInfo, err := e.node.StateMinerInfo(p.MinerID)
 	if err != nil {
 		log.Errorf("confirmMinerWorker: failed to get miner info: %v", err)
-		return err
+		return nil, err
 	}
 
 	newAddr, err := e.node.StateLookupID(p.NewWorker)
 	if err != nil {
 		log.Errorf("confirmMinerWorker: failed to lookup new worker ID: %v", err)
-		return err
+		return nil, err
 	}
 	if minerInfo.NewWorker.Empty() || minerInfo.NewWorker != newAddr {
 		log.Errorf("confirmMinerWorker: no matching worker change proposed")
-		return fmt.Errorf("no matching worker change proposed")
+		return nil, fmt.Errorf("no matching worker change proposed")
 	}
 
 	head, err := e.node.ChainHead()
 	if err != nil {
 		log.Errorf("confirmMinerWorker: failed to get chain head: %v", err)
-		return err
+		return nil, err
 	}
 	if head.Height() < minerInfo.WorkerChangeEpoch {
 		log.Errorf("confirmMinerWorker: cannot confirm until epoch %d, current height %d", minerInfo.WorkerChangeEpoch, head.Height())
-		return fmt.Errorf("cannot confirm until %d, current height %d", minerInfo.WorkerChangeEpoch, head.Height())
+		return nil, fmt.Errorf("cannot confirm until %d, current height %d", minerInfo.WorkerChangeEpoch, head.Height())
 	}
 
 	log.Infof("confirmMinerWorker: ready to confirm worker change at epoch %d", head.Height())
 
-	nonce, err := e.node.MpoolGetNonce(minerInfo.Owner)
-	if err != nil {
-		log.Errorf("confirmMinerWorker: failed to get nonce: %v", err)
-		return err
-	}
-	owner, err := e.node.StateAccountKey(minerInfo.Owner)
+	owner, err := e.resolveSender(minerInfo.Owner)
 	if err != nil {
 		log.Errorf("confirmMinerWorker: failed to get owner account key: %v", err)
-		return err
+		return nil, err
 	}
 	msg := &types.Message{
 		To:     p.MinerID,
 		From:   owner,
 		Value:  types.NewInt(0),
-		Nonce:  nonce,
 		Method: builtintypes.MethodsMiner.ConfirmChangeWorkerAddress,
 	}
-	if err := wallet.SetGas(e.node, msg); err != nil {
-		log.Errorf("confirmMinerWorker: failed to set gas: %v", err)
-		return err
-	}
-
-	hasKey, err := wallet.WalletHas(e.store, msg.From)
-	if err != nil {
-		log.Errorf("confirmMinerWorker: failed to check key: %v", err)
-		return err
-	}
-	if !hasKey {
-		log.Errorf("confirmMinerWorker: wallet does not have key for %s", msg.From)
-		return fmt.Errorf("wallet does not have key for %s", msg.From)
-	}
-
-	log.Infof("confirmMinerWorker: signing message for %s", msg.From)
-	sig, err := wallet.WalletSign(e.store, msg.From, msg.Cid().Bytes())
-	if err != nil {
-		log.Errorf("confirmMinerWorker: failed to sign: %v", err)
-		return err
-	}
-	signed := &types.SignedMessage{Message: *msg, Signature: *sig}
-
-	log.Infof("confirmMinerWorker: pushing message to mempool")
-	msgCid, err := e.node.MpoolPush(signed)
-	if err != nil {
-		log.Errorf("confirmMinerWorker: failed to push message: %v", err)
-		return err
-	}
-
-	log.Infof("confirmMinerWorker: waiting for message %s", msgCid)
-	_, err = e.node.StateWaitMsg(msgCid)
-	if err != nil {
-		log.Errorf("confirmMinerWorker: message %s failed: %v", msgCid, err)
-		return err
-	}
 
-	log.Infof("confirmMinerWorker: completed successfully, msgCid=%s", msgCid)
-	return err
+	return &PreparedMessage{
+		RequestType: RequestTypeMinerConfirmWorker,
+		Summary:     fmt.Sprintf("confirm worker change of miner %s from %s to %s", p.MinerID, minerInfo.Worker, newAddr),
+		Message:     msg,
+	}, nil
 }
 
 func contextBackground() context.Context {
@@ -0,0 +1,830 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
+
+	"wallet-sign/internal/chain/types"
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/lotusmock"
+	"wallet-sign/internal/repository"
+	"wallet-sign/internal/wallet"
+)
+
+// testEnv 连接到模拟 Lotus 节点的执行器和临时钱包数据库
+type testEnv struct {
+	t     *testing.T
+	node  *lotusmock.Node
+	store *repository.Store
+	ex    *Executor
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	return newTestEnvWith(t, false)
+}
+
+// newTestEnvWith 创建测试环境，webSocket 为 true 时通过 WebSocket 连接节点
+func newTestEnvWith(t *testing.T, webSocket bool) *testEnv {
+	t.Helper()
+	node := lotusmock.New()
+	node.Start()
+	t.Cleanup(node.Close)
+
+	host := node.URL()
+	if webSocket {
+		host = node.WebSocketURL()
+	}
+	prev := appcfg.LotusConfig.Lotus
+	appcfg.LotusConfig.Lotus = &appcfg.Lotus{Host: host, RetryBackoff: "1ms"}
+	t.Cleanup(func() { appcfg.LotusConfig.Lotus = prev })
+
+	t.Setenv(repository.PassphraseEnv, "e2e-test")
+	store, err := repository.OpenStore(filepath.Join(t.TempDir(), "wallet.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() {
+		if db, err := store.DB.DB(); err == nil {
+			db.Close()
+		}
+	})
+	if err := store.EnsureUnlocked(); err != nil {
+		t.Fatalf("unlock keystore: %v", err)
+	}
+
+	return &testEnv{t: t, node: node, store: store, ex: NewExecutor(store)}
+}
+
+// newKey 生成 secp256k1 密钥保存到钱包，并在链上创建余额为 balance 的账户
+func (env *testEnv) newKey(balance abi.TokenAmount) address.Address {
+	env.t.Helper()
+	ki, addr, err := wallet.WalletNew(types.KTSecp256k1)
+	if err != nil {
+		env.t.Fatalf("new key: %v", err)
+	}
+	if err := env.store.SaveWalletKey(addr.String(), *ki); err != nil {
+		env.t.Fatalf("save key: %v", err)
+	}
+	env.node.AddAccount(addr, balance)
+	return addr
+}
+
+// newAddress 生成钱包中没有密钥、链上也不存在的地址
+func (env *testEnv) newAddress() address.Address {
+	env.t.Helper()
+	_, addr, err := wallet.WalletNew(types.KTSecp256k1)
+	if err != nil {
+		env.t.Fatalf("new key: %v", err)
+	}
+	return addr
+}
+
+func (env *testEnv) id(addr address.Address) address.Address {
+	env.t.Helper()
+	id, ok := env.node.LookupID(addr)
+	if !ok {
+		env.t.Fatalf("%s not found on chain", addr)
+	}
+	return id
+}
+
+// miner 创建 owner、worker 账户和可提现余额为 50 FIL 的矿工
+func (env *testEnv) miner() (miner, owner, worker address.Address) {
+	owner = env.newKey(fil(10))
+	worker = env.newKey(fil(10))
+	miner = env.node.AddMiner(owner, worker, fil(50))
+	return miner, owner, worker
+}
+
+// multisig 创建签名人为 signers、余额为 20 FIL 的多签钱包
+func (env *testEnv) multisig(threshold uint64, signers ...address.Address) address.Address {
+	msig, _ := env.node.AddMultisig(signers, threshold, fil(20))
+	return msig
+}
+
+func fil(n uint64) abi.TokenAmount {
+	return types.FromFil(n)
+}
+
+func expectBalance(t *testing.T, env *testEnv, addr address.Address, want abi.TokenAmount) {
+	t.Helper()
+	if got := env.node.Balance(addr); !got.Equals(want) {
+		t.Errorf("balance of %s = %s, want %s", addr, types.FIL(got), types.FIL(want))
+	}
+}
+
+// e2eCase 一种请求的端到端场景
+// setup 准备链状态并返回请求和签名发送方，check 检查请求成功执行后的链状态
+type e2eCase struct {
+	name  string
+	setup func(env *testEnv) (*Payload, address.Address)
+	check func(t *testing.T, env *testEnv, req *Payload, res *Result)
+}
+
+var e2eCases = []e2eCase{
+	{
+		name: RequestTypeTransfer,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			from := env.newKey(fil(100))
+			return &Payload{Type: RequestTypeTransfer, FromAddr: from, ToAddr: env.newAddress(), Amount: types.FIL(fil(1))}, from
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			expectBalance(t, env, req.ToAddr, fil(1))
+			if res.FeeBurned == nil || res.FeeBurned.Sign() <= 0 {
+				t.Errorf("fee burned = %v, want > 0", res.FeeBurned)
+			}
+		},
+	},
+	{
+		name: RequestTypeBatchTransfer,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			from := env.newKey(fil(100))
+			return &Payload{Type: RequestTypeBatchTransfer, Items: []BatchTransferItem{
+				{From: from, To: env.newAddress(), Amount: types.FIL(fil(1))},
+				{From: from, To: env.newAddress(), Amount: types.FIL(fil(2))},
+			}}, from
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			if len(res.Items) != 2 {
+				t.Fatalf("got %d items, want 2", len(res.Items))
+			}
+			expectBalance(t, env, req.Items[0].To, fil(1))
+			expectBalance(t, env, req.Items[1].To, fil(2))
+		},
+	},
+	{
+		name: RequestTypeMinerWithdraw,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			miner, owner, _ := env.miner()
+			return &Payload{Type: RequestTypeMinerWithdraw, MinerID: miner, Amount: types.FIL(fil(10))}, owner
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			if got := env.node.MinerAvailable(req.MinerID); !got.Equals(fil(40)) {
+				t.Errorf("available = %s, want 40 FIL", types.FIL(got))
+			}
+		},
+	},
+	{
+		name: RequestTypeMarketWithdraw,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			client := env.newKey(fil(10))
+			env.node.SetMarketBalance(client, fil(5), fil(1))
+			return &Payload{Type: RequestTypeMarketWithdraw, MinerID: client, Amount: types.FIL(fil(2))}, client
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			if got := env.node.MarketBalance(req.MinerID); !got.Escrow.Equals(fil(3)) {
+				t.Errorf("escrow = %s, want 3 FIL", types.FIL(got.Escrow))
+			}
+		},
+	},
+	{
+		name: RequestTypeMinerChangeOwner + "/propose",
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			miner, owner, _ := env.miner()
+			newOwner := env.newKey(fil(10))
+			return &Payload{Type: RequestTypeMinerChangeOwner, MinerID: miner, NewOwner: newOwner, FromOwner: owner}, owner
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			info := env.node.MinerInfo(req.MinerID)
+			if info.PendingOwnerAddress == nil || *info.PendingOwnerAddress != env.id(req.NewOwner) {
+				t.Errorf("pending owner = %v, want %s", info.PendingOwnerAddress, env.id(req.NewOwner))
+			}
+		},
+	},
+	{
+		name: RequestTypeMinerChangeOwner + "/confirm",
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			miner, _, _ := env.miner()
+			newOwner := env.newKey(fil(10))
+			newID := env.id(newOwner)
+			env.node.UpdateMinerInfo(miner, func(info *types.MinerInfo) { info.PendingOwnerAddress = &newID })
+			return &Payload{Type: RequestTypeMinerChangeOwner, MinerID: miner, NewOwner: newOwner, FromOwner: newOwner}, newOwner
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			info := env.node.MinerInfo(req.MinerID)
+			if info.Owner != env.id(req.NewOwner) || info.PendingOwnerAddress != nil {
+				t.Errorf("owner = %s, pending %v, want %s", info.Owner, info.PendingOwnerAddress, env.id(req.NewOwner))
+			}
+		},
+	},
+	{
+		name: RequestTypeMinerChangeWorker,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			miner, owner, _ := env.miner()
+			return &Payload{Type: RequestTypeMinerChangeWorker, MinerID: miner, NewWorker: env.newKey(fil(1))}, owner
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			info := env.node.MinerInfo(req.MinerID)
+			if info.NewWorker != env.id(req.NewWorker) || info.WorkerChangeEpoch <= res.Height {
+				t.Errorf("new worker = %s at %d, want %s after %d", info.NewWorker, info.WorkerChangeEpoch, env.id(req.NewWorker), res.Height)
+			}
+		},
+	},
+	{
+		name: RequestTypeMinerConfirmWorker,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			miner, owner, _ := env.miner()
+			newWorker := env.newKey(fil(1))
+			newID := env.id(newWorker)
+			height := env.node.Height()
+			env.node.UpdateMinerInfo(miner, func(info *types.MinerInfo) {
+				info.NewWorker = newID
+				info.WorkerChangeEpoch = height
+			})
+			return &Payload{Type: RequestTypeMinerConfirmWorker, MinerID: miner, NewWorker: newWorker}, owner
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			if info := env.node.MinerInfo(req.MinerID); info.Worker != env.id(req.NewWorker) || !info.NewWorker.Empty() {
+				t.Errorf("worker = %s, pending %s, want %s", info.Worker, info.NewWorker, env.id(req.NewWorker))
+			}
+		},
+	},
+	{
+		name: RequestTypeMinerSetControl,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			miner, owner, _ := env.miner()
+			controls := []address.Address{env.newKey(fil(1)), env.newKey(fil(1))}
+			return &Payload{Type: RequestTypeMinerSetControl, MinerID: miner, NewControlAddrs: controls}, owner
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			info := env.node.MinerInfo(req.MinerID)
+			if len(info.ControlAddresses) != 2 || info.ControlAddresses[0] != env.id(req.NewControlAddrs[0]) || info.ControlAddresses[1] != env.id(req.NewControlAddrs[1]) {
+				t.Errorf("control addresses = %v", info.ControlAddresses)
+			}
+		},
+	},
+	{
+		name: RequestTypeMinerProposeBeneficiary,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			miner, owner, _ := env.miner()
+			return &Payload{
+				Type:        RequestTypeMinerProposeBeneficiary,
+				MinerID:     miner,
+				Beneficiary: env.newKey(fil(1)),
+				Quota:       types.FIL(fil(10)),
+				Expiration:  env.node.Height() + 10000,
+			}, owner
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			pending := env.node.MinerInfo(req.MinerID).PendingBeneficiaryTerm
+			if pending == nil || pending.NewBeneficiary != env.id(req.Beneficiary) || !pending.ApprovedByBeneficiary || pending.ApprovedByNominee {
+				t.Errorf("pending beneficiary = %+v", pending)
+			}
+		},
+	},
+	{
+		name: RequestTypeMinerConfirmBeneficiary,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			miner, _, _ := env.miner()
+			nominee := env.newKey(fil(1))
+			nomineeID := env.id(nominee)
+			expiration := env.node.Height() + 10000
+			env.node.UpdateMinerInfo(miner, func(info *types.MinerInfo) {
+				info.PendingBeneficiaryTerm = &types.PendingBeneficiaryChange{
+					NewBeneficiary:        nomineeID,
+					NewQuota:              fil(10),
+					NewExpiration:         expiration,
+					ApprovedByBeneficiary: true,
+				}
+			})
+			return &Payload{Type: RequestTypeMinerConfirmBeneficiary, MinerID: miner, FromAddr: nominee}, nominee
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			info := env.node.MinerInfo(req.MinerID)
+			if info.Beneficiary != env.id(req.FromAddr) || info.BeneficiaryTerm == nil || !info.BeneficiaryTerm.Quota.Equals(fil(10)) {
+				t.Errorf("beneficiary = %s, term %+v", info.Beneficiary, info.BeneficiaryTerm)
+			}
+		},
+	},
+	{
+		name: RequestTypeMsigCreate,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			from := env.newKey(fil(100))
+			return &Payload{
+				Type:      RequestTypeMsigCreate,
+				FromAddr:  from,
+				Signers:   []address.Address{from, env.newAddress()},
+				Threshold: 2,
+				Amount:    types.FIL(fil(5)),
+			}, from
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			ret, ok := res.Return.(*MsigCreateResult)
+			if !ok {
+				t.Fatalf("return = %#v, want *MsigCreateResult", res.Return)
+			}
+			expectBalance(t, env, ret.IDAddress, fil(5))
+			if signers, threshold := env.node.MsigSigners(ret.RobustAddress); len(signers) != 2 || threshold != 2 {
+				t.Errorf("signers = %v, threshold %d", signers, threshold)
+			}
+		},
+	},
+	{
+		name: RequestTypeMsigPropose,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			proposer := env.newKey(fil(10))
+			msig := env.multisig(2, proposer, env.newKey(fil(10)))
+			return &Payload{Type: RequestTypeMsigPropose, Multisig: msig, ToAddr: env.newAddress(), Amount: types.FIL(fil(1)), Proposer: proposer}, proposer
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			ret, ok := res.Return.(*MsigTxnResult)
+			if !ok || ret.Applied {
+				t.Fatalf("return = %#v, want a pending proposal", res.Return)
+			}
+			if pending := env.node.MsigPending(req.Multisig); len(pending) != 1 || pending[0].To != req.ToAddr {
+				t.Errorf("pending = %v", pending)
+			}
+		},
+	},
+	{
+		name: RequestTypeMsigApprove,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			signer, proposer := env.newKey(fil(10)), env.newKey(fil(10))
+			msig := env.multisig(2, signer, proposer)
+			txn := env.node.AddMsigTxn(msig, proposer, env.newAddress(), fil(1), 0, nil)
+			return &Payload{Type: RequestTypeMsigApprove, Multisig: msig, FromAddr: signer, TxnID: txn}, signer
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			ret, ok := res.Return.(*MsigTxnResult)
+			if !ok || !ret.Applied || ret.ExitCode != 0 {
+				t.Fatalf("return = %#v, want an applied transaction", res.Return)
+			}
+			if pending := env.node.MsigPending(req.Multisig); len(pending) != 0 {
+				t.Errorf("pending = %v, want none", pending)
+			}
+			expectBalance(t, env, req.Multisig, fil(19))
+		},
+	},
+	{
+		name: RequestTypeMsigCancel,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			proposer := env.newKey(fil(10))
+			msig := env.multisig(2, proposer, env.newKey(fil(10)))
+			txn := env.node.AddMsigTxn(msig, proposer, env.newAddress(), fil(1), 0, nil)
+			return &Payload{Type: RequestTypeMsigCancel, Multisig: msig, FromAddr: proposer, TxnID: txn}, proposer
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			if pending := env.node.MsigPending(req.Multisig); len(pending) != 0 {
+				t.Errorf("pending = %v, want none", pending)
+			}
+		},
+	},
+	{
+		name: RequestTypeMsigAddSigner,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			proposer := env.newKey(fil(10))
+			msig := env.multisig(1, proposer)
+			return &Payload{Type: RequestTypeMsigAddSigner, Multisig: msig, NewSigner: env.newKey(fil(1)), ChangeThreshold: true, Proposer: proposer}, proposer
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			signers, threshold := env.node.MsigSigners(req.Multisig)
+			if len(signers) != 2 || signers[1] != env.id(req.NewSigner) || threshold != 2 {
+				t.Errorf("signers = %v, threshold %d", signers, threshold)
+			}
+		},
+	},
+	{
+		name: RequestTypeMsigRemoveSigner,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			proposer, signer := env.newKey(fil(10)), env.newKey(fil(10))
+			msig := env.multisig(1, proposer, signer)
+			return &Payload{Type: RequestTypeMsigRemoveSigner, Multisig: msig, Signer: signer, Proposer: proposer}, proposer
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			if signers, _ := env.node.MsigSigners(req.Multisig); len(signers) != 1 || signers[0] != env.id(req.Proposer) {
+				t.Errorf("signers = %v", signers)
+			}
+		},
+	},
+	{
+		name: RequestTypeMsigSwapSigner,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			proposer, signer := env.newKey(fil(10)), env.newKey(fil(10))
+			msig := env.multisig(1, proposer, signer)
+			return &Payload{Type: RequestTypeMsigSwapSigner, Multisig: msig, Signer: signer, NewSigner: env.newKey(fil(1)), Proposer: proposer}, proposer
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			if signers, _ := env.node.MsigSigners(req.Multisig); len(signers) != 2 || signers[1] != env.id(req.NewSigner) {
+				t.Errorf("signers = %v", signers)
+			}
+		},
+	},
+	{
+		name: RequestTypeMsigThreshold,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			proposer := env.newKey(fil(10))
+			msig := env.multisig(1, proposer, env.newKey(fil(10)))
+			return &Payload{Type: RequestTypeMsigThreshold, Multisig: msig, Threshold: 2, Proposer: proposer}, proposer
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			if _, threshold := env.node.MsigSigners(req.Multisig); threshold != 2 {
+				t.Errorf("threshold = %d, want 2", threshold)
+			}
+		},
+	},
+	{
+		name: RequestTypeInvoke,
+		setup: func(env *testEnv) (*Payload, address.Address) {
+			miner, owner, _ := env.miner()
+			return &Payload{
+				Type:       RequestTypeInvoke,
+				FromAddr:   owner,
+				ToAddr:     miner,
+				MethodName: "WithdrawBalance",
+				ParamsJSON: json.RawMessage(`{"AmountRequested":"1000000000000000000"}`),
+			}, owner
+		},
+		check: func(t *testing.T, env *testEnv, req *Payload, res *Result) {
+			if res.Return == nil {
+				t.Errorf("return value was not decoded")
+			}
+			if got := env.node.MinerAvailable(req.ToAddr); !got.Equals(fil(49)) {
+				t.Errorf("available = %s, want 49 FIL", types.FIL(got))
+			}
+		},
+	},
+}
+
+// TestExecutorE2E 对每种请求执行成功路径，以及余额不足、退出码非零和缺少密钥三种失败路径
+func TestExecutorE2E(t *testing.T) {
+	for _, tc := range e2eCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Run("success", func(t *testing.T) {
+				env := newTestEnv(t)
+				req, _ := tc.setup(env)
+				res, err := env.ex.Execute(req)
+				if err != nil {
+					t.Fatalf("execute: %v", err)
+				}
+				if res.ExitCode != 0 {
+					t.Fatalf("exit code = %d", res.ExitCode)
+				}
+				tc.check(t, env, req, res)
+			})
+
+			t.Run("insufficient balance", func(t *testing.T) {
+				env := newTestEnv(t)
+				req, sender := tc.setup(env)
+				env.node.SetBalance(sender, big.NewInt(1))
+				_, err := env.ex.Execute(req)
+				if err == nil || !isInsufficientFunds(err) {
+					t.Fatalf("error = %v, want insufficient funds", err)
+				}
+				if pending := env.node.Pending(); len(pending) != 0 {
+					t.Errorf("%d messages were pushed", len(pending))
+				}
+				expectNoReservations(t, env, sender)
+			})
+
+			t.Run("exit code", func(t *testing.T) {
+				env := newTestEnv(t)
+				req, sender := tc.setup(env)
+				env.node.FailNext(sender, exitcode.ErrForbidden)
+				res, err := env.ex.Execute(req)
+				var failed *MessageFailedError
+				if !errors.As(err, &failed) || failed.ExitCode != int64(exitcode.ErrForbidden) {
+					t.Fatalf("error = %v, want exit code %d", err, exitcode.ErrForbidden)
+				}
+				if res == nil || res.ExitCode != int64(exitcode.ErrForbidden) {
+					t.Fatalf("result = %+v, want exit code %d", res, exitcode.ErrForbidden)
+				}
+				if env.node.Nonce(sender) == 0 {
+					t.Errorf("nonce = 0, the failed message must still be on chain")
+				}
+			})
+
+			t.Run("missing key", func(t *testing.T) {
+				env := newTestEnv(t)
+				req, sender := tc.setup(env)
+				if err := env.store.DeleteWalletKey(sender.String()); err != nil {
+					t.Fatalf("delete key: %v", err)
+				}
+				_, err := env.ex.Execute(req)
+				if err == nil || !strings.Contains(err.Error(), "wallet does not have key") {
+					t.Fatalf("error = %v, want missing key", err)
+				}
+				if n := env.node.Calls("MpoolPush"); n != 0 {
+					t.Errorf("MpoolPush called %d times", n)
+				}
+				expectNoReservations(t, env, sender)
+			})
+		})
+	}
+}
+
+// isInsufficientFunds 余额不足以支付金额时 Gas 估算失败，不足以支付 Gas 时推送失败
+func isInsufficientFunds(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "not enough funds") || strings.Contains(msg, "SysErrInsufficientFunds")
+}
+
+// expectNoReservations 检查失败的请求释放了预留的 nonce
+func expectNoReservations(t *testing.T, env *testEnv, sender address.Address) {
+	t.Helper()
+	reserved, err := env.store.ListNonceReservations(sender.String())
+	if err != nil {
+		t.Fatalf("list reservations: %v", err)
+	}
+	if len(reserved) != 0 {
+		t.Errorf("%d nonce reservations left for %s", len(reserved), sender)
+	}
+}
+
+func TestExecutorE2EMinerWithdrawExceedsAvailable(t *testing.T) {
+	env := newTestEnv(t)
+	miner, _, _ := env.miner()
+	_, err := env.ex.Execute(&Payload{Type: RequestTypeMinerWithdraw, MinerID: miner, Amount: types.FIL(fil(51))})
+	if err == nil || !strings.Contains(err.Error(), "> available") {
+		t.Fatalf("error = %v, want requested > available", err)
+	}
+	if n := env.node.Calls("MpoolPush"); n != 0 {
+		t.Errorf("MpoolPush called %d times", n)
+	}
+}
+
+func TestExecutorE2EConfirmWorkerTooEarly(t *testing.T) {
+	env := newTestEnv(t)
+	miner, _, _ := env.miner()
+	req := &Payload{Type: RequestTypeMinerChangeWorker, MinerID: miner, NewWorker: env.newKey(fil(1))}
+	if _, err := env.ex.Execute(req); err != nil {
+		t.Fatalf("change worker: %v", err)
+	}
+
+	confirm := &Payload{Type: RequestTypeMinerConfirmWorker, MinerID: miner, NewWorker: req.NewWorker}
+	if _, err := env.ex.Execute(confirm); err == nil || !strings.Contains(err.Error(), "cannot confirm until") {
+		t.Fatalf("error = %v, want cannot confirm yet", err)
+	}
+	env.node.Advance(int(env.node.MinerInfo(miner).WorkerChangeEpoch - env.node.Height()))
+	if _, err := env.ex.Execute(confirm); err != nil {
+		t.Fatalf("confirm worker: %v", err)
+	}
+	if info := env.node.MinerInfo(miner); info.Worker != env.id(req.NewWorker) {
+		t.Errorf("worker = %s, want %s", info.Worker, env.id(req.NewWorker))
+	}
+}
+
+// TestExecutorE2EMsigInsufficientBalance 提案立即执行但多签钱包余额不足：外层消息成功，被执行的交易失败
+func TestExecutorE2EMsigInsufficientBalance(t *testing.T) {
+	env := newTestEnv(t)
+	proposer := env.newKey(fil(10))
+	msig := env.multisig(1, proposer)
+	to := env.newAddress()
+	res, err := env.ex.Execute(&Payload{Type: RequestTypeMsigPropose, Multisig: msig, ToAddr: to, Amount: types.FIL(fil(21)), Proposer: proposer})
+
+	var failed *MessageFailedError
+	if !errors.As(err, &failed) || failed.ExitCode != int64(exitcode.ErrInsufficientFunds) {
+		t.Fatalf("error = %v, want exit code %d", err, exitcode.ErrInsufficientFunds)
+	}
+	if ret, ok := res.Return.(*MsigTxnResult); !ok || !ret.Applied {
+		t.Fatalf("return = %#v, want an applied transaction", res.Return)
+	}
+	expectBalance(t, env, msig, fil(20))
+	expectBalance(t, env, to, big.Zero())
+}
+
+// TestExecutorE2EReplace 消息滞留在内存池时以更高的小费替换
+func TestExecutorE2EReplace(t *testing.T) {
+	env := newTestEnv(t)
+	env.node.SetAutoMine(0)
+	from := env.newKey(fil(100))
+	to := env.newAddress()
+
+	pm, err := env.ex.Prepare(&Payload{Type: RequestTypeTransfer, FromAddr: from, ToAddr: to, Amount: types.FIL(fil(1))})
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	oldCid, _, err := env.ex.pushMessage(pm)
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	old, err := env.ex.PendingMessage(oldCid)
+	if err != nil {
+		t.Fatalf("pending message: %v", err)
+	}
+	rep, err := env.ex.Replace(old, ReplaceOptions{})
+	if err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if rep.GasPremium.LessThan(ComputeMinRBF(old.Message.GasPremium)) {
+		t.Errorf("premium %s is below the minimum replacement premium", rep.GasPremium)
+	}
+
+	pending := env.node.Pending()
+	if len(pending) != 1 || pending[0].Cid() != rep.MsgCid {
+		t.Fatalf("mpool = %v, want only the replacement", pending)
+	}
+	env.node.Advance(lotusmock.DefaultAutoMine)
+	if env.node.Receipt(oldCid) != nil || env.node.Receipt(rep.MsgCid) == nil {
+		t.Errorf("replacement was not mined instead of the original message")
+	}
+	expectBalance(t, env, to, fil(1))
+}
+
+// TestExecutorE2EWebSocket 通过 WebSocket 连接时由链头订阅等待消息
+func TestExecutorE2EWebSocket(t *testing.T) {
+	env := newTestEnvWith(t, true)
+	from := env.newKey(fil(100))
+	to := env.newAddress()
+	res, err := env.ex.Execute(&Payload{Type: RequestTypeTransfer, FromAddr: from, ToAddr: to, Amount: types.FIL(fil(1))})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if n := env.node.Calls("ChainNotify"); n == 0 {
+		t.Errorf("message was not waited for through ChainNotify")
+	}
+	if n := env.node.Calls("StateWaitMsg"); n != 0 {
+		t.Errorf("StateWaitMsg called %d times", n)
+	}
+	if res.Height == 0 {
+		t.Errorf("result has no height")
+	}
+	expectBalance(t, env, to, fil(1))
+}
+
+// TestExecutorE2ERetry 只读请求遇到 502 时重试，推送失败时确认节点上没有消息后重新推送
+func TestExecutorE2ERetry(t *testing.T) {
+	env := newTestEnv(t)
+	from := env.newKey(fil(100))
+	to := env.newAddress()
+	env.node.FailRPC("GasEstimateMessageGas", 502, 2)
+	env.node.FailRPC("MpoolPush", 503, 1)
+
+	if _, err := env.ex.Execute(&Payload{Type: RequestTypeTransfer, FromAddr: from, ToAddr: to, Amount: types.FIL(fil(1))}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if n := env.node.Calls("GasEstimateMessageGas"); n != 1 {
+		t.Errorf("GasEstimateMessageGas reached the node %d times, want 1", n)
+	}
+	expectBalance(t, env, to, fil(1))
+}
+
+// TestExecutorE2EKeyNetwork 密钥在第一次签名时记录网络，切换到其他网络后拒绝签名
+func TestExecutorE2EKeyNetwork(t *testing.T) {
+	env := newTestEnv(t)
+	from := env.newKey(fil(100))
+	transfer := &Payload{Type: RequestTypeTransfer, FromAddr: from, ToAddr: env.newAddress(), Amount: types.FIL(fil(1))}
+	if _, err := env.ex.Execute(transfer); err != nil {
+		t.Fatalf("execute on mainnet: %v", err)
+	}
+
+	prev := appcfg.LotusConfig.Networks
+	appcfg.LotusConfig.Networks = map[string]*appcfg.Network{appcfg.NetworkCalibnet: {Host: env.node.URL()}}
+	t.Cleanup(func() {
+		appcfg.LotusConfig.Networks = prev
+		if err := appcfg.UseNetwork(""); err != nil {
+			t.Errorf("restore network: %v", err)
+		}
+	})
+	if err := appcfg.UseNetwork(appcfg.NetworkCalibnet); err != nil {
+		t.Fatalf("use calibnet: %v", err)
+	}
+	env.node.SetNetwork("calibrationnet")
+	if !strings.HasPrefix(from.String(), "t1") {
+		t.Errorf("address %s does not use the testnet prefix", from)
+	}
+
+	env.ex = NewExecutor(env.store)
+	_, err := env.ex.Execute(transfer)
+	if err == nil || !strings.Contains(err.Error(), "is used on network mainnet") {
+		t.Fatalf("error = %v, want key used on mainnet", err)
+	}
+	expectNoReservations(t, env, from)
+
+	if err := env.store.SetWalletKeyNetwork(from.String(), appcfg.NetworkCalibnet); err != nil {
+		t.Fatalf("set network: %v", err)
+	}
+	if _, err := env.ex.Execute(transfer); err != nil {
+		t.Fatalf("execute on calibnet: %v", err)
+	}
+	expectBalance(t, env, transfer.ToAddr, fil(2))
+}
+
+// TestExecutorE2EV1Endpoint 内置的 calibnet、devnet 配置使用 /rpc/v1 地址，按 v1 API 的参数等待消息
+func TestExecutorE2EV1Endpoint(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		host func(n *lotusmock.Node) string
+		wait string // 等待消息使用的方法
+	}{
+		{"http", (*lotusmock.Node).V1URL, "StateWaitMsg"},
+		{"websocket", (*lotusmock.Node).WebSocketV1URL, "StateSearchMsg"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			prev := appcfg.LotusConfig.Networks
+			appcfg.LotusConfig.Networks = map[string]*appcfg.Network{appcfg.NetworkCalibnet: {Host: tc.host(env.node)}}
+			t.Cleanup(func() {
+				appcfg.LotusConfig.Networks = prev
+				if err := appcfg.UseNetwork(""); err != nil {
+					t.Errorf("restore network: %v", err)
+				}
+			})
+			if err := appcfg.UseNetwork(appcfg.NetworkCalibnet); err != nil {
+				t.Fatalf("use calibnet: %v", err)
+			}
+			env.node.SetNetwork("calibrationnet")
+
+			env.ex = NewExecutor(env.store)
+			from := env.newKey(fil(100))
+			to := env.newAddress()
+			res, err := env.ex.Execute(&Payload{Type: RequestTypeTransfer, FromAddr: from, ToAddr: to, Amount: types.FIL(fil(1))})
+			if err != nil {
+				t.Fatalf("execute: %v", err)
+			}
+			if n := env.node.Calls(tc.wait); n == 0 {
+				t.Errorf("message was not waited for through %s", tc.wait)
+			}
+			if res.Height == 0 {
+				t.Errorf("result has no height")
+			}
+			expectBalance(t, env, to, fil(1))
+
+			lookup, err := env.ex.node.StateSearchMsg(res.MsgCid)
+			if err != nil {
+				t.Fatalf("search message: %v", err)
+			}
+			if lookup == nil || lookup.Height != res.Height {
+				t.Errorf("lookup = %+v, want message at height %d", lookup, res.Height)
+			}
+		})
+	}
+}
+
+// useNetwork 选择网络，测试结束后恢复为未选择网络
+func useNetwork(t *testing.T, name string) {
+	t.Helper()
+	t.Cleanup(func() {
+		if err := appcfg.UseNetwork(""); err != nil {
+			t.Errorf("restore network: %v", err)
+		}
+	})
+	if err := appcfg.UseNetwork(name); err != nil {
+		t.Fatalf("use network %q: %v", name, err)
+	}
+}
+
+// TestExecutorE2ENetworkMismatch 选择的网络与节点所在网络不一致时拒绝发送请求
+func TestExecutorE2ENetworkMismatch(t *testing.T) {
+	env := newTestEnv(t)
+	prev := appcfg.LotusConfig.Networks
+	appcfg.LotusConfig.Networks = map[string]*appcfg.Network{appcfg.NetworkCalibnet: {Host: env.node.URL()}}
+	t.Cleanup(func() { appcfg.LotusConfig.Networks = prev })
+	useNetwork(t, appcfg.NetworkCalibnet)
+
+	env.ex = NewExecutor(env.store)
+	from := env.newKey(fil(100))
+	_, err := env.ex.Execute(&Payload{Type: RequestTypeTransfer, FromAddr: from, ToAddr: env.newAddress(), Amount: types.FIL(fil(1))})
+	if err == nil || !strings.Contains(err.Error(), `expects "calibrationnet"`) {
+		t.Fatalf("error = %v, want network mismatch", err)
+	}
+	if n := env.node.Calls("MpoolPush"); n != 0 {
+		t.Errorf("MpoolPush called %d times on the wrong network", n)
+	}
+	expectNoReservations(t, env, from)
+}
+
+// TestExecutorE2EDetectNetwork 未选择网络时使用节点所在的网络，没有对应的网络配置时拒绝
+func TestExecutorE2EDetectNetwork(t *testing.T) {
+	env := newTestEnv(t)
+	useNetwork(t, "")
+	env.node.SetNetwork("calibrationnet")
+
+	env.ex = NewExecutor(env.store)
+	from := env.newKey(fil(100))
+	to := env.newAddress()
+	if _, err := env.ex.Execute(&Payload{Type: RequestTypeTransfer, FromAddr: from, ToAddr: to, Amount: types.FIL(fil(1))}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if name := appcfg.NetworkName(); name != appcfg.NetworkCalibnet {
+		t.Errorf("network = %s, want %s", name, appcfg.NetworkCalibnet)
+	}
+	if id := appcfg.EthChainID(); id != 314159 {
+		t.Errorf("chain ID = %d, want calibnet", id)
+	}
+	if !strings.HasPrefix(from.String(), "t1") {
+		t.Errorf("address %s does not use the testnet prefix", from)
+	}
+	if network, err := env.store.WalletKeyNetwork(from.String()); err != nil || network != appcfg.NetworkCalibnet {
+		t.Errorf("key network = %q (%v), want %s", network, err, appcfg.NetworkCalibnet)
+	}
+	expectBalance(t, env, to, fil(1))
+
+	useNetwork(t, "")
+	env.node.SetNetwork("localnet-1234")
+	env.ex = NewExecutor(env.store)
+	_, err := env.ex.Execute(&Payload{Type: RequestTypeTransfer, FromAddr: from, ToAddr: to, Amount: types.FIL(fil(1))})
+	if err == nil || !strings.Contains(err.Error(), "matches no network") {
+		t.Fatalf("error = %v, want unknown network", err)
+	}
+	if name := appcfg.NetworkName(); name != appcfg.NetworkMainnet {
+		t.Errorf("network = %s after an unknown node, want %s until verified", name, appcfg.NetworkMainnet)
+	}
+}
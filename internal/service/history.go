@@ -0,0 +1,112 @@
+package service
+
+import (
+	"github.com/ipfs/go-cid"
+
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/models"
+	"wallet-sign/internal/repository"
+)
+
+// messageRecord 根据未签名消息构建消息记录
+func messageRecord(requestType string, msg *types.Message, status string) *models.Message {
+	item := &models.Message{
+		UnsignedCid: msg.Cid().String(),
+		RequestType: requestType,
+		FromAddr:    msg.From.String(),
+		ToAddr:      msg.To.String(),
+		Nonce:       msg.Nonce,
+		Method:      uint64(msg.Method),
+		Status:      status,
+	}
+	if msg.Value.Int != nil {
+		item.Value = msg.Value.String()
+	}
+	if raw, err := msg.Serialize(); err == nil {
+		item.Message = raw
+	}
+	return item
+}
+
+// signedRecord 根据已签名消息构建消息记录
+func signedRecord(requestType string, signed *types.SignedMessage, status string) *models.Message {
+	item := messageRecord(requestType, &signed.Message, status)
+	item.Cid = signed.Cid().String()
+	if sig, err := signed.Signature.MarshalBinary(); err == nil {
+		item.Signature = sig
+	}
+	return item
+}
+
+// RecordSigned 记录已签名的消息，用于离线签名
+func RecordSigned(store *repository.Store, requestType string, signed *types.SignedMessage) {
+	saveRecord(store, signedRecord(requestType, signed, models.MessageStatusSigned))
+}
+
+// RecordPushed 记录已推送到内存池的消息，用于推送外部签名的消息
+func RecordPushed(store *repository.Store, signed *types.SignedMessage, msgCid cid.Cid) {
+	item := signedRecord("", signed, models.MessageStatusPushed)
+	item.Cid = msgCid.String()
+	saveRecord(store, item)
+}
+
+// RecordBuilt 记录已构建但未签名的消息，用于离线签名
+func RecordBuilt(store *repository.Store, env *MessageEnvelope) {
+	saveRecord(store, messageRecord(env.RequestType, env.Message, models.MessageStatusBuilt))
+}
+
+// saveRecord 保存消息记录
+// 历史记录不影响消息的发送，保存失败时只记录日志
+func saveRecord(store *repository.Store, item *models.Message) {
+	if err := store.SaveMessage(item); err != nil {
+		log.Warnf("saveRecord: failed to record message %s: %v", item.UnsignedCid, err)
+	}
+}
+
+// applyLookup 根据链上查找结果更新消息记录
+func applyLookup(item *models.Message, lookup *types.MsgLookup) {
+	if lookup.Message.String() != item.Cid {
+		item.Status = models.MessageStatusReplaced
+		item.ReplacedBy = lookup.Message.String()
+	} else if lookup.Receipt.ExitCode != 0 {
+		item.Status = models.MessageStatusFailed
+	} else {
+		item.Status = models.MessageStatusIncluded
+	}
+	item.Height = int64(lookup.Height)
+	item.ExitCode = lookup.Receipt.ExitCode
+	item.GasUsed = lookup.Receipt.GasUsed
+}
+
+// SyncHistory 使用 StateSearchMsg 重新查询所有待确认的消息并更新状态
+// 返回状态发生变化的记录
+func (e *Executor) SyncHistory() ([]*models.Message, error) {
+	pending, err := e.store.PendingMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []*models.Message
+	for _, item := range pending {
+		c, err := cid.Decode(item.Cid)
+		if err != nil {
+			log.Warnf("SyncHistory: invalid cid %q for message %s: %v", item.Cid, item.UnsignedCid, err)
+			continue
+		}
+		lookup, err := e.node.StateSearchMsg(c)
+		if err != nil {
+			return updated, err
+		}
+		if lookup == nil {
+			continue
+		}
+
+		applyLookup(item, lookup)
+		if err := e.store.SaveMessage(item); err != nil {
+			return updated, err
+		}
+		log.Infof("SyncHistory: message %s is %s at height %d", item.Cid, item.Status, item.Height)
+		updated = append(updated, item)
+	}
+	return updated, nil
+}
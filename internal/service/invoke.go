@@ -0,0 +1,127 @@
+package service
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
+	"github.com/filecoin-project/go-state-types/network"
+	"github.com/ipfs/go-cid"
+
+	"wallet-sign/internal/chain/actors"
+	"wallet-sign/internal/chain/types"
+)
+
+// buildInvoke 构建调用任意 actor 方法的消息
+// 目标为内置 actor 时按 go-state-types 的方法表解析方法名，并将 JSON 参数编码为对应的参数类型；
+// 其他 actor 只能使用方法编号和十六进制参数
+func (e *Executor) buildInvoke(p InvokePayload) (*PreparedMessage, error) {
+	if p.Method == "" {
+		return nil, fmt.Errorf("must specify method")
+	}
+	if len(p.Params) > 0 && len(p.ParamsJSON) > 0 {
+		return nil, fmt.Errorf("cannot specify both raw params and JSON params")
+	}
+
+	msg := &types.Message{
+		To:    p.To,
+		From:  p.From,
+		Value: types.BigInt(p.Amount),
+	}
+	pm := &PreparedMessage{
+		RequestType: RequestTypeInvoke,
+		Message:     msg,
+	}
+
+	// 方法 0 为普通转账，接收方可以是尚未上链的地址
+	actorName := ""
+	if p.Method != "0" {
+		var err error
+		actorName, err = e.actorName(p.To)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if actorName == "" || !actors.IsBuiltin(actorName) {
+		num, err := strconv.ParseUint(p.Method, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a builtin actor, method must be a number", p.To)
+		}
+		if len(p.ParamsJSON) > 0 {
+			return nil, fmt.Errorf("%s is not a builtin actor, JSON params are not supported", p.To)
+		}
+		msg.Method = abi.MethodNum(num)
+		msg.Params = p.Params
+		pm.Params = hex.EncodeToString(p.Params)
+		pm.Summary = fmt.Sprintf("invoke method %d on %s with %s", num, p.To, p.Amount)
+		pm.DecodeReturn = decodeRawReturn
+		return pm, nil
+	}
+
+	method, err := actors.LookupMethod(actorName, p.Method)
+	if err != nil {
+		return nil, err
+	}
+	msg.Method = method.Num
+	if len(p.Params) > 0 {
+		msg.Params = p.Params
+		pm.Params = hex.EncodeToString(p.Params)
+	} else {
+		params, enc, err := method.EncodeParams(p.ParamsJSON)
+		if err != nil {
+			log.Errorf("invoke: failed to encode params for %s.%s: %v", actorName, method.Name, err)
+			return nil, err
+		}
+		msg.Params = enc
+		pm.Params = params
+	}
+	pm.Summary = fmt.Sprintf("invoke %s.%s (method %d) on %s with %s", actorName, method.Name, method.Num, p.To, p.Amount)
+	pm.DecodeReturn = method.DecodeReturn
+	return pm, nil
+}
+
+// actorName 返回地址对应 actor 的内置名称（如 storageminer、multisig）
+// 地址上还没有 actor 时返回错误，代码不在当前网络版本的内置 actor 列表中时返回空字符串
+func (e *Executor) actorName(addr address.Address) (string, error) {
+	act, err := e.node.StateGetActor(addr)
+	if err != nil {
+		log.Errorf("actorName: failed to get actor %s: %v", addr, err)
+		return "", err
+	}
+
+	nv, err := e.node.StateNetworkVersion()
+	if err != nil {
+		return "", err
+	}
+	if av, err := actorstypes.VersionForNetwork(network.Version(nv)); err == nil && av != actors.MethodsVersion {
+		log.Warnf("actorName: network actors version %d differs from method tables version %d", av, actors.MethodsVersion)
+	}
+
+	codes, err := e.node.StateActorCodeCIDs(nv)
+	if err != nil {
+		return "", err
+	}
+	return builtinActorName(codes, act.Code), nil
+}
+
+// builtinActorName 在内置 actor 代码表中查找代码对应的名称
+func builtinActorName(codes map[string]cid.Cid, code cid.Cid) string {
+	for name, c := range codes {
+		if c.Equals(code) {
+			return name
+		}
+	}
+	return ""
+}
+
+// decodeRawReturn 以十六进制返回非内置 actor 的返回值
+func decodeRawReturn(ret []byte) (interface{}, error) {
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	return hex.EncodeToString(ret), nil
+}
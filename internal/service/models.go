@@ -1,11 +1,25 @@
 package service
 
 const (
-	RequestTypeTransfer           = "transfer"
-	RequestTypeMinerWithdraw      = "miner_withdraw"
-	RequestTypeMarketWithdraw     = "market_withdraw"
-	RequestTypeBatchTransfer      = "batch_transfer"
-	RequestTypeMinerChangeOwner   = "miner_change_owner"
-	RequestTypeMinerChangeWorker  = "miner_change_worker"
-	RequestTypeMinerConfirmWorker = "miner_confirm_worker"
+	RequestTypeTransfer                = "transfer"
+	RequestTypeMinerWithdraw           = "miner_withdraw"
+	RequestTypeMarketWithdraw          = "market_withdraw"
+	RequestTypeBatchTransfer           = "batch_transfer"
+	RequestTypeMinerChangeOwner        = "miner_change_owner"
+	RequestTypeMinerChangeWorker       = "miner_change_worker"
+	RequestTypeMinerConfirmWorker      = "miner_confirm_worker"
+	RequestTypeMinerSetControl         = "miner_set_control"
+	RequestTypeMinerProposeBeneficiary = "miner_propose_beneficiary"
+	RequestTypeMinerConfirmBeneficiary = "miner_confirm_beneficiary"
+	RequestTypeMsigCreate              = "msig_create"
+	RequestTypeMsigPropose             = "msig_propose"
+	RequestTypeMsigApprove             = "msig_approve"
+	RequestTypeMsigCancel              = "msig_cancel"
+	RequestTypeMsigAddSigner           = "msig_add_signer"
+	RequestTypeMsigRemoveSigner        = "msig_remove_signer"
+	RequestTypeMsigSwapSigner          = "msig_swap_signer"
+	RequestTypeMsigThreshold           = "msig_threshold"
+	RequestTypeMpoolReplace            = "mpool_replace"
+	RequestTypeMpoolCancel             = "mpool_cancel"
+	RequestTypeInvoke                  = "invoke"
 )
@@ -0,0 +1,457 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	inittypes "github.com/filecoin-project/go-state-types/builtin/v9/init"
+	multisigtypes "github.com/filecoin-project/go-state-types/builtin/v9/multisig"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/crypto/blake2b"
+
+	"wallet-sign/internal/chain/actors"
+	"wallet-sign/internal/chain/types"
+)
+
+// MsigProposal 多签提案的展示内容
+// Params 为被包装请求解码后的参数
+type MsigProposal struct {
+	Multisig address.Address `json:"multisig"`
+	Proposer address.Address `json:"proposer"`
+	To       address.Address `json:"to"`
+	Value    types.BigInt    `json:"value"`
+	Method   abi.MethodNum   `json:"method"`
+	Params   interface{}     `json:"params,omitempty"`
+}
+
+// MsigCreateResult 创建多签钱包的返回值
+type MsigCreateResult struct {
+	IDAddress     address.Address `json:"id_address"`
+	RobustAddress address.Address `json:"robust_address"`
+}
+
+// MsigTxnResult 多签提案或审批的返回值
+// Applied 为 true 时交易已执行，ExitCode 为被执行交易的退出码
+type MsigTxnResult struct {
+	TxnID    int64 `json:"txn_id"`
+	Applied  bool  `json:"applied"`
+	ExitCode int64 `json:"exit_code"`
+}
+
+// wrapMultisig 将已构建的消息包装为多签提案
+// 原消息的发送方必须是多签钱包，proposer 必须是该多签钱包的签名人
+func (e *Executor) wrapMultisig(pm *PreparedMessage, proposer address.Address) error {
+	inner := pm.Message
+
+	msigID, err := e.node.StateLookupID(inner.From)
+	if err != nil {
+		log.Errorf("%s: failed to lookup multisig %s: %v", pm.RequestType, inner.From, err)
+		return err
+	}
+	st, err := e.msigState(msigID)
+	if err != nil {
+		return fmt.Errorf("%s: sender %s cannot be proposed through a multisig: %w", pm.RequestType, inner.From, err)
+	}
+	if err := e.checkSigner(st, msigID, proposer); err != nil {
+		return err
+	}
+	from, err := e.resolveSender(proposer)
+	if err != nil {
+		return err
+	}
+
+	proposeParams := &multisigtypes.ProposeParams{
+		To:     inner.To,
+		Value:  inner.Value,
+		Method: inner.Method,
+		Params: inner.Params,
+	}
+	params, err := actors.SerializeParams(proposeParams)
+	if err != nil {
+		log.Errorf("%s: failed to serialize propose params: %v", pm.RequestType, err)
+		return err
+	}
+
+	pm.Message = &types.Message{
+		To:     msigID,
+		From:   from,
+		Value:  types.NewInt(0),
+		Method: builtintypes.MethodsMultisig.Propose,
+		Params: params,
+	}
+	pm.Summary = fmt.Sprintf("propose to multisig %s (threshold %d of %d): %s", msigID, st.NumApprovalsThreshold, len(st.Signers), pm.Summary)
+	pm.Params = &MsigProposal{
+		Multisig: msigID,
+		Proposer: proposer,
+		To:       inner.To,
+		Value:    inner.Value,
+		Method:   inner.Method,
+		Params:   pm.Params,
+	}
+	pm.DecodeReturn = decodeProposeReturn
+	return nil
+}
+
+// buildMsigCreate 构建通过 Init actor 创建多签钱包的消息
+func (e *Executor) buildMsigCreate(p MsigCreatePayload) (*PreparedMessage, error) {
+	if len(p.Signers) == 0 {
+		return nil, fmt.Errorf("must specify at least one signer")
+	}
+	threshold := p.Threshold
+	if threshold == 0 {
+		threshold = uint64(len(p.Signers))
+	}
+	if threshold > uint64(len(p.Signers)) {
+		return nil, fmt.Errorf("threshold %d exceeds number of signers %d", threshold, len(p.Signers))
+	}
+
+	code, err := e.actorCode("multisig")
+	if err != nil {
+		log.Errorf("msigCreate: failed to get multisig actor code: %v", err)
+		return nil, err
+	}
+
+	ctorParams := &multisigtypes.ConstructorParams{
+		Signers:               p.Signers,
+		NumApprovalsThreshold: threshold,
+		UnlockDuration:        p.UnlockDuration,
+		StartEpoch:            p.StartEpoch,
+	}
+	enc, err := actors.SerializeParams(ctorParams)
+	if err != nil {
+		log.Errorf("msigCreate: failed to serialize constructor params: %v", err)
+		return nil, err
+	}
+	params, err := actors.SerializeParams(&inittypes.ExecParams{
+		CodeCID:           code,
+		ConstructorParams: enc,
+	})
+	if err != nil {
+		log.Errorf("msigCreate: failed to serialize exec params: %v", err)
+		return nil, err
+	}
+
+	from, err := e.resolveSender(p.From)
+	if err != nil {
+		return nil, err
+	}
+	msg := &types.Message{
+		To:     builtintypes.InitActorAddr,
+		From:   from,
+		Value:  types.BigInt(p.Amount),
+		Method: builtintypes.MethodsInit.Exec,
+		Params: params,
+	}
+
+	return &PreparedMessage{
+		RequestType:  RequestTypeMsigCreate,
+		Summary:      fmt.Sprintf("create %d-of-%d multisig with %s funded by %s", threshold, len(p.Signers), p.Amount, from),
+		Message:      msg,
+		Params:       ctorParams,
+		DecodeReturn: decodeExecReturn,
+	}, nil
+}
+
+// buildMsigPropose 构建由多签钱包发出的任意消息，由 Prepare 包装为提案
+func (e *Executor) buildMsigPropose(p MsigProposePayload) (*PreparedMessage, error) {
+	msigID, err := e.node.StateLookupID(p.Multisig)
+	if err != nil {
+		log.Errorf("msigPropose: failed to lookup multisig %s: %v", p.Multisig, err)
+		return nil, err
+	}
+
+	msg := &types.Message{
+		To:     p.To,
+		From:   msigID,
+		Value:  types.BigInt(p.Amount),
+		Method: p.Method,
+		Params: p.Params,
+	}
+
+	return &PreparedMessage{
+		RequestType: RequestTypeMsigPropose,
+		Summary:     fmt.Sprintf("send %s to %s (method %d)", p.Amount, p.To, p.Method),
+		Message:     msg,
+	}, nil
+}
+
+// buildMsigTxn 构建审批或取消待处理多签交易的消息
+// 附带提案哈希，确保操作的是链上对应的那笔交易
+func (e *Executor) buildMsigTxn(p MsigTxnPayload, requestType string) (*PreparedMessage, error) {
+	msigID, err := e.node.StateLookupID(p.Multisig)
+	if err != nil {
+		log.Errorf("%s: failed to lookup multisig %s: %v", requestType, p.Multisig, err)
+		return nil, err
+	}
+	st, err := e.msigState(msigID)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.checkSigner(st, msigID, p.Signer); err != nil {
+		return nil, err
+	}
+	signerID, err := e.node.StateLookupID(p.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	txn, err := e.msigPendingTxn(msigID, p.TxnID)
+	if err != nil {
+		return nil, err
+	}
+
+	method := builtintypes.MethodsMultisig.Approve
+	action := "approve"
+	if requestType == RequestTypeMsigCancel {
+		method = builtintypes.MethodsMultisig.Cancel
+		action = "cancel"
+		if len(txn.Approved) > 0 && txn.Approved[0] != signerID {
+			return nil, fmt.Errorf("only the proposer %s can cancel transaction %d", txn.Approved[0], p.TxnID)
+		}
+	} else {
+		for _, a := range txn.Approved {
+			if a == signerID {
+				return nil, fmt.Errorf("%s has already approved transaction %d", p.Signer, p.TxnID)
+			}
+		}
+	}
+
+	hash, err := proposalHash(txn)
+	if err != nil {
+		log.Errorf("%s: failed to compute proposal hash: %v", requestType, err)
+		return nil, err
+	}
+	txnParams := &multisigtypes.TxnIDParams{
+		ID:           multisigtypes.TxnID(p.TxnID),
+		ProposalHash: hash,
+	}
+	params, err := actors.SerializeParams(txnParams)
+	if err != nil {
+		log.Errorf("%s: failed to serialize params: %v", requestType, err)
+		return nil, err
+	}
+
+	from, err := e.resolveSender(p.Signer)
+	if err != nil {
+		return nil, err
+	}
+	msg := &types.Message{
+		To:     msigID,
+		From:   from,
+		Value:  types.NewInt(0),
+		Method: method,
+		Params: params,
+	}
+
+	pm := &PreparedMessage{
+		RequestType: requestType,
+		Summary: fmt.Sprintf("%s transaction %d of multisig %s: send %s to %s (method %d), %d of %d approvals",
+			action, p.TxnID, msigID, types.FIL(txn.Value), txn.To, txn.Method, len(txn.Approved), st.NumApprovalsThreshold),
+		Message: msg,
+		Params:  txn,
+	}
+	if requestType == RequestTypeMsigApprove {
+		pm.DecodeReturn = func(ret []byte) (interface{}, error) {
+			var ar multisigtypes.ApproveReturn
+			if err := ar.UnmarshalCBOR(bytes.NewReader(ret)); err != nil {
+				return nil, err
+			}
+			return &MsigTxnResult{TxnID: p.TxnID, Applied: ar.Applied, ExitCode: int64(ar.Code)}, nil
+		}
+	}
+	return pm, nil
+}
+
+// buildMsigAddSigner 构建多签钱包添加签名人的消息，由 Prepare 包装为提案
+func (e *Executor) buildMsigAddSigner(p MsigSignerPayload) (*PreparedMessage, error) {
+	addParams := &multisigtypes.AddSignerParams{
+		Signer:   p.NewSigner,
+		Increase: p.ChangeThreshold,
+	}
+	summary := fmt.Sprintf("add signer %s to multisig %s", p.NewSigner, p.Multisig)
+	return e.buildMsigSelfCall(p.Multisig, RequestTypeMsigAddSigner, builtintypes.MethodsMultisig.AddSigner, addParams, summary)
+}
+
+// buildMsigRemoveSigner 构建多签钱包移除签名人的消息，由 Prepare 包装为提案
+func (e *Executor) buildMsigRemoveSigner(p MsigSignerPayload) (*PreparedMessage, error) {
+	removeParams := &multisigtypes.RemoveSignerParams{
+		Signer:   p.Signer,
+		Decrease: p.ChangeThreshold,
+	}
+	summary := fmt.Sprintf("remove signer %s from multisig %s", p.Signer, p.Multisig)
+	return e.buildMsigSelfCall(p.Multisig, RequestTypeMsigRemoveSigner, builtintypes.MethodsMultisig.RemoveSigner, removeParams, summary)
+}
+
+// buildMsigSwapSigner 构建多签钱包替换签名人的消息，由 Prepare 包装为提案
+func (e *Executor) buildMsigSwapSigner(p MsigSignerPayload) (*PreparedMessage, error) {
+	swapParams := &multisigtypes.SwapSignerParams{
+		From: p.Signer,
+		To:   p.NewSigner,
+	}
+	summary := fmt.Sprintf("swap signer %s with %s in multisig %s", p.Signer, p.NewSigner, p.Multisig)
+	return e.buildMsigSelfCall(p.Multisig, RequestTypeMsigSwapSigner, builtintypes.MethodsMultisig.SwapSigner, swapParams, summary)
+}
+
+// buildMsigThreshold 构建修改多签审批阈值的消息，由 Prepare 包装为提案
+func (e *Executor) buildMsigThreshold(p MsigThresholdPayload) (*PreparedMessage, error) {
+	if p.Threshold == 0 {
+		return nil, fmt.Errorf("threshold must be at least 1")
+	}
+	thresholdParams := &multisigtypes.ChangeNumApprovalsThresholdParams{
+		NewThreshold: p.Threshold,
+	}
+	summary := fmt.Sprintf("change approval threshold of multisig %s to %d", p.Multisig, p.Threshold)
+	return e.buildMsigSelfCall(p.Multisig, RequestTypeMsigThreshold, builtintypes.MethodsMultisig.ChangeNumApprovalsThreshold, thresholdParams, summary)
+}
+
+// buildMsigSelfCall 构建多签钱包调用自身方法的消息
+// 多签钱包只接受自身发出的成员管理调用，因此这些消息总是需要通过提案执行
+func (e *Executor) buildMsigSelfCall(msig address.Address, requestType string, method abi.MethodNum, p interface{}, summary string) (*PreparedMessage, error) {
+	msigID, err := e.node.StateLookupID(msig)
+	if err != nil {
+		log.Errorf("%s: failed to lookup multisig %s: %v", requestType, msig, err)
+		return nil, err
+	}
+	params, err := actors.SerializeParams(p)
+	if err != nil {
+		log.Errorf("%s: failed to serialize params: %v", requestType, err)
+		return nil, err
+	}
+
+	return &PreparedMessage{
+		RequestType: requestType,
+		Summary:     summary,
+		Message: &types.Message{
+			To:     msigID,
+			From:   msigID,
+			Value:  types.NewInt(0),
+			Method: method,
+			Params: params,
+		},
+		Params: p,
+	}, nil
+}
+
+// msigState 读取多签钱包状态，地址不是多签钱包时返回错误
+func (e *Executor) msigState(msig address.Address) (*types.MsigState, error) {
+	code, err := e.actorCode("multisig")
+	if err != nil {
+		return nil, err
+	}
+	act, err := e.node.StateReadState(msig)
+	if err != nil {
+		return nil, err
+	}
+	if act.Code != code {
+		return nil, fmt.Errorf("%s is not a multisig", msig)
+	}
+
+	var st types.MsigState
+	if err := json.Unmarshal(act.State, &st); err != nil {
+		return nil, fmt.Errorf("failed to decode multisig state: %w", err)
+	}
+	return &st, nil
+}
+
+// checkSigner 检查地址是否为多签钱包的签名人
+func (e *Executor) checkSigner(st *types.MsigState, msig, signer address.Address) error {
+	signerID, err := e.node.StateLookupID(signer)
+	if err != nil {
+		log.Errorf("checkSigner: failed to lookup signer %s: %v", signer, err)
+		return err
+	}
+	for _, s := range st.Signers {
+		if s == signerID {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a signer of multisig %s", signer, msig)
+}
+
+// msigPendingTxn 查找多签钱包中指定 ID 的待处理交易
+func (e *Executor) msigPendingTxn(msig address.Address, id int64) (*types.MsigTransaction, error) {
+	txns, err := e.node.MsigGetPending(msig)
+	if err != nil {
+		return nil, err
+	}
+	for _, txn := range txns {
+		if txn.ID == id {
+			return txn, nil
+		}
+	}
+	return nil, fmt.Errorf("transaction %d is not pending in multisig %s", id, msig)
+}
+
+// actorCode 返回当前网络版本下指定内置 actor 的代码 CID
+func (e *Executor) actorCode(name string) (cid.Cid, error) {
+	nv, err := e.node.StateNetworkVersion()
+	if err != nil {
+		return cid.Undef, err
+	}
+	codes, err := e.node.StateActorCodeCIDs(nv)
+	if err != nil {
+		return cid.Undef, err
+	}
+	code, ok := codes[name]
+	if !ok {
+		return cid.Undef, fmt.Errorf("no %s actor code for network version %d", name, nv)
+	}
+	return code, nil
+}
+
+// resolveSender 将 ID 地址解析为可以签名的账户地址
+// 多签钱包没有账户地址，保留 ID 地址，由 Proposer 包装为提案后发送
+func (e *Executor) resolveSender(addr address.Address) (address.Address, error) {
+	if addr.Protocol() != address.ID {
+		return addr, nil
+	}
+	key, err := e.node.StateAccountKey(addr)
+	if err == nil {
+		return key, nil
+	}
+	if _, merr := e.msigState(addr); merr == nil {
+		return addr, nil
+	}
+	log.Errorf("resolveSender: failed to get account key for %s: %v", addr, err)
+	return address.Undef, err
+}
+
+// proposalHash 计算多签交易的提案哈希
+func proposalHash(txn *types.MsigTransaction) ([]byte, error) {
+	if len(txn.Approved) == 0 {
+		return nil, fmt.Errorf("transaction %d has no proposer", txn.ID)
+	}
+	data := &multisigtypes.ProposalHashData{
+		Requester: txn.Approved[0],
+		To:        txn.To,
+		Value:     txn.Value,
+		Method:    txn.Method,
+		Params:    txn.Params,
+	}
+	buf, err := data.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	hash := blake2b.Sum256(buf)
+	return hash[:], nil
+}
+
+func decodeProposeReturn(ret []byte) (interface{}, error) {
+	var pr multisigtypes.ProposeReturn
+	if err := pr.UnmarshalCBOR(bytes.NewReader(ret)); err != nil {
+		return nil, err
+	}
+	return &MsigTxnResult{TxnID: int64(pr.TxnID), Applied: pr.Applied, ExitCode: int64(pr.Code)}, nil
+}
+
+func decodeExecReturn(ret []byte) (interface{}, error) {
+	var er inittypes.ExecReturn
+	if err := er.UnmarshalCBOR(bytes.NewReader(ret)); err != nil {
+		return nil, err
+	}
+	return &MsigCreateResult{IDAddress: er.IDAddress, RobustAddress: er.RobustAddress}, nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+
+	"wallet-sign/internal/models"
+	"wallet-sign/internal/repository"
+	"wallet-sign/internal/vapi"
+)
+
+const (
+	// reservationGrace 预留后尚未生成消息记录的宽限时间，超过后视为中断遗留的空洞
+	reservationGrace = 10 * time.Minute
+	// reservationTTL 已构建或已签名但未推送的消息保留 nonce 的时间，用于离线签名
+	reservationTTL = 24 * time.Hour
+)
+
+// NonceManager 按发送方在本地分配 nonce
+// 已分配的 nonce 持久化在 nonce_reservations 表中，每次分配时与 MpoolGetNonce 对账：
+// 小于内存池 nonce 的预留已被使用，其余预留如果对应的消息没有推送成功则视为空洞并优先重新分配
+type NonceManager struct {
+	store *repository.Store
+	node  *vapi.Node
+
+	mu       sync.Mutex
+	inflight map[address.Address]map[uint64]bool // 本进程已分配但尚未推送的 nonce
+}
+
+// NewNonceManager 创建 nonce 分配器
+func NewNonceManager(store *repository.Store, node *vapi.Node) *NonceManager {
+	return &NonceManager{
+		store:    store,
+		node:     node,
+		inflight: make(map[address.Address]map[uint64]bool),
+	}
+}
+
+// Reserve 为发送方分配下一个可用的 nonce
+// 优先填补内存池 nonce 之后的空洞，否则使用已预留的最大 nonce 之后的值
+func (nm *NonceManager) Reserve(from address.Address) (uint64, error) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	base, err := nm.node.MpoolGetNonce(from)
+	if err != nil {
+		log.Errorf("Reserve: failed to get nonce for %s: %v", from, err)
+		return 0, err
+	}
+
+	addr := from.String()
+	if err := nm.store.PruneNonceReservations(addr, base); err != nil {
+		return 0, err
+	}
+	reserved, err := nm.store.ListNonceReservations(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	taken := make(map[uint64]bool, len(reserved))
+	for _, r := range reserved {
+		stale, err := nm.stale(from, r)
+		if err != nil {
+			return 0, err
+		}
+		if !stale {
+			taken[r.Nonce] = true
+			continue
+		}
+
+		// 未推送成功的预留会在后续消息之前留下空洞，优先重新分配
+		log.Warnf("Reserve: nonce gap detected for %s at %d, reusing it", from, r.Nonce)
+		if err := nm.store.RenewNonceReservation(addr, r.Nonce); err != nil {
+			return 0, err
+		}
+		nm.markInflight(from, r.Nonce)
+		return r.Nonce, nil
+	}
+
+	for nonce := base; ; nonce++ {
+		if taken[nonce] {
+			continue
+		}
+		ok, err := nm.store.ReserveNonce(addr, nonce)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			// 其他进程同时预留了该 nonce
+			continue
+		}
+		nm.markInflight(from, nonce)
+		log.Debugf("Reserve: reserved nonce %d for %s (mpool nonce %d)", nonce, from, base)
+		return nonce, nil
+	}
+}
+
+// Release 释放未能推送的 nonce，使其可以立即被重新分配
+func (nm *NonceManager) Release(from address.Address, nonce uint64) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	delete(nm.inflight[from], nonce)
+	if err := nm.store.ReleaseNonce(from.String(), nonce); err != nil {
+		log.Warnf("Release: failed to release nonce %d for %s: %v", nonce, from, err)
+	}
+}
+
+// Commit 标记 nonce 已交由消息记录跟踪（消息已推送或已写入待签名文件）
+// 之后该预留是否失效由消息历史中的状态决定
+func (nm *NonceManager) Commit(from address.Address, nonce uint64) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	delete(nm.inflight[from], nonce)
+}
+
+// markInflight 记录本进程分配的 nonce
+func (nm *NonceManager) markInflight(from address.Address, nonce uint64) {
+	if nm.inflight[from] == nil {
+		nm.inflight[from] = make(map[uint64]bool)
+	}
+	nm.inflight[from][nonce] = true
+}
+
+// stale 判断预留是否已失效（对应的消息不会再被推送）
+func (nm *NonceManager) stale(from address.Address, r *models.NonceReservation) (bool, error) {
+	if nm.inflight[from][r.Nonce] {
+		return false, nil
+	}
+
+	item, err := nm.store.MessageByNonce(from.String(), r.Nonce)
+	if err != nil {
+		return false, err
+	}
+	if item == nil {
+		return time.Since(r.CreatedAt) > reservationGrace, nil
+	}
+
+	switch item.Status {
+	case models.MessageStatusBuilt, models.MessageStatusSigned:
+		// 推送失败，或离线消息超过保留时间仍未推送
+		return item.Error != "" || time.Since(item.UpdatedAt) > reservationTTL, nil
+	case models.MessageStatusPushed:
+		// 已推送但仍在内存池 nonce 之后，等待前面的空洞被填补
+		return false, nil
+	default:
+		return true, nil
+	}
+}
+
+// NonceStatus 发送方的 nonce 状态
+type NonceStatus struct {
+	Address      address.Address
+	ChainNonce   uint64                     // 链上已执行的 nonce
+	MpoolNonce   uint64                     // 内存池中的下一个 nonce
+	Reservations []*models.NonceReservation // 本地预留（不小于内存池 nonce）
+	Gaps         []uint64                   // 已失效的预留
+}
+
+// NonceStatus 返回发送方链上、内存池及本地预留的 nonce
+func (e *Executor) NonceStatus(from address.Address) (*NonceStatus, error) {
+	act, err := e.node.StateGetActor(from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get actor %s: %w", from, err)
+	}
+	base, err := e.node.MpoolGetNonce(from)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := from.String()
+	if err := e.store.PruneNonceReservations(addr, base); err != nil {
+		return nil, err
+	}
+	reserved, err := e.store.ListNonceReservations(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &NonceStatus{
+		Address:      from,
+		ChainNonce:   act.Nonce,
+		MpoolNonce:   base,
+		Reservations: reserved,
+	}
+	for _, r := range reserved {
+		stale, err := e.nonces.stale(from, r)
+		if err != nil {
+			return nil, err
+		}
+		if stale {
+			st.Gaps = append(st.Gaps, r.Nonce)
+		}
+	}
+	return st, nil
+}
+
+// ResetNonces 删除发送方的所有本地 nonce 预留，下一次分配从内存池 nonce 开始
+func (e *Executor) ResetNonces(from address.Address) (int64, error) {
+	return e.store.ClearNonceReservations(from.String())
+}
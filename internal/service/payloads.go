@@ -1,11 +1,15 @@
 package service
 
 import (
+	"encoding/json"
 	"wallet-sign/internal/chain/types"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
 )
 
+// Payload 执行器请求
+// Proposer 不为空时，请求构建的消息由该签名人作为多签提案发出，消息原发送方必须是多签钱包
 type Payload struct {
 	Type            string              `json:"type"`
 	FromAddr        address.Address     `json:"from_address"`
@@ -17,6 +21,23 @@ type Payload struct {
 	NewWorker       address.Address     `json:"new_worker"`
 	NewControlAddrs []address.Address   `json:"new_control_addrs"`
 	Items           []BatchTransferItem `json:"items"`
+	Proposer        address.Address     `json:"proposer"`
+	Multisig        address.Address     `json:"multisig"`
+	Signers         []address.Address   `json:"signers"`
+	Threshold       uint64              `json:"threshold"`
+	UnlockDuration  abi.ChainEpoch      `json:"unlock_duration"`
+	StartEpoch      abi.ChainEpoch      `json:"start_epoch"`
+	TxnID           int64               `json:"txn_id"`
+	Method          abi.MethodNum       `json:"method"`
+	Params          []byte              `json:"params"`
+	Signer          address.Address     `json:"signer"`
+	NewSigner       address.Address     `json:"new_signer"`
+	ChangeThreshold bool                `json:"change_threshold"`
+	MethodName      string              `json:"method_name"`
+	ParamsJSON      json.RawMessage     `json:"params_json"`
+	Beneficiary     address.Address     `json:"beneficiary"`
+	Quota           types.FIL           `json:"quota"`
+	Expiration      abi.ChainEpoch      `json:"expiration"`
 }
 
 type TransferPayload struct {
@@ -61,3 +82,64 @@ type MinerConfirmWorkerPayload struct {
 	MinerID   address.Address `json:"miner_id"`
 	NewWorker address.Address `json:"new_worker"`
 }
+
+type MinerSetControlPayload struct {
+	MinerID      address.Address   `json:"miner_id"`
+	ControlAddrs []address.Address `json:"control_addrs"`
+}
+
+type MinerProposeBeneficiaryPayload struct {
+	MinerID     address.Address `json:"miner_id"`
+	Beneficiary address.Address `json:"beneficiary"`
+	Quota       types.FIL       `json:"quota"`
+	Expiration  abi.ChainEpoch  `json:"expiration"`
+}
+
+type MinerConfirmBeneficiaryPayload struct {
+	MinerID address.Address `json:"miner_id"`
+	From    address.Address `json:"from"`
+}
+
+type MsigCreatePayload struct {
+	From           address.Address   `json:"from"`
+	Signers        []address.Address `json:"signers"`
+	Threshold      uint64            `json:"threshold"`
+	UnlockDuration abi.ChainEpoch    `json:"unlock_duration"`
+	StartEpoch     abi.ChainEpoch    `json:"start_epoch"`
+	Amount         types.FIL         `json:"amount"`
+}
+
+type MsigProposePayload struct {
+	Multisig address.Address `json:"multisig"`
+	To       address.Address `json:"to"`
+	Amount   types.FIL       `json:"amount"`
+	Method   abi.MethodNum   `json:"method"`
+	Params   []byte          `json:"params"`
+}
+
+type MsigTxnPayload struct {
+	Multisig address.Address `json:"multisig"`
+	Signer   address.Address `json:"signer"`
+	TxnID    int64           `json:"txn_id"`
+}
+
+type MsigSignerPayload struct {
+	Multisig        address.Address `json:"multisig"`
+	Signer          address.Address `json:"signer"`
+	NewSigner       address.Address `json:"new_signer"`
+	ChangeThreshold bool            `json:"change_threshold"`
+}
+
+type MsigThresholdPayload struct {
+	Multisig  address.Address `json:"multisig"`
+	Threshold uint64          `json:"threshold"`
+}
+
+type InvokePayload struct {
+	From       address.Address `json:"from"`
+	To         address.Address `json:"to"`
+	Amount     types.FIL       `json:"amount"`
+	Method     string          `json:"method"`
+	Params     []byte          `json:"params"`
+	ParamsJSON json.RawMessage `json:"params_json"`
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	builtintypes "github.com/filecoin-project/go-state-types/builtin"
+	"github.com/ipfs/go-cid"
+
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/metrics"
+	"wallet-sign/internal/models"
+	"wallet-sign/internal/wallet"
+)
+
+// ReplaceByFeePercentageMinimum Lotus 内存池接受替换消息的最小溢价比例（百分比）
+// 替换消息的 GasPremium 必须不低于原消息的 110% + 1
+const ReplaceByFeePercentageMinimum = 110
+
+// ReplaceOptions 替换消息的 Gas 参数，未设置的字段按最小替换比例自动提高
+type ReplaceOptions struct {
+	GasPremium *types.BigInt
+	GasFeeCap  *types.BigInt
+}
+
+// Replacement 替换消息结果
+type Replacement struct {
+	RequestType string          `json:"request_type"`
+	OldCid      cid.Cid         `json:"old_cid"`
+	MsgCid      cid.Cid         `json:"msg_cid"`
+	From        address.Address `json:"from"`
+	Nonce       uint64          `json:"nonce"`
+	GasPremium  types.BigInt    `json:"gas_premium"`
+	GasFeeCap   types.BigInt    `json:"gas_fee_cap"`
+}
+
+// ComputeMinRBF 计算替换消息所需的最小 GasPremium
+func ComputeMinRBF(premium types.BigInt) types.BigInt {
+	minRBF := big.Div(big.Mul(premium, big.NewInt(ReplaceByFeePercentageMinimum)), big.NewInt(100))
+	return big.Add(minRBF, big.NewInt(1))
+}
+
+// PendingMessage 根据 CID 查找内存池中待打包的消息
+func (e *Executor) PendingMessage(msgCid cid.Cid) (*types.SignedMessage, error) {
+	msg, err := e.node.ChainGetMessage(msgCid)
+	if err != nil {
+		return nil, err
+	}
+	return e.PendingByNonce(msg.From, msg.Nonce)
+}
+
+// PendingByNonce 查找发送方指定 nonce 的待打包消息
+func (e *Executor) PendingByNonce(from address.Address, nonce uint64) (*types.SignedMessage, error) {
+	from, err := e.resolveSender(from)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := e.node.MpoolPending()
+	if err != nil {
+		return nil, err
+	}
+	for _, sm := range pending {
+		if sm.Message.From == from && sm.Message.Nonce == nonce {
+			return sm, nil
+		}
+	}
+	return nil, fmt.Errorf("no pending message from %s with nonce %d in mpool", from, nonce)
+}
+
+// Replace 以更高的 Gas 费用重新签名并推送待打包的消息，消息内容不变
+func (e *Executor) Replace(old *types.SignedMessage, opts ReplaceOptions) (*Replacement, error) {
+	defer e.node.Pin()()
+	msg := old.Message
+	if opts.GasFeeCap == nil {
+		// 原消息通常因基础费上涨而滞留，同时按节点当前估算提高费用上限
+		feeCap, err := e.node.GasEstimateFeeCap(&msg, 20)
+		if err != nil {
+			return nil, err
+		}
+		msg.GasFeeCap = big.Max(feeCap, ComputeMinRBF(old.Message.GasFeeCap))
+	}
+	return e.replace(RequestTypeMpoolReplace, old, &msg, opts)
+}
+
+// Cancel 以相同 nonce 的零金额自转账替换待打包的消息
+func (e *Executor) Cancel(old *types.SignedMessage, opts ReplaceOptions) (*Replacement, error) {
+	defer e.node.Pin()()
+	pm := &PreparedMessage{
+		RequestType: RequestTypeMpoolCancel,
+		Summary:     fmt.Sprintf("cancel message %s from %s with nonce %d", old.Cid(), old.Message.From, old.Message.Nonce),
+		Message: &types.Message{
+			To:         old.Message.From,
+			From:       old.Message.From,
+			Nonce:      old.Message.Nonce,
+			Value:      types.NewInt(0),
+			Method:     builtintypes.MethodSend,
+			GasFeeCap:  types.NewInt(0),
+			GasPremium: types.NewInt(0),
+		},
+	}
+	if err := e.adaptDelegatedSender(pm); err != nil {
+		return nil, err
+	}
+
+	msg := pm.Message
+	if _, err := e.node.GasEstimateGasLimit(msg); err != nil {
+		return nil, err
+	}
+	if opts.GasFeeCap == nil {
+		msg.GasFeeCap = big.Max(msg.GasFeeCap, ComputeMinRBF(old.Message.GasFeeCap))
+	}
+	return e.replace(RequestTypeMpoolCancel, old, msg, opts)
+}
+
+// replace 设置替换消息的 GasPremium 与 GasFeeCap，签名后推送并更新消息历史
+func (e *Executor) replace(name string, old *types.SignedMessage, msg *types.Message, opts ReplaceOptions) (*Replacement, error) {
+	minRBF := ComputeMinRBF(old.Message.GasPremium)
+	if opts.GasPremium != nil {
+		msg.GasPremium = *opts.GasPremium
+	} else {
+		msg.GasPremium = big.Max(msg.GasPremium, minRBF)
+	}
+	if opts.GasFeeCap != nil {
+		msg.GasFeeCap = *opts.GasFeeCap
+	}
+	msg.GasFeeCap = big.Max(msg.GasFeeCap, msg.GasPremium)
+	if opts.GasFeeCap == nil {
+		wallet.CapGasFee(msg)
+	}
+
+	if msg.GasPremium.LessThan(minRBF) {
+		log.Errorf("%s: gas premium %s is below the minimum replacement premium %s", name, msg.GasPremium, minRBF)
+		return nil, fmt.Errorf("gas premium %s is below the minimum replacement premium %s", msg.GasPremium, minRBF)
+	}
+	if msg.GasFeeCap.LessThan(msg.GasPremium) {
+		return nil, fmt.Errorf("gas fee cap %s is below gas premium %s", msg.GasFeeCap, msg.GasPremium)
+	}
+
+	signed, err := wallet.SignMessage(e.store, msg)
+	if err != nil {
+		log.Errorf("%s: failed to sign message: %v", name, err)
+		metrics.MessageFailed(name)
+		return nil, err
+	}
+	metrics.MessageSigned(name)
+	record := signedRecord(name, signed, models.MessageStatusSigned)
+	saveRecord(e.store, record)
+
+	log.Infof("%s: replacing message %s (nonce %d) with premium %s and fee cap %s", name, old.Cid(), msg.Nonce, msg.GasPremium, msg.GasFeeCap)
+	msgCid, err := e.node.MpoolPush(signed)
+	if err != nil {
+		log.Errorf("%s: failed to push message: %v", name, err)
+		metrics.MessageFailed(name)
+		record.Error = err.Error()
+		saveRecord(e.store, record)
+		return nil, err
+	}
+	metrics.MessagePushed(name)
+	record.Cid = msgCid.String()
+	record.Status = models.MessageStatusPushed
+	saveRecord(e.store, record)
+	e.recordReplaced(old.Cid(), msgCid)
+
+	return &Replacement{
+		RequestType: name,
+		OldCid:      old.Cid(),
+		MsgCid:      msgCid,
+		From:        msg.From,
+		Nonce:       msg.Nonce,
+		GasPremium:  msg.GasPremium,
+		GasFeeCap:   msg.GasFeeCap,
+	}, nil
+}
+
+// recordReplaced 将被替换消息的历史记录标记为已替换
+func (e *Executor) recordReplaced(oldCid, newCid cid.Cid) {
+	item, err := e.store.GetMessage(oldCid.String())
+	if err != nil {
+		// 不是本工具发出的消息，没有历史记录
+		return
+	}
+	item.Status = models.MessageStatusReplaced
+	item.ReplacedBy = newCid.String()
+	saveRecord(e.store, item)
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+
+	"wallet-sign/internal/chain/types"
+)
+
+// Result 请求执行结果
+// 单条消息请求填充消息字段，批量请求在 Items 中按顺序记录每条消息的结果
+type Result struct {
+	RequestType string         `json:"request_type"`
+	MsgCid      cid.Cid        `json:"msg_cid"`
+	Height      abi.ChainEpoch `json:"height,omitempty"`
+	ExitCode    int64          `json:"exit_code"`
+	GasUsed     int64          `json:"gas_used,omitempty"`
+	FeeBurned   *types.BigInt  `json:"fee_burned,omitempty"`
+	Return      interface{}    `json:"return,omitempty"`
+	Items       []*Result      `json:"items,omitempty"`
+}
+
+// MessageFailedError 消息已上链但执行失败（退出码非零）
+type MessageFailedError struct {
+	MsgCid   cid.Cid
+	ExitCode int64
+}
+
+func (e *MessageFailedError) Error() string {
+	return fmt.Sprintf("message %s failed with exit code: %d", e.MsgCid, e.ExitCode)
+}
+
+// newResult 根据消息查找结果构建执行结果
+func newResult(requestType string, msgCid cid.Cid, lookup *types.MsgLookup) *Result {
+	return &Result{
+		RequestType: requestType,
+		MsgCid:      msgCid,
+		Height:      lookup.Height,
+		ExitCode:    lookup.Receipt.ExitCode,
+		GasUsed:     lookup.Receipt.GasUsed,
+	}
+}
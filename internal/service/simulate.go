@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/wallet"
+)
+
+// Simulation 消息预执行结果
+// 由 Simulate 生成，消息不会被签名或推送
+type Simulation struct {
+	RequestType string         `json:"request_type"`
+	Summary     string         `json:"summary"`
+	Message     *types.Message `json:"message"`
+	Params      interface{}    `json:"params,omitempty"`
+	ExitCode    int64          `json:"exit_code"`
+	GasUsed     int64          `json:"gas_used"`
+	MaxFee      types.BigInt   `json:"max_fee"`
+	Error       string         `json:"error,omitempty"`
+	HasKey      bool           `json:"has_key"`
+}
+
+// Simulate 构建请求对应的完整消息，并在节点上预执行
+// 返回预测的退出码、Gas 消耗和最大手续费，不签名也不推送
+func (e *Executor) Simulate(req *Payload) (*Simulation, error) {
+	if req.Type == RequestTypeBatchTransfer {
+		return nil, fmt.Errorf("request type %s cannot be simulated", req.Type)
+	}
+
+	// 预执行不会推送消息，不预留 nonce
+	pm, err := e.prepare(req, false)
+	if err != nil {
+		return nil, err
+	}
+	msg := pm.Message
+
+	res, err := e.node.StateCall(msg)
+	if err != nil {
+		log.Errorf("Simulate: failed to call message: %v", err)
+		return nil, err
+	}
+
+	hasKey, err := wallet.WalletHas(e.store, msg.From)
+	if err != nil {
+		return nil, err
+	}
+
+	sim := &Simulation{
+		RequestType: pm.RequestType,
+		Summary:     pm.Summary,
+		Message:     msg,
+		Params:      pm.Params,
+		MaxFee:      types.BigMul(msg.GasFeeCap, types.NewInt(uint64(msg.GasLimit))),
+		Error:       res.Error,
+		HasKey:      hasKey,
+	}
+	if res.MsgRct != nil {
+		sim.ExitCode = res.MsgRct.ExitCode
+		sim.GasUsed = res.MsgRct.GasUsed
+	}
+
+	log.Infof("Simulate: %s predicted exit code %d, gas used %d", pm.RequestType, sim.ExitCode, sim.GasUsed)
+	return sim, nil
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+
+	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/models"
+	"wallet-sign/internal/sweep"
+)
+
+// SweepActionPlanned 预演模式下计划执行的动作状态，不写入数据库
+const SweepActionPlanned = "planned"
+
+// sweepPendingGrace 尚未签名的动作在该时间内视为其他进程正在处理
+const sweepPendingGrace = 10 * time.Minute
+
+// SweepReport 一个轮询周期的执行结果
+type SweepReport struct {
+	Height  abi.ChainEpoch        `json:"height"`
+	Actions []*models.SweepAction `json:"actions"`
+	Skipped []string              `json:"skipped,omitempty"`
+	Errors  []string              `json:"errors,omitempty"`
+}
+
+// Sweep 按规则执行一个轮询周期
+// 每个动作发送前先写入数据库，并等待上链后再处理下一个动作；
+// 同一规则的同一对象存在未完成的动作时先与链上状态对账，仍未完成则本周期跳过
+// dryRun 为 true 时只计算计划执行的动作，不签名也不写入数据库
+func (e *Executor) Sweep(rules *sweep.Rules, dryRun bool) (*SweepReport, error) {
+	head, err := e.node.ChainHead()
+	if err != nil {
+		log.Errorf("Sweep: failed to get chain head: %v", err)
+		return nil, err
+	}
+	report := &SweepReport{Height: head.Height()}
+
+	for _, r := range rules.Miners {
+		if err := e.sweepMiner(report, r, dryRun); err != nil {
+			log.Errorf("Sweep: rule %s: %v", r.Name, err)
+			report.Errors = append(report.Errors, fmt.Sprintf("rule %s: %v", r.Name, err))
+		}
+	}
+	for _, r := range rules.TopUps {
+		if err := e.sweepTopUp(report, r, dryRun); err != nil {
+			log.Errorf("Sweep: rule %s: %v", r.Name, err)
+			report.Errors = append(report.Errors, fmt.Sprintf("rule %s: %v", r.Name, err))
+		}
+	}
+	return report, nil
+}
+
+// sweepMiner 执行矿工规则：可用余额超过阈值时提现全部可用余额，再将 owner 超出保留金额的余额转出
+func (e *Executor) sweepMiner(report *SweepReport, r *sweep.Miner, dryRun bool) error {
+	minerInfo, err := e.node.StateMinerInfo(r.Miner)
+	if err != nil {
+		return err
+	}
+	// FIP-0029 之后提现金额转给受益人，受益人不是 owner 时无法从 owner 转出
+	if minerInfo.Beneficiary != address.Undef && minerInfo.Beneficiary != minerInfo.Owner {
+		return fmt.Errorf("beneficiary %s of %s is not the owner, withdrawn funds would not reach the owner", minerInfo.Beneficiary, r.Miner)
+	}
+
+	available, err := e.node.StateMinerAvailableBalance(r.Miner)
+	if err != nil {
+		return err
+	}
+
+	withdrawn := types.NewInt(0)
+	if available.GreaterThan(r.Threshold) {
+		action := &models.SweepAction{
+			Rule:     r.Name,
+			Kind:     models.SweepKindWithdraw,
+			Subject:  r.Miner.String(),
+			FromAddr: minerInfo.Owner.String(),
+			ToAddr:   minerInfo.Owner.String(),
+			Amount:   available.String(),
+			Reason:   fmt.Sprintf("available balance %s > %s", types.FIL(available), types.FIL(r.Threshold)),
+		}
+		done, err := e.runSweepAction(report, action, &Payload{
+			Type:    RequestTypeMinerWithdraw,
+			MinerID: r.Miner,
+			Amount:  types.FIL(available),
+		}, dryRun)
+		if err != nil {
+			return err
+		}
+		if !done {
+			// 提现尚未完成时 owner 余额不准确，本周期不转出
+			return nil
+		}
+		if dryRun {
+			withdrawn = available
+		}
+	}
+
+	if r.ForwardTo == address.Undef {
+		return nil
+	}
+	owner, err := e.node.StateGetActor(minerInfo.Owner)
+	if err != nil {
+		return err
+	}
+	// 预演时提现没有实际执行，按提现后的余额计算
+	balance := types.BigAdd(owner.Balance, withdrawn)
+	excess := types.BigSub(balance, r.OwnerKeep)
+	if excess.Sign() <= 0 {
+		return nil
+	}
+	action := &models.SweepAction{
+		Rule:     r.Name,
+		Kind:     models.SweepKindForward,
+		Subject:  minerInfo.Owner.String(),
+		FromAddr: minerInfo.Owner.String(),
+		ToAddr:   r.ForwardTo.String(),
+		Amount:   excess.String(),
+		Reason:   fmt.Sprintf("owner balance %s > keep %s", types.FIL(balance), types.FIL(r.OwnerKeep)),
+	}
+	_, err = e.runSweepAction(report, action, &Payload{
+		Type:     RequestTypeTransfer,
+		FromAddr: minerInfo.Owner,
+		ToAddr:   r.ForwardTo,
+		Amount:   types.FIL(excess),
+	}, dryRun)
+	return err
+}
+
+// sweepTopUp 执行补充规则：余额低于 Below 的地址由热钱包补充到 TopUpTo
+func (e *Executor) sweepTopUp(report *SweepReport, r *sweep.TopUp, dryRun bool) error {
+	targets := append([]address.Address{}, r.Targets...)
+	if r.Miner != address.Undef {
+		minerInfo, err := e.node.StateMinerInfo(r.Miner)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, minerInfo.Worker)
+		targets = append(targets, minerInfo.ControlAddresses...)
+	}
+
+	seen := make(map[address.Address]bool, len(targets))
+	var errs []error
+	for _, target := range targets {
+		id, err := e.node.StateLookupID(target)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to lookup %s: %w", target, err))
+			continue
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		act, err := e.node.StateGetActor(id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get actor %s: %w", id, err))
+			continue
+		}
+		if !act.Balance.LessThan(r.Below) {
+			continue
+		}
+		amount := types.BigSub(r.TopUpTo, act.Balance)
+		action := &models.SweepAction{
+			Rule:     r.Name,
+			Kind:     models.SweepKindTopUp,
+			Subject:  id.String(),
+			FromAddr: r.From.String(),
+			ToAddr:   target.String(),
+			Amount:   amount.String(),
+			Reason:   fmt.Sprintf("balance %s < %s", types.FIL(act.Balance), types.FIL(r.Below)),
+		}
+		if _, err := e.runSweepAction(report, action, &Payload{
+			Type:     RequestTypeTransfer,
+			FromAddr: r.From,
+			ToAddr:   target,
+			Amount:   types.FIL(amount),
+		}, dryRun); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runSweepAction 记录并执行一个动作，返回动作是否已完成（上链或预演）
+// 动作执行失败时记录在动作中并返回 false，只有数据库或节点错误时返回 error
+func (e *Executor) runSweepAction(report *SweepReport, action *models.SweepAction, req *Payload, dryRun bool) (bool, error) {
+	unfinished, err := e.store.UnfinishedSweepActions(action.Rule, action.Kind, action.Subject)
+	if err != nil {
+		return false, err
+	}
+	for _, prev := range unfinished {
+		finished, err := e.reconcileSweepAction(prev)
+		if err != nil {
+			return false, err
+		}
+		if !finished {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("rule %s: %s of %s is waiting for action %d (%s)",
+				action.Rule, action.Kind, action.Subject, prev.ID, prev.Status))
+			return false, nil
+		}
+	}
+
+	report.Actions = append(report.Actions, action)
+	if dryRun {
+		action.Status = SweepActionPlanned
+		return true, nil
+	}
+
+	action.IdemKey = fmt.Sprintf("%s/%s/%s@%d", action.Rule, action.Kind, action.Subject, report.Height)
+	action.Status = models.SweepActionPending
+	created, err := e.store.CreateSweepAction(action)
+	if err != nil {
+		return false, err
+	}
+	if !created {
+		report.Actions = report.Actions[:len(report.Actions)-1]
+		report.Skipped = append(report.Skipped, fmt.Sprintf("rule %s: %s of %s already handled at height %d",
+			action.Rule, action.Kind, action.Subject, report.Height))
+		return false, nil
+	}
+	log.Infof("runSweepAction: rule %s: %s %s attoFIL from %s to %s (%s)", action.Rule, action.Kind,
+		action.Amount, action.FromAddr, action.ToAddr, action.Reason)
+
+	defer e.node.Pin()()
+	pm, err := e.Prepare(req)
+	if err != nil {
+		return false, e.failSweepAction(action, err)
+	}
+	pm.Summary = fmt.Sprintf("%s (sweep rule %s)", pm.Summary, action.Rule)
+
+	signed, record, err := e.signMessage(pm)
+	if err != nil {
+		return false, e.failSweepAction(action, err)
+	}
+	action.Status = models.SweepActionSigned
+	action.FromAddr = pm.Message.From.String()
+	action.MsgCid = signed.Cid().String()
+	action.Nonce = pm.Message.Nonce
+	if err := e.store.SaveSweepAction(action); err != nil {
+		// 无法记录签名结果时不能推送，否则中断后无法确认动作是否已经执行
+		e.nonces.Release(pm.Message.From, pm.Message.Nonce)
+		return false, err
+	}
+
+	msgCid, err := e.pushSigned(pm, signed, record)
+	if err != nil {
+		// 推送失败时消息仍可能已被节点接收，保留签名状态，下个周期对账
+		action.Error = err.Error()
+		return false, e.store.SaveSweepAction(action)
+	}
+	action.Status = models.SweepActionPushed
+	action.MsgCid = msgCid.String()
+	if err := e.store.SaveSweepAction(action); err != nil {
+		return false, err
+	}
+
+	res, err := e.waitMessage(pm, msgCid, record)
+	var failed *MessageFailedError
+	switch {
+	case errors.As(err, &failed):
+		action.Status = models.SweepActionFailed
+		action.Height = int64(res.Height)
+		action.ExitCode = failed.ExitCode
+		action.Error = err.Error()
+	case err != nil:
+		action.Error = err.Error()
+	default:
+		action.Status = models.SweepActionIncluded
+		action.Height = int64(res.Height)
+		action.Error = ""
+	}
+	if err := e.store.SaveSweepAction(action); err != nil {
+		return false, err
+	}
+	return action.Status == models.SweepActionIncluded, nil
+}
+
+// failSweepAction 记录签名前失败的动作，失败的动作不会阻止下个周期重新判断
+func (e *Executor) failSweepAction(action *models.SweepAction, cause error) error {
+	log.Errorf("failSweepAction: rule %s: %s of %s failed: %v", action.Rule, action.Kind, action.Subject, cause)
+	action.Status = models.SweepActionFailed
+	action.Error = cause.Error()
+	return e.store.SaveSweepAction(action)
+}
+
+// reconcileSweepAction 将未完成的动作与链上状态对账，返回动作是否已结束
+// 消息仍在内存池中时视为未结束；消息丢失或 nonce 被其他消息使用时标记为失败，由规则按最新余额重新判断
+func (e *Executor) reconcileSweepAction(action *models.SweepAction) (bool, error) {
+	if action.MsgCid == "" {
+		if time.Since(action.CreatedAt) < sweepPendingGrace {
+			return false, nil
+		}
+		action.Status = models.SweepActionFailed
+		action.Error = "interrupted before signing"
+		return true, e.store.SaveSweepAction(action)
+	}
+
+	c, err := cid.Decode(action.MsgCid)
+	if err != nil {
+		return false, fmt.Errorf("invalid message cid %q in sweep action %d: %w", action.MsgCid, action.ID, err)
+	}
+	lookup, err := e.node.StateSearchMsg(c)
+	if err != nil {
+		return false, err
+	}
+	if lookup != nil {
+		if item, err := e.store.GetMessage(c.String()); err == nil {
+			applyLookup(item, lookup)
+			saveRecord(e.store, item)
+		}
+		if lookup.Message != c {
+			same, err := e.sameTransfer(c, lookup.Message)
+			if err != nil {
+				return false, err
+			}
+			if !same {
+				action.Status = models.SweepActionFailed
+				action.Error = fmt.Sprintf("message %s was replaced by %s", c, lookup.Message)
+				return true, e.store.SaveSweepAction(action)
+			}
+			action.MsgCid = lookup.Message.String()
+		}
+		action.Height = int64(lookup.Height)
+		action.ExitCode = lookup.Receipt.ExitCode
+		if lookup.Receipt.ExitCode != 0 {
+			action.Status = models.SweepActionFailed
+			action.Error = fmt.Sprintf("message %s failed with exit code: %d", lookup.Message, lookup.Receipt.ExitCode)
+		} else {
+			action.Status = models.SweepActionIncluded
+			action.Error = ""
+		}
+		return true, e.store.SaveSweepAction(action)
+	}
+
+	from, err := address.NewFromString(action.FromAddr)
+	if err != nil {
+		return false, err
+	}
+	if _, err := e.PendingByNonce(from, action.Nonce); err == nil {
+		if action.Status != models.SweepActionPushed {
+			action.Status = models.SweepActionPushed
+			action.Error = ""
+			if err := e.store.SaveSweepAction(action); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+
+	act, err := e.node.StateGetActor(from)
+	if err != nil {
+		return false, err
+	}
+	if act.Nonce > action.Nonce {
+		action.Error = fmt.Sprintf("nonce %d was used by another message", action.Nonce)
+	} else {
+		e.nonces.Release(from, action.Nonce)
+		action.Error = fmt.Sprintf("message %s was not found in mpool or chain", c)
+	}
+	log.Warnf("reconcileSweepAction: action %d of rule %s: %s", action.ID, action.Rule, action.Error)
+	action.Status = models.SweepActionFailed
+	return true, e.store.SaveSweepAction(action)
+}
@@ -0,0 +1,217 @@
+package sweep
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/filecoin-project/go-address"
+	logging "github.com/ipfs/go-log/v2"
+
+	"wallet-sign/internal/chain/types"
+)
+
+var log = logging.Logger("sweep")
+
+// DefaultInterval 默认轮询间隔
+const DefaultInterval = 5 * time.Minute
+
+// File 自动归集规则文件（TOML）
+type File struct {
+	Interval string      // 轮询间隔，例如 "5m"，默认 5 分钟
+	Miner    []MinerRule // 矿工提现及转出规则
+	TopUp    []TopUpRule // 钱包余额补充规则
+}
+
+// MinerRule 矿工可用余额超过 Threshold 时由 owner 提取全部可用余额；
+// 设置 ForwardTo 时再将 owner 余额中超过 OwnerKeep 的部分转到 ForwardTo
+type MinerRule struct {
+	Name      string // 规则名称，用于记录和幂等判断，修改后视为新规则
+	Miner     string // 矿工地址
+	Threshold string // 触发提现的可用余额（FIL）
+	ForwardTo string // 转出地址（可选），例如冷钱包
+	OwnerKeep string // owner 保留的余额（FIL），手续费从保留余额中支付，默认 0
+}
+
+// TopUpRule 地址余额低于 Below 时由 From 补充到 TopUpTo
+// Miner 不为空时对该矿工的 worker 和所有 control 地址生效，Targets 为额外指定的地址
+type TopUpRule struct {
+	Name    string   // 规则名称
+	From    string   // 补充资金的热钱包
+	Miner   string   // 矿工地址（可选）
+	Targets []string // 需要补充的地址（可选）
+	Below   string   // 触发补充的余额（FIL）
+	TopUpTo string   // 补充后的余额（FIL），不能小于 Below
+}
+
+// Rules 解析后的规则
+type Rules struct {
+	Interval time.Duration
+	Miners   []*Miner
+	TopUps   []*TopUp
+}
+
+// Miner 解析后的矿工规则
+type Miner struct {
+	Name      string
+	Miner     address.Address
+	Threshold types.BigInt
+	ForwardTo address.Address // 为 address.Undef 时不转出
+	OwnerKeep types.BigInt
+}
+
+// TopUp 解析后的补充规则
+type TopUp struct {
+	Name    string
+	From    address.Address
+	Miner   address.Address // 为 address.Undef 时只补充 Targets
+	Targets []address.Address
+	Below   types.BigInt
+	TopUpTo types.BigInt
+}
+
+// LoadFile 读取并解析规则文件
+func LoadFile(path string) (*Rules, error) {
+	var f File
+	md, err := toml.DecodeFile(path, &f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sweep rules %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("unknown keys in sweep rules %s: %v", path, undecoded)
+	}
+	r, err := New(&f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sweep rules %s: %w", path, err)
+	}
+	log.Infof("LoadFile: loaded %d miner rules and %d top-up rules from %s", len(r.Miners), len(r.TopUps), path)
+	return r, nil
+}
+
+// New 校验规则定义并生成 Rules
+func New(f *File) (*Rules, error) {
+	r := &Rules{Interval: DefaultInterval}
+	if f.Interval != "" {
+		d, err := time.ParseDuration(f.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %w", err)
+		}
+		if d < time.Minute {
+			return nil, fmt.Errorf("interval must be at least 1m")
+		}
+		r.Interval = d
+	}
+
+	names := make(map[string]bool)
+	checkName := func(name string) error {
+		if name == "" {
+			return fmt.Errorf("rule name is required")
+		}
+		if names[name] {
+			return fmt.Errorf("duplicate rule name %q", name)
+		}
+		names[name] = true
+		return nil
+	}
+
+	for _, def := range f.Miner {
+		if err := checkName(def.Name); err != nil {
+			return nil, err
+		}
+		m, err := newMiner(&def)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", def.Name, err)
+		}
+		r.Miners = append(r.Miners, m)
+	}
+	for _, def := range f.TopUp {
+		if err := checkName(def.Name); err != nil {
+			return nil, err
+		}
+		t, err := newTopUp(&def)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", def.Name, err)
+		}
+		r.TopUps = append(r.TopUps, t)
+	}
+	if len(r.Miners) == 0 && len(r.TopUps) == 0 {
+		return nil, fmt.Errorf("no rules defined")
+	}
+	return r, nil
+}
+
+func newMiner(def *MinerRule) (*Miner, error) {
+	m := &Miner{Name: def.Name, OwnerKeep: types.NewInt(0)}
+	var err error
+	if m.Miner, err = address.NewFromString(def.Miner); err != nil {
+		return nil, fmt.Errorf("invalid miner %q: %w", def.Miner, err)
+	}
+	if m.Threshold, err = parseAmount("Threshold", def.Threshold, true); err != nil {
+		return nil, err
+	}
+	if def.ForwardTo != "" {
+		if m.ForwardTo, err = address.NewFromString(def.ForwardTo); err != nil {
+			return nil, fmt.Errorf("invalid forward address %q: %w", def.ForwardTo, err)
+		}
+	} else if def.OwnerKeep != "" {
+		return nil, fmt.Errorf("OwnerKeep requires ForwardTo")
+	}
+	if def.OwnerKeep != "" {
+		if m.OwnerKeep, err = parseAmount("OwnerKeep", def.OwnerKeep, false); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func newTopUp(def *TopUpRule) (*TopUp, error) {
+	t := &TopUp{Name: def.Name}
+	var err error
+	if t.From, err = address.NewFromString(def.From); err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", def.From, err)
+	}
+	if def.Miner != "" {
+		if t.Miner, err = address.NewFromString(def.Miner); err != nil {
+			return nil, fmt.Errorf("invalid miner %q: %w", def.Miner, err)
+		}
+	}
+	for _, s := range def.Targets {
+		a, err := address.NewFromString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %q: %w", s, err)
+		}
+		t.Targets = append(t.Targets, a)
+	}
+	if t.Miner == address.Undef && len(t.Targets) == 0 {
+		return nil, fmt.Errorf("must set Miner or Targets")
+	}
+	if t.Below, err = parseAmount("Below", def.Below, true); err != nil {
+		return nil, err
+	}
+	if t.TopUpTo, err = parseAmount("TopUpTo", def.TopUpTo, true); err != nil {
+		return nil, err
+	}
+	if t.TopUpTo.LessThan(t.Below) {
+		return nil, fmt.Errorf("TopUpTo must not be less than Below")
+	}
+	return t, nil
+}
+
+// parseAmount 解析 FIL 金额，positive 为 true 时金额必须大于 0
+func parseAmount(name, s string, positive bool) (types.BigInt, error) {
+	if s == "" {
+		return types.BigInt{}, fmt.Errorf("%s is required", name)
+	}
+	f, err := types.ParseFIL(s)
+	if err != nil {
+		return types.BigInt{}, fmt.Errorf("%s: %w", name, err)
+	}
+	v := types.BigInt(f)
+	if v.Sign() < 0 || (positive && v.Sign() == 0) {
+		if positive {
+			return types.BigInt{}, fmt.Errorf("%s must be greater than 0", name)
+		}
+		return types.BigInt{}, fmt.Errorf("%s must not be negative", name)
+	}
+	return v, nil
+}
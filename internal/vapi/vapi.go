@@ -2,13 +2,18 @@ package vapi
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/ipfs/go-cid"
 	logging "github.com/ipfs/go-log/v2"
 
 	"wallet-sign/internal/chain/types"
+	appcfg "wallet-sign/internal/config"
 	"wallet-sign/internal/rpc"
 )
 
@@ -37,24 +42,121 @@ func NewNode(ctx context.Context, rpc *rpc.Client) *Node {
 }
 
 // StateWaitMsg 等待消息被打包到区块中并返回消息查找结果
-// 轮询链直到给定 CID 的消息被确认（3 个 tipset 的确认度）
-// 如果消息执行失败（非零退出码）或 RPC 调用失败，返回错误
+// 配置了 ws:// 或 wss:// 节点时订阅链头变化，每个新链头用 StateSearchMsg 查找消息，
+// 直到达到网络配置的确认度（默认 3 个 tipset）；否则回退到长时间阻塞的 StateWaitMsg 调用
+// 消息执行失败（非零退出码）时仍返回查找结果，由调用方检查 Receipt.ExitCode
 func (vapi Node) StateWaitMsg(msgCid cid.Cid) (*types.MsgLookup, error) {
 	log.Debugf("StateWaitMsg: waiting for message with CID: %s", msgCid)
+	ctx, cancel := context.WithCancel(vapi.ctx)
+	defer cancel()
+	confidence := abi.ChainEpoch(appcfg.Confidence())
+	for {
+		heads, err := vapi.ChainNotify(ctx)
+		if err != nil {
+			if !errors.Is(err, rpc.ErrSubscriptionsUnsupported) {
+				log.Warnf("StateWaitMsg: falling back to StateWaitMsg call: %v", err)
+			}
+			return vapi.stateWaitMsgCall(msgCid, confidence)
+		}
+		lookup, err := vapi.waitMsgHeads(ctx, msgCid, heads, confidence)
+		if err != nil || lookup != nil {
+			return lookup, err
+		}
+		log.Warnf("StateWaitMsg: head change subscription closed, resubscribing")
+	}
+}
+
+// waitMsgHeads 在每次链头变化时查找消息，直到达到确认度
+// 订阅被关闭时返回 nil, nil
+func (vapi Node) waitMsgHeads(ctx context.Context, msgCid cid.Cid, heads <-chan []*types.HeadChange, confidence abi.ChainEpoch) (*types.MsgLookup, error) {
+	for {
+		var changes []*types.HeadChange
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to wait for message: %w", ctx.Err())
+		case c, ok := <-heads:
+			if !ok {
+				return nil, nil
+			}
+			changes = c
+		}
+
+		var height abi.ChainEpoch
+		for _, hc := range changes {
+			if hc.Type != types.HCRevert && hc.Val.Height() > height {
+				height = hc.Val.Height()
+			}
+		}
+		if height == 0 {
+			continue
+		}
+		lookup, err := vapi.StateSearchMsg(msgCid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait for message: %w", err)
+		}
+		if lookup == nil {
+			continue
+		}
+		if height >= lookup.Height+confidence {
+			log.Debugf("StateWaitMsg: message included at height %d, exit code: %d", lookup.Height, lookup.Receipt.ExitCode)
+			return lookup, nil
+		}
+		log.Debugf("StateWaitMsg: message found at height %d, head %d, waiting for confidence", lookup.Height, height)
+	}
+}
+
+// stateWaitMsgCall 使用节点的 StateWaitMsg 等待消息，请求会一直阻塞到消息被确认
+func (vapi Node) stateWaitMsgCall(msgCid cid.Cid, confidence abi.ChainEpoch) (*types.MsgLookup, error) {
 	var msgLookup types.MsgLookup
-	err := vapi.Call(vapi.ctx, "StateWaitMsg", []interface{}{msgCid, 3}, &msgLookup)
+	err := vapi.Call(vapi.ctx, "StateWaitMsg", []interface{}{msgCid, confidence, abi.ChainEpoch(-1), true}, &msgLookup)
 	if err != nil {
 		log.Errorf("StateWaitMsg: failed to wait for message: %v", err)
 		return nil, fmt.Errorf("failed to wait for message: %w", err)
 	}
 
-	if msgLookup.Receipt.ExitCode != 0 {
-		log.Errorf("StateWaitMsg: message execution failed with exit code: %d", msgLookup.Receipt.ExitCode)
-		return nil, fmt.Errorf("withdrawal failed with exit code: %d", msgLookup.Receipt.ExitCode)
+	log.Debugf("StateWaitMsg: message included at height %d, exit code: %d", msgLookup.Height, msgLookup.Receipt.ExitCode)
+	return &msgLookup, nil
+}
+
+// ChainNotify 订阅链头变化，需要 ws:// 或 wss:// 节点
+// 第一次推送当前链头（current），之后推送 apply 和 revert；ctx 结束或连接断开时通道关闭
+func (vapi Node) ChainNotify(ctx context.Context) (<-chan []*types.HeadChange, error) {
+	raw, err := vapi.Subscribe(ctx, "ChainNotify", []interface{}{})
+	if err != nil {
+		log.Debugf("ChainNotify: failed to subscribe to head changes: %v", err)
+		return nil, fmt.Errorf("failed to subscribe to head changes: %w", err)
 	}
+	out := make(chan []*types.HeadChange)
+	go func() {
+		defer close(out)
+		for v := range raw {
+			var changes []*types.HeadChange
+			if err := json.Unmarshal(v, &changes); err != nil {
+				log.Errorf("ChainNotify: failed to unmarshal head change: %v", err)
+				continue
+			}
+			select {
+			case out <- changes:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
 
-	log.Debugf("StateWaitMsg: message confirmed successfully, exit code: %d", msgLookup.Receipt.ExitCode)
-	return &msgLookup, nil
+// StateReplay 在消息所在的 tipset 上重放消息
+// 返回执行结果和 Gas 费用明细（燃烧、小费、退款等）
+func (vapi Node) StateReplay(tsk []cid.Cid, msgCid cid.Cid) (*types.InvocResult, error) {
+	log.Debugf("StateReplay: replaying message %s", msgCid)
+	var res types.InvocResult
+	err := vapi.Call(vapi.ctx, "StateReplay", []interface{}{tsk, msgCid}, &res)
+	if err != nil {
+		log.Errorf("StateReplay: failed to replay message: %v", err)
+		return nil, fmt.Errorf("failed to replay message: %w", err)
+	}
+	log.Debugf("StateReplay: message %s replayed successfully", msgCid)
+	return &res, nil
 }
 
 // MpoolPush 将已签名的消息推送到内存池并返回其 CID
@@ -64,14 +166,32 @@ func (vapi Node) MpoolPush(signedMsg *types.SignedMessage) (cid.Cid, error) {
 	log.Debugf("MpoolPush: pushing signed message to mempool")
 	var msgCid cid.Cid
 	err := vapi.Call(vapi.ctx, "MpoolPush", []interface{}{signedMsg}, &msgCid)
+	// 网络错误时节点可能已经接收了消息，只有确认节点上没有该消息时才重新推送
+	for attempt := 1; err != nil && rpc.IsTransient(err) && attempt <= vapi.Retries(); attempt++ {
+		expected := signedMsg.Cid()
+		_, gerr := vapi.ChainGetMessage(expected)
+		if gerr == nil {
+			log.Warnf("MpoolPush: push failed but the node has message %s: %v", expected, err)
+			msgCid, err = expected, nil
+			break
+		}
+		if !rpc.IsRPCError(gerr) {
+			log.Errorf("MpoolPush: cannot confirm whether message %s was pushed: %v", expected, gerr)
+			break
+		}
+		log.Warnf("MpoolPush: message %s is not on the node, retrying push (%d/%d): %v", expected, attempt, vapi.Retries(), err)
+		if vapi.Backoff(vapi.ctx, attempt) != nil {
+			break
+		}
+		err = vapi.Call(vapi.ctx, "MpoolPush", []interface{}{signedMsg}, &msgCid)
+	}
 	if err != nil {
 		log.Errorf("MpoolPush: failed to push message: %v", err)
 		return cid.Undef, fmt.Errorf("failed to push message: %w", err)
 	}
 
 	log.Debugf("MpoolPush: message pushed successfully, CID: %s", msgCid)
-	fmt.Printf("Message CID: %s\n", msgCid)
-	fmt.Println("Waiting for message confirmation...")
+	fmt.Fprintf(os.Stderr, "Message CID: %s\n", msgCid)
 	return msgCid, nil
 }
 
@@ -248,3 +368,156 @@ func (vapi Node) GasEstimateFeeCap(msg *types.Message, maxqueueblks int64) (type
 	log.Debugf("GasEstimateFeeCap: fee cap estimated successfully, feecap: %s", feecap)
 	return feecap, nil
 }
+
+// StateNetworkName 返回节点所在网络的名称
+// 例如 mainnet、calibrationnet
+func (vapi Node) StateNetworkName() (string, error) {
+	log.Debugf("StateNetworkName: getting network name")
+	var name string
+	err := vapi.Call(vapi.ctx, "StateNetworkName", []interface{}{}, &name)
+	if err != nil {
+		log.Errorf("StateNetworkName: failed to get network name: %v", err)
+		return "", fmt.Errorf("failed to get network name: %w", err)
+	}
+	log.Debugf("StateNetworkName: network name retrieved successfully: %s", name)
+	return name, nil
+}
+
+// StateCall 在当前链头状态上执行消息但不上链
+// 用于预测消息的退出码和 Gas 消耗
+func (vapi Node) StateCall(msg *types.Message) (*types.InvocResult, error) {
+	log.Debugf("StateCall: calling message from %s to %s, method %d", msg.From, msg.To, msg.Method)
+	var res types.InvocResult
+	err := vapi.Call(vapi.ctx, "StateCall", []interface{}{msg, nil}, &res)
+	if err != nil {
+		log.Errorf("StateCall: failed to call message: %v", err)
+		return nil, fmt.Errorf("failed to call message: %w", err)
+	}
+	// 执行出错时节点可能不返回回执
+	if res.MsgRct != nil {
+		log.Debugf("StateCall: message executed, exit code: %d", res.MsgRct.ExitCode)
+	} else {
+		log.Debugf("StateCall: message executed without receipt: %s", res.Error)
+	}
+	return &res, nil
+}
+
+// StateNetworkVersion 返回当前链头的网络版本
+func (vapi Node) StateNetworkVersion() (uint, error) {
+	log.Debugf("StateNetworkVersion: getting network version")
+	var nv uint
+	err := vapi.Call(vapi.ctx, "StateNetworkVersion", []interface{}{nil}, &nv)
+	if err != nil {
+		log.Errorf("StateNetworkVersion: failed to get network version: %v", err)
+		return 0, fmt.Errorf("failed to get network version: %w", err)
+	}
+	log.Debugf("StateNetworkVersion: network version retrieved successfully: %d", nv)
+	return nv, nil
+}
+
+// StateActorCodeCIDs 返回指定网络版本下内置 actor 名称到代码 CID 的映射
+// 例如 multisig、account、storageminer
+func (vapi Node) StateActorCodeCIDs(nv uint) (map[string]cid.Cid, error) {
+	log.Debugf("StateActorCodeCIDs: getting actor code CIDs for network version %d", nv)
+	var codes map[string]cid.Cid
+	err := vapi.Call(vapi.ctx, "StateActorCodeCIDs", []interface{}{nv}, &codes)
+	if err != nil {
+		log.Errorf("StateActorCodeCIDs: failed to get actor code CIDs: %v", err)
+		return nil, fmt.Errorf("failed to get actor code CIDs: %w", err)
+	}
+	log.Debugf("StateActorCodeCIDs: retrieved %d actor code CIDs", len(codes))
+	return codes, nil
+}
+
+// StateReadState 读取 actor 的状态
+// 状态以 JSON 形式返回，由调用方按 actor 类型解析
+func (vapi Node) StateReadState(addr address.Address) (*types.ActorState, error) {
+	log.Debugf("StateReadState: reading state of %s", addr)
+	var st types.ActorState
+	err := vapi.Call(vapi.ctx, "StateReadState", []interface{}{addr, nil}, &st)
+	if err != nil {
+		log.Errorf("StateReadState: failed to read state: %v", err)
+		return nil, fmt.Errorf("failed to read actor state: %w", err)
+	}
+	log.Debugf("StateReadState: state of %s retrieved successfully", addr)
+	return &st, nil
+}
+
+// MsigGetPending 返回多签钱包中待审批的交易
+func (vapi Node) MsigGetPending(msig address.Address) ([]*types.MsigTransaction, error) {
+	log.Debugf("MsigGetPending: getting pending transactions of %s", msig)
+	var txns []*types.MsigTransaction
+	err := vapi.Call(vapi.ctx, "MsigGetPending", []interface{}{msig, nil}, &txns)
+	if err != nil {
+		log.Errorf("MsigGetPending: failed to get pending transactions: %v", err)
+		return nil, fmt.Errorf("failed to get pending transactions: %w", err)
+	}
+	log.Debugf("MsigGetPending: %s has %d pending transactions", msig, len(txns))
+	return txns, nil
+}
+
+// MsigGetAvailableBalance 返回多签钱包中已解锁、可以花费的余额
+func (vapi Node) MsigGetAvailableBalance(msig address.Address) (types.BigInt, error) {
+	log.Debugf("MsigGetAvailableBalance: getting available balance of %s", msig)
+	var bal types.BigInt
+	err := vapi.Call(vapi.ctx, "MsigGetAvailableBalance", []interface{}{msig, nil}, &bal)
+	if err != nil {
+		log.Errorf("MsigGetAvailableBalance: failed to get available balance: %v", err)
+		return types.BigInt{}, fmt.Errorf("failed to get available balance: %w", err)
+	}
+	log.Debugf("MsigGetAvailableBalance: available balance of %s: %s", msig, bal)
+	return bal, nil
+}
+
+// MsigGetVestingSchedule 返回多签钱包的锁仓计划
+func (vapi Node) MsigGetVestingSchedule(msig address.Address) (*types.MsigVesting, error) {
+	log.Debugf("MsigGetVestingSchedule: getting vesting schedule of %s", msig)
+	var vest types.MsigVesting
+	err := vapi.Call(vapi.ctx, "MsigGetVestingSchedule", []interface{}{msig, nil}, &vest)
+	if err != nil {
+		log.Errorf("MsigGetVestingSchedule: failed to get vesting schedule: %v", err)
+		return nil, fmt.Errorf("failed to get vesting schedule: %w", err)
+	}
+	log.Debugf("MsigGetVestingSchedule: vesting schedule of %s retrieved successfully", msig)
+	return &vest, nil
+}
+
+// StateSearchMsg 在链上查找消息的执行结果，不等待
+// 消息尚未上链时返回 nil；消息被替换时返回的 Message 为替换消息的 CID
+func (vapi Node) StateSearchMsg(msgCid cid.Cid) (*types.MsgLookup, error) {
+	log.Debugf("StateSearchMsg: searching for message %s", msgCid)
+	var lookup *types.MsgLookup
+	err := vapi.Call(vapi.ctx, "StateSearchMsg", []interface{}{nil, msgCid, abi.ChainEpoch(-1), true}, &lookup)
+	if err != nil {
+		log.Errorf("StateSearchMsg: failed to search message: %v", err)
+		return nil, fmt.Errorf("failed to search message: %w", err)
+	}
+	return lookup, nil
+}
+
+// MpoolPending 返回内存池中所有待打包的消息
+func (vapi Node) MpoolPending() ([]*types.SignedMessage, error) {
+	log.Debugf("MpoolPending: getting pending messages")
+	var msgs []*types.SignedMessage
+	err := vapi.Call(vapi.ctx, "MpoolPending", []interface{}{nil}, &msgs)
+	if err != nil {
+		log.Errorf("MpoolPending: failed to get pending messages: %v", err)
+		return nil, fmt.Errorf("failed to get pending messages: %w", err)
+	}
+	log.Debugf("MpoolPending: %d pending messages", len(msgs))
+	return msgs, nil
+}
+
+// ChainGetMessage 根据 CID 读取消息内容
+// 已签名消息的 CID 同样可以查询，返回其中的未签名消息
+func (vapi Node) ChainGetMessage(msgCid cid.Cid) (*types.Message, error) {
+	log.Debugf("ChainGetMessage: getting message %s", msgCid)
+	var msg types.Message
+	err := vapi.Call(vapi.ctx, "ChainGetMessage", []interface{}{msgCid}, &msg)
+	if err != nil {
+		log.Errorf("ChainGetMessage: failed to get message: %v", err)
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	log.Debugf("ChainGetMessage: message %s from %s with nonce %d", msgCid, msg.From, msg.Nonce)
+	return &msg, nil
+}
@@ -2,8 +2,10 @@ package wallet
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"math/big"
 
 	bls12381 "github.com/kilic/bls12-381"
 	"golang.org/x/crypto/hkdf"
@@ -125,3 +127,89 @@ func BLSGeneratePrivateKeyWithSeed(ikm []byte) ([]byte, error) {
 	log.Debug("BLSGeneratePrivateKeyWithSeed: successfully generated BLS private key")
 	return privKey, nil
 }
+
+// blsCurveOrder BLS12-381 曲线阶 r
+var blsCurveOrder, _ = new(big.Int).SetString("52435875175126190479447740508185965837690552500527637822603658699938581184513", 10)
+
+// blsHKDFModR 按 EIP-2333 的 HKDF_mod_r 从输入密钥材料派生私钥标量
+// 返回大端序 32 字节标量
+func blsHKDFModR(ikm []byte) ([]byte, error) {
+	salt := []byte("BLS-SIG-KEYGEN-SALT-")
+	sk := new(big.Int)
+	for sk.Sign() == 0 {
+		h := sha256.Sum256(salt)
+		salt = h[:]
+
+		prk := hkdf.Extract(sha256.New, append(append([]byte{}, ikm...), 0), salt)
+		// key_info 为空，L = 48
+		okm := make([]byte, 48)
+		if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, []byte{0, 48}), okm); err != nil {
+			return nil, fmt.Errorf("failed to expand key material: %w", err)
+		}
+		sk.Mod(new(big.Int).SetBytes(okm), blsCurveOrder)
+	}
+	return sk.FillBytes(make([]byte, BLSPrivateKeyBytes)), nil
+}
+
+// blsIKMToLamportSK 按 EIP-2333 从 IKM 生成 255 个 Lamport 私钥块
+func blsIKMToLamportSK(ikm, salt []byte) ([][]byte, error) {
+	prk := hkdf.Extract(sha256.New, ikm, salt)
+	okm := make([]byte, 32*255)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, nil), okm); err != nil {
+		return nil, fmt.Errorf("failed to expand lamport key: %w", err)
+	}
+	chunks := make([][]byte, 255)
+	for i := range chunks {
+		chunks[i] = okm[i*32 : (i+1)*32]
+	}
+	return chunks, nil
+}
+
+// blsParentSKToLamportPK 按 EIP-2333 计算父私钥对应的压缩 Lamport 公钥
+func blsParentSKToLamportPK(parentSK []byte, index uint32) ([]byte, error) {
+	salt := make([]byte, 4)
+	binary.BigEndian.PutUint32(salt, index)
+
+	notIKM := make([]byte, len(parentSK))
+	for i, b := range parentSK {
+		notIKM[i] = ^b
+	}
+
+	lamport0, err := blsIKMToLamportSK(parentSK, salt)
+	if err != nil {
+		return nil, err
+	}
+	lamport1, err := blsIKMToLamportSK(notIKM, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	pk := sha256.New()
+	for _, chunk := range append(lamport0, lamport1...) {
+		h := sha256.Sum256(chunk)
+		pk.Write(h[:])
+	}
+	return pk.Sum(nil), nil
+}
+
+// BLSDeriveMasterSK 按 EIP-2333 从种子派生 BLS 主私钥
+// 返回大端序 32 字节标量，与 BLSGeneratePrivateKeyWithSeed 一样要求种子至少 32 字节
+// 不复用 BLSGeneratePrivateKeyWithSeed：它只做一次 HKDF 并直接截取 32 字节，没有 EIP-2333 KeyGen 的
+// 盐值迭代、IKM 补 0、48 字节输出及模 r 约简，派生结果与其他 EIP-2333 钱包不一致，也不保证是有效标量；
+// 它生成的已有随机密钥仍沿用原算法，保持不变
+func BLSDeriveMasterSK(seed []byte) ([]byte, error) {
+	if len(seed) < 32 {
+		return nil, fmt.Errorf("seed must be at least 32 bytes, got %d", len(seed))
+	}
+	return blsHKDFModR(seed)
+}
+
+// BLSDeriveChildSK 按 EIP-2333 从父私钥派生子私钥
+// 输入输出均为大端序 32 字节标量
+func BLSDeriveChildSK(parentSK []byte, index uint32) ([]byte, error) {
+	lamportPK, err := blsParentSKToLamportPK(parentSK, index)
+	if err != nil {
+		return nil, err
+	}
+	return blsHKDFModR(lamportPK)
+}
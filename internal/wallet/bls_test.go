@@ -0,0 +1,84 @@
+package wallet
+
+import (
+	"math/big"
+	"testing"
+)
+
+// eip2333Cases EIP-2333 测试向量，私钥为十进制
+var eip2333Cases = []struct {
+	seed     string
+	masterSK string
+	index    uint32
+	childSK  string
+}{
+	{
+		seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		masterSK: "6083874454709270928345386274498605044986640685124978867557563392430687146096",
+		index:    0,
+		childSK:  "20397789859736650942317412262472558107875392172444076792671091975210932703118",
+	},
+	{
+		seed:     "3141592653589793238462643383279502884197169399375105820974944592",
+		masterSK: "29757020647961307431480504535336562678282505419141012933316116377660817309383",
+		index:    3141592653,
+		childSK:  "25457201688850691947727629385191704516744796114925897962676248250929345014287",
+	},
+	{
+		seed:     "0099ff991111002299dd7744ee3355bbdd8844115566cc55663355668888cc00",
+		masterSK: "27580842291869792442942448775674722299803720648445448686099262467207037398656",
+		index:    4294967295,
+		childSK:  "29358610794459428860402234341874281240803786294062035874021252734817515685787",
+	},
+	{
+		seed:     "d4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3",
+		masterSK: "19022158461524446591288038168518313374041767046816487870552872741050760015818",
+		index:    42,
+		childSK:  "31372231650479070279774297061823572166496564838472787488249775572789064611981",
+	},
+}
+
+func expectScalar(t *testing.T, name string, got []byte, want string) {
+	t.Helper()
+	if s := new(big.Int).SetBytes(got).String(); s != want {
+		t.Errorf("%s = %s, want %s", name, s, want)
+	}
+}
+
+func TestBLSDeriveEIP2333(t *testing.T) {
+	for _, tc := range eip2333Cases {
+		master, err := BLSDeriveMasterSK(mustHex(t, tc.seed))
+		if err != nil {
+			t.Fatalf("master key of %s: %v", tc.seed, err)
+		}
+		expectScalar(t, "master key of "+tc.seed, master, tc.masterSK)
+
+		child, err := BLSDeriveChildSK(master, tc.index)
+		if err != nil {
+			t.Fatalf("child %d of %s: %v", tc.index, tc.seed, err)
+		}
+		expectScalar(t, "child key of "+tc.seed, child, tc.childSK)
+	}
+
+	if _, err := BLSDeriveMasterSK(make([]byte, 31)); err == nil {
+		t.Errorf("seed shorter than 32 bytes was accepted")
+	}
+}
+
+// TestDeriveBLSByteOrder 派生的私钥以小端序保存，与 EIP-2333 的大端序标量互为反序
+func TestDeriveBLSByteOrder(t *testing.T) {
+	tc := eip2333Cases[3]
+	key, err := deriveBLS(mustHex(t, tc.seed), []uint32{tc.index})
+	if err != nil {
+		t.Fatal(err)
+	}
+	be := make([]byte, len(key))
+	for i := range key {
+		be[i] = key[len(key)-1-i]
+	}
+	expectScalar(t, "derived key", be, tc.childSK)
+
+	if _, err := BLSPrivateKeyToPublicKey(key); err != nil {
+		t.Errorf("public key: %v", err)
+	}
+}
@@ -7,10 +7,13 @@ import (
 	fcrypto "github.com/filecoin-project/go-crypto"
 	"github.com/filecoin-project/go-state-types/crypto"
 	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+
+	"wallet-sign/internal/chain/types/ethtypes"
 )
 
 // SignBytes signs data with a private key using the specified signature type.
-// Supports both secp256k1 and BLS signing using pure Go implementations.
+// Supports secp256k1, delegated and BLS signing using pure Go implementations.
 func SignBytes(data []byte, privKey []byte, sigType crypto.SigType) ([]byte, error) {
 	log.Debugf("SignBytes: signing data with signature type %d", sigType)
 
@@ -25,6 +28,18 @@ func SignBytes(data []byte, privKey []byte, sigType crypto.SigType) ([]byte, err
 		log.Debug("SignBytes: secp256k1 signing successful")
 		return sig, nil
 
+	case crypto.SigTypeDelegated:
+		// Delegated keys are secp256k1 keys signing the keccak256 digest of the Ethereum transaction
+		hasher := sha3.NewLegacyKeccak256()
+		hasher.Write(data)
+		sig, err := fcrypto.Sign(privKey, hasher.Sum(nil))
+		if err != nil {
+			log.Errorf("SignBytes: delegated signing failed: %v", err)
+			return nil, err
+		}
+		log.Debug("SignBytes: delegated signing successful")
+		return sig, nil
+
 	case crypto.SigTypeBLS:
 		// Use pure Go BLS implementation
 		sig, err := BLSSign(privKey, data)
@@ -42,7 +57,7 @@ func SignBytes(data []byte, privKey []byte, sigType crypto.SigType) ([]byte, err
 }
 
 // PrivateKeyToAddress derives a Filecoin address from a private key.
-// Supports both secp256k1 and BLS addresses using pure Go implementations.
+// Supports secp256k1, delegated (f410) and BLS addresses using pure Go implementations.
 func PrivateKeyToAddress(privKey []byte, sigType crypto.SigType) (address.Address, error) {
 	log.Debugf("PrivateKeyToAddress: deriving address for signature type %d", sigType)
 
@@ -64,6 +79,25 @@ func PrivateKeyToAddress(privKey []byte, sigType crypto.SigType) (address.Addres
 		log.Debugf("PrivateKeyToAddress: created secp256k1 address %s", addr)
 		return addr, nil
 
+	case crypto.SigTypeDelegated:
+		pubKey, err = secpPublicKey(privKey)
+		if err != nil {
+			log.Errorf("PrivateKeyToAddress: failed to get secp256k1 public key: %v", err)
+			return address.Undef, err
+		}
+		ethAddr, err := ethtypes.EthAddressFromPubKey(pubKey)
+		if err != nil {
+			log.Errorf("PrivateKeyToAddress: failed to derive ethereum address: %v", err)
+			return address.Undef, err
+		}
+		addr, err := ethAddr.ToFilecoinAddress()
+		if err != nil {
+			log.Errorf("PrivateKeyToAddress: failed to create delegated address: %v", err)
+			return address.Undef, err
+		}
+		log.Debugf("PrivateKeyToAddress: created delegated address %s (%s)", addr, ethAddr)
+		return addr, nil
+
 	case crypto.SigTypeBLS:
 		// Use pure Go BLS implementation
 		pubKey, err = BLSPrivateKeyToPublicKey(privKey)
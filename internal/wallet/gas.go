@@ -6,16 +6,15 @@ import (
 	"golang.org/x/xerrors"
 
 	"wallet-sign/internal/chain/types"
+	appcfg "wallet-sign/internal/config"
 	"wallet-sign/internal/vapi"
 )
 
+// CapGasFee 将消息的最大手续费（GasFeeCap × GasLimit）限制在当前网络配置的 MaxFee 以内
 func CapGasFee(msg *types.Message) {
 	log.Debugf("CapGasFee: capping gas fee for message to %s", msg.To)
 
-	var maxFee abi.TokenAmount
-	if maxFee.Int == nil || maxFee.Equals(big.Zero()) {
-		maxFee, _ = big.FromString("10000000000000000000")
-	}
+	maxFee := maxFee()
 
 	gl := types.NewInt(uint64(msg.GasLimit))
 	totalFee := types.BigMul(msg.GasFeeCap, gl)
@@ -31,6 +30,16 @@ func CapGasFee(msg *types.Message) {
 	log.Infof("CapGasFee: capped gas fee from %s to %s", totalFee, msg.GasFeeCap)
 }
 
+// maxFee 返回当前网络配置的单条消息最大手续费，配置无效时使用默认值
+func maxFee() abi.TokenAmount {
+	f, err := types.ParseFIL(appcfg.MaxFee())
+	if err != nil || f.Int == nil || f.Sign() <= 0 {
+		log.Warnf("maxFee: invalid max fee %q, using %s FIL", appcfg.MaxFee(), appcfg.DefaultMaxFee)
+		f, _ = types.ParseFIL(appcfg.DefaultMaxFee)
+	}
+	return abi.TokenAmount(f)
+}
+
 func SetGas(api *vapi.Node, msg *types.Message) error {
 	log.Infof("SetGas: estimating gas for message from %s to %s", msg.From, msg.To)
 
@@ -0,0 +1,213 @@
+package wallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/tyler-smith/go-bip39"
+
+	"wallet-sign/internal/chain/types"
+)
+
+const (
+	// FilecoinCoinType Filecoin 在 SLIP-44 中注册的币种编号
+	FilecoinCoinType = 461
+	// EthereumCoinType 以太坊在 SLIP-44 中注册的币种编号，委托地址使用以太坊钱包的派生路径
+	EthereumCoinType = 60
+	// HardenedOffset BIP32 强化派生索引偏移量
+	HardenedOffset = 0x80000000
+)
+
+// secp256k1Order secp256k1 曲线阶 n
+var secp256k1Order, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+
+// NewMnemonic 生成新的 BIP39 助记词
+// bits 为熵长度：128 位对应 12 个单词，256 位对应 24 个单词
+func NewMnemonic(bits int) (string, error) {
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate entropy: %w", err)
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// MnemonicToSeed 校验助记词并派生 BIP39 种子
+func MnemonicToSeed(mnemonic, password string) ([]byte, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, password)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	return seed, nil
+}
+
+// DerivationPath 返回指定密钥类型和账户索引的派生路径
+// secp256k1 使用 BIP44 路径 m/44'/461'/0'/0/i，BLS 使用 EIP-2334 风格路径 m/12381/461/0/i，
+// 委托地址使用以太坊钱包通用的 m/44'/60'/0'/0/i，与 MetaMask 等钱包派生出相同的账户
+func DerivationPath(typ types.KeyType, index uint32) (string, error) {
+	switch typ {
+	case types.KTSecp256k1:
+		return fmt.Sprintf("m/44'/%d'/0'/0/%d", FilecoinCoinType, index), nil
+	case types.KTDelegated:
+		return fmt.Sprintf("m/44'/%d'/0'/0/%d", EthereumCoinType, index), nil
+	case types.KTBLS:
+		return fmt.Sprintf("m/12381/%d/0/%d", FilecoinCoinType, index), nil
+	default:
+		return "", fmt.Errorf("unsupported key type for derivation: %s", typ)
+	}
+}
+
+// WalletDerive 从 BIP39 种子按账户索引派生密钥
+// 返回密钥信息、地址以及使用的派生路径
+func WalletDerive(typ types.KeyType, seed []byte, index uint32) (*types.KeyInfo, address.Address, string, error) {
+	log.Infof("WalletDerive: deriving %s key at index %d", typ, index)
+
+	path, err := DerivationPath(typ, index)
+	if err != nil {
+		return nil, address.Undef, "", err
+	}
+	segments, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, address.Undef, "", err
+	}
+
+	var privKey []byte
+	var sigType crypto.SigType
+	switch typ {
+	case types.KTSecp256k1:
+		privKey, err = deriveSecp256k1(seed, segments)
+		sigType = crypto.SigTypeSecp256k1
+	case types.KTDelegated:
+		privKey, err = deriveSecp256k1(seed, segments)
+		sigType = crypto.SigTypeDelegated
+	case types.KTBLS:
+		privKey, err = deriveBLS(seed, segments)
+		sigType = crypto.SigTypeBLS
+	}
+	if err != nil {
+		log.Errorf("WalletDerive: failed to derive key at %s: %v", path, err)
+		return nil, address.Undef, "", err
+	}
+
+	addr, err := PrivateKeyToAddress(privKey, sigType)
+	if err != nil {
+		log.Errorf("WalletDerive: failed to derive address: %v", err)
+		return nil, address.Undef, "", fmt.Errorf("failed to derive address: %w", err)
+	}
+
+	log.Infof("WalletDerive: derived %s at %s", addr, path)
+	return &types.KeyInfo{Type: typ, PrivateKey: privKey}, addr, path, nil
+}
+
+// parseDerivationPath 解析形如 m/44'/461'/0'/0/0 的派生路径
+// 带 ' 或 h 后缀的段为强化派生
+func parseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q", path)
+	}
+
+	segments := make([]uint32, 0, len(parts)-1)
+	for _, p := range parts[1:] {
+		var offset uint32
+		if strings.HasSuffix(p, "'") || strings.HasSuffix(p, "h") {
+			offset = HardenedOffset
+			p = p[:len(p)-1]
+		}
+		n, err := strconv.ParseUint(p, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path segment %q: %w", p, err)
+		}
+		segments = append(segments, uint32(n)+offset)
+	}
+	return segments, nil
+}
+
+// deriveSecp256k1 按 BIP32 从种子派生 secp256k1 私钥
+func deriveSecp256k1(seed []byte, segments []uint32) ([]byte, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+
+	k := new(big.Int).SetBytes(key)
+	if k.Sign() == 0 || k.Cmp(secp256k1Order) >= 0 {
+		return nil, fmt.Errorf("invalid master key")
+	}
+
+	for _, index := range segments {
+		data := make([]byte, 0, 37)
+		if index >= HardenedOffset {
+			data = append(data, 0)
+			data = append(data, key...)
+		} else {
+			pub, err := compressedSecpPublicKey(key)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, pub...)
+		}
+		data = append(data, byte(index>>24), byte(index>>16), byte(index>>8), byte(index))
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		il := new(big.Int).SetBytes(sum[:32])
+		if il.Cmp(secp256k1Order) >= 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		child := il.Add(il, new(big.Int).SetBytes(key))
+		child.Mod(child, secp256k1Order)
+		if child.Sign() == 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+
+		key = child.FillBytes(make([]byte, 32))
+		chainCode = sum[32:]
+	}
+	return key, nil
+}
+
+// compressedSecpPublicKey 返回 33 字节压缩格式的 secp256k1 公钥
+func compressedSecpPublicKey(privKey []byte) ([]byte, error) {
+	pub, err := secpPublicKey(privKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(pub) != 65 {
+		return nil, fmt.Errorf("unexpected public key length %d", len(pub))
+	}
+	prefix := byte(0x02)
+	if pub[64]&1 == 1 {
+		prefix = 0x03
+	}
+	return append([]byte{prefix}, pub[1:33]...), nil
+}
+
+// deriveBLS 按 EIP-2333 从种子派生 BLS 私钥
+// 返回 Filecoin 使用的小端序私钥
+func deriveBLS(seed []byte, segments []uint32) ([]byte, error) {
+	sk, err := BLSDeriveMasterSK(seed)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range segments {
+		if sk, err = BLSDeriveChildSK(sk, index); err != nil {
+			return nil, err
+		}
+	}
+
+	// EIP-2333 输出大端序标量，Filecoin 使用小端序存储私钥
+	privKey := make([]byte, BLSPrivateKeyBytes)
+	for i := 0; i < BLSPrivateKeyBytes; i++ {
+		privKey[i] = sk[BLSPrivateKeyBytes-1-i]
+	}
+	return privKey, nil
+}
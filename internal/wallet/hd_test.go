@@ -0,0 +1,116 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"wallet-sign/internal/chain/types"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decode %q: %v", s, err)
+	}
+	return b
+}
+
+// TestMnemonicToSeed BIP39 测试向量（口令 TREZOR）
+func TestMnemonicToSeed(t *testing.T) {
+	for _, tc := range []struct {
+		mnemonic string
+		seed     string
+	}{
+		{
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			// 多余的空白和换行与终端输入一致
+			"  legal winner thank year wave sausage worth useful legal winner thank yellow\n",
+			"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+	} {
+		seed, err := MnemonicToSeed(tc.mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatalf("seed of %q: %v", tc.mnemonic, err)
+		}
+		if got := hex.EncodeToString(seed); got != tc.seed {
+			t.Errorf("seed of %q = %s, want %s", tc.mnemonic, got, tc.seed)
+		}
+	}
+
+	if _, err := MnemonicToSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", ""); err == nil {
+		t.Errorf("mnemonic with a bad checksum was accepted")
+	}
+}
+
+// TestDeriveSecp256k1 BIP32 测试向量 1
+func TestDeriveSecp256k1(t *testing.T) {
+	seed := mustHex(t, "000102030405060708090a0b0c0d0e0f")
+	for _, tc := range []struct {
+		path string
+		key  string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{"m/0'/1/2'/2", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	} {
+		segments, err := parseDerivationPath(tc.path)
+		if err != nil {
+			t.Fatalf("parse %s: %v", tc.path, err)
+		}
+		key, err := deriveSecp256k1(seed, segments)
+		if err != nil {
+			t.Fatalf("derive %s: %v", tc.path, err)
+		}
+		if got := hex.EncodeToString(key); got != tc.key {
+			t.Errorf("key at %s = %s, want %s", tc.path, got, tc.key)
+		}
+	}
+}
+
+// TestWalletDeriveDelegated 委托地址与以太坊钱包从同一助记词派生出相同的私钥
+func TestWalletDeriveDelegated(t *testing.T) {
+	seed, err := MnemonicToSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ki, _, path, err := WalletDerive(types.KTDelegated, seed, 0)
+	if err != nil {
+		t.Fatalf("derive: %v", err)
+	}
+	if path != "m/44'/60'/0'/0/0" {
+		t.Errorf("path = %s", path)
+	}
+	if got, want := hex.EncodeToString(ki.PrivateKey), "1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727"; got != want {
+		t.Errorf("key = %s, want %s", got, want)
+	}
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	segments, err := parseDerivationPath("m/44'/461h/0'/0/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint32{44 + HardenedOffset, 461 + HardenedOffset, HardenedOffset, 0, 7}
+	if len(segments) != len(want) {
+		t.Fatalf("segments = %v, want %v", segments, want)
+	}
+	for i := range want {
+		if segments[i] != want[i] {
+			t.Errorf("segments = %v, want %v", segments, want)
+			break
+		}
+	}
+
+	for _, path := range []string{"44'/0", "m/x", "m/2147483648"} {
+		if _, err := parseDerivationPath(path); err == nil {
+			t.Errorf("invalid path %q was accepted", path)
+		}
+	}
+}
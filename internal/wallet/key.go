@@ -9,7 +9,7 @@ import (
 )
 
 // sigTypeForKeyType 将密钥类型转换为签名类型
-// 支持 Secp256k1 和 BLS 两种密钥类型
+// 支持 Secp256k1、BLS 和委托（f410）三种密钥类型
 func sigTypeForKeyType(kt types.KeyType) (crypto.SigType, error) {
 	log.Debugf("sigTypeForKeyType: converting key type %s to signature type", kt)
 
@@ -20,6 +20,9 @@ func sigTypeForKeyType(kt types.KeyType) (crypto.SigType, error) {
 	case types.KTBLS:
 		log.Debug("sigTypeForKeyType: key type is BLS")
 		return crypto.SigTypeBLS, nil
+	case types.KTDelegated:
+		log.Debug("sigTypeForKeyType: key type is Delegated")
+		return crypto.SigTypeDelegated, nil
 	default:
 		log.Errorf("sigTypeForKeyType: unsupported key type: %s", kt)
 		return crypto.SigTypeUnknown, fmt.Errorf("unsupported key type: %s", kt)
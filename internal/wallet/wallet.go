@@ -3,8 +3,13 @@ package wallet
 import (
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"time"
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/metrics"
+	"wallet-sign/internal/policy"
 	"wallet-sign/internal/repository"
 
 	"github.com/filecoin-project/go-address"
@@ -12,15 +17,23 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 
 	"wallet-sign/internal/chain/types"
+	"wallet-sign/internal/chain/types/ethtypes"
 )
 
 var log = logging.Logger("wallet")
 
+// ErrNetworkUnknown 未选择网络且未连接节点确认网络时拒绝签名
+var ErrNetworkUnknown = errors.New("network is not determined: select one with --network or configure a Lotus node")
+
 // WalletSign 使用指定地址的私钥签名消息
 // 从数据库中查找密钥并执行签名操作
 func WalletSign(store *repository.Store, addr address.Address, msg []byte) (*crypto.Signature, error) {
 	log.Infof("WalletSign: signing message for address %s", addr.String())
 
+	if err := CheckNetwork(store, addr); err != nil {
+		return nil, err
+	}
+
 	res, err := store.GetWalletKey(addr.String())
 	if err != nil {
 		log.Errorf("WalletSign: failed to get key for %s: %v", addr.String(), err)
@@ -39,7 +52,9 @@ func WalletSign(store *repository.Store, addr address.Address, msg []byte) (*cry
 		return nil, err
 	}
 
+	start := time.Now()
 	sigBytes, err := SignBytes(msg, ki.PrivateKey, sigType)
+	metrics.ObserveSign(string(ki.Type), start)
 	if err != nil {
 		log.Errorf("WalletSign: failed to sign bytes for %s: %v", addr.String(), err)
 		return nil, err
@@ -52,6 +67,78 @@ func WalletSign(store *repository.Store, addr address.Address, msg []byte) (*cry
 	}, nil
 }
 
+// SignMessage 使用发送方的私钥签名消息
+// 签名前检查数据库中是否存在发送方的密钥，并按配置的签名策略检查消息
+func SignMessage(store *repository.Store, msg *types.Message) (*types.SignedMessage, error) {
+	log.Infof("SignMessage: signing message %s from %s", msg.Cid(), msg.From)
+
+	hasKey, err := WalletHas(store, msg.From)
+	if err != nil {
+		log.Errorf("SignMessage: failed to check key for %s: %v", msg.From, err)
+		return nil, err
+	}
+	if !hasKey {
+		log.Errorf("SignMessage: wallet does not have key for %s", msg.From)
+		return nil, fmt.Errorf("wallet does not have key for %s", msg.From)
+	}
+	if err := CheckNetwork(store, msg.From); err != nil {
+		return nil, err
+	}
+
+	if err := policy.Authorize(store, msg); err != nil {
+		return nil, err
+	}
+
+	sb, err := SigningBytes(msg)
+	if err != nil {
+		log.Errorf("SignMessage: failed to encode message for signing: %v", err)
+		return nil, err
+	}
+
+	sig, err := WalletSign(store, msg.From, sb)
+	if err != nil {
+		return nil, err
+	}
+	return &types.SignedMessage{Message: *msg, Signature: *sig}, nil
+}
+
+// CheckNetwork 检查密钥是否在当前网络上使用
+// 密钥第一次签名时记录当前网络，之后在其他网络上签名会被拒绝，防止主网密钥被误用于测试网，反之亦然；
+// 当前网络未确定时（见 appcfg.NetworkConfirmed）拒绝签名
+func CheckNetwork(store *repository.Store, addr address.Address) error {
+	if !appcfg.NetworkConfirmed() {
+		log.Errorf("CheckNetwork: network is not determined, refusing to sign for %s", addr)
+		return ErrNetworkUnknown
+	}
+	current := appcfg.NetworkName()
+	network, err := store.WalletKeyNetwork(addr.String())
+	if err != nil {
+		return fmt.Errorf("getting key for %s: %w", addr, err)
+	}
+	if network == "" {
+		log.Infof("CheckNetwork: recording network %s for key %s", current, addr)
+		return store.SetWalletKeyNetwork(addr.String(), current)
+	}
+	if network != current {
+		log.Errorf("CheckNetwork: key for %s is used on %s, current network is %s", addr, network, current)
+		return fmt.Errorf("key for %s is used on network %s, not %s (run 'wallet set-network' to change it)", addr, network, current)
+	}
+	return nil
+}
+
+// SigningBytes 返回消息的待签名内容
+// 委托（f410）地址签名 EIP-1559 交易编码，其余地址签名消息 CID
+func SigningBytes(msg *types.Message) ([]byte, error) {
+	if msg.From.Protocol() == address.Delegated {
+		tx, err := ethtypes.Eth1559TxArgsFromUnsignedFilecoinMessage(msg, appcfg.EthChainID())
+		if err != nil {
+			return nil, err
+		}
+		return tx.ToRlpUnsignedMsg()
+	}
+	return msg.Cid().Bytes(), nil
+}
+
 // WalletImport 导入密钥到钱包
 // 从密钥信息派生地址
 func WalletImport(ki *types.KeyInfo) (address.Address, error) {
@@ -83,13 +170,13 @@ func WalletNew(typ types.KeyType) (*types.KeyInfo, address.Address, error) {
 	var sigType crypto.SigType
 
 	switch typ {
-	case types.KTSecp256k1:
+	case types.KTSecp256k1, types.KTDelegated:
 		privKey, err = GenerateKey()
 		if err != nil {
 			log.Errorf("WalletNew: failed to generate secp256k1 key: %v", err)
 			return nil, address.Undef, fmt.Errorf("failed to generate secp256k1 key: %w", err)
 		}
-		sigType = crypto.SigTypeSecp256k1
+		sigType, _ = sigTypeForKeyType(typ)
 
 	case types.KTBLS:
 		seed := make([]byte, 32)
@@ -128,12 +215,11 @@ func WalletNew(typ types.KeyType) (*types.KeyInfo, address.Address, error) {
 func WalletHas(store *repository.Store, addr address.Address) (bool, error) {
 	log.Debugf("WalletHas: checking if key exists for address %s", addr.String())
 
-	_, err := store.GetWalletKey(addr.String())
+	has, err := store.HasWalletKey(addr.String())
 	if err != nil {
-		log.Debugf("WalletHas: key not found for address %s", addr.String())
-		return false, nil
+		return false, err
 	}
 
-	log.Debugf("WalletHas: key found for address %s", addr.String())
-	return true, nil
+	log.Debugf("WalletHas: key found for address %s: %t", addr.String(), has)
+	return has, nil
 }
@@ -0,0 +1,202 @@
+package walletapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	logging "github.com/ipfs/go-log/v2"
+
+	"wallet-sign/internal/chain/types"
+	appcfg "wallet-sign/internal/config"
+	"wallet-sign/internal/metrics"
+	"wallet-sign/internal/policy"
+	"wallet-sign/internal/repository"
+	"wallet-sign/internal/wallet"
+)
+
+var log = logging.Logger("walletapi")
+
+// MsgType 签名内容类型，与 Lotus api.MsgType 一致
+type MsgType string
+
+const (
+	MTUnknown         MsgType = "unknown"
+	MTChainMsg        MsgType = "message"
+	MTBlock           MsgType = "block"
+	MTDealProposal    MsgType = "dealproposal"
+	MTNetWorkResponse MsgType = "network_response"
+)
+
+// walletAPIRequestType 通过远程钱包服务签名的链上消息在指标中的请求类型
+const walletAPIRequestType = "wallet_api"
+
+// MsgMeta 签名请求的附加信息
+// Type 为 message 时 Extra 是消息的 CBOR 编码
+type MsgMeta struct {
+	Type  MsgType
+	Extra []byte
+}
+
+// WalletAPI Lotus 钱包接口（WalletNew、WalletHas、WalletList、WalletSign、WalletExport、WalletImport、WalletDelete）
+// 密钥保存在加密的 repository.Store 中，调用前密钥库必须已解锁
+type WalletAPI struct {
+	store *repository.Store
+	mu    sync.Mutex // 串行化写入数据库的操作
+}
+
+// NewWalletAPI 创建钱包接口实例
+func NewWalletAPI(store *repository.Store) *WalletAPI {
+	return &WalletAPI{store: store}
+}
+
+// WalletNew 生成指定类型的新密钥并保存
+func (a *WalletAPI) WalletNew(ctx context.Context, typ types.KeyType) (address.Address, error) {
+	ki, addr, err := wallet.WalletNew(typ)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.store.SaveWalletKey(addr.String(), *ki); err != nil {
+		log.Errorf("WalletNew: failed to save key for %s: %v", addr, err)
+		return address.Undef, err
+	}
+	return addr, nil
+}
+
+// WalletHas 检查是否有指定地址的密钥
+func (a *WalletAPI) WalletHas(ctx context.Context, addr address.Address) (bool, error) {
+	return wallet.WalletHas(a.store, addr)
+}
+
+// WalletList 列出所有钱包地址
+func (a *WalletAPI) WalletList(ctx context.Context) ([]address.Address, error) {
+	keys, err := a.store.ListWalletKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]address.Address, 0, len(keys))
+	for _, k := range keys {
+		addr, err := address.NewFromString(k.Address)
+		if err != nil {
+			log.Errorf("WalletList: invalid address in store %s: %v", k.Address, err)
+			return nil, err
+		}
+		out = append(out, addr)
+	}
+	return out, nil
+}
+
+// WalletSign 使用指定地址的私钥签名
+// 签名链上消息时校验 meta 中的消息与待签名内容一致，防止调用方借用消息类型签名任意数据，
+// 并按签名策略检查消息；其他类型的数据需要策略允许 AllowRawSign
+func (a *WalletAPI) WalletSign(ctx context.Context, signer address.Address, toSign []byte, meta MsgMeta) (*crypto.Signature, error) {
+	has, err := wallet.WalletHas(a.store, signer)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, fmt.Errorf("wallet does not have key for %s", signer)
+	}
+	if err := wallet.CheckNetwork(a.store, signer); err != nil {
+		return nil, err
+	}
+
+	if meta.Type == MTChainMsg {
+		msg, err := types.DecodeMessage(meta.Extra)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode message from meta: %w", err)
+		}
+		if msg.From != signer {
+			return nil, fmt.Errorf("message sender %s does not match signer %s", msg.From, signer)
+		}
+		sb, err := wallet.SigningBytes(msg)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(sb, toSign) {
+			return nil, fmt.Errorf("signing bytes do not match message %s", msg.Cid())
+		}
+		if err := policy.Authorize(a.store, msg); err != nil {
+			return nil, err
+		}
+	} else if err := policy.AuthorizeRaw(signer, string(meta.Type)); err != nil {
+		return nil, err
+	}
+
+	log.Infof("WalletSign: signing %s request for %s", meta.Type, signer)
+	sig, err := wallet.WalletSign(a.store, signer, toSign)
+	if meta.Type == MTChainMsg {
+		if err != nil {
+			metrics.MessageFailed(walletAPIRequestType)
+		} else {
+			metrics.MessageSigned(walletAPIRequestType)
+		}
+	}
+	return sig, err
+}
+
+// ErrExportDisabled 未配置 Daemon.AllowExport 时拒绝导出私钥
+var ErrExportDisabled = errors.New("key export is disabled (set Daemon.AllowExport to permit it)")
+
+// exportAllowed 检查配置是否允许通过远程钱包服务导出私钥
+func exportAllowed() bool {
+	d := appcfg.LotusConfig.Daemon
+	return d != nil && d.AllowExport
+}
+
+// WalletExport 导出指定地址的私钥，需要配置 Daemon.AllowExport
+func (a *WalletAPI) WalletExport(ctx context.Context, addr address.Address) (*types.KeyInfo, error) {
+	if !exportAllowed() {
+		log.Warnf("WalletExport: rejected export of %s: %v", addr, ErrExportDisabled)
+		return nil, ErrExportDisabled
+	}
+
+	item, err := a.store.GetWalletKey(addr.String())
+	if err != nil {
+		return nil, fmt.Errorf("getting key for %s: %w", addr, err)
+	}
+
+	var ki types.KeyInfo
+	if err := json.Unmarshal(item.EncryptedKey, &ki); err != nil {
+		return nil, fmt.Errorf("unmarshaling key: %w", err)
+	}
+
+	log.Warnf("WalletExport: exported private key for %s", addr)
+	return &ki, nil
+}
+
+// WalletImport 导入密钥并返回对应地址
+func (a *WalletAPI) WalletImport(ctx context.Context, ki *types.KeyInfo) (address.Address, error) {
+	if ki == nil {
+		return address.Undef, fmt.Errorf("key info must not be empty")
+	}
+
+	addr, err := wallet.WalletImport(ki)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.store.SaveWalletKey(addr.String(), *ki); err != nil {
+		log.Errorf("WalletImport: failed to save key for %s: %v", addr, err)
+		return address.Undef, err
+	}
+	return addr, nil
+}
+
+// WalletDelete 删除指定地址的密钥
+func (a *WalletAPI) WalletDelete(ctx context.Context, addr address.Address) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.store.DeleteWalletKey(addr.String())
+}
@@ -0,0 +1,244 @@
+package walletapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+
+	"wallet-sign/internal/chain/types"
+)
+
+// maxRequestSize 单个 JSON-RPC 请求的最大字节数
+const maxRequestSize = 16 << 20
+
+// methodPrefix Lotus JSON-RPC 方法名前缀
+const methodPrefix = "Filecoin."
+
+// request JSON-RPC 2.0 请求
+type request struct {
+	Jsonrpc string            `json:"jsonrpc"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+	ID      json.RawMessage   `json:"id"`
+}
+
+// response JSON-RPC 2.0 响应
+type response struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *respError      `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// respError JSON-RPC 2.0 错误对象
+type respError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC 错误码，方法执行失败与 go-jsonrpc 一致使用 1
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeCallError      = 1
+)
+
+// handlerFunc 方法处理函数，参数为 JSON 数组中的各个元素
+type handlerFunc func(ctx context.Context, params []json.RawMessage) (interface{}, error)
+
+// Server 钱包接口的 JSON-RPC HTTP 服务
+// 与 lotus-wallet 相同，在 /rpc/v0 和 /rpc/v1 上提供服务，使用 Bearer 令牌认证
+type Server struct {
+	token   string
+	methods map[string]handlerFunc
+}
+
+// NewServer 创建 JSON-RPC 服务，token 为空时拒绝所有请求
+func NewServer(api *WalletAPI, token string) *Server {
+	s := &Server{token: token}
+	s.methods = map[string]handlerFunc{
+		"WalletNew": func(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+			var typ types.KeyType
+			if err := decodeParams(params, &typ); err != nil {
+				return nil, err
+			}
+			return api.WalletNew(ctx, typ)
+		},
+		"WalletHas": func(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+			var addr address.Address
+			if err := decodeParams(params, &addr); err != nil {
+				return nil, err
+			}
+			return api.WalletHas(ctx, addr)
+		},
+		"WalletList": func(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+			if err := decodeParams(params); err != nil {
+				return nil, err
+			}
+			return api.WalletList(ctx)
+		},
+		"WalletSign": func(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+			var (
+				signer address.Address
+				toSign []byte
+				meta   MsgMeta
+			)
+			if err := decodeParams(params, &signer, &toSign, &meta); err != nil {
+				return nil, err
+			}
+			return api.WalletSign(ctx, signer, toSign, meta)
+		},
+		"WalletExport": func(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+			var addr address.Address
+			if err := decodeParams(params, &addr); err != nil {
+				return nil, err
+			}
+			return api.WalletExport(ctx, addr)
+		},
+		"WalletImport": func(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+			var ki types.KeyInfo
+			if err := decodeParams(params, &ki); err != nil {
+				return nil, err
+			}
+			return api.WalletImport(ctx, &ki)
+		},
+		"WalletDelete": func(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+			var addr address.Address
+			if err := decodeParams(params, &addr); err != nil {
+				return nil, err
+			}
+			return nil, api.WalletDelete(ctx, addr)
+		},
+	}
+	return s
+}
+
+// Handler 返回挂载了 /rpc/v0 和 /rpc/v1 的 HTTP 处理器
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/rpc/v0", s)
+	mux.Handle("/rpc/v1", s)
+	return mux
+}
+
+// ServeHTTP 处理单个 JSON-RPC 请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(r) {
+		log.Warnf("ServeHTTP: unauthorized request from %s", r.RemoteAddr)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxRequestSize {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeResponse(w, &response{Error: &respError{Code: codeParseError, Message: err.Error()}})
+		return
+	}
+	writeResponse(w, s.call(r.Context(), &req))
+}
+
+// call 分发请求到对应的方法
+func (s *Server) call(ctx context.Context, req *request) *response {
+	resp := &response{ID: req.ID}
+
+	if req.Jsonrpc != "2.0" || !strings.HasPrefix(req.Method, methodPrefix) {
+		resp.Error = &respError{Code: codeInvalidRequest, Message: fmt.Sprintf("invalid request for method %q", req.Method)}
+		return resp
+	}
+
+	name := strings.TrimPrefix(req.Method, methodPrefix)
+	handler, ok := s.methods[name]
+	if !ok {
+		resp.Error = &respError{Code: codeMethodNotFound, Message: fmt.Sprintf("method '%s' not found", req.Method)}
+		return resp
+	}
+
+	log.Debugf("call: handling %s", req.Method)
+	result, err := handler(ctx, req.Params)
+	if err != nil {
+		code := codeCallError
+		if _, ok := err.(*paramsError); ok {
+			code = codeInvalidParams
+		}
+		log.Errorf("call: %s failed: %v", req.Method, err)
+		resp.Error = &respError{Code: code, Message: err.Error()}
+		return resp
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Errorf("call: failed to marshal result of %s: %v", req.Method, err)
+		resp.Error = &respError{Code: codeCallError, Message: fmt.Sprintf("marshaling result: %v", err)}
+		return resp
+	}
+	resp.Result = data
+	return resp
+}
+
+// authorized 校验 Authorization 请求头中的 Bearer 令牌
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// paramsError 请求参数错误
+type paramsError struct {
+	msg string
+}
+
+func (e *paramsError) Error() string {
+	return e.msg
+}
+
+// decodeParams 按位置解析请求参数，参数数量必须一致
+func decodeParams(params []json.RawMessage, out ...interface{}) error {
+	if len(params) != len(out) {
+		return &paramsError{msg: fmt.Sprintf("wrong param count (expected %d, got %d)", len(out), len(params))}
+	}
+	for i := range out {
+		if err := json.Unmarshal(params[i], out[i]); err != nil {
+			return &paramsError{msg: fmt.Sprintf("unmarshaling param %d: %v", i, err)}
+		}
+	}
+	return nil
+}
+
+// writeResponse 输出 JSON-RPC 响应
+func writeResponse(w http.ResponseWriter, resp *response) {
+	resp.Jsonrpc = "2.0"
+	if resp.ID == nil {
+		resp.ID = json.RawMessage("null")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorf("writeResponse: failed to write response: %v", err)
+	}
+}
@@ -26,9 +26,6 @@ func main() {
 		return
 	}
 
-	// 初始化加密密钥
-	repository.InitEncryptionKey()
-
 	// 加载配置
 	cfg, err := appcfg.LoadConfig()
 	if err != nil {
@@ -38,6 +35,7 @@ func main() {
 
 	// 打开数据库连接并自动迁移表结构
 	if _, err := repository.OpenStore(cfg.DBDSN); err != nil {
+		log.Fatal(err)
 		return
 	}
 
@@ -46,6 +44,22 @@ func main() {
 		Name:    "lotus-sign",
 		Usage:   "Lotus-sign 钱包签名工具，支持转账、提现、修改worker地址",
 		Version: "1.0.0",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "以 JSON 格式输出执行结果",
+			},
+			&cli.StringFlag{
+				Name:    "network",
+				Aliases: []string{"profile"},
+				Usage:   "使用的网络（mainnet、calibnet、devnet 或配置文件 [Networks] 中的名称），默认使用配置文件中的 Network",
+				EnvVars: []string{"WALLET_SIGN_NETWORK"},
+			},
+		},
+		// 选择网络：设置地址前缀、节点地址、确认度及手续费上限
+		Before: func(c *cli.Context) error {
+			return appcfg.UseNetwork(c.String("network"))
+		},
 
 		Commands: cli2.All(),
 	}